@@ -11,6 +11,7 @@ import "C"
 
 import (
 	"encoding/csv"
+	"errors"
 	"rpc/pkg/utils"
 	"strings"
 
@@ -28,11 +29,6 @@ func rpcCheckAccess() int {
 
 //export rpcExec
 func rpcExec(Input *C.char, Output **C.char) int {
-	if accessStatus := rpcCheckAccess(); accessStatus != int(utils.Success) {
-		*Output = C.CString(AccessErrMsg)
-		return accessStatus
-	}
-
 	//create argument array from input string
 	inputString := C.GoString(Input)
 	// Split string
@@ -44,7 +40,20 @@ func rpcExec(Input *C.char, Output **C.char) int {
 		return utils.InvalidParameterCombination.Code
 	}
 	args = append([]string{"rpc"}, args...)
-	err = runRPC(args)
+	// parse first so -mei applies to the access check
+	flags, err := parseCommandLine(args)
+	if err != nil {
+		*Output = C.CString("rpcExec failed: " + inputString)
+		return handleError(err)
+	}
+	// a remote target is reached over the network, not the MEI driver
+	if flags.Target == "" {
+		if accessStatus := rpcCheckAccess(); accessStatus != int(utils.Success) {
+			*Output = C.CString(AccessErrMsg)
+			return accessStatus
+		}
+	}
+	err = runRPC(flags)
 	if err != nil {
 		*Output = C.CString("rpcExec failed: " + inputString)
 		return handleError(err)
@@ -53,8 +62,9 @@ func rpcExec(Input *C.char, Output **C.char) int {
 }
 
 func handleError(err error) int {
-	if customErr, ok := err.(utils.CustomError); ok {
-		log.Error(customErr.Error())
+	var customErr utils.CustomError
+	if errors.As(err, &customErr) {
+		log.Error(err.Error())
 		return customErr.Code
 	} else {
 		log.Error(err.Error())
@@ -5,11 +5,14 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"rpc/internal/amt"
 	"rpc/internal/flags"
 	"rpc/internal/local"
 	"rpc/internal/rps"
+	"rpc/pkg/heci"
+	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 
 	log "github.com/sirupsen/logrus"
@@ -21,6 +24,11 @@ const AccessErrMsg = "Failed to execute due to access issues. " +
 	"and the runtime has administrator or root privileges."
 
 func checkAccess() error {
+	_, emulated := pthi.EmulatorStateFile()
+	_, replayed := heci.ReplayFile()
+	if !emulated && !replayed {
+		reportDeviceDiscovery()
+	}
 	amtCommand := amt.NewAMTCommand()
 	err := amtCommand.Initialize()
 	if err != nil {
@@ -29,11 +37,24 @@ func checkAccess() error {
 	return nil
 }
 
-func runRPC(args []string) error {
-	flags, err := parseCommandLine(args)
+// reportDeviceDiscovery logs the MEI device that will be used and why the
+// others were skipped; the reasons are errors when no device was usable
+func reportDeviceDiscovery() {
+	discovery, err := heci.Discover()
+	logRejected := log.Debug
 	if err != nil {
-		return err
+		logRejected = log.Error
+		log.Error(err)
+	} else {
+		log.Debug("using MEI device ", discovery.Selected.Path, ": ", discovery.Selected.Reason)
 	}
+	for _, candidate := range discovery.Rejected {
+		logRejected("skipped MEI device ", candidate.Path, ": ", candidate.Reason)
+	}
+}
+
+func runRPC(flags *flags.Flags) error {
+	var err error
 	if flags.Local {
 		err = local.ExecuteCommand(flags)
 	} else {
@@ -73,22 +94,34 @@ func parseCommandLine(args []string) (*flags.Flags, error) {
 }
 
 func main() {
-	err := checkAccess()
+	// parse first so -mei and the log level apply to the access check
+	flags, err := parseCommandLine(os.Args)
 	if err != nil {
-		log.Error(AccessErrMsg)
 		handleErrorAndExit(err)
 	}
 
-	err = runRPC(os.Args)
+	// a remote target is reached over the network, not the MEI driver
+	if flags.Target == "" {
+		err = checkAccess()
+		if err != nil {
+			if err != utils.MEIDeviceBusy {
+				log.Error(AccessErrMsg)
+			}
+			handleErrorAndExit(err)
+		}
+	}
+
+	err = runRPC(flags)
 	if err != nil {
 		handleErrorAndExit(err)
 	}
 }
 
 func handleErrorAndExit(err error) {
-	if customErr, ok := err.(utils.CustomError); ok {
+	var customErr utils.CustomError
+	if errors.As(err, &customErr) {
 		if err != utils.HelpRequested {
-			log.Error(customErr.Error())
+			log.Error(err.Error())
 		}
 		os.Exit(customErr.Code)
 	} else {
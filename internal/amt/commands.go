@@ -7,11 +7,14 @@ package amt
 import (
 	"errors"
 	"fmt"
+	"rpc/pkg/heci"
 	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 	"strconv"
 	"strings"
 	"time"
+
+	log "github.com/sirupsen/logrus"
 )
 
 //TODO: Ensure pointers are freed properly throughout this file
@@ -68,6 +71,48 @@ type LocalSystemAccount struct {
 	Password string
 }
 
+// FeaturesState holds the redirection, system defense and web UI state
+type FeaturesState struct {
+	IDERSessionOpen        bool `json:"iderSessionOpen"`
+	SOLSessionOpen         bool `json:"solSessionOpen"`
+	SystemDefenseActivated bool `json:"systemDefenseActivated"`
+	WebUIEnabled           bool `json:"webUIEnabled"`
+}
+
+// HostResetReason holds the reason of the last host reset
+type HostResetReason struct {
+	Reason                 string `json:"reason"`
+	RemoteControlTimeStamp uint32 `json:"remoteControlTimeStamp"`
+}
+
+// MACAddresses holds the dedicated (AMT) and host MAC addresses
+type MACAddresses struct {
+	DedicatedMACAddress string `json:"dedicatedMacAddress"`
+	HostMACAddress      string `json:"hostMacAddress"`
+}
+
+// SecurityParameters holds the AMT security settings
+type SecurityParameters struct {
+	EnterpriseMode          bool   `json:"enterpriseMode"`
+	TLSEnabled              bool   `json:"tlsEnabled"`
+	HWCryptoEnabled         bool   `json:"hwCryptoEnabled"`
+	ProvisioningState       string `json:"provisioningState"`
+	NetworkInterfaceEnabled bool   `json:"networkInterfaceEnabled"`
+	SOLEnabled              bool   `json:"solEnabled"`
+	IDEREnabled             bool   `json:"iderEnabled"`
+	FWUpdateEnabled         bool   `json:"fwUpdateEnabled"`
+	LinkIsUp                bool   `json:"linkIsUp"`
+}
+
+// FQDN holds the AMT FQDN and dynamic DNS settings
+type FQDN struct {
+	FQDN                       string `json:"fqdn"`
+	SharedFQDN                 bool   `json:"sharedFqdn"`
+	DDNSUpdateEnabled          bool   `json:"ddnsUpdateEnabled"`
+	DDNSPeriodicUpdateInterval uint32 `json:"ddnsPeriodicUpdateInterval"`
+	DDNSTTL                    uint32 `json:"ddnsTTL"`
+}
+
 type ChangeEnabledResponse uint8
 
 func (r ChangeEnabledResponse) IsTransitionAllowed() bool {
@@ -95,6 +140,25 @@ type Interface interface {
 	GetLANInterfaceSettings(useWireless bool) (InterfaceSettings, error)
 	GetLocalSystemAccount() (LocalSystemAccount, error)
 	Unprovision() (mode int, err error)
+	GetFeaturesState() (FeaturesState, error)
+	GetLastHostResetReason() (HostResetReason, error)
+	GetCurrentPowerPolicy() (string, error)
+	GetMACAddresses() (MACAddresses, error)
+	GetSecurityParameters() (SecurityParameters, error)
+	GetProvisioningTLSMode() (string, error)
+	GetZeroTouchEnabled() (bool, error)
+	GetFQDN() (FQDN, error)
+	GetEHBCState() (bool, error)
+	GetDNSSuffixList() ([]string, error)
+	SetDNSSuffix(suffix string) error
+	SetHostFQDN(fqdn string) error
+	GetProvisioningState() (int, error)
+	StopConfiguration() error
+	StartConfiguration(otp string) error
+	OpenUserInitiatedConnection() error
+	CloseUserInitiatedConnection() error
+	SendWatchdogHeartbeat(timeout uint16) (required bool, err error)
+	StopWatchdogTimer() error
 }
 
 func ANSI2String(ansi pthi.AMTANSIString) string {
@@ -122,6 +186,18 @@ func (amt AMTCommand) Initialize() error {
 	err := amt.PTHI.Open(false)
 
 	if err != nil {
+		if errors.Is(err, heci.ErrDeviceBusy) {
+			log.Error(err)
+			return utils.MEIDeviceBusy
+		}
+		if errors.Is(err, heci.ErrAccessDenied) {
+			log.Error(err)
+			return utils.IncorrectPermissions
+		}
+		if errors.Is(err, heci.ErrClientNotFound) {
+			log.Error(err)
+			return utils.AmtNotDetected
+		}
 		if err.Error() == "The handle is invalid." {
 			return utils.HECIDriverNotDetected //, errors.New("AMT not found: MEI/driver is missing or the call to the HECI driver failed")
 		} else {
@@ -202,8 +278,7 @@ func setAmtOperationalState(state pthi.AMTOperationalState, amt AMTCommand) erro
 		return err
 	}
 	if status != pthi.AMT_STATUS_SUCCESS {
-		s := fmt.Sprintf("error setting AMT operational state %s: %s", state, status)
-		return errors.New(s)
+		return fmt.Errorf("error setting AMT operational state %s: %w", state, status.Err())
 	}
 	return nil
 }
@@ -220,19 +295,23 @@ func (amt AMTCommand) GetUUID() (string, error) {
 		return "", err
 	}
 
+	return UUIDString([]byte(result)), nil
+}
+
+// UUIDString formats the 16 UUID bytes AMT reports, whose first three fields
+// are little-endian, the way the device UUID is shown
+func UUIDString(uuid []byte) string {
 	var hexValues [16]string
 
 	for i := 0; i < 16; i++ {
-		hexValues[i] = fmt.Sprintf("%02x", int(result[i]))
+		hexValues[i] = fmt.Sprintf("%02x", int(uuid[i]))
 	}
 
-	uuidStr := hexValues[3] + hexValues[2] + hexValues[1] + hexValues[0] + "-" +
+	return hexValues[3] + hexValues[2] + hexValues[1] + hexValues[0] + "-" +
 		hexValues[5] + hexValues[4] + "-" +
 		hexValues[7] + hexValues[6] + "-" +
 		hexValues[8] + hexValues[9] + "-" +
 		hexValues[10] + hexValues[11] + hexValues[12] + hexValues[13] + hexValues[14] + hexValues[15]
-	return uuidStr, nil
-
 }
 
 // GetControlMode ...
@@ -416,3 +495,353 @@ func (amt AMTCommand) GetLocalSystemAccount() (LocalSystemAccount, error) {
 
 	return lsa, nil
 }
+
+func MACAddress2String(mac [6]uint8) string {
+	parts := make([]string, len(mac))
+	for i := range mac {
+		parts[i] = fmt.Sprintf("%02x", int(mac[i]))
+	}
+	return strings.Join(parts, ":")
+}
+
+func (amt AMTCommand) GetFeaturesState() (FeaturesState, error) {
+	err := amt.PTHI.Open(false)
+	emptyState := FeaturesState{}
+	if err != nil {
+		return emptyState, err
+	}
+	defer amt.PTHI.Close()
+	redirection, err := amt.PTHI.GetFeaturesState(pthi.FeaturesStateRedirectionSession)
+	if err != nil {
+		return emptyState, err
+	}
+	systemDefense, err := amt.PTHI.GetFeaturesState(pthi.FeaturesStateSystemDefense)
+	if err != nil {
+		return emptyState, err
+	}
+	webUI, err := amt.PTHI.GetFeaturesState(pthi.FeaturesStateWebUI)
+	if err != nil {
+		return emptyState, err
+	}
+
+	state := FeaturesState{
+		IDERSessionOpen:        redirection.Data[0] > 0,
+		SOLSessionOpen:         redirection.Data[1] > 0,
+		SystemDefenseActivated: systemDefense.Data[0] > 0,
+		WebUIEnabled:           webUI.Data[0] > 0,
+	}
+
+	return state, nil
+}
+
+func (amt AMTCommand) GetLastHostResetReason() (HostResetReason, error) {
+	err := amt.PTHI.Open(false)
+	emptyReason := HostResetReason{}
+	if err != nil {
+		return emptyReason, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetLastHostResetReason()
+	if err != nil {
+		return emptyReason, err
+	}
+
+	reason := HostResetReason{
+		Reason:                 utils.InterpretHostResetReason(int(result.Reason)),
+		RemoteControlTimeStamp: result.RemoteControlTimeStamp,
+	}
+
+	return reason, nil
+}
+
+func (amt AMTCommand) GetCurrentPowerPolicy() (string, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return "", err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetCurrentPowerPolicy()
+	if err != nil {
+		return "", err
+	}
+
+	return result, nil
+}
+
+func (amt AMTCommand) GetMACAddresses() (MACAddresses, error) {
+	err := amt.PTHI.Open(false)
+	emptyAddresses := MACAddresses{}
+	if err != nil {
+		return emptyAddresses, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetMACAddresses()
+	if err != nil {
+		return emptyAddresses, err
+	}
+
+	addresses := MACAddresses{
+		DedicatedMACAddress: MACAddress2String(result.DedicatedMac),
+		HostMACAddress:      MACAddress2String(result.HostMac),
+	}
+
+	return addresses, nil
+}
+
+func (amt AMTCommand) GetSecurityParameters() (SecurityParameters, error) {
+	err := amt.PTHI.Open(false)
+	emptyParameters := SecurityParameters{}
+	if err != nil {
+		return emptyParameters, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetSecurityParameters()
+	if err != nil {
+		return emptyParameters, err
+	}
+
+	parameters := SecurityParameters{
+		EnterpriseMode:          result.EnterpriseMode > 0,
+		TLSEnabled:              result.TLSEnabled > 0,
+		HWCryptoEnabled:         result.HWCryptoEnabled > 0,
+		ProvisioningState:       utils.InterpretProvisioningState(int(result.ProvisioningState)),
+		NetworkInterfaceEnabled: result.NetworkInterfaceEnabled > 0,
+		SOLEnabled:              result.SOLEnabled > 0,
+		IDEREnabled:             result.IDEREnabled > 0,
+		FWUpdateEnabled:         result.FWUpdateEnabled > 0,
+		LinkIsUp:                result.LinkIsUp > 0,
+	}
+
+	return parameters, nil
+}
+
+func (amt AMTCommand) GetProvisioningTLSMode() (string, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return "", err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetProvisioningTLSMode()
+	if err != nil {
+		return "", err
+	}
+
+	return utils.InterpretProvisioningTLSMode(result), nil
+}
+
+func (amt AMTCommand) GetZeroTouchEnabled() (bool, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return false, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetZeroTouchEnabled()
+	if err != nil {
+		return false, err
+	}
+
+	return result, nil
+}
+
+func (amt AMTCommand) GetFQDN() (FQDN, error) {
+	err := amt.PTHI.Open(false)
+	emptyFQDN := FQDN{}
+	if err != nil {
+		return emptyFQDN, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetFQDN()
+	if err != nil {
+		return emptyFQDN, err
+	}
+
+	fqdn := FQDN{
+		FQDN:                       ANSI2String(result.FQDN),
+		SharedFQDN:                 result.SharedFQDN > 0,
+		DDNSUpdateEnabled:          result.DDNSUpdateEnabled > 0,
+		DDNSPeriodicUpdateInterval: result.DDNSPeriodicUpdateInterval,
+		DDNSTTL:                    result.DDNSTTL,
+	}
+
+	return fqdn, nil
+}
+
+// GetEHBCState reports whether embedded host based configuration is enabled
+func (amt AMTCommand) GetEHBCState() (bool, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return false, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetEHBCState()
+	if err != nil {
+		return false, err
+	}
+
+	return result == 1, nil
+}
+
+func (amt AMTCommand) GetDNSSuffixList() ([]string, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return []string{}, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetDNSSuffixList()
+	if err != nil {
+		return []string{}, err
+	}
+
+	return result, nil
+}
+
+// SetDNSSuffix writes the PKI DNS suffix used to match the provisioning certificate
+func (amt AMTCommand) SetDNSSuffix(suffix string) error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	status, err := amt.PTHI.SetDNSSuffix(suffix)
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error setting DNS suffix %s: %w", suffix, status.Err())
+	}
+	return nil
+}
+
+func (amt AMTCommand) SetHostFQDN(fqdn string) error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	status, err := amt.PTHI.SetHostFQDN(fqdn)
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error setting host FQDN %s: %w", fqdn, status.Err())
+	}
+	return nil
+}
+
+func (amt AMTCommand) GetProvisioningState() (int, error) {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return -1, err
+	}
+	defer amt.PTHI.Close()
+	result, err := amt.PTHI.GetProvisioningState()
+	if err != nil {
+		return -1, err
+	}
+
+	return result, nil
+}
+
+// StopConfiguration cancels a pending remote configuration session
+func (amt AMTCommand) StopConfiguration() error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	status, err := amt.PTHI.StopConfiguration()
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error stopping configuration: %w", status.Err())
+	}
+	return nil
+}
+
+// StartConfiguration opens a remote configuration session, setting the
+// provisioning server one-time password first when one is given
+func (amt AMTCommand) StartConfiguration(otp string) error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	if otp != "" {
+		status, err := amt.PTHI.SetProvisioningServerOTP(otp)
+		if err != nil {
+			return err
+		}
+		if status != pthi.AMT_STATUS_SUCCESS {
+			return fmt.Errorf("error setting provisioning server OTP: %w", status.Err())
+		}
+	}
+	status, err := amt.PTHI.StartConfiguration()
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error starting configuration: %w", status.Err())
+	}
+	return nil
+}
+
+// OpenUserInitiatedConnection asks AMT to open a CIRA tunnel to the configured MPS
+func (amt AMTCommand) OpenUserInitiatedConnection() error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	status, err := amt.PTHI.OpenUserInitiatedConnection()
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error opening user initiated connection: %w", status.Err())
+	}
+	return nil
+}
+
+// CloseUserInitiatedConnection closes a user initiated CIRA tunnel
+func (amt AMTCommand) CloseUserInitiatedConnection() error {
+	err := amt.PTHI.Open(false)
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	status, err := amt.PTHI.CloseUserInitiatedConnection()
+	if err != nil {
+		return err
+	}
+	if status != pthi.AMT_STATUS_SUCCESS {
+		return fmt.Errorf("error closing user initiated connection: %w", status.Err())
+	}
+	return nil
+}
+
+// SendWatchdogHeartbeat rearms the agent presence watchdog timer for timeout
+// seconds and reports whether AMT still expects heartbeats
+func (amt AMTCommand) SendWatchdogHeartbeat(timeout uint16) (bool, error) {
+	err := amt.PTHI.OpenWatchdog()
+	if err != nil {
+		return false, err
+	}
+	defer amt.PTHI.Close()
+	response, err := amt.PTHI.StartWatchdogTimer(timeout)
+	if err != nil {
+		return false, fmt.Errorf("error sending watchdog heartbeat: %w", err)
+	}
+	return response.Required(), nil
+}
+
+// StopWatchdogTimer disarms the watchdog timer so a clean shutdown of the
+// agent is not reported as an expiry
+func (amt AMTCommand) StopWatchdogTimer() error {
+	err := amt.PTHI.OpenWatchdog()
+	if err != nil {
+		return err
+	}
+	defer amt.PTHI.Close()
+	return amt.PTHI.StopWatchdogTimer()
+}
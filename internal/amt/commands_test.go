@@ -7,6 +7,7 @@ package amt
 import (
 	"errors"
 	"fmt"
+	"rpc/pkg/heci"
 	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 	"testing"
@@ -30,8 +31,13 @@ func (c MockPTHICommands) OpenWatchdog() error {
 var flag bool = false
 var flag1 bool = false
 var returnError bool = false
+var mockOpenErr error = nil
+var mockWatchdogState uint8 = 0
 
 func (c MockPTHICommands) Open(useLME bool) error {
+	if mockOpenErr != nil {
+		return mockOpenErr
+	}
 	if flag == true {
 		return errors.New("The handle is invalid.")
 	} else if flag1 == true {
@@ -146,6 +152,85 @@ func (c MockPTHICommands) GetLocalSystemAccount() (localAccount pthi.GetLocalSys
 	}, nil
 }
 func (c MockPTHICommands) Unprovision() (state int, err error) { return 0, nil }
+func (c MockPTHICommands) GetFeaturesState(requestID pthi.FeaturesStateRequestID) (pthi.GetFeaturesStateResponse, error) {
+	response := pthi.GetFeaturesStateResponse{RequestID: requestID}
+	switch requestID {
+	case pthi.FeaturesStateRedirectionSession:
+		response.Data = [3]uint8{0, 1, 0}
+	case pthi.FeaturesStateWebUI:
+		response.Data = [3]uint8{1, 0, 0}
+	}
+	return response, nil
+}
+func (c MockPTHICommands) GetLastHostResetReason() (pthi.GetLastHostResetReasonResponse, error) {
+	return pthi.GetLastHostResetReasonResponse{Reason: 0, RemoteControlTimeStamp: 1700000000}, nil
+}
+func (c MockPTHICommands) GetCurrentPowerPolicy() (string, error) {
+	return "Always on (S0-S5)", nil
+}
+func (c MockPTHICommands) GetMACAddresses() (pthi.GetMACAddressesResponse, error) {
+	return pthi.GetMACAddressesResponse{
+		DedicatedMac: [6]uint8{0, 0, 0, 0, 0, 0},
+		HostMac:      [6]uint8{0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+	}, nil
+}
+func (c MockPTHICommands) GetSecurityParameters() (pthi.GetSecurityParametersResponse, error) {
+	return pthi.GetSecurityParametersResponse{
+		TLSEnabled:        1,
+		ProvisioningState: 2,
+		LinkIsUp:          1,
+	}, nil
+}
+func (c MockPTHICommands) GetProvisioningTLSMode() (int, error) { return 2, nil }
+func (c MockPTHICommands) GetZeroTouchEnabled() (bool, error)   { return true, nil }
+func (c MockPTHICommands) GetFQDN() (pthi.GetFQDNResponse, error) {
+	return pthi.GetFQDNResponse{
+		SharedFQDN: 1,
+		DDNSTTL:    900,
+		FQDN: pthi.AMTANSIString{
+			Length: 4,
+			Buffer: [1000]uint8{'h', 'o', 's', 't'},
+		},
+	}, nil
+}
+func (c MockPTHICommands) GetEHBCState() (int, error) { return 1, nil }
+func (c MockPTHICommands) SetDNSSuffix(suffix string) (pthi.Status, error) {
+	if suffix == "" {
+		return pthi.AMT_STATUS_INVALID_PARAMETER, nil
+	}
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) SetHostFQDN(fqdn string) (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) GetProvisioningState() (int, error) { return 1, nil }
+func (c MockPTHICommands) StopConfiguration() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) StartConfiguration() (pthi.Status, error) {
+	return pthi.AMT_STATUS_INVALID_AMT_MODE, nil
+}
+func (c MockPTHICommands) SetProvisioningServerOTP(otp string) (pthi.Status, error) {
+	if otp == "bad" {
+		return pthi.AMT_STATUS_INVALID_PARAMETER, nil
+	}
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) OpenUserInitiatedConnection() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) CloseUserInitiatedConnection() (pthi.Status, error) {
+	return pthi.AMT_STATUS_INVALID_AMT_MODE, nil
+}
+func (c MockPTHICommands) StartWatchdogTimer(timeout uint16) (pthi.WatchdogStartTimerResponse, error) {
+	return pthi.WatchdogStartTimerResponse{WatchdogState: mockWatchdogState}, nil
+}
+func (c MockPTHICommands) StopWatchdogTimer() error {
+	return nil
+}
+func (c MockPTHICommands) GetDNSSuffixList() ([]string, error) {
+	return []string{"vprodemo.com"}, nil
+}
 
 var amt AMTCommand
 
@@ -169,6 +254,24 @@ func TestInitializeError(t *testing.T) {
 	assert.Error(t, err, utils.HECIDriverNotDetected)
 	flag1 = false
 }
+func TestInitializeDeviceBusy(t *testing.T) {
+	mockOpenErr = fmt.Errorf("%w: timed out", heci.ErrDeviceBusy)
+	err := amt.Initialize()
+	assert.Equal(t, utils.MEIDeviceBusy, err)
+	mockOpenErr = nil
+}
+func TestInitializeAccessDenied(t *testing.T) {
+	mockOpenErr = fmt.Errorf("%w: /dev/mei0", heci.ErrAccessDenied)
+	err := amt.Initialize()
+	assert.Equal(t, utils.IncorrectPermissions, err)
+	mockOpenErr = nil
+}
+func TestInitializeClientNotFound(t *testing.T) {
+	mockOpenErr = fmt.Errorf("%w: /dev/mei0", heci.ErrClientNotFound)
+	err := amt.Initialize()
+	assert.Equal(t, utils.AmtNotDetected, err)
+	mockOpenErr = nil
+}
 func TestGetVersionDataFromME(t *testing.T) {
 	result, err := amt.GetVersionDataFromME("Flash", 1*time.Second)
 	assert.NoError(t, err)
@@ -357,3 +460,112 @@ func TestChangeEnabledResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFeaturesState(t *testing.T) {
+	result, err := amt.GetFeaturesState()
+	assert.NoError(t, err)
+	assert.Equal(t, FeaturesState{SOLSessionOpen: true, WebUIEnabled: true}, result)
+}
+func TestGetLastHostResetReason(t *testing.T) {
+	result, err := amt.GetLastHostResetReason()
+	assert.NoError(t, err)
+	assert.Equal(t, "remote control", result.Reason)
+	assert.Equal(t, uint32(1700000000), result.RemoteControlTimeStamp)
+}
+func TestGetCurrentPowerPolicy(t *testing.T) {
+	result, err := amt.GetCurrentPowerPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, "Always on (S0-S5)", result)
+}
+func TestGetMACAddresses(t *testing.T) {
+	result, err := amt.GetMACAddresses()
+	assert.NoError(t, err)
+	assert.Equal(t, "00:00:00:00:00:00", result.DedicatedMACAddress)
+	assert.Equal(t, "0a:0b:0c:0d:0e:0f", result.HostMACAddress)
+}
+func TestGetSecurityParameters(t *testing.T) {
+	result, err := amt.GetSecurityParameters()
+	assert.NoError(t, err)
+	assert.True(t, result.TLSEnabled)
+	assert.True(t, result.LinkIsUp)
+	assert.False(t, result.EnterpriseMode)
+	assert.Equal(t, "post-provisioning", result.ProvisioningState)
+}
+func TestGetProvisioningTLSMode(t *testing.T) {
+	result, err := amt.GetProvisioningTLSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, "pki", result)
+}
+func TestGetZeroTouchEnabled(t *testing.T) {
+	result, err := amt.GetZeroTouchEnabled()
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
+func TestGetFQDN(t *testing.T) {
+	result, err := amt.GetFQDN()
+	assert.NoError(t, err)
+	assert.Equal(t, "host", result.FQDN)
+	assert.True(t, result.SharedFQDN)
+	assert.Equal(t, uint32(900), result.DDNSTTL)
+}
+func TestGetEHBCState(t *testing.T) {
+	result, err := amt.GetEHBCState()
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
+func TestGetDNSSuffixList(t *testing.T) {
+	result, err := amt.GetDNSSuffixList()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vprodemo.com"}, result)
+}
+func TestSetDNSSuffix(t *testing.T) {
+	err := amt.SetDNSSuffix("vprodemo.com")
+	assert.NoError(t, err)
+}
+func TestSetDNSSuffixStatusError(t *testing.T) {
+	err := amt.SetDNSSuffix("")
+	assert.Error(t, err)
+}
+func TestSetHostFQDN(t *testing.T) {
+	err := amt.SetHostFQDN("host.vprodemo.com")
+	assert.NoError(t, err)
+}
+func TestGetProvisioningState(t *testing.T) {
+	result, err := amt.GetProvisioningState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+}
+func TestStopConfiguration(t *testing.T) {
+	err := amt.StopConfiguration()
+	assert.NoError(t, err)
+}
+func TestStartConfiguration(t *testing.T) {
+	err := amt.StartConfiguration("bad")
+	assert.EqualError(t, err, "error setting provisioning server OTP: Error 1036: AMT_STATUS_INVALID_PARAMETER: parameter is invalid")
+	assert.ErrorIs(t, err, pthi.AMT_STATUS_INVALID_PARAMETER.CustomError())
+	err = amt.StartConfiguration("Otp12345")
+	assert.EqualError(t, err, "error starting configuration: Error 1003: AMT_STATUS_INVALID_AMT_MODE: request is not allowed in the current AMT mode")
+	assert.ErrorIs(t, err, pthi.AMT_STATUS_INVALID_AMT_MODE.CustomError())
+}
+func TestOpenUserInitiatedConnection(t *testing.T) {
+	err := amt.OpenUserInitiatedConnection()
+	assert.NoError(t, err)
+}
+func TestSendWatchdogHeartbeat(t *testing.T) {
+	required, err := amt.SendWatchdogHeartbeat(120)
+	assert.NoError(t, err)
+	assert.True(t, required)
+	mockWatchdogState = pthi.WATCHDOG_STATE_NOT_REQUIRED
+	required, err = amt.SendWatchdogHeartbeat(120)
+	assert.NoError(t, err)
+	assert.False(t, required)
+	mockWatchdogState = 0
+}
+func TestStopWatchdogTimer(t *testing.T) {
+	err := amt.StopWatchdogTimer()
+	assert.NoError(t, err)
+}
+func TestCloseUserInitiatedConnection(t *testing.T) {
+	err := amt.CloseUserInitiatedConnection()
+	assert.EqualError(t, err, "error closing user initiated connection: Error 1003: AMT_STATUS_INVALID_AMT_MODE: request is not allowed in the current AMT mode")
+}
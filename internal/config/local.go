@@ -7,13 +7,15 @@ package config
 
 type (
 	Config struct {
-		Password            string              `yaml:"password"`
-		TlsConfig           TlsConfig           `yaml:"tlsConfig"`
-		WiredConfig         EthernetConfig      `yaml:"wiredConfig"`
-		WifiConfigs         []WifiConfig        `yaml:"wifiConfigs"`
-		Ieee8021xConfigs    []Ieee8021xConfig   `yaml:"ieee8021xConfigs"`
-		ACMSettings         ACMSettings         `yaml:"acmactivate"`
-		EnterpriseAssistant EnterpriseAssistant `yaml:"enterpriseAssistant"`
+		Password             string               `yaml:"password"`
+		TlsConfig            TlsConfig            `yaml:"tlsConfig"`
+		WiredConfig          EthernetConfig       `yaml:"wiredConfig"`
+		WifiConfigs          []WifiConfig         `yaml:"wifiConfigs"`
+		Ieee8021xConfigs     []Ieee8021xConfig    `yaml:"ieee8021xConfigs"`
+		ACMSettings          ACMSettings          `yaml:"acmactivate"`
+		EnterpriseAssistant  EnterpriseAssistant  `yaml:"enterpriseAssistant"`
+		EnvironmentDetection EnvironmentDetection `yaml:"environmentDetection"`
+		CIRA                 CIRA                 `yaml:"cira"`
 	}
 	TlsConfig struct {
 		Delay int    `yaml:"delay" env-default:"3"`
@@ -69,4 +71,15 @@ type (
 		EAPassword   string `yaml:"eaPassword"`
 		EAConfigured bool   `yaml:"eaConfigured"`
 	}
+	EnvironmentDetection struct {
+		Domains []string `yaml:"domains"`
+	}
+	CIRA struct {
+		MPSAddress  string `yaml:"mpsAddress"`
+		MPSPort     int    `yaml:"mpsPort"`
+		MPSUsername string `yaml:"mpsUsername"`
+		MPSPassword string `yaml:"mpsPassword"`
+		MPSCert     string `yaml:"mpsCert"`
+		CommonName  string `yaml:"commonName"`
+	}
 )
@@ -9,13 +9,14 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 
 	log "github.com/sirupsen/logrus"
 )
 
 func (f *Flags) handleActivateCommand() error {
-	f.amtActivateCommand.StringVar(&f.DNS, "d", f.lookupEnvOrString("DNS_SUFFIX", ""), "dns suffix override")
+	f.amtActivateCommand.StringVar(&f.DNS, "d", f.lookupEnvOrString("DNS_SUFFIX", ""), "dns suffix override (sets the AMT PKI DNS suffix for local ACM activation)")
 	f.amtActivateCommand.StringVar(&f.Hostname, "h", f.lookupEnvOrString("HOSTNAME", ""), "hostname override")
 	f.amtActivateCommand.StringVar(&f.Profile, "profile", f.lookupEnvOrString("PROFILE", ""), "name of the profile to use")
 	f.amtActivateCommand.BoolVar(&f.Local, "local", false, "activate amt locally")
@@ -82,6 +83,10 @@ func (f *Flags) handleActivateCommand() error {
 			fmt.Println("must specify -ccm or -acm, but not both")
 			return utils.InvalidParameterCombination
 		}
+		if len(f.DNS) > pthi.HOST_FQDN_MAX_LENGTH {
+			fmt.Printf("-d cannot be longer than %d characters\n", pthi.HOST_FQDN_MAX_LENGTH)
+			return utils.IncorrectCommandLineParameters
+		}
 
 		err := f.handleLocalConfig()
 		if err != nil {
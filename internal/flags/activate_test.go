@@ -218,6 +218,10 @@ func TestHandleActivateCommandLocal(t *testing.T) {
 			cmdLine:    "./rpc activate -local -acm -ccm",
 			wantResult: utils.InvalidParameterCombination,
 		},
+		"should fail if the dns suffix is too long": {
+			cmdLine:    "./rpc activate -local -ccm -d " + strings.Repeat("a", 257),
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
 		"should fail if ccm and missing password": {
 			cmdLine:      "./rpc activate -local -ccm",
 			wantResult:   utils.MissingOrIncorrectPassword,
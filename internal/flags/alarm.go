@@ -0,0 +1,160 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// alarmNamePattern keeps alarm names to characters AMT stores as they are
+var alarmNamePattern = regexp.MustCompile(`^[A-Za-z0-9 ._-]{1,32}$`)
+
+type AlarmFlags struct {
+	Action             string
+	Name               string
+	Start              time.Time
+	Interval           time.Duration
+	DeleteOnCompletion bool
+}
+
+func (f *Flags) printAlarmUsage() string {
+	baseCommand := fmt.Sprintf("%s %s %s", filepath.Base(os.Args[0]), utils.CommandConfigure, utils.SubCommandAlarm)
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage += "Usage: " + baseCommand + " COMMAND [OPTIONS]\n\n"
+	usage += "Supported Alarm Commands:\n"
+	usage += "  add        Add an alarm that powers the device on at -start, and every -interval after it\n"
+	usage += "             Example: " + baseCommand + " add -name patching -start 02:00 -interval 24h\n"
+	usage += "  list       List the alarms, in local time\n"
+	usage += "             Example: " + baseCommand + " list -json\n"
+	usage += "  delete     Delete an alarm\n"
+	usage += "             Example: " + baseCommand + " delete -name patching\n"
+	usage += "\nOptions:\n"
+	usage += "  -name                Name of the alarm, up to 32 letters, digits, spaces, '.', '_' or '-'\n"
+	usage += "  -start               First alarm in local time: HH:MM (the next one), YYYY-MM-DDTHH:MM or RFC 3339\n"
+	usage += "  -interval            Time between alarms in whole minutes, like 30m, 12h or 7d. Default once\n"
+	usage += "  -deleteOnCompletion  Let AMT delete the alarm once it went off\n"
+	usage += "\nAMT keeps up to 5 alarms. AMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleAlarm() error {
+	if len(f.commandLineArgs) == 3 {
+		f.printAlarmUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	f.Alarm.Action = f.commandLineArgs[3]
+	switch f.Alarm.Action {
+	case utils.SubCommandAdd, utils.SubCommandList, utils.SubCommandDelete:
+	default:
+		f.printAlarmUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	start, interval := "", ""
+	fs := f.NewConfigureFlagSet(utils.SubCommandAlarm + " " + f.Alarm.Action)
+	if f.Alarm.Action != utils.SubCommandList {
+		fs.StringVar(&f.Alarm.Name, "name", "", "Name of the alarm")
+	}
+	if f.Alarm.Action == utils.SubCommandAdd {
+		fs.StringVar(&start, "start", "", "First alarm in local time")
+		fs.StringVar(&interval, "interval", "", "Time between alarms, once if not set")
+		fs.BoolVar(&f.Alarm.DeleteOnCompletion, "deleteOnCompletion", false, "Delete the alarm once it went off")
+	}
+	if err := fs.Parse(f.commandLineArgs[4:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printAlarmUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.Alarm.Action == utils.SubCommandList {
+		return nil
+	}
+	if !alarmNamePattern.MatchString(f.Alarm.Name) {
+		fmt.Println("-name is required, up to 32 letters, digits, spaces, '.', '_' or '-'")
+		return utils.InvalidUserInput
+	}
+	if f.Alarm.Action == utils.SubCommandDelete {
+		return nil
+	}
+	var err error
+	if f.Alarm.Start, err = parseAlarmStart(start, time.Now()); err != nil {
+		fmt.Println("-start:", err)
+		return utils.InvalidUserInput
+	}
+	if f.Alarm.Interval, err = parseAlarmInterval(interval); err != nil {
+		fmt.Println("-interval:", err)
+		return utils.InvalidUserInput
+	}
+	return nil
+}
+
+// parseAlarmStart reads the time of the first alarm. AMT sets alarms to the
+// minute and refuses times that have passed. A time of day alone is its next
+// occurrence.
+func parseAlarmStart(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("the start time is required")
+	}
+	start, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		start, err = time.ParseInLocation("2006-01-02T15:04", value, now.Location())
+	}
+	if err != nil {
+		clock, clockErr := time.ParseInLocation("15:04", value, now.Location())
+		if clockErr != nil {
+			return time.Time{}, fmt.Errorf("%s is not HH:MM, YYYY-MM-DDTHH:MM or RFC 3339", value)
+		}
+		start = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !start.After(now) {
+			start = start.AddDate(0, 0, 1)
+		}
+	}
+	if start.Second() != 0 || start.Nanosecond() != 0 {
+		return time.Time{}, fmt.Errorf("%s is not on a whole minute", value)
+	}
+	if !start.After(now) {
+		return time.Time{}, fmt.Errorf("%s has passed", value)
+	}
+	return start, nil
+}
+
+// parseAlarmInterval reads the time between alarms as a duration or a number
+// of days. AMT keeps the interval in minutes; none means the alarm goes off
+// once.
+func parseAlarmInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	var interval time.Duration
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %s", value)
+		}
+		interval = time.Duration(count) * 24 * time.Hour
+	} else {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid interval %s", value)
+		}
+	}
+	if interval < time.Minute || interval%time.Minute != 0 {
+		return 0, fmt.Errorf("%s is not a whole number of minutes", value)
+	}
+	return interval, nil
+}
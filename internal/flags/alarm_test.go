@@ -0,0 +1,122 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleAlarm(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine      []string
+		wantResult   error
+		wantName     string
+		wantInterval time.Duration
+	}{
+		"should fail without action": {
+			cmdLine:    []string{"rpc", "configure", "alarm"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown action": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "snooze"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with list": {
+			cmdLine: []string{"rpc", "configure", "alarm", "list", "-json", "-password", "P@ssw0rd"},
+		},
+		"should pass with add": {
+			cmdLine:      []string{"rpc", "configure", "alarm", "add", "-name", "patching", "-start", "02:00", "-interval", "1d", "-deleteOnCompletion", "-password", "P@ssw0rd"},
+			wantName:     "patching",
+			wantInterval: 24 * time.Hour,
+		},
+		"should pass with delete": {
+			cmdLine:  []string{"rpc", "configure", "alarm", "delete", "-name", "patching", "-password", "P@ssw0rd"},
+			wantName: "patching",
+		},
+		"should fail with add without start": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "add", "-name", "patching", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with delete without name": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "delete", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid name": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "delete", "-name", "<patching>", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with start on list": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "list", "-start", "02:00", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with an interval below a minute": {
+			cmdLine:    []string{"rpc", "configure", "alarm", "add", "-name", "patching", "-start", "02:00", "-interval", "30s", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, utils.SubCommandAlarm, flags.SubCommand)
+				assert.Equal(t, tc.cmdLine[3], flags.Alarm.Action)
+				assert.Equal(t, tc.wantName, flags.Alarm.Name)
+				assert.Equal(t, tc.wantInterval, flags.Alarm.Interval)
+			}
+		})
+	}
+}
+
+func TestParseAlarmStart(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	start, err := parseAlarmStart("02:00", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC), start)
+	start, err = parseAlarmStart("11:00", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC), start)
+	start, err = parseAlarmStart("2024-05-03T02:00", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 3, 2, 0, 0, 0, time.UTC), start)
+	start, err = parseAlarmStart("2024-05-03T02:00:00+02:00", now)
+	assert.NoError(t, err)
+	assert.True(t, start.Equal(time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)))
+
+	_, err = parseAlarmStart("", now)
+	assert.Error(t, err)
+	_, err = parseAlarmStart("2024-04-30T02:00", now)
+	assert.Error(t, err)
+	_, err = parseAlarmStart("2024-05-03T02:00:30Z", now)
+	assert.Error(t, err)
+	_, err = parseAlarmStart("tomorrow", now)
+	assert.Error(t, err)
+}
+
+func TestParseAlarmInterval(t *testing.T) {
+	interval, err := parseAlarmInterval("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), interval)
+	interval, err = parseAlarmInterval("7d")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, interval)
+	interval, err = parseAlarmInterval("1h30m")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, interval)
+
+	_, err = parseAlarmInterval("90s")
+	assert.Error(t, err)
+	_, err = parseAlarmInterval("-1h")
+	assert.Error(t, err)
+	_, err = parseAlarmInterval("xd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type BootFlags struct {
+	Reset         bool
+	URL           string
+	HTTPSUsername string
+	HTTPSPassword string
+}
+
+func (f *Flags) printBootUsage() string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " boot COMMAND [OPTIONS]\n\n"
+	usage = usage + "Supported Boot Commands:\n"
+	usage = usage + "  capabilities  Show the boot options the platform supports\n"
+	usage = usage + "                Example: " + executable + " boot capabilities -json\n"
+	usage = usage + "  pxe           Boot from the network (PXE) on the next boot\n"
+	usage = usage + "  hdd           Boot from the hard drive on the next boot\n"
+	usage = usage + "  cd            Boot from the CD/DVD drive on the next boot\n"
+	usage = usage + "  ider          Boot from the IDE redirection CD on the next boot\n"
+	usage = usage + "                Example: " + executable + " boot pxe -reset -password P@ssw0rd -target 192.168.1.20\n"
+	usage = usage + "  https         Boot the UEFI HTTPS image at -url on the next boot. Requires AMT 16 or later\n"
+	usage = usage + "                Example: " + executable + " boot https -url https://server/image.efi -reset\n"
+	usage = usage + "\nOptions:\n"
+	usage = usage + "  -reset          Power cycle the device (or power it on) after setting the boot device\n"
+	usage = usage + "  -url            HTTPS URL of the UEFI boot image, https only\n"
+	usage = usage + "  -httpsUser      User name for the HTTPS server, https only\n"
+	usage = usage + "  -httpsPassword  Password for the HTTPS server, https only\n"
+	usage = usage + "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleBootCommand() error {
+	if len(f.commandLineArgs) == 2 {
+		f.printBootUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	f.SubCommand = f.commandLineArgs[2]
+	switch f.SubCommand {
+	case utils.SubCommandBootCapabilities, utils.SubCommandBootPXE, utils.SubCommandBootHDD,
+		utils.SubCommandBootCD, utils.SubCommandBootIDER, utils.SubCommandBootHTTPS:
+	default:
+		f.printBootUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	if f.SubCommand != utils.SubCommandBootCapabilities {
+		fs.BoolVar(&f.Boot.Reset, "reset", false, "Power cycle the device after setting the boot device")
+	}
+	if f.SubCommand == utils.SubCommandBootHTTPS {
+		fs.StringVar(&f.Boot.URL, "url", "", "HTTPS URL of the UEFI boot image")
+		fs.StringVar(&f.Boot.HTTPSUsername, "httpsUser", "", "User name for the HTTPS server")
+		fs.StringVar(&f.Boot.HTTPSPassword, "httpsPassword", "", "Password for the HTTPS server")
+	}
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printBootUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.SubCommand == utils.SubCommandBootHTTPS {
+		bootURL, err := url.Parse(f.Boot.URL)
+		if err != nil || bootURL.Scheme != "https" || bootURL.Host == "" {
+			log.Error("-url must be the https:// URL of the boot image")
+			return utils.IncorrectCommandLineParameters
+		}
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	f.Local = true
+	return nil
+}
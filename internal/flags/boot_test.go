@@ -0,0 +1,98 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintBootUsage(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	flags := NewFlags([]string{executable, utils.CommandBoot}, MockPRSuccess)
+	output := flags.printBootUsage()
+	assert.Contains(t, output, "Usage: "+executable+" boot COMMAND [OPTIONS]")
+	assert.Contains(t, output, "boot capabilities")
+	assert.Contains(t, output, "-httpsPassword")
+}
+
+func TestHandleBootCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		pr         utils.PasswordReader
+		wantResult error
+		wantBoot   BootFlags
+	}{
+		"should fail without subcommand": {
+			cmdLine:    []string{"rpc", "boot"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown subcommand": {
+			cmdLine:    []string{"rpc", "boot", "floppy"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with capabilities": {
+			cmdLine: []string{"rpc", "boot", "capabilities", "-json", "-password", "P@ssw0rd"},
+		},
+		"should fail with reset on capabilities": {
+			cmdLine:    []string{"rpc", "boot", "capabilities", "-reset", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with pxe and reset": {
+			cmdLine:  []string{"rpc", "boot", "pxe", "-reset", "-password", "P@ssw0rd"},
+			wantBoot: BootFlags{Reset: true},
+		},
+		"should prompt for the password": {
+			cmdLine: []string{"rpc", "boot", "ider"},
+		},
+		"should fail without password": {
+			cmdLine:    []string{"rpc", "boot", "hdd"},
+			pr:         MockPRFail,
+			wantResult: utils.MissingOrIncorrectPassword,
+		},
+		"should fail with url on cd": {
+			cmdLine:    []string{"rpc", "boot", "cd", "-url", "https://server/image.efi", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with https": {
+			cmdLine:  []string{"rpc", "boot", "https", "-url", "https://server/image.efi", "-httpsUser", "user", "-httpsPassword", "secret", "-password", "P@ssw0rd"},
+			wantBoot: BootFlags{URL: "https://server/image.efi", HTTPSUsername: "user", HTTPSPassword: "secret"},
+		},
+		"should fail with https without url": {
+			cmdLine:    []string{"rpc", "boot", "https", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with an http url": {
+			cmdLine:    []string{"rpc", "boot", "https", "-url", "http://server/image.efi", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "boot", "pxe", "-password", "P@ssw0rd", "now"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pr := tc.pr
+			if pr == nil {
+				pr = MockPRSuccess
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.cmdLine[2], flags.SubCommand)
+				assert.Equal(t, tc.wantBoot, flags.Boot)
+				assert.NotEmpty(t, flags.Password)
+			}
+		})
+	}
+}
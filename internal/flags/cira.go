@@ -0,0 +1,69 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"time"
+)
+
+const defaultCIRATimeout = 60 * time.Second
+
+func (f *Flags) printCIRAUsage() string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " cira COMMAND [OPTIONS]\n\n"
+	usage = usage + "Supported CIRA Commands:\n"
+	usage = usage + "  connect    Open a user initiated CIRA connection to the configured MPS\n"
+	usage = usage + "             Example: " + executable + " cira connect\n"
+	usage = usage + "  disconnect Close the user initiated CIRA connection\n"
+	usage = usage + "             Example: " + executable + " cira disconnect\n"
+	usage = usage + "  status     Wait until the CIRA connection is established or the timeout passes\n"
+	usage = usage + "             Example: " + executable + " cira status -timeout 2m\n"
+	usage = usage + "\nRun '" + executable + " cira COMMAND -h' for more information on a command.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleCIRACommand() error {
+	if len(f.commandLineArgs) == 2 {
+		f.printCIRAUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	f.SubCommand = f.commandLineArgs[2]
+	switch f.SubCommand {
+	case utils.SubCommandConnect, utils.SubCommandDisconnect, utils.SubCommandStatus:
+	default:
+		f.printCIRAUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	if f.SubCommand == utils.SubCommandStatus {
+		fs.DurationVar(&f.CIRATimeout, "timeout", defaultCIRATimeout, "time to wait for the connection to be established")
+		fs.DurationVar(&f.CIRATimeout, "t", defaultCIRATimeout, "time to wait for the connection to be established (shorthand)")
+	}
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.CIRATimeout < 0 {
+		return utils.InvalidUserInput
+	}
+	// CIRA connections are requested over the host interface
+	f.Local = true
+	return nil
+}
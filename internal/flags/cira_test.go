@@ -0,0 +1,87 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintCIRAUsage(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	args := []string{executable, utils.CommandCIRA}
+	flags := NewFlags(args, MockPRSuccess)
+	output := flags.printCIRAUsage()
+	assert.Contains(t, output, "Usage: "+executable+" cira COMMAND [OPTIONS]")
+	assert.Contains(t, output, "cira connect")
+	assert.Contains(t, output, "cira disconnect")
+	assert.Contains(t, output, "cira status")
+}
+
+func TestHandleCIRACommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine     []string
+		wantTimeout time.Duration
+		wantResult  error
+	}{
+		"should fail without subcommand": {
+			cmdLine:    []string{"rpc", "cira"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown subcommand": {
+			cmdLine:    []string{"rpc", "cira", "open"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with connect": {
+			cmdLine:    []string{"rpc", "cira", "connect"},
+			wantResult: nil,
+		},
+		"should pass with disconnect": {
+			cmdLine:    []string{"rpc", "cira", "disconnect", "-json"},
+			wantResult: nil,
+		},
+		"should use default status timeout": {
+			cmdLine:     []string{"rpc", "cira", "status"},
+			wantTimeout: defaultCIRATimeout,
+			wantResult:  nil,
+		},
+		"should pass with status timeout": {
+			cmdLine:     []string{"rpc", "cira", "status", "-timeout", "2m"},
+			wantTimeout: 2 * time.Minute,
+			wantResult:  nil,
+		},
+		"should fail with negative timeout": {
+			cmdLine:    []string{"rpc", "cira", "status", "-t", "-5s"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with timeout on connect": {
+			cmdLine:    []string{"rpc", "cira", "connect", "-timeout", "2m"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should return help requested": {
+			cmdLine:    []string{"rpc", "cira", "status", "-h"},
+			wantResult: utils.HelpRequested,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			gotResult := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, gotResult)
+			if tc.wantResult == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, utils.CommandCIRA, flags.Command)
+				assert.Equal(t, tc.cmdLine[2], flags.SubCommand)
+				assert.Equal(t, tc.wantTimeout, flags.CIRATimeout)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"rpc/internal/config"
+	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 	"strings"
 
@@ -99,6 +100,20 @@ func (f *Flags) printConfigurationUsage() string {
 	usage += "                  Example: " + baseCommand + " " + utils.SubCommandSetAMTFeatures + " -userConsent all -kvm -sol -ider\n"
 	usage += "  " + utils.SubCommandChangeAMTPassword + "     Updates AMT password. If flags are not provided, new and current AMT passwords will be prompted for. AMT password is required\n"
 	usage += "                  Example: " + baseCommand + " " + utils.SubCommandChangeAMTPassword + " -password YourAMTPassword -newamtpassword YourNewPassword\n"
+	usage += "  " + utils.SubCommandAlarm + "           Adds, lists or deletes alarms that power the device on at a set time. AMT password is required.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandAlarm + " add -name patching -start 02:00 -interval 24h -password YourAMTPassword\n"
+	usage += "  " + utils.SubCommandUsers + "           Adds, lists, updates or deletes AMT digest users with their realms and access. AMT password is required.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandUsers + " add -username operator -realms redirection,hwasset -access network -password YourAMTPassword\n"
+	usage += "  " + utils.SubCommandEnvDetection + " Sets or shows the DNS suffixes AMT uses to detect the enterprise network, which decides when CIRA connects. AMT password is required.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandEnvDetection + " -domains corp.example.com,lab.example.com -password YourAMTPassword\n"
+	usage += "  " + utils.SubCommandConfigureCIRA + "            Configures the MPS and the policies AMT uses to open CIRA connections, or removes them. AMT password is required. This command runs without cloud interaction.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandConfigureCIRA + " -mpsaddress mps.example.com -mpsusername admin -mpscert mpsroot.pem -password YourAMTPassword\n"
+	usage += "  " + utils.SubCommandDNSSuffix + "       Sets the PKI DNS suffix and/or the host FQDN in AMT. AMT password is not required. This command runs without cloud interaction.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandDNSSuffix + " -suffix vprodemo.com -setfqdn host.vprodemo.com\n"
+	usage += "  " + utils.SubCommandStopConfig + "      Cancels a pending remote configuration session. AMT password is not required.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandStopConfig + "\n"
+	usage += "  " + utils.SubCommandStartConfig + "     Starts a remote configuration session, optionally setting a one-time password first. AMT password is not required.\n"
+	usage += "                  Example: " + baseCommand + " " + utils.SubCommandStartConfig + " -otp YourOneTimePassword\n"
 	usage += "\nRun '" + baseCommand + " COMMAND -h' for more information on a command.\n"
 	fmt.Println(usage)
 	return usage
@@ -136,6 +151,28 @@ func (f *Flags) handleConfigureCommand() error {
 		err = f.handleChangeAMTPassword()
 	case utils.SubCommandSetAMTFeatures:
 		err = f.handleSetAMTFeatures()
+	case utils.SubCommandAlarm:
+		err = f.handleAlarm()
+	case utils.SubCommandUsers:
+		err = f.handleUsers()
+	case utils.SubCommandEnvDetection:
+		err = f.handleEnvironmentDetection()
+	case utils.SubCommandConfigureCIRA:
+		err = f.handleConfigureCIRA()
+	case utils.SubCommandDNSSuffix:
+		// the suffix is written over the host interface,
+		// so no AMT password is needed
+		if err = f.handleDNSSuffix(); err != nil {
+			return err
+		}
+		f.Local = true
+		return nil
+	case utils.SubCommandStopConfig, utils.SubCommandStartConfig:
+		if err = f.handleConfigurationSession(); err != nil {
+			return err
+		}
+		f.Local = true
+		return nil
 	default:
 		f.printConfigurationUsage()
 		err = utils.IncorrectCommandLineParameters
@@ -191,6 +228,33 @@ func (f *Flags) handleChangeAMTPassword() error {
 	return nil
 }
 
+func (f *Flags) handleDNSSuffix() error {
+	fs := flag.NewFlagSet(utils.SubCommandDNSSuffix, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	fs.StringVar(&f.DNS, "suffix", f.lookupEnvOrString("DNS_SUFFIX", ""), "PKI DNS suffix")
+	fs.StringVar(&f.HostFQDN, "setfqdn", "", "host FQDN")
+
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		f.printConfigurationUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.DNS == "" && f.HostFQDN == "" {
+		log.Error("-suffix or -setfqdn is required")
+		return utils.MissingDNSSuffix
+	}
+	if strings.ContainsAny(f.DNS, " \t") || strings.ContainsAny(f.HostFQDN, " \t") {
+		log.Error("DNS suffix and host FQDN cannot contain whitespace")
+		return utils.IncorrectCommandLineParameters
+	}
+	if len(f.DNS) > pthi.HOST_FQDN_MAX_LENGTH || len(f.HostFQDN) > pthi.HOST_FQDN_MAX_LENGTH {
+		log.Error("DNS suffix and host FQDN cannot be longer than ", pthi.HOST_FQDN_MAX_LENGTH, " characters")
+		return utils.IncorrectCommandLineParameters
+	}
+	return nil
+}
+
 func (f *Flags) handleSyncClock() error {
 	if err := f.amtMaintenanceSyncClockCommand.Parse(f.commandLineArgs[3:]); err != nil {
 		f.printConfigurationUsage()
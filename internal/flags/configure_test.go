@@ -814,3 +814,66 @@ func TestHandleAddEthernetSettings(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleDNSSuffix(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine      []string
+		wantResult   error
+		wantDNS      string
+		wantHostFQDN string
+	}{
+		"expect success for suffix": {
+			cmdLine:    []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-suffix`, `vprodemo.com`},
+			wantResult: nil,
+			wantDNS:    `vprodemo.com`,
+		},
+		"expect success for suffix and host fqdn": {
+			cmdLine:      []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-suffix`, `vprodemo.com`, `-setfqdn`, `host.vprodemo.com`},
+			wantResult:   nil,
+			wantDNS:      `vprodemo.com`,
+			wantHostFQDN: `host.vprodemo.com`,
+		},
+		"expect success for host fqdn only": {
+			cmdLine:      []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-setfqdn`, `host.vprodemo.com`},
+			wantResult:   nil,
+			wantHostFQDN: `host.vprodemo.com`,
+		},
+		"expect MissingDNSSuffix with no values": {
+			cmdLine:    []string{`rpc`, `configure`, utils.SubCommandDNSSuffix},
+			wantResult: utils.MissingDNSSuffix,
+		},
+		"expect IncorrectCommandLineParameters for whitespace": {
+			cmdLine:    []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-suffix`, `vpro demo.com`},
+			wantResult: utils.IncorrectCommandLineParameters,
+			wantDNS:    `vpro demo.com`,
+		},
+		"expect IncorrectCommandLineParameters for a suffix too long": {
+			cmdLine:    []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-suffix`, strings.Repeat(`a`, 257)},
+			wantResult: utils.IncorrectCommandLineParameters,
+			wantDNS:    strings.Repeat(`a`, 257),
+		},
+		"expect IncorrectCommandLineParameters for a host fqdn too long": {
+			cmdLine:      []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-setfqdn`, strings.Repeat(`a`, 257)},
+			wantResult:   utils.IncorrectCommandLineParameters,
+			wantHostFQDN: strings.Repeat(`a`, 257),
+		},
+		"expect IncorrectCommandLineParameters for unknown flag": {
+			cmdLine:    []string{`rpc`, `configure`, utils.SubCommandDNSSuffix, `-bogus`},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := NewFlags(tc.cmdLine, MockPRFail)
+			gotResult := f.ParseFlags()
+			assert.Equal(t, tc.wantResult, gotResult)
+			assert.Equal(t, tc.wantDNS, f.DNS)
+			assert.Equal(t, tc.wantHostFQDN, f.HostFQDN)
+			// the password is never prompted for
+			assert.Equal(t, "", f.Password)
+			if tc.wantResult == nil {
+				assert.True(t, f.Local)
+			}
+		})
+	}
+}
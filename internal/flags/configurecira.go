@@ -0,0 +1,172 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"rpc/pkg/utils"
+)
+
+// DefaultMPSPort is the port the MPS listens on for CIRA connections
+const DefaultMPSPort = 4433
+
+// maxMPSCredentialLength is the longest MPS username and password AMT takes
+const maxMPSCredentialLength = 16
+
+var mpsUsernamePattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+type ConfigureCIRAFlags struct {
+	// Remove takes the MPS and its policies off AMT instead of adding them
+	Remove bool
+}
+
+func (f *Flags) printConfigureCIRAUsage() string {
+	baseCommand := fmt.Sprintf("%s %s %s", filepath.Base(os.Args[0]), utils.CommandConfigure, utils.SubCommandConfigureCIRA)
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage += "Usage: " + baseCommand + " [OPTIONS]\n\n"
+	usage += "Adds the MPS to AMT, replacing one with the same address, and adds user initiated, alert and periodic CIRA policies for it.\n"
+	usage += "AMT only connects to the MPS outside of the environment detection domains.\n\n"
+	usage += "Options:\n"
+	usage += "  -mpsaddress   FQDN or IP address of the MPS\n"
+	usage += "                Example: " + baseCommand + " -mpsaddress mps.example.com -mpsusername admin -mpscert mpsroot.pem\n"
+	usage += "  -mpsport      Port of the MPS. Default 4433\n"
+	usage += "  -mpsusername  Username AMT uses with the MPS, up to 16 letters and digits\n"
+	usage += "  -mpspassword  Password AMT uses with the MPS, up to 16 characters. Prompted for if not set\n"
+	usage += "  -mpscert      Root certificate of the MPS server certificate, as base64 or a PEM or DER file\n"
+	usage += "  -mpscn        Common name of the MPS server certificate. Default the MPS address\n"
+	usage += "  -config       Config file or smb: file share URL with a cira section, options override it\n"
+	usage += "                Example: " + baseCommand + " -config config.yaml\n"
+	usage += "  -remove       Remove the MPS and the CIRA policies. The root certificate is also removed if -mpscert is set\n"
+	usage += "                Example: " + baseCommand + " -remove\n"
+	usage += "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleConfigureCIRA() error {
+	address, username, password, cert, commonName := "", "", "", "", ""
+	port := 0
+	fs := f.NewConfigureFlagSet(utils.SubCommandConfigureCIRA)
+	fs.StringVar(&address, "mpsaddress", "", "FQDN or IP address of the MPS")
+	fs.IntVar(&port, "mpsport", 0, "Port of the MPS (default 4433)")
+	fs.StringVar(&username, "mpsusername", "", "Username AMT uses with the MPS")
+	fs.StringVar(&password, "mpspassword", "", "Password AMT uses with the MPS")
+	fs.StringVar(&cert, "mpscert", "", "Root certificate of the MPS, as base64 or a PEM or DER file")
+	fs.StringVar(&commonName, "mpscn", "", "Common name of the MPS server certificate")
+	fs.BoolVar(&f.ConfigureCIRA.Remove, "remove", false, "Remove the MPS and the CIRA policies")
+	fs.StringVar(&f.configContent, "config", "", "specify a config file or smb: file share URL")
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printConfigureCIRAUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.ConfigureCIRA.Remove {
+		if address != "" || port != 0 || username != "" || password != "" || commonName != "" || f.configContent != "" {
+			fmt.Println("-remove only takes -mpscert")
+			return utils.IncorrectCommandLineParameters
+		}
+	} else if err := f.handleLocalConfig(); err != nil {
+		return utils.FailedReadingConfiguration
+	}
+
+	cira := &f.LocalConfig.CIRA
+	// options given on the command line override the config file
+	overrides := []struct {
+		value string
+		field *string
+	}{
+		{address, &cira.MPSAddress}, {username, &cira.MPSUsername}, {password, &cira.MPSPassword},
+		{cert, &cira.MPSCert}, {commonName, &cira.CommonName},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.field = override.value
+		}
+	}
+	if cira.MPSCert != "" {
+		der, err := mpsCertificate(cira.MPSCert)
+		if err != nil {
+			fmt.Println("-mpscert:", err)
+			return utils.InvalidUserInput
+		}
+		cira.MPSCert = der
+	}
+	if f.ConfigureCIRA.Remove {
+		return nil
+	}
+
+	if port != 0 {
+		cira.MPSPort = port
+	}
+	if cira.MPSPort == 0 {
+		cira.MPSPort = DefaultMPSPort
+	}
+	if cira.MPSPort < 1 || cira.MPSPort > 65535 {
+		fmt.Println("-mpsport must be between 1 and 65535")
+		return utils.InvalidUserInput
+	}
+	if cira.MPSAddress == "" {
+		fmt.Println("-mpsaddress is required")
+		return utils.InvalidUserInput
+	}
+	if net.ParseIP(cira.MPSAddress) == nil && !detectionDomainPattern.MatchString(cira.MPSAddress) {
+		fmt.Printf("-mpsaddress: %q is not an FQDN or IP address\n", cira.MPSAddress)
+		return utils.InvalidUserInput
+	}
+	if len(cira.MPSUsername) > maxMPSCredentialLength || !mpsUsernamePattern.MatchString(cira.MPSUsername) {
+		fmt.Printf("-mpsusername is required, up to %d letters and digits\n", maxMPSCredentialLength)
+		return utils.InvalidUserInput
+	}
+	if cira.MPSCert == "" {
+		fmt.Println("-mpscert is required, AMT checks the MPS server certificate against it")
+		return utils.InvalidUserInput
+	}
+	if cira.CommonName == "" {
+		cira.CommonName = cira.MPSAddress
+	}
+	if cira.MPSPassword == "" {
+		if err := f.ReadNewPasswordTo(&cira.MPSPassword, "MPS password"); err != nil {
+			return err
+		}
+	}
+	if len(cira.MPSPassword) > maxMPSCredentialLength {
+		fmt.Printf("-mpspassword: AMT takes up to %d characters\n", maxMPSCredentialLength)
+		return utils.InvalidUserInput
+	}
+	return nil
+}
+
+// mpsCertificate returns the base64 DER encoding AMT takes of a certificate
+// given as base64, or as a PEM or DER file
+func mpsCertificate(value string) (string, error) {
+	der, err := base64.StdEncoding.DecodeString(value)
+	if info, statErr := os.Stat(value); statErr == nil && !info.IsDir() {
+		if der, err = os.ReadFile(value); err != nil {
+			return "", err
+		}
+		if block, _ := pem.Decode(der); block != nil {
+			der = block.Bytes
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("%s is neither a file nor base64", value)
+	}
+	if _, err = x509.ParseCertificate(der); err != nil {
+		return "", fmt.Errorf("not a certificate: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(der), nil
+}
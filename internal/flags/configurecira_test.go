@@ -0,0 +1,128 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/internal/certs"
+	"rpc/internal/config"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleConfigureCIRA(t *testing.T) {
+	root, err := certs.NewRootComposite()
+	assert.NoError(t, err)
+	mpsCert := root.StripPem()
+	pemFile := filepath.Join(t.TempDir(), "mpsroot.pem")
+	err = os.WriteFile(pemFile, []byte(root.Pem), 0644)
+	assert.NoError(t, err)
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err = os.WriteFile(configFile, []byte("password: P@ssw0rd\ncira:\n  mpsAddress: 192.168.1.10\n  mpsPort: 4434\n  mpsUsername: admin\n  mpsPassword: MPSp@ss\n  mpsCert: "+pemFile+"\n  commonName: mps.example.com\n"), 0644)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		cmdLine    []string
+		wantResult error
+		wantRemove bool
+		wantCIRA   config.CIRA
+	}{
+		"should pass with options": {
+			cmdLine:  []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantCIRA: config.CIRA{MPSAddress: "mps.example.com", MPSPort: DefaultMPSPort, MPSUsername: "admin", MPSPassword: "MPSp@ss", MPSCert: mpsCert, CommonName: "mps.example.com"},
+		},
+		"should pass with a PEM file and prompt for the MPS password": {
+			cmdLine:  []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsport", "443", "-mpsusername", "admin", "-mpscert", pemFile, "-password", "P@ssw0rd"},
+			wantCIRA: config.CIRA{MPSAddress: "mps.example.com", MPSPort: 443, MPSUsername: "admin", MPSPassword: utils.TestPassword, MPSCert: mpsCert, CommonName: "mps.example.com"},
+		},
+		"should pass with config": {
+			cmdLine:  []string{"rpc", "configure", "cira", "-config", configFile},
+			wantCIRA: config.CIRA{MPSAddress: "192.168.1.10", MPSPort: 4434, MPSUsername: "admin", MPSPassword: "MPSp@ss", MPSCert: mpsCert, CommonName: "mps.example.com"},
+		},
+		"should pass with config and options overriding it": {
+			cmdLine:  []string{"rpc", "configure", "cira", "-config", configFile, "-mpsusername", "operator"},
+			wantCIRA: config.CIRA{MPSAddress: "192.168.1.10", MPSPort: 4434, MPSUsername: "operator", MPSPassword: "MPSp@ss", MPSCert: mpsCert, CommonName: "mps.example.com"},
+		},
+		"should pass with remove": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-remove", "-password", "P@ssw0rd"},
+			wantRemove: true,
+		},
+		"should pass with remove and the root certificate": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-remove", "-mpscert", pemFile, "-password", "P@ssw0rd"},
+			wantRemove: true,
+			wantCIRA:   config.CIRA{MPSCert: mpsCert},
+		},
+		"should fail with remove and an address": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-remove", "-mpsaddress", "mps.example.com", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail without an address": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid address": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps example.com", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid port": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsport", "70000", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid username": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsusername", "mps-admin", "-mpspassword", "MPSp@ss", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with a long password": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsusername", "admin", "-mpspassword", "a-very-long-MPS-password", "-mpscert", mpsCert, "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail without a root certificate": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid root certificate": {
+			cmdLine:    []string{"rpc", "configure", "cira", "-mpsaddress", "mps.example.com", "-mpsusername", "admin", "-mpspassword", "MPSp@ss", "-mpscert", "bm90IGEgY2VydA==", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "configure", "cira", "mps.example.com", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, utils.SubCommandConfigureCIRA, flags.SubCommand)
+				assert.Equal(t, tc.wantRemove, flags.ConfigureCIRA.Remove)
+				assert.Equal(t, tc.wantCIRA, flags.LocalConfig.CIRA)
+			}
+		})
+	}
+}
+
+func TestMPSCertificate(t *testing.T) {
+	root, err := certs.NewRootComposite()
+	assert.NoError(t, err)
+	derFile := filepath.Join(t.TempDir(), "mpsroot.cer")
+	err = os.WriteFile(derFile, root.Cert.Raw, 0644)
+	assert.NoError(t, err)
+
+	cert, err := mpsCertificate(derFile)
+	assert.NoError(t, err)
+	assert.Equal(t, root.StripPem(), cert)
+	cert, err = mpsCertificate(root.StripPem())
+	assert.NoError(t, err)
+	assert.Equal(t, root.StripPem(), cert)
+	_, err = mpsCertificate("missing.pem")
+	assert.Error(t, err)
+}
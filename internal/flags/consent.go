@@ -0,0 +1,95 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"strconv"
+)
+
+type ConsentFlags struct {
+	Code int
+}
+
+func (f *Flags) printConsentUsage() string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " consent COMMAND [OPTIONS]\n\n"
+	usage = usage + "Supported Consent Commands:\n"
+	usage = usage + "  start      Start a user consent session, AMT shows a code on the device screen\n"
+	usage = usage + "             Example: " + executable + " consent start -password P@ssw0rd -target 192.168.1.20\n"
+	usage = usage + "  send CODE  Send the 6 digit code the user reads from the screen\n"
+	usage = usage + "             Example: " + executable + " consent send 123456 -password P@ssw0rd -target 192.168.1.20\n"
+	usage = usage + "  cancel     Cancel the user consent session\n"
+	usage = usage + "  status     Show the user consent state\n"
+	usage = usage + "             Example: " + executable + " consent status -json\n"
+	usage = usage + "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleConsentCommand() error {
+	if len(f.commandLineArgs) == 2 {
+		f.printConsentUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	f.SubCommand = f.commandLineArgs[2]
+	args := f.commandLineArgs[3:]
+	switch f.SubCommand {
+	case utils.SubCommandStart, utils.SubCommandCancel, utils.SubCommandStatus:
+	case utils.SubCommandSend:
+		// the code comes right after send, the options after it
+		if len(args) == 0 || !isConsentCode(args[0]) {
+			fmt.Println("send needs the 6 digit code shown on the device screen")
+			return utils.InvalidUserInput
+		}
+		f.Consent.Code, _ = strconv.Atoi(args[0])
+		args = args[1:]
+	default:
+		f.printConsentUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	if err := fs.Parse(args); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printConsentUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	f.Local = true
+	return nil
+}
+
+func isConsentCode(value string) bool {
+	if len(value) != 6 {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
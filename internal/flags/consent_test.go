@@ -0,0 +1,81 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleConsentCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		pr         utils.PasswordReader
+		wantResult error
+		wantCode   int
+	}{
+		"should fail without subcommand": {
+			cmdLine:    []string{"rpc", "consent"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown subcommand": {
+			cmdLine:    []string{"rpc", "consent", "grant"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with start": {
+			cmdLine: []string{"rpc", "consent", "start", "-password", "P@ssw0rd"},
+		},
+		"should pass with status": {
+			cmdLine: []string{"rpc", "consent", "status", "-json", "-password", "P@ssw0rd"},
+		},
+		"should pass with cancel": {
+			cmdLine: []string{"rpc", "consent", "cancel", "-password", "P@ssw0rd"},
+		},
+		"should pass with send": {
+			cmdLine:  []string{"rpc", "consent", "send", "012345", "-password", "P@ssw0rd"},
+			wantCode: 12345,
+		},
+		"should fail with send without code": {
+			cmdLine:    []string{"rpc", "consent", "send", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with a short code": {
+			cmdLine:    []string{"rpc", "consent", "send", "12345", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with a code that is not a number": {
+			cmdLine:    []string{"rpc", "consent", "send", "12345a", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "consent", "start", "now", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail without password": {
+			cmdLine:    []string{"rpc", "consent", "status"},
+			pr:         MockPRFail,
+			wantResult: utils.MissingOrIncorrectPassword,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pr := tc.pr
+			if pr == nil {
+				pr = MockPRSuccess
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.cmdLine[2], flags.SubCommand)
+				assert.Equal(t, tc.wantCode, flags.Consent.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"rpc/pkg/utils"
+	"strings"
+)
+
+// MaxDetectionDomains is the number of detection strings AMT keeps
+const MaxDetectionDomains = 5
+
+// maxDetectionDomainLength is the longest detection string AMT takes
+const maxDetectionDomainLength = 192
+
+var detectionDomainPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+type EnvironmentDetectionFlags struct {
+	// Update is false when the domains are only read back
+	Update bool
+}
+
+func (f *Flags) printEnvironmentDetectionUsage() string {
+	baseCommand := fmt.Sprintf("%s %s %s", filepath.Base(os.Args[0]), utils.CommandConfigure, utils.SubCommandEnvDetection)
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage += "Usage: " + baseCommand + " [OPTIONS]\n\n"
+	usage += "Sets the DNS suffixes AMT uses to tell it is inside the enterprise network. CIRA only connects\n"
+	usage += "to the MPS outside of them. Without options the domains are shown.\n\n"
+	usage += "Options:\n"
+	usage += "  -domains   Comma separated DNS suffixes, up to 5\n"
+	usage += "             Example: " + baseCommand + " -domains corp.example.com,lab.example.com\n"
+	usage += "  -clear     Remove all domains\n"
+	usage += "  -config    Config file or smb: file share URL with an environmentDetection section\n"
+	usage += "             Example: " + baseCommand + " -config config.yaml\n"
+	usage += "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleEnvironmentDetection() error {
+	domains := ""
+	clearDomains := false
+	fs := f.NewConfigureFlagSet(utils.SubCommandEnvDetection)
+	fs.StringVar(&domains, "domains", "", "Comma separated DNS suffixes")
+	fs.BoolVar(&clearDomains, "clear", false, "Remove all domains")
+	fs.StringVar(&f.configContent, "config", "", "specify a config file or smb: file share URL")
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printEnvironmentDetectionUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	sources := 0
+	for _, set := range []bool{domains != "", clearDomains, f.configContent != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		fmt.Println("use only one of -domains, -clear and -config")
+		return utils.IncorrectCommandLineParameters
+	}
+	if sources == 0 {
+		return nil
+	}
+	f.EnvironmentDetection.Update = true
+	if clearDomains {
+		f.LocalConfig.EnvironmentDetection.Domains = nil
+		return nil
+	}
+	if domains != "" {
+		f.LocalConfig.EnvironmentDetection.Domains = strings.Split(domains, ",")
+	} else {
+		if err := f.handleLocalConfig(); err != nil {
+			return utils.FailedReadingConfiguration
+		}
+		if len(f.LocalConfig.EnvironmentDetection.Domains) == 0 {
+			fmt.Println("the config has no environmentDetection domains")
+			return utils.InvalidUserInput
+		}
+	}
+	if err := validateDetectionDomains(f.LocalConfig.EnvironmentDetection.Domains); err != nil {
+		fmt.Println("-domains:", err)
+		return utils.InvalidUserInput
+	}
+	return nil
+}
+
+// validateDetectionDomains checks the domains are DNS names AMT can keep
+func validateDetectionDomains(domains []string) error {
+	if len(domains) > MaxDetectionDomains {
+		return fmt.Errorf("AMT keeps up to %d domains", MaxDetectionDomains)
+	}
+	for i, domain := range domains {
+		if len(domain) > maxDetectionDomainLength || !detectionDomainPattern.MatchString(domain) {
+			return fmt.Errorf("%q is not a DNS suffix", domain)
+		}
+		for _, other := range domains[:i] {
+			if strings.EqualFold(other, domain) {
+				return fmt.Errorf("%s is listed twice", domain)
+			}
+		}
+	}
+	return nil
+}
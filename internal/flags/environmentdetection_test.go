@@ -0,0 +1,87 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleEnvironmentDetection(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte("password: P@ssw0rd\nenvironmentDetection:\n  domains:\n    - corp.example.com\n    - lab.example.com\n"), 0644)
+	assert.NoError(t, err)
+	emptyConfigFile := filepath.Join(t.TempDir(), "empty.yaml")
+	err = os.WriteFile(emptyConfigFile, []byte("password: P@ssw0rd\n"), 0644)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		cmdLine     []string
+		wantResult  error
+		wantUpdate  bool
+		wantDomains []string
+	}{
+		"should read back without options": {
+			cmdLine: []string{"rpc", "configure", "environmentdetection", "-json", "-password", "P@ssw0rd"},
+		},
+		"should pass with domains": {
+			cmdLine:     []string{"rpc", "configure", "environmentdetection", "-domains", "a.corp,b.corp", "-password", "P@ssw0rd"},
+			wantUpdate:  true,
+			wantDomains: []string{"a.corp", "b.corp"},
+		},
+		"should pass with clear": {
+			cmdLine:    []string{"rpc", "configure", "environmentdetection", "-clear", "-password", "P@ssw0rd"},
+			wantUpdate: true,
+		},
+		"should pass with config": {
+			cmdLine:     []string{"rpc", "configure", "environmentdetection", "-config", configFile},
+			wantUpdate:  true,
+			wantDomains: []string{"corp.example.com", "lab.example.com"},
+		},
+		"should fail with config without domains": {
+			cmdLine:    []string{"rpc", "configure", "environmentdetection", "-config", emptyConfigFile},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with domains and clear": {
+			cmdLine:    []string{"rpc", "configure", "environmentdetection", "-domains", "a.corp", "-clear", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with an invalid domain": {
+			cmdLine:    []string{"rpc", "configure", "environmentdetection", "-domains", "a.corp,-b.corp", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "configure", "environmentdetection", "a.corp", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, utils.SubCommandEnvDetection, flags.SubCommand)
+				assert.Equal(t, tc.wantUpdate, flags.EnvironmentDetection.Update)
+				assert.Equal(t, tc.wantDomains, flags.LocalConfig.EnvironmentDetection.Domains)
+			}
+		})
+	}
+}
+
+func TestValidateDetectionDomains(t *testing.T) {
+	assert.NoError(t, validateDetectionDomains([]string{"corp", "corp.example.com", "lab-1.example.com"}))
+	assert.Error(t, validateDetectionDomains([]string{"a", "b", "c", "d", "e", "f"}))
+	assert.Error(t, validateDetectionDomains([]string{""}))
+	assert.Error(t, validateDetectionDomains([]string{"corp example.com"}))
+	assert.Error(t, validateDetectionDomains([]string{".example.com"}))
+	assert.Error(t, validateDetectionDomains([]string{"a.corp", "A.corp"}))
+}
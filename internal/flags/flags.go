@@ -14,7 +14,9 @@ import (
 	"path/filepath"
 	"rpc/internal/amt"
 	"rpc/internal/config"
+	"rpc/internal/lm"
 	"rpc/internal/smb"
+	"rpc/pkg/heci"
 	"rpc/pkg/utils"
 	"strconv"
 	"strings"
@@ -108,12 +110,33 @@ type Flags struct {
 	KVM                                 bool
 	SOL                                 bool
 	IDER                                bool
+	HostFQDN                            string
+	ProvisioningOTP                     string
+	CIRATimeout                         time.Duration
+	MEIDevice                           string
+	HECICapture                         string
+	Transport                           string
+	TLSPin                              string
+	TLSCABundle                         string
+	TLSTOFU                             bool
+	Target                              string
+	Watchdog                            WatchdogFlags
+	LMS                                 LMSFlags
+	Boot                                BootFlags
+	Logs                                LogsFlags
+	Inventory                           InventoryFlags
+	Alarm                               AlarmFlags
+	Users                               UsersFlags
+	EnvironmentDetection                EnvironmentDetectionFlags
+	ConfigureCIRA                       ConfigureCIRAFlags
+	Consent                             ConsentFlags
 }
 
 func NewFlags(args []string, pr utils.PasswordReader) *Flags {
 	flags := &Flags{}
 	flags.passwordReader = pr
 	flags.commandLineArgs = args
+	flags.Transport = lm.TransportAuto
 	flags.amtInfoCommand = flag.NewFlagSet(utils.CommandAMTInfo, flag.ContinueOnError)
 	flags.amtInfoCommand.BoolVar(&flags.JsonOutput, "json", false, "json output")
 
@@ -149,6 +172,9 @@ func NewFlags(args []string, pr utils.PasswordReader) *Flags {
 // ParseFlags is used for understanding the command line flags
 func (f *Flags) ParseFlags() error {
 	var err error
+	if f.commandLineArgs, err = f.extractGlobalOptions(f.commandLineArgs); err != nil {
+		return err
+	}
 	if len(f.commandLineArgs) > 1 {
 		f.Command = f.commandLineArgs[1]
 	}
@@ -165,13 +191,63 @@ func (f *Flags) ParseFlags() error {
 		err = f.handleVersionCommand()
 	case utils.CommandConfigure:
 		err = f.handleConfigureCommand()
+	case utils.CommandCIRA:
+		err = f.handleCIRACommand()
+	case utils.CommandWatchdog:
+		err = f.handleWatchdogCommand()
+	case utils.CommandLMS:
+		err = f.handleLMSCommand()
+	case utils.CommandPower:
+		err = f.handlePowerCommand()
+	case utils.CommandBoot:
+		err = f.handleBootCommand()
+	case utils.CommandLogs:
+		err = f.handleLogsCommand()
+	case utils.CommandInventory:
+		err = f.handleInventoryCommand()
+	case utils.CommandConsent:
+		err = f.handleConsentCommand()
 	default:
 		err = utils.IncorrectCommandLineParameters
 		f.printUsage()
 	}
+	if err == nil {
+		err = f.validateTarget()
+	}
 	return err
 }
 
+// validateTarget checks that the command can run against a remote -target.
+// Only WS-MAN reaches a remote AMT, so commands needing the host interface
+// or a server are refused.
+func (f *Flags) validateTarget() error {
+	if f.Target == "" {
+		return nil
+	}
+	switch f.Command {
+	case utils.CommandConfigure:
+		switch f.SubCommand {
+		case utils.SubCommandDNSSuffix, utils.SubCommandStartConfig, utils.SubCommandStopConfig:
+		default:
+			return nil
+		}
+	case utils.CommandDeactivate:
+		if f.Local {
+			return nil
+		}
+	case utils.CommandPower, utils.CommandBoot, utils.CommandLogs, utils.CommandInventory, utils.CommandConsent:
+		return nil
+	case utils.CommandAMTInfo:
+		if f.AmtInfo.hostOnly() {
+			log.Error("with -target amtinfo shows -ver, -bld, -sku, -uuid, -mode and -userCert only")
+			return utils.NotAvailableOnRemoteTarget
+		}
+		return nil
+	}
+	log.Error("-target works with configure, deactivate -local, amtinfo, power, boot, logs, inventory and consent only")
+	return utils.NotAvailableOnRemoteTarget
+}
+
 func (f *Flags) printUsage() string {
 	executable := filepath.Base(os.Args[0])
 	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
@@ -181,19 +257,128 @@ func (f *Flags) printUsage() string {
 	usage = usage + "              Example: " + executable + " activate -u wss://server/activate --profile acmprofile\n"
 	usage = usage + "  amtinfo     Displays information about AMT status and configuration\n"
 	usage = usage + "              Example: " + executable + " amtinfo\n"
+	usage = usage + "  boot        Sets the device to boot from PXE, HDD, CD, IDER or a UEFI HTTPS URL. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " boot pxe -reset\n"
+	usage = usage + "  cira        Open, close or check a user initiated CIRA connection to the configured MPS\n"
+	usage = usage + "              Example: " + executable + " cira connect\n"
 	usage = usage + "  configure   Local configuration of a feature on this device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " configure " + utils.SubCommandWireless + " ...\n"
+	usage = usage + "  consent     Starts, answers or cancels a user consent session. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " consent send 123456\n"
 	usage = usage + "  deactivate  Deactivates this device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " deactivate -u wss://server/activate\n"
+	usage = usage + "  inventory   Exports the hardware inventory AMT reports as JSON, YAML or CSV. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " inventory -format yaml\n"
+	usage = usage + "  lms         Forwards the AMT ports on localhost over LME for hosts without Intel LMS\n"
+	usage = usage + "              Example: " + executable + " lms\n"
+	usage = usage + "  logs        Reads the AMT event and audit logs. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " logs events -since 24h\n"
 	usage = usage + "  maintenance Execute a maintenance task for the device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " maintenance syncclock -u wss://server/activate \n"
+	usage = usage + "  power       Shows or changes the power state of the device. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " power reset\n"
 	usage = usage + "  version     Displays the current version of RPC and the RPC Protocol version\n"
 	usage = usage + "              Example: " + executable + " version\n"
+	usage = usage + "  watchdog    Registers an agent presence watchdog and sends heartbeats. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " watchdog -interval 30s -timeout 2m\n"
+	usage = usage + "\nGlobal Options:\n"
+	usage = usage + "  -mei        MEI device to use instead of discovering it. Can also be set with " + heci.DeviceEnvVar + "\n"
+	usage = usage + "              Example: " + executable + " amtinfo -mei /dev/mei1\n"
+	usage = usage + "  -heci-capture Record the raw HECI traffic to a JSON-lines file. Play it back with " + heci.ReplayEnvVar + "\n"
+	usage = usage + "              Example: " + executable + " activate -local -heci-capture capture.jsonl ...\n"
+	usage = usage + "  -transport  How to reach AMT: auto (LMS if it answers, else LME), lms or lme. Default auto\n"
+	usage = usage + "              Example: " + executable + " amtinfo -userCert -transport lme\n"
+	usage = usage + "  -tls-pin    SHA-256 fingerprint of the AMT TLS certificate to trust when local TLS is in use\n"
+	usage = usage + "  -tls-ca     PEM bundle of CAs the AMT TLS certificate must chain to\n"
+	usage = usage + "  -tls-tofu   Trust the AMT TLS certificate on first use and pin its fingerprint\n"
+	usage = usage + "              Example: " + executable + " configure wired -dhcp -ipsync -tls-tofu ...\n"
+	usage = usage + "  -target     Manage the AMT at host[:port] over the network instead of this host. Port 16993 uses TLS\n"
+	usage = usage + "              Example: " + executable + " amtinfo -target 192.168.1.20:16993 -tls-tofu -password P@ssw0rd\n"
 	usage = usage + "\nRun '" + executable + " COMMAND' for more information on a command.\n"
 	fmt.Println(usage)
 	return usage
 }
 
+// globalOptions are the options extractGlobalOptions takes from any command,
+// mapped to whether they need a value
+var globalOptions = map[string]bool{
+	"mei":          true,
+	"heci-capture": true,
+	"transport":    true,
+	"tls-pin":      true,
+	"tls-ca":       true,
+	"tls-tofu":     false,
+	"target":       true,
+}
+
+// extractGlobalOptions removes the global options (-mei, -heci-capture,
+// -transport, -target and the -tls trust options), which may appear anywhere after
+// the command, and applies them before any subcommand touches the device
+func (f *Flags) extractGlobalOptions(args []string) ([]string, error) {
+	remaining := []string{}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		needsValue, global := globalOptions[name]
+		if i == 0 || !strings.HasPrefix(args[i], "-") || !global {
+			remaining = append(remaining, args[i])
+			continue
+		}
+		if !needsValue {
+			enabled := true
+			if hasValue {
+				var err error
+				if enabled, err = strconv.ParseBool(value); err != nil {
+					return args, utils.IncorrectCommandLineParameters
+				}
+			}
+			f.TLSTOFU = enabled
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return args, utils.IncorrectCommandLineParameters
+			}
+			i++
+			value = args[i]
+		}
+		if value == "" {
+			return args, utils.IncorrectCommandLineParameters
+		}
+		switch name {
+		case "mei":
+			f.MEIDevice = value
+		case "transport":
+			if !lm.IsTransport(value) {
+				log.Error("unknown transport ", value, ", expected auto, lms or lme")
+				return args, utils.IncorrectCommandLineParameters
+			}
+			f.Transport = value
+		case "tls-pin":
+			f.TLSPin = value
+		case "tls-ca":
+			f.TLSCABundle = value
+		case "target":
+			if _, _, err := utils.ParseTarget(value); err != nil {
+				log.Error(err)
+				return args, utils.IncorrectCommandLineParameters
+			}
+			f.Target = value
+		default:
+			f.HECICapture = value
+		}
+	}
+	if f.MEIDevice != "" {
+		heci.DevicePath = f.MEIDevice
+	}
+	if f.HECICapture != "" {
+		if err := heci.StartCapture(f.HECICapture); err != nil {
+			log.Error("unable to create HECI capture file: ", err)
+			return args, utils.InvalidUserInput
+		}
+	}
+	return remaining, nil
+}
+
 func (f *Flags) setupCommonFlags() {
 	for _, fs := range []*flag.FlagSet{
 		f.amtActivateCommand,
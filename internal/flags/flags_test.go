@@ -13,7 +13,9 @@ import (
 	"os"
 	"path/filepath"
 	"rpc/internal/config"
+	"rpc/internal/lm"
 	"rpc/internal/smb"
+	"rpc/pkg/heci"
 	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 	"testing"
@@ -112,6 +114,55 @@ func (c MockPTHICommands) Unprovision() (mode int, err error) {
 	return result, nil
 }
 
+func (c MockPTHICommands) GetFeaturesState(requestID pthi.FeaturesStateRequestID) (pthi.GetFeaturesStateResponse, error) {
+	return pthi.GetFeaturesStateResponse{}, nil
+}
+func (c MockPTHICommands) GetLastHostResetReason() (pthi.GetLastHostResetReasonResponse, error) {
+	return pthi.GetLastHostResetReasonResponse{}, nil
+}
+func (c MockPTHICommands) GetCurrentPowerPolicy() (string, error) { return "", nil }
+func (c MockPTHICommands) GetMACAddresses() (pthi.GetMACAddressesResponse, error) {
+	return pthi.GetMACAddressesResponse{}, nil
+}
+func (c MockPTHICommands) GetSecurityParameters() (pthi.GetSecurityParametersResponse, error) {
+	return pthi.GetSecurityParametersResponse{}, nil
+}
+func (c MockPTHICommands) GetProvisioningTLSMode() (int, error) { return 0, nil }
+func (c MockPTHICommands) GetZeroTouchEnabled() (bool, error)   { return false, nil }
+func (c MockPTHICommands) GetFQDN() (pthi.GetFQDNResponse, error) {
+	return pthi.GetFQDNResponse{}, nil
+}
+func (c MockPTHICommands) GetEHBCState() (int, error)          { return 0, nil }
+func (c MockPTHICommands) GetDNSSuffixList() ([]string, error) { return []string{}, nil }
+func (c MockPTHICommands) SetDNSSuffix(string) (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) SetHostFQDN(string) (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) GetProvisioningState() (int, error) { return 0, nil }
+func (c MockPTHICommands) StopConfiguration() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) StartConfiguration() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) SetProvisioningServerOTP(string) (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) OpenUserInitiatedConnection() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) CloseUserInitiatedConnection() (pthi.Status, error) {
+	return pthi.AMT_STATUS_SUCCESS, nil
+}
+func (c MockPTHICommands) StartWatchdogTimer(timeout uint16) (pthi.WatchdogStartTimerResponse, error) {
+	return pthi.WatchdogStartTimerResponse{}, nil
+}
+func (c MockPTHICommands) StopWatchdogTimer() error {
+	return nil
+}
+
 var testNetEnumerator = NetEnumerator{
 	Interfaces: func() ([]net.Interface, error) {
 		return []net.Interface{
@@ -192,14 +243,43 @@ func TestPrintUsage(t *testing.T) {
 	usage = usage + "              Example: " + executable + " activate -u wss://server/activate --profile acmprofile\n"
 	usage = usage + "  amtinfo     Displays information about AMT status and configuration\n"
 	usage = usage + "              Example: " + executable + " amtinfo\n"
+	usage = usage + "  boot        Sets the device to boot from PXE, HDD, CD, IDER or a UEFI HTTPS URL. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " boot pxe -reset\n"
+	usage = usage + "  cira        Open, close or check a user initiated CIRA connection to the configured MPS\n"
+	usage = usage + "              Example: " + executable + " cira connect\n"
 	usage = usage + "  configure   Local configuration of a feature on this device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " configure " + utils.SubCommandWireless + " ...\n"
+	usage = usage + "  consent     Starts, answers or cancels a user consent session. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " consent send 123456\n"
 	usage = usage + "  deactivate  Deactivates this device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " deactivate -u wss://server/activate\n"
+	usage = usage + "  inventory   Exports the hardware inventory AMT reports as JSON, YAML or CSV. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " inventory -format yaml\n"
+	usage = usage + "  lms         Forwards the AMT ports on localhost over LME for hosts without Intel LMS\n"
+	usage = usage + "              Example: " + executable + " lms\n"
+	usage = usage + "  logs        Reads the AMT event and audit logs. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " logs events -since 24h\n"
 	usage = usage + "  maintenance Execute a maintenance task for the device. AMT password is required\n"
 	usage = usage + "              Example: " + executable + " maintenance syncclock -u wss://server/activate \n"
+	usage = usage + "  power       Shows or changes the power state of the device. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " power reset\n"
 	usage = usage + "  version     Displays the current version of RPC and the RPC Protocol version\n"
 	usage = usage + "              Example: " + executable + " version\n"
+	usage = usage + "  watchdog    Registers an agent presence watchdog and sends heartbeats. AMT password is required\n"
+	usage = usage + "              Example: " + executable + " watchdog -interval 30s -timeout 2m\n"
+	usage = usage + "\nGlobal Options:\n"
+	usage = usage + "  -mei        MEI device to use instead of discovering it. Can also be set with RPC_MEI_DEVICE\n"
+	usage = usage + "              Example: " + executable + " amtinfo -mei /dev/mei1\n"
+	usage = usage + "  -heci-capture Record the raw HECI traffic to a JSON-lines file. Play it back with RPC_HECI_REPLAY\n"
+	usage = usage + "              Example: " + executable + " activate -local -heci-capture capture.jsonl ...\n"
+	usage = usage + "  -transport  How to reach AMT: auto (LMS if it answers, else LME), lms or lme. Default auto\n"
+	usage = usage + "              Example: " + executable + " amtinfo -userCert -transport lme\n"
+	usage = usage + "  -tls-pin    SHA-256 fingerprint of the AMT TLS certificate to trust when local TLS is in use\n"
+	usage = usage + "  -tls-ca     PEM bundle of CAs the AMT TLS certificate must chain to\n"
+	usage = usage + "  -tls-tofu   Trust the AMT TLS certificate on first use and pin its fingerprint\n"
+	usage = usage + "              Example: " + executable + " configure wired -dhcp -ipsync -tls-tofu ...\n"
+	usage = usage + "  -target     Manage the AMT at host[:port] over the network instead of this host. Port 16993 uses TLS\n"
+	usage = usage + "              Example: " + executable + " amtinfo -target 192.168.1.20:16993 -tls-tofu -password P@ssw0rd\n"
 	usage = usage + "\nRun '" + executable + " COMMAND' for more information on a command.\n"
 	assert.Equal(t, usage, output)
 }
@@ -478,3 +558,154 @@ func TestReadNewPasswordTo(t *testing.T) {
 	flags.ReadNewPasswordTo(&password, "TEST")
 	assert.Equal(t, utils.TestPassword, password)
 }
+
+func TestExtractGlobalOptions(t *testing.T) {
+	defer func() { heci.DevicePath = "" }()
+	tests := map[string]struct {
+		cmdLine    []string
+		wantArgs   []string
+		wantDevice string
+		wantErr    error
+	}{
+		"no override": {
+			cmdLine:  []string{"rpc", "amtinfo", "-json"},
+			wantArgs: []string{"rpc", "amtinfo", "-json"},
+		},
+		"separate value": {
+			cmdLine:    []string{"rpc", "amtinfo", "-mei", "/dev/mei1", "-json"},
+			wantArgs:   []string{"rpc", "amtinfo", "-json"},
+			wantDevice: "/dev/mei1",
+		},
+		"equals value": {
+			cmdLine:    []string{"rpc", "version", "--mei=/dev/mei2"},
+			wantArgs:   []string{"rpc", "version"},
+			wantDevice: "/dev/mei2",
+		},
+		"missing value": {
+			cmdLine: []string{"rpc", "amtinfo", "-mei"},
+			wantErr: utils.IncorrectCommandLineParameters,
+		},
+		"empty value": {
+			cmdLine: []string{"rpc", "amtinfo", "-mei="},
+			wantErr: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			heci.DevicePath = ""
+			f := NewFlags(tc.cmdLine, MockPRSuccess)
+			args, err := f.extractGlobalOptions(tc.cmdLine)
+			assert.Equal(t, tc.wantErr, err)
+			if err == nil {
+				assert.Equal(t, tc.wantArgs, args)
+				assert.Equal(t, tc.wantDevice, f.MEIDevice)
+				assert.Equal(t, tc.wantDevice, heci.DevicePath)
+			}
+		})
+	}
+}
+
+func TestExtractHECICapture(t *testing.T) {
+	defer heci.StopCapture()
+	capture := filepath.Join(t.TempDir(), "capture.jsonl")
+	f := NewFlags([]string{"rpc", "amtinfo", "-heci-capture", capture, "-ver"}, MockPRSuccess)
+	args, err := f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rpc", "amtinfo", "-ver"}, args)
+	assert.Equal(t, capture, f.HECICapture)
+	assert.NotNil(t, heci.ActiveCapture())
+	assert.FileExists(t, capture)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-heci-capture", t.TempDir()}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.Equal(t, utils.InvalidUserInput, err)
+}
+
+func TestExtractTransport(t *testing.T) {
+	f := NewFlags([]string{"rpc", "amtinfo", "-userCert"}, MockPRSuccess)
+	_, err := f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.Equal(t, lm.TransportAuto, f.Transport)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-transport", "lme", "-userCert"}, MockPRSuccess)
+	args, err := f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rpc", "amtinfo", "-userCert"}, args)
+	assert.Equal(t, lm.TransportLME, f.Transport)
+
+	f = NewFlags([]string{"rpc", "activate", "-u", "wss://localhost", "--transport=lms"}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.Equal(t, lm.TransportLMS, f.Transport)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-transport", "usb"}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.Equal(t, utils.IncorrectCommandLineParameters, err)
+}
+
+func TestExtractTLSOptions(t *testing.T) {
+	f := NewFlags([]string{"rpc", "amtinfo", "-tls-pin", "AB:CD", "-userCert", "--tls-ca=/etc/amt-ca.pem", "-tls-tofu"}, MockPRSuccess)
+	args, err := f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rpc", "amtinfo", "-userCert"}, args)
+	assert.Equal(t, "AB:CD", f.TLSPin)
+	assert.Equal(t, "/etc/amt-ca.pem", f.TLSCABundle)
+	assert.True(t, f.TLSTOFU)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-tls-tofu=false"}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.NoError(t, err)
+	assert.False(t, f.TLSTOFU)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-tls-tofu=maybe"}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.Equal(t, utils.IncorrectCommandLineParameters, err)
+
+	f = NewFlags([]string{"rpc", "amtinfo", "-tls-pin"}, MockPRSuccess)
+	_, err = f.extractGlobalOptions(f.commandLineArgs)
+	assert.Equal(t, utils.IncorrectCommandLineParameters, err)
+}
+
+func TestParseFlagsWithTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdLine []string
+		wantErr error
+	}{
+		{name: "amtinfo", cmdLine: []string{"rpc", "amtinfo", "-target", "192.168.1.20"}},
+		{name: "amtinfo userCert", cmdLine: []string{"rpc", "amtinfo", "-userCert", "-target=192.168.1.20:16993", "-password", "P@ssw0rd"}},
+		{name: "amtinfo host only", cmdLine: []string{"rpc", "amtinfo", "-ras", "-target", "192.168.1.20"}, wantErr: utils.NotAvailableOnRemoteTarget},
+		{name: "configure", cmdLine: []string{"rpc", "configure", utils.SubCommandSyncClock, "-password", "P@ssw0rd", "-target", "amt.example.com"}},
+		{name: "configure dnssuffix", cmdLine: []string{"rpc", "configure", utils.SubCommandDNSSuffix, "-suffix", "vprodemo.com", "-target", "amt.example.com"}, wantErr: utils.NotAvailableOnRemoteTarget},
+		{name: "deactivate local", cmdLine: []string{"rpc", "deactivate", "-local", "-target", "[fe80::1]:16993"}},
+		{name: "deactivate remote", cmdLine: []string{"rpc", "deactivate", "-u", "wss://localhost", "-password", "P@ssw0rd", "-target", "192.168.1.20"}, wantErr: utils.NotAvailableOnRemoteTarget},
+		{name: "power", cmdLine: []string{"rpc", "power", "reset", "-password", "P@ssw0rd", "-target", "192.168.1.20"}},
+		{name: "boot", cmdLine: []string{"rpc", "boot", "pxe", "-reset", "-password", "P@ssw0rd", "-target", "192.168.1.20"}},
+		{name: "logs", cmdLine: []string{"rpc", "logs", "audit", "-json", "-password", "P@ssw0rd", "-target", "192.168.1.20"}},
+		{name: "inventory", cmdLine: []string{"rpc", "inventory", "-format", "csv", "-password", "P@ssw0rd", "-target", "192.168.1.20"}},
+		{name: "consent", cmdLine: []string{"rpc", "consent", "send", "123456", "-password", "P@ssw0rd", "-target", "192.168.1.20"}},
+		{name: "version", cmdLine: []string{"rpc", "version", "-target", "192.168.1.20"}, wantErr: utils.NotAvailableOnRemoteTarget},
+		{name: "bad port", cmdLine: []string{"rpc", "amtinfo", "-target", "192.168.1.20:70000"}, wantErr: utils.IncorrectCommandLineParameters},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFlags(tt.cmdLine, MockPRSuccess)
+			err := f.ParseFlags()
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+
+	f := NewFlags([]string{"rpc", "amtinfo", "-target", "192.168.1.20"}, MockPRSuccess)
+	assert.NoError(t, f.ParseFlags())
+	assert.Equal(t, "192.168.1.20", f.Target)
+	assert.Equal(t, AmtInfoFlags{Ver: true, Bld: true, Sku: true, UUID: true, Mode: true}, f.AmtInfo)
+}
+
+func TestParseFlagsWithMEIDevice(t *testing.T) {
+	defer func() { heci.DevicePath = "" }()
+	f := NewFlags([]string{"rpc", "version", "-mei", "/dev/mei1", "-json"}, MockPRSuccess)
+	err := f.ParseFlags()
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/mei1", f.MEIDevice)
+	assert.True(t, f.JsonOutput)
+}
@@ -23,6 +23,17 @@ type AmtInfoFlags struct {
 	Lan      bool
 	Hostname bool
 	OpState  bool
+
+	FeatureState  bool
+	ResetReason   bool
+	PowerPolicy   bool
+	MAC           bool
+	Security      bool
+	TLSMode       bool
+	ZeroTouch     bool
+	FQDN          bool
+	EHBC          bool
+	DNSSuffixList bool
 }
 
 func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) error {
@@ -41,6 +52,16 @@ func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) error {
 	amtInfoCommand.BoolVar(&f.AmtInfo.Lan, "lan", false, "LAN Settings")
 	amtInfoCommand.BoolVar(&f.AmtInfo.Hostname, "hostname", false, "OS Hostname")
 	amtInfoCommand.BoolVar(&f.AmtInfo.OpState, "operationalState", false, "AMT Operational State")
+	amtInfoCommand.BoolVar(&f.AmtInfo.FeatureState, "featureState", false, "Redirection Session, System Defense and Web UI State")
+	amtInfoCommand.BoolVar(&f.AmtInfo.ResetReason, "resetReason", false, "Last Host Reset Reason")
+	amtInfoCommand.BoolVar(&f.AmtInfo.PowerPolicy, "powerPolicy", false, "Current Power Policy")
+	amtInfoCommand.BoolVar(&f.AmtInfo.MAC, "mac", false, "Dedicated and Host MAC Addresses")
+	amtInfoCommand.BoolVar(&f.AmtInfo.Security, "security", false, "Security Parameters")
+	amtInfoCommand.BoolVar(&f.AmtInfo.TLSMode, "tlsMode", false, "Provisioning TLS Mode")
+	amtInfoCommand.BoolVar(&f.AmtInfo.ZeroTouch, "zeroTouch", false, "Zero Touch Configuration State")
+	amtInfoCommand.BoolVar(&f.AmtInfo.FQDN, "fqdn", false, "AMT FQDN and Dynamic DNS Settings")
+	amtInfoCommand.BoolVar(&f.AmtInfo.EHBC, "ehbc", false, "Embedded Host Based Configuration State")
+	amtInfoCommand.BoolVar(&f.AmtInfo.DNSSuffixList, "dnsSuffixList", false, "DNS Suffixes from DHCP")
 	amtInfoCommand.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT Password")
 
 	if err := amtInfoCommand.Parse(f.commandLineArgs[2:]); err != nil {
@@ -51,7 +72,14 @@ func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) error {
 	if f.JsonOutput {
 		defaultFlagCount = defaultFlagCount + 1
 	}
-	if len(f.commandLineArgs) == defaultFlagCount {
+	if len(f.commandLineArgs) == defaultFlagCount && f.Target != "" {
+		// the rest is only known to the host of the device
+		f.AmtInfo.Ver = true
+		f.AmtInfo.Bld = true
+		f.AmtInfo.Sku = true
+		f.AmtInfo.UUID = true
+		f.AmtInfo.Mode = true
+	} else if len(f.commandLineArgs) == defaultFlagCount {
 		f.AmtInfo.Ver = true
 		f.AmtInfo.Bld = true
 		f.AmtInfo.Sku = true
@@ -75,3 +103,11 @@ func (f *Flags) handleAMTInfo(amtInfoCommand *flag.FlagSet) error {
 
 	return nil
 }
+
+// hostOnly reports whether any selected item needs the host interface, which
+// a remote target does not offer
+func (a AmtInfoFlags) hostOnly() bool {
+	return a.DNS || a.Cert || a.Ras || a.Lan || a.Hostname || a.OpState ||
+		a.FeatureState || a.ResetReason || a.PowerPolicy || a.MAC || a.Security ||
+		a.TLSMode || a.ZeroTouch || a.FQDN || a.EHBC || a.DNSSuffixList
+}
@@ -38,6 +38,31 @@ func TestParseFlagsAmtInfo(t *testing.T) {
 			wantResult: nil,
 			wantFlags:  defaultFlags,
 		},
+		"expect only the requested PTHI flags": {
+			cmdLine:    "./rpc amtinfo -resetReason -powerPolicy -zeroTouch -json",
+			wantResult: nil,
+			wantFlags: AmtInfoFlags{
+				ResetReason: true,
+				PowerPolicy: true,
+				ZeroTouch:   true,
+			},
+		},
+		"expect all PTHI flags": {
+			cmdLine:    "./rpc amtinfo -featureState -resetReason -powerPolicy -mac -security -tlsMode -zeroTouch -fqdn -ehbc -dnsSuffixList",
+			wantResult: nil,
+			wantFlags: AmtInfoFlags{
+				FeatureState:  true,
+				ResetReason:   true,
+				PowerPolicy:   true,
+				MAC:           true,
+				Security:      true,
+				TLSMode:       true,
+				ZeroTouch:     true,
+				FQDN:          true,
+				EHBC:          true,
+				DNSSuffixList: true,
+			},
+		},
 		"expect IncorrectCommandLineParameters on Parse error": {
 			cmdLine:    "./rpc amtinfo -balderdash",
 			wantResult: utils.IncorrectCommandLineParameters,
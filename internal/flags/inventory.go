@@ -0,0 +1,72 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+)
+
+// Formats of the inventory document
+const (
+	InventoryFormatJSON = "json"
+	InventoryFormatYAML = "yaml"
+	InventoryFormatCSV  = "csv"
+)
+
+type InventoryFlags struct {
+	Format string
+}
+
+func (f *Flags) printInventoryUsage(fs *flag.FlagSet) string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " inventory [OPTIONS]\n\n"
+	usage = usage + "Reads the chassis, boards, BIOS, processors, memory and storage AMT reports through CIM.\n"
+	usage = usage + "The inventory comes from the firmware and does not depend on the operating system.\n"
+	usage = usage + "  Example: " + executable + " inventory -format yaml -password P@ssw0rd\n"
+	usage = usage + "\nAMT password is required.\n"
+	fmt.Println(usage)
+	if fs != nil {
+		fs.PrintDefaults()
+	}
+	return usage
+}
+
+func (f *Flags) handleInventoryCommand() error {
+	fs := flag.NewFlagSet(utils.CommandInventory, flag.ContinueOnError)
+	fs.Usage = func() { f.printInventoryUsage(fs) }
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	fs.StringVar(&f.Inventory.Format, "format", InventoryFormatJSON, "Output format (json,yaml,csv)")
+	if err := fs.Parse(f.commandLineArgs[2:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printInventoryUsage(fs)
+		return utils.IncorrectCommandLineParameters
+	}
+	switch f.Inventory.Format {
+	case InventoryFormatJSON, InventoryFormatYAML, InventoryFormatCSV:
+	default:
+		fmt.Println("-format must be json, yaml or csv:", f.Inventory.Format)
+		return utils.InvalidUserInput
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	f.Local = true
+	return nil
+}
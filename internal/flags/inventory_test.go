@@ -0,0 +1,73 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintInventoryUsage(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	flags := NewFlags([]string{executable, utils.CommandInventory}, MockPRSuccess)
+	output := flags.printInventoryUsage(nil)
+	assert.Contains(t, output, "Usage: "+executable+" inventory [OPTIONS]")
+	assert.Contains(t, output, "inventory -format yaml")
+}
+
+func TestHandleInventoryCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		pr         utils.PasswordReader
+		wantResult error
+		wantFormat string
+	}{
+		"should default to json": {
+			cmdLine:    []string{"rpc", "inventory", "-password", "P@ssw0rd"},
+			wantFormat: InventoryFormatJSON,
+		},
+		"should pass with yaml": {
+			cmdLine:    []string{"rpc", "inventory", "-format", "yaml", "-password", "P@ssw0rd"},
+			wantFormat: InventoryFormatYAML,
+		},
+		"should pass with csv": {
+			cmdLine:    []string{"rpc", "inventory", "-format=csv", "-password", "P@ssw0rd"},
+			wantFormat: InventoryFormatCSV,
+		},
+		"should fail with an unknown format": {
+			cmdLine:    []string{"rpc", "inventory", "-format", "xml", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "inventory", "memory", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail without password": {
+			cmdLine:    []string{"rpc", "inventory"},
+			pr:         MockPRFail,
+			wantResult: utils.MissingOrIncorrectPassword,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pr := tc.pr
+			if pr == nil {
+				pr = MockPRSuccess
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.wantFormat, flags.Inventory.Format)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// DefaultLMSPorts are the AMT ports Intel LMS forwards: WS-MAN over http and
+// https, and redirection over plain and TLS connections
+const DefaultLMSPorts = "16992,16993,16994,16995"
+
+type LMSFlags struct {
+	ListenAddress string
+	Ports         []uint32
+}
+
+func (f *Flags) handleLMSCommand() error {
+	fs := flag.NewFlagSet(utils.CommandLMS, flag.ContinueOnError)
+	fs.Usage = func() { f.printLMSUsage(fs) }
+	ports := ""
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.StringVar(&f.LMS.ListenAddress, "address", "127.0.0.1", "local address to listen on")
+	fs.StringVar(&ports, "ports", DefaultLMSPorts, "comma separated AMT ports to forward")
+	if err := fs.Parse(f.commandLineArgs[2:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printLMSUsage(fs)
+		return utils.IncorrectCommandLineParameters
+	}
+	f.LMS.Ports = nil
+	for _, port := range strings.Split(ports, ",") {
+		value, err := strconv.ParseUint(strings.TrimSpace(port), 10, 16)
+		if err != nil || value == 0 {
+			fmt.Println("invalid -ports entry:", port)
+			return utils.InvalidUserInput
+		}
+		f.LMS.Ports = append(f.LMS.Ports, uint32(value))
+	}
+	// the ports are forwarded over the host interface
+	f.Local = true
+	return nil
+}
+
+func (f *Flags) printLMSUsage(fs *flag.FlagSet) string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " lms [OPTIONS]\n\n"
+	usage = usage + "Listens on the AMT ports and tunnels each connection to AMT over LME until stopped.\n"
+	usage = usage + "Use it where Intel LMS is not installed so other tools can reach AMT from this host.\n"
+	usage = usage + "  Example: " + executable + " lms -ports 16992,16994\n"
+	fmt.Println(usage)
+	fs.PrintDefaults()
+	return usage
+}
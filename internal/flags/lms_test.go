@@ -0,0 +1,57 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleLMSCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		want       LMSFlags
+		wantResult error
+	}{
+		"should use defaults": {
+			cmdLine: []string{"rpc", "lms"},
+			want:    LMSFlags{ListenAddress: "127.0.0.1", Ports: []uint32{16992, 16993, 16994, 16995}},
+		},
+		"should pass with all options": {
+			cmdLine: []string{"rpc", "lms", "-address", "::1", "-ports", "16992, 16994"},
+			want:    LMSFlags{ListenAddress: "::1", Ports: []uint32{16992, 16994}},
+		},
+		"should fail with invalid port": {
+			cmdLine:    []string{"rpc", "lms", "-ports", "16992,http"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with port out of range": {
+			cmdLine:    []string{"rpc", "lms", "-ports", "70000"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "lms", "start"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should return help requested": {
+			cmdLine:    []string{"rpc", "lms", "-h"},
+			wantResult: utils.HelpRequested,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			gotResult := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, gotResult)
+			if tc.wantResult == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.want, flags.LMS)
+			}
+		})
+	}
+}
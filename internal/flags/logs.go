@@ -0,0 +1,171 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultLogsInterval = 30 * time.Second
+
+// AuditEventID names an audit log event by the application and event IDs
+// shown in the audit log records
+type AuditEventID struct {
+	AppID   uint16
+	EventID uint16
+}
+
+type LogsFlags struct {
+	Since     time.Time
+	Follow    bool
+	Interval  time.Duration
+	Configure bool
+	Enable    []AuditEventID
+	Disable   []AuditEventID
+}
+
+func (f *Flags) printLogsUsage() string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " logs COMMAND [OPTIONS]\n\n"
+	usage = usage + "Supported Logs Commands:\n"
+	usage = usage + "  events     Read the hardware event log (AMT_MessageLog)\n"
+	usage = usage + "             Example: " + executable + " logs events -since 24h -json\n"
+	usage = usage + "  audit      Read the audit log (AMT_AuditLog)\n"
+	usage = usage + "             Example: " + executable + " logs audit -follow -interval 1m\n"
+	usage = usage + "             With -configure, show or change the audited events\n"
+	usage = usage + "             Example: " + executable + " logs audit -configure -enable 16:0,16:1 -disable 19:0\n"
+	usage = usage + "\nOptions:\n"
+	usage = usage + "  -since      Only records from this time on, RFC 3339 (2024-05-01T00:00:00Z), a date or a duration back from now (24h)\n"
+	usage = usage + "  -follow     Keep reading and print new records every -interval until stopped\n"
+	usage = usage + "  -interval   Time between reads with -follow. Default 30s\n"
+	usage = usage + "  -enable     Comma separated APP:EVENT IDs to audit, audit -configure only\n"
+	usage = usage + "  -disable    Comma separated APP:EVENT IDs to stop auditing, audit -configure only\n"
+	usage = usage + "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleLogsCommand() error {
+	if len(f.commandLineArgs) == 2 {
+		f.printLogsUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	f.SubCommand = f.commandLineArgs[2]
+	switch f.SubCommand {
+	case utils.SubCommandEvents, utils.SubCommandAudit:
+	default:
+		f.printLogsUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	since, enable, disable := "", "", ""
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output, one record per line")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	fs.StringVar(&since, "since", "", "Only records from this time on")
+	fs.BoolVar(&f.Logs.Follow, "follow", false, "Print new records until stopped")
+	fs.DurationVar(&f.Logs.Interval, "interval", defaultLogsInterval, "Time between reads with -follow")
+	if f.SubCommand == utils.SubCommandAudit {
+		fs.BoolVar(&f.Logs.Configure, "configure", false, "Show or change the audited events")
+		fs.StringVar(&enable, "enable", "", "Comma separated APP:EVENT IDs to audit")
+		fs.StringVar(&disable, "disable", "", "Comma separated APP:EVENT IDs to stop auditing")
+	}
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printLogsUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if since != "" {
+		var err error
+		if f.Logs.Since, err = parseSince(since, time.Now()); err != nil {
+			fmt.Println("-since must be an RFC 3339 time, a date or a duration:", since)
+			return utils.InvalidUserInput
+		}
+	}
+	if f.Logs.Interval <= 0 {
+		fmt.Println("-interval must be greater than zero")
+		return utils.InvalidUserInput
+	}
+	var err error
+	if f.Logs.Enable, err = parseAuditEventIDs(enable); err != nil {
+		fmt.Println("-enable:", err)
+		return utils.InvalidUserInput
+	}
+	if f.Logs.Disable, err = parseAuditEventIDs(disable); err != nil {
+		fmt.Println("-disable:", err)
+		return utils.InvalidUserInput
+	}
+	if !f.Logs.Configure && (len(f.Logs.Enable) > 0 || len(f.Logs.Disable) > 0) {
+		fmt.Println("-enable and -disable need -configure")
+		return utils.InvalidUserInput
+	}
+	if f.Logs.Configure && (f.Logs.Follow || since != "") {
+		fmt.Println("-configure does not read the log, -follow and -since do not apply")
+		return utils.InvalidUserInput
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	f.Local = true
+	return nil
+}
+
+// parseSince reads -since as an RFC 3339 time, a date or a duration before now
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if since, err := time.Parse(time.RFC3339, value); err == nil {
+		return since, nil
+	}
+	if since, err := time.Parse("2006-01-02", value); err == nil {
+		return since, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("invalid time %s", value)
+	}
+	return now.Add(-duration), nil
+}
+
+// parseAuditEventIDs reads a comma separated list of APP:EVENT IDs
+func parseAuditEventIDs(value string) ([]AuditEventID, error) {
+	ids := []AuditEventID{}
+	if value == "" {
+		return ids, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		app, event, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			return nil, fmt.Errorf("%s is not APP:EVENT", entry)
+		}
+		appID, err := strconv.ParseUint(app, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid application ID in %s", entry)
+		}
+		eventID, err := strconv.ParseUint(event, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid event ID in %s", entry)
+		}
+		ids = append(ids, AuditEventID{AppID: uint16(appID), EventID: uint16(eventID)})
+	}
+	return ids, nil
+}
@@ -0,0 +1,112 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintLogsUsage(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	flags := NewFlags([]string{executable, utils.CommandLogs}, MockPRSuccess)
+	output := flags.printLogsUsage()
+	assert.Contains(t, output, "Usage: "+executable+" logs COMMAND [OPTIONS]")
+	assert.Contains(t, output, "logs audit -configure")
+}
+
+func TestHandleLogsCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		pr         utils.PasswordReader
+		wantResult error
+		wantLogs   LogsFlags
+	}{
+		"should fail without subcommand": {
+			cmdLine:    []string{"rpc", "logs"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown subcommand": {
+			cmdLine:    []string{"rpc", "logs", "system"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with events": {
+			cmdLine:  []string{"rpc", "logs", "events", "-json", "-password", "P@ssw0rd"},
+			wantLogs: LogsFlags{Interval: defaultLogsInterval, Enable: []AuditEventID{}, Disable: []AuditEventID{}},
+		},
+		"should pass with audit since and follow": {
+			cmdLine:  []string{"rpc", "logs", "audit", "-since", "2024-05-01T10:00:00Z", "-follow", "-interval", "1m", "-password", "P@ssw0rd"},
+			wantLogs: LogsFlags{Since: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Follow: true, Interval: time.Minute, Enable: []AuditEventID{}, Disable: []AuditEventID{}},
+		},
+		"should pass with audit configure": {
+			cmdLine: []string{"rpc", "logs", "audit", "-configure", "-enable", "16:0,19:1", "-disable", "17:2", "-password", "P@ssw0rd"},
+			wantLogs: LogsFlags{Interval: defaultLogsInterval, Configure: true,
+				Enable: []AuditEventID{{AppID: 16, EventID: 0}, {AppID: 19, EventID: 1}}, Disable: []AuditEventID{{AppID: 17, EventID: 2}}},
+		},
+		"should fail with configure on events": {
+			cmdLine:    []string{"rpc", "logs", "events", "-configure", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with enable without configure": {
+			cmdLine:    []string{"rpc", "logs", "audit", "-enable", "16:0", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with configure and follow": {
+			cmdLine:    []string{"rpc", "logs", "audit", "-configure", "-follow", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid event ID": {
+			cmdLine:    []string{"rpc", "logs", "audit", "-configure", "-enable", "16", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an invalid since": {
+			cmdLine:    []string{"rpc", "logs", "events", "-since", "yesterday", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with a zero interval": {
+			cmdLine:    []string{"rpc", "logs", "events", "-follow", "-interval", "0s", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail without password": {
+			cmdLine:    []string{"rpc", "logs", "events"},
+			pr:         MockPRFail,
+			wantResult: utils.MissingOrIncorrectPassword,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pr := tc.pr
+			if pr == nil {
+				pr = MockPRSuccess
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.cmdLine[2], flags.SubCommand)
+				assert.Equal(t, tc.wantLogs, flags.Logs)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	since, err := parseSince("24h", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), since)
+	since, err = parseSince("2024-05-01", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), since)
+	_, err = parseSince("-1h", now)
+	assert.Error(t, err)
+}
@@ -7,6 +7,7 @@ package flags
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"net"
 	"os"
@@ -34,6 +35,10 @@ func (f *Flags) printMaintenanceUsage() string {
 	usage = usage + "  syncip         Sync the IP configuration of the host OS to AMT Network Settings. AMT password is required\n"
 	usage = usage + "                 Example: " + executable + " maintenance syncip -staticip 192.168.1.7 -netmask 255.255.255.0 -gateway 192.168.1.1 -primarydns 8.8.8.8 -secondarydns 4.4.4.4 -u wss://server/activate\n"
 	usage = usage + "                 If a static ip is not specified, the ip address and netmask of the host OS is used\n"
+	usage = usage + "  stopconfig     Cancel a pending remote configuration session. Runs locally, AMT password is not required\n"
+	usage = usage + "                 Example: " + executable + " maintenance stopconfig\n"
+	usage = usage + "  startconfig    Start a remote configuration session, optionally setting a one-time password first. Runs locally, AMT password is not required\n"
+	usage = usage + "                 Example: " + executable + " maintenance startconfig -otp YourOneTimePassword\n"
 	usage = usage + "\nRun '" + executable + " maintenance COMMAND -h' for more information on a command.\n"
 	fmt.Println(usage)
 	return usage
@@ -59,6 +64,14 @@ func (f *Flags) handleMaintenanceCommand() error {
 		err = f.handleMaintenanceSyncChangePassword()
 	case "syncdeviceinfo":
 		err = f.handleMaintenanceSyncDeviceInfo()
+	case utils.SubCommandStopConfig, utils.SubCommandStartConfig:
+		// configuration sessions are handled over the host
+		// interface and do not involve the server
+		if err = f.handleConfigurationSession(); err != nil {
+			return err
+		}
+		f.Local = true
+		return nil
 	default:
 		f.printMaintenanceUsage()
 		err = utils.IncorrectCommandLineParameters
@@ -90,6 +103,28 @@ func (f *Flags) handleMaintenanceCommand() error {
 	return nil
 }
 
+func (f *Flags) handleConfigurationSession() error {
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	if f.SubCommand == utils.SubCommandStartConfig {
+		fs.StringVar(&f.ProvisioningOTP, "otp", f.lookupEnvOrString("PROVISIONING_OTP", ""), "one-time password for the provisioning server (8-32 characters)")
+	}
+
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.ProvisioningOTP != "" && (len(f.ProvisioningOTP) < 8 || len(f.ProvisioningOTP) > 32) {
+		log.Error("one-time password must be between 8 and 32 characters")
+		return utils.InvalidUserInput
+	}
+	return nil
+}
+
 func (f *Flags) handleMaintenanceSyncClock() error {
 	err := f.amtMaintenanceSyncClockCommand.Parse(f.commandLineArgs[3:])
 	if err != nil {
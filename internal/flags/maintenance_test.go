@@ -34,6 +34,10 @@ func TestPrintMaintenanceUsage(t *testing.T) {
 	usage = usage + "  syncip         Sync the IP configuration of the host OS to AMT Network Settings. AMT password is required\n"
 	usage = usage + "                 Example: " + executable + " maintenance syncip -staticip 192.168.1.7 -netmask 255.255.255.0 -gateway 192.168.1.1 -primarydns 8.8.8.8 -secondarydns 4.4.4.4 -u wss://server/activate\n"
 	usage = usage + "                 If a static ip is not specified, the ip address and netmask of the host OS is used\n"
+	usage = usage + "  stopconfig     Cancel a pending remote configuration session. Runs locally, AMT password is not required\n"
+	usage = usage + "                 Example: " + executable + " maintenance stopconfig\n"
+	usage = usage + "  startconfig    Start a remote configuration session, optionally setting a one-time password first. Runs locally, AMT password is not required\n"
+	usage = usage + "                 Example: " + executable + " maintenance startconfig -otp YourOneTimePassword\n"
 	usage = usage + "\nRun '" + executable + " maintenance COMMAND -h' for more information on a command.\n"
 	assert.Equal(t, usage, output)
 }
@@ -214,3 +218,53 @@ func TestParseFlagsMaintenance(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleConfigurationSession(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    string
+		wantResult error
+		wantOTP    string
+	}{
+		"expect success for maintenance stopconfig": {
+			cmdLine:    "./rpc maintenance stopconfig",
+			wantResult: nil,
+		},
+		"expect success for maintenance startconfig": {
+			cmdLine:    "./rpc maintenance startconfig",
+			wantResult: nil,
+		},
+		"expect success for maintenance startconfig with otp": {
+			cmdLine:    "./rpc maintenance startconfig -otp Otp12345",
+			wantResult: nil,
+			wantOTP:    "Otp12345",
+		},
+		"expect success for configure startconfig with otp": {
+			cmdLine:    "./rpc configure startconfig -otp Otp12345",
+			wantResult: nil,
+			wantOTP:    "Otp12345",
+		},
+		"expect InvalidUserInput for short otp": {
+			cmdLine:    "./rpc maintenance startconfig -otp short",
+			wantResult: utils.InvalidUserInput,
+			wantOTP:    "short",
+		},
+		"expect IncorrectCommandLineParameters for otp on stopconfig": {
+			cmdLine:    "./rpc maintenance stopconfig -otp Otp12345",
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			args := strings.Fields(tc.cmdLine)
+			flags := NewFlags(args, MockPRFail)
+			gotResult := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, gotResult)
+			assert.Equal(t, tc.wantOTP, flags.ProvisioningOTP)
+			assert.Equal(t, "", flags.Password)
+			assert.Equal(t, "", flags.URL)
+			if tc.wantResult == nil {
+				assert.True(t, flags.Local)
+			}
+		})
+	}
+}
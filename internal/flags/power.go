@@ -0,0 +1,74 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+)
+
+func (f *Flags) printPowerUsage() string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " power COMMAND [OPTIONS]\n\n"
+	usage = usage + "Supported Power Commands:\n"
+	usage = usage + "  status     Show the current power state of the device\n"
+	usage = usage + "             Example: " + executable + " power status -json\n"
+	usage = usage + "  on         Power the device on\n"
+	usage = usage + "  off        Power the device off without shutting down the OS\n"
+	usage = usage + "  reset      Reset the device without shutting down the OS\n"
+	usage = usage + "  cycle      Power the device off and on again\n"
+	usage = usage + "  soft-off   Ask the OS to shut down\n"
+	usage = usage + "  hibernate  Ask the OS to hibernate\n"
+	usage = usage + "             Example: " + executable + " power reset -password P@ssw0rd -target 192.168.1.20\n"
+	usage = usage + "\nAMT password is required.\n"
+	usage = usage + "Run '" + executable + " power COMMAND -h' for more information on a command.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handlePowerCommand() error {
+	if len(f.commandLineArgs) == 2 {
+		f.printPowerUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	f.SubCommand = f.commandLineArgs[2]
+	switch f.SubCommand {
+	case utils.SubCommandStatus, utils.SubCommandPowerOn, utils.SubCommandPowerOff,
+		utils.SubCommandReset, utils.SubCommandPowerCycle, utils.SubCommandSoftOff,
+		utils.SubCommandHibernate:
+	default:
+		f.printPowerUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	fs := flag.NewFlagSet(f.SubCommand, flag.ContinueOnError)
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	if err := fs.Parse(f.commandLineArgs[3:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printPowerUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	f.Local = true
+	return nil
+}
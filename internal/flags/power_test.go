@@ -0,0 +1,75 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintPowerUsage(t *testing.T) {
+	executable := filepath.Base(os.Args[0])
+	flags := NewFlags([]string{executable, utils.CommandPower}, MockPRSuccess)
+	output := flags.printPowerUsage()
+	assert.Contains(t, output, "Usage: "+executable+" power COMMAND [OPTIONS]")
+	assert.Contains(t, output, "power status")
+	assert.Contains(t, output, "soft-off")
+}
+
+func TestHandlePowerCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine    []string
+		pr         utils.PasswordReader
+		wantResult error
+	}{
+		"should fail without subcommand": {
+			cmdLine:    []string{"rpc", "power"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown subcommand": {
+			cmdLine:    []string{"rpc", "power", "sleep"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with status": {
+			cmdLine: []string{"rpc", "power", "status", "-json", "-password", "P@ssw0rd"},
+		},
+		"should pass with reset": {
+			cmdLine: []string{"rpc", "power", "reset", "-password", "P@ssw0rd"},
+		},
+		"should prompt for the password": {
+			cmdLine: []string{"rpc", "power", "soft-off"},
+		},
+		"should fail without password": {
+			cmdLine:    []string{"rpc", "power", "cycle"},
+			pr:         MockPRFail,
+			wantResult: utils.MissingOrIncorrectPassword,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "power", "on", "-password", "P@ssw0rd", "now"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pr := tc.pr
+			if pr == nil {
+				pr = MockPRSuccess
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, tc.cmdLine[2], flags.SubCommand)
+				assert.NotEmpty(t, flags.Password)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"sort"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+)
+
+// UserRealms names the realms a digest user can be given
+var UserRealms = map[string]authorization.RealmValues{
+	"redirection":                authorization.RealmValuesRedirectionRealm,
+	"ptadmin":                    authorization.RealmValuesPTAdministrationRealm,
+	"hwasset":                    authorization.RealmValuesHardwareAssetRealm,
+	"remotecontrol":              authorization.RealmValuesRemoteControlRealm,
+	"storage":                    authorization.RealmValuesStorageRealm,
+	"eventmanager":               authorization.RealmValuesEventManagerRealm,
+	"storageadmin":               authorization.RealmValuesStorageAdminRealm,
+	"agentpresencelocal":         authorization.RealmValuesAgentPresenceLocalRealm,
+	"agentpresenceremote":        authorization.RealmValuesAgentPresenceRemoteRealm,
+	"circuitbreaker":             authorization.RealmValuesCircuitBreakerRealm,
+	"networktime":                authorization.RealmValuesNetworkTimeRealm,
+	"generalinfo":                authorization.RealmValuesGeneralInfoRealm,
+	"firmwareupdate":             authorization.RealmValuesFirmwareUpdateRealm,
+	"eit":                        authorization.RealmValuesEITRealm,
+	"localun":                    authorization.RealmValuesLocalUN,
+	"endpointaccesscontrol":      authorization.RealmValuesEndpointAccessControlRealm,
+	"endpointaccesscontroladmin": authorization.RealmValuesEndpointAccessControlAdminRealm,
+	"eventlogreader":             authorization.RealmValuesEventLogReaderRealm,
+	"auditlog":                   authorization.RealmValuesAuditLogRealm,
+	"acl":                        authorization.RealmValuesACLRealm,
+	"localsystem":                authorization.RealmValuesLocalSystemRealm,
+}
+
+// UserAccessPermissions names the interfaces a digest user can reach AMT from
+var UserAccessPermissions = map[string]authorization.AccessPermission{
+	"local":   authorization.AccessPermissionLocalAccessOnly,
+	"network": authorization.AccessPermissionNetworkAccessOnly,
+	"any":     authorization.AccessPermissionLocalAndNetworkAccess,
+}
+
+// maxUsernameLength is the longest digest username AMT takes
+const maxUsernameLength = 16
+
+type UsersFlags struct {
+	Action       string
+	Username     string
+	UserPassword string
+	// Realms and Access are empty on update when they stay as they are
+	Realms []string
+	Access string
+}
+
+func userRealmNames() string {
+	names := []string{}
+	for name := range UserRealms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (f *Flags) printUsersUsage() string {
+	baseCommand := fmt.Sprintf("%s %s %s", filepath.Base(os.Args[0]), utils.CommandConfigure, utils.SubCommandUsers)
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage += "Usage: " + baseCommand + " COMMAND [OPTIONS]\n\n"
+	usage += "Supported Users Commands:\n"
+	usage += "  add        Add a digest user\n"
+	usage += "             Example: " + baseCommand + " add -username operator -realms redirection,hwasset -access network\n"
+	usage += "  list       List the digest users\n"
+	usage += "             Example: " + baseCommand + " list -json\n"
+	usage += "  update     Change the password, realms or access of a digest user\n"
+	usage += "             Example: " + baseCommand + " update -username operator -realms redirection\n"
+	usage += "  delete     Delete a digest user\n"
+	usage += "             Example: " + baseCommand + " delete -username operator\n"
+	usage += "\nOptions:\n"
+	usage += "  -username      Name of the user, up to 16 ASCII characters\n"
+	usage += "  -userpassword  Password of the user, prompted for if not set. Needed on add and update\n"
+	usage += "  -realms        Comma separated realms the user may use: " + userRealmNames() + "\n"
+	usage += "  -access        Where the user may reach AMT from: local, network or any. Default any on add\n"
+	usage += "\nAMT password is required.\n"
+	fmt.Println(usage)
+	return usage
+}
+
+func (f *Flags) handleUsers() error {
+	if len(f.commandLineArgs) == 3 {
+		f.printUsersUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	f.Users.Action = f.commandLineArgs[3]
+	switch f.Users.Action {
+	case utils.SubCommandAdd, utils.SubCommandList, utils.SubCommandUpdate, utils.SubCommandDelete:
+	default:
+		f.printUsersUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+
+	realms := ""
+	changesUser := f.Users.Action == utils.SubCommandAdd || f.Users.Action == utils.SubCommandUpdate
+	fs := f.NewConfigureFlagSet(utils.SubCommandUsers + " " + f.Users.Action)
+	if f.Users.Action != utils.SubCommandList {
+		fs.StringVar(&f.Users.Username, "username", "", "Name of the user")
+	}
+	if changesUser {
+		fs.StringVar(&f.Users.UserPassword, "userpassword", "", "Password of the user")
+		fs.StringVar(&realms, "realms", "", "Comma separated realms the user may use")
+		fs.StringVar(&f.Users.Access, "access", "", "Where the user may reach AMT from: local, network or any")
+	}
+	if err := fs.Parse(f.commandLineArgs[4:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printUsersUsage()
+		return utils.IncorrectCommandLineParameters
+	}
+	if f.Users.Action == utils.SubCommandList {
+		return nil
+	}
+	if err := validateUsername(f.Users.Username); err != nil {
+		fmt.Println("-username:", err)
+		return utils.InvalidUserInput
+	}
+	if !changesUser {
+		return nil
+	}
+	if realms != "" {
+		f.Users.Realms = strings.Split(realms, ",")
+		for _, realm := range f.Users.Realms {
+			if _, ok := UserRealms[realm]; !ok {
+				fmt.Printf("-realms: unknown realm %s, use %s\n", realm, userRealmNames())
+				return utils.InvalidUserInput
+			}
+		}
+	}
+	if f.Users.Access != "" {
+		if _, ok := UserAccessPermissions[f.Users.Access]; !ok {
+			fmt.Println("-access must be local, network or any")
+			return utils.InvalidUserInput
+		}
+	}
+	if f.Users.Action == utils.SubCommandAdd {
+		if len(f.Users.Realms) == 0 {
+			fmt.Println("-realms is required, use " + userRealmNames())
+			return utils.InvalidUserInput
+		}
+		if f.Users.Access == "" {
+			f.Users.Access = "any"
+		}
+	}
+	// AMT keeps a hash bound to the username, so updates need the password too
+	if f.Users.UserPassword == "" {
+		if err := f.ReadNewPasswordTo(&f.Users.UserPassword, "password for "+f.Users.Username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateUsername checks a digest username against what AMT accepts
+func validateUsername(username string) error {
+	if username == "" || len(username) > maxUsernameLength {
+		return fmt.Errorf("the username is required, up to %d characters", maxUsernameLength)
+	}
+	for _, c := range username {
+		if c < ' ' || c > '~' || c == ':' {
+			return fmt.Errorf("%q may only hold printable ASCII characters other than ':'", username)
+		}
+	}
+	if strings.EqualFold(username, utils.AMTUserName) {
+		return fmt.Errorf("%s is the AMT administrator, use %s to change its password", username, utils.SubCommandChangeAMTPassword)
+	}
+	return nil
+}
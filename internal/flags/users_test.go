@@ -0,0 +1,110 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleUsers(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine      []string
+		wantResult   error
+		wantUsername string
+		wantRealms   []string
+		wantAccess   string
+	}{
+		"should fail without action": {
+			cmdLine:    []string{"rpc", "configure", "users"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should fail with unknown action": {
+			cmdLine:    []string{"rpc", "configure", "users", "rename"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should pass with list": {
+			cmdLine: []string{"rpc", "configure", "users", "list", "-json", "-password", "P@ssw0rd"},
+		},
+		"should pass with add": {
+			cmdLine:      []string{"rpc", "configure", "users", "add", "-username", "operator", "-userpassword", "Us3r!pass", "-realms", "redirection,hwasset", "-access", "network", "-password", "P@ssw0rd"},
+			wantUsername: "operator",
+			wantRealms:   []string{"redirection", "hwasset"},
+			wantAccess:   "network",
+		},
+		"should default add to any access": {
+			cmdLine:      []string{"rpc", "configure", "users", "add", "-username", "operator", "-realms", "redirection", "-password", "P@ssw0rd"},
+			wantUsername: "operator",
+			wantRealms:   []string{"redirection"},
+			wantAccess:   "any",
+		},
+		"should pass with update keeping realms and access": {
+			cmdLine:      []string{"rpc", "configure", "users", "update", "-username", "operator", "-userpassword", "Us3r!pass", "-password", "P@ssw0rd"},
+			wantUsername: "operator",
+		},
+		"should pass with delete": {
+			cmdLine:      []string{"rpc", "configure", "users", "delete", "-username", "operator", "-password", "P@ssw0rd"},
+			wantUsername: "operator",
+		},
+		"should fail with add without realms": {
+			cmdLine:    []string{"rpc", "configure", "users", "add", "-username", "operator", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an unknown realm": {
+			cmdLine:    []string{"rpc", "configure", "users", "add", "-username", "operator", "-realms", "redirection,kvm", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with an unknown access": {
+			cmdLine:    []string{"rpc", "configure", "users", "update", "-username", "operator", "-access", "remote", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with delete without username": {
+			cmdLine:    []string{"rpc", "configure", "users", "delete", "-password", "P@ssw0rd"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with realms on delete": {
+			cmdLine:    []string{"rpc", "configure", "users", "delete", "-username", "operator", "-realms", "redirection", "-password", "P@ssw0rd"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			flags := NewFlags(tc.cmdLine, MockPRSuccess)
+			result := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, result)
+			if result == nil {
+				assert.True(t, flags.Local)
+				assert.Equal(t, utils.SubCommandUsers, flags.SubCommand)
+				assert.Equal(t, tc.cmdLine[3], flags.Users.Action)
+				assert.Equal(t, tc.wantUsername, flags.Users.Username)
+				assert.Equal(t, tc.wantRealms, flags.Users.Realms)
+				assert.Equal(t, tc.wantAccess, flags.Users.Access)
+			}
+		})
+	}
+}
+
+func TestHandleUsersPromptsForUserPassword(t *testing.T) {
+	cmdLine := []string{"rpc", "configure", "users", "add", "-username", "operator", "-realms", "redirection", "-password", "P@ssw0rd"}
+	flags := NewFlags(cmdLine, MockPRSuccess)
+	assert.NoError(t, flags.ParseFlags())
+	assert.NotEmpty(t, flags.Users.UserPassword)
+
+	flags = NewFlags(cmdLine, MockPRFail)
+	assert.Equal(t, utils.MissingOrIncorrectPassword, flags.ParseFlags())
+}
+
+func TestValidateUsername(t *testing.T) {
+	assert.NoError(t, validateUsername("operator"))
+	assert.NoError(t, validateUsername("help desk 01"))
+	assert.Error(t, validateUsername(""))
+	assert.Error(t, validateUsername("a-username-too-long"))
+	assert.Error(t, validateUsername("domain:user"))
+	assert.Error(t, validateUsername("opérateur"))
+	assert.Error(t, validateUsername("Admin"))
+}
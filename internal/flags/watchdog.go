@@ -0,0 +1,106 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"rpc/pkg/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultWatchdogAgentID identifies rpc as the agent when -agentid is not set
+	DefaultWatchdogAgentID  = "5d7a1c8e-3b4f-4a6e-9c21-7f0e8b2d4a93"
+	defaultWatchdogTimeout  = 120 * time.Second
+	defaultWatchdogInterval = 30 * time.Second
+	// AMT takes the timeout in whole seconds as a uint16
+	minWatchdogTimeout = 120 * time.Second
+	maxWatchdogTimeout = 65535 * time.Second
+
+	WatchdogActionLog = "log"
+	WatchdogActionMPS = "mps"
+)
+
+type WatchdogFlags struct {
+	AgentID  string
+	Timeout  time.Duration
+	Interval time.Duration
+	Actions  []string
+}
+
+func (f *Flags) handleWatchdogCommand() error {
+	fs := flag.NewFlagSet(utils.CommandWatchdog, flag.ContinueOnError)
+	fs.Usage = func() { f.printWatchdogUsage(fs) }
+	actions := ""
+	fs.BoolVar(&f.Verbose, "v", false, "Verbose output")
+	fs.StringVar(&f.LogLevel, "l", "info", "Log level (panic,fatal,error,warn,info,debug,trace)")
+	fs.BoolVar(&f.JsonOutput, "json", false, "JSON output")
+	fs.StringVar(&f.Password, "password", f.lookupEnvOrString("AMT_PASSWORD", ""), "AMT password")
+	fs.StringVar(&f.Watchdog.AgentID, "agentid", DefaultWatchdogAgentID, "GUID identifying this agent to AMT")
+	fs.DurationVar(&f.Watchdog.Timeout, "timeout", defaultWatchdogTimeout, "time without a heartbeat before AMT treats the agent as gone (120s to 65535s)")
+	fs.DurationVar(&f.Watchdog.Interval, "interval", defaultWatchdogInterval, "time between heartbeats, must be shorter than -timeout")
+	fs.StringVar(&actions, "action", WatchdogActionLog, "comma separated actions when the watchdog expires (log,mps)")
+	if err := fs.Parse(f.commandLineArgs[2:]); err != nil {
+		if err.Error() == utils.HelpRequested.Message {
+			return utils.HelpRequested
+		}
+		return utils.IncorrectCommandLineParameters
+	}
+	if fs.NArg() > 0 {
+		f.printWatchdogUsage(fs)
+		return utils.IncorrectCommandLineParameters
+	}
+	if _, err := uuid.Parse(f.Watchdog.AgentID); err != nil {
+		fmt.Println("-agentid must be a GUID:", err)
+		return utils.InvalidUserInput
+	}
+	timeout := f.Watchdog.Timeout
+	if timeout < minWatchdogTimeout || timeout > maxWatchdogTimeout || timeout%time.Second != 0 {
+		fmt.Println("-timeout must be a whole number of seconds between 120s and 65535s")
+		return utils.InvalidUserInput
+	}
+	if f.Watchdog.Interval <= 0 || f.Watchdog.Interval >= timeout {
+		fmt.Println("-interval must be greater than zero and shorter than -timeout")
+		return utils.InvalidUserInput
+	}
+	f.Watchdog.Actions = nil
+	for _, action := range strings.Split(actions, ",") {
+		action = strings.ToLower(strings.TrimSpace(action))
+		switch action {
+		case WatchdogActionLog, WatchdogActionMPS:
+			f.Watchdog.Actions = append(f.Watchdog.Actions, action)
+		default:
+			fmt.Println("unknown -action:", action)
+			return utils.InvalidUserInput
+		}
+	}
+	if f.Password == "" {
+		if err := f.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	// the watchdog is registered and fed over the host interface
+	f.Local = true
+	return nil
+}
+
+func (f *Flags) printWatchdogUsage(fs *flag.FlagSet) string {
+	executable := filepath.Base(os.Args[0])
+	usage := "\nRemote Provisioning Client (RPC) - used for activation, deactivation, maintenance and status of AMT\n\n"
+	usage = usage + "Usage: " + executable + " watchdog [OPTIONS]\n\n"
+	usage = usage + "Registers this host as an AMT agent presence watchdog and sends heartbeats until stopped.\n"
+	usage = usage + "AMT password is required.\n"
+	usage = usage + "  Example: " + executable + " watchdog -password P@ssw0rd -interval 30s -timeout 2m -action log,mps\n"
+	fmt.Println(usage)
+	fs.PrintDefaults()
+	return usage
+}
@@ -0,0 +1,108 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package flags
+
+import (
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleWatchdogCommand(t *testing.T) {
+	tests := map[string]struct {
+		cmdLine      []string
+		passwordFail bool
+		want         WatchdogFlags
+		wantResult   error
+	}{
+		"should use defaults": {
+			cmdLine: []string{"rpc", "watchdog", "-password", "P@ssw0rd"},
+			want: WatchdogFlags{
+				AgentID:  DefaultWatchdogAgentID,
+				Timeout:  defaultWatchdogTimeout,
+				Interval: defaultWatchdogInterval,
+				Actions:  []string{WatchdogActionLog},
+			},
+		},
+		"should pass with all options": {
+			cmdLine: []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-agentid", "12345678-9abc-def0-1234-56789abcdef0", "-timeout", "5m", "-interval", "1m", "-action", "log, MPS"},
+			want: WatchdogFlags{
+				AgentID:  "12345678-9abc-def0-1234-56789abcdef0",
+				Timeout:  5 * time.Minute,
+				Interval: time.Minute,
+				Actions:  []string{WatchdogActionLog, WatchdogActionMPS},
+			},
+		},
+		"should prompt for password": {
+			cmdLine: []string{"rpc", "watchdog"},
+			want: WatchdogFlags{
+				AgentID:  DefaultWatchdogAgentID,
+				Timeout:  defaultWatchdogTimeout,
+				Interval: defaultWatchdogInterval,
+				Actions:  []string{WatchdogActionLog},
+			},
+		},
+		"should fail when password prompt fails": {
+			cmdLine:      []string{"rpc", "watchdog"},
+			passwordFail: true,
+			wantResult:   utils.MissingOrIncorrectPassword,
+		},
+		"should fail with invalid agent id": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-agentid", "rpc"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with timeout below minimum": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-timeout", "60s", "-interval", "10s"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with timeout above maximum": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-timeout", "65536s"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with fractional timeout": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-timeout", "150500ms"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail when interval is not shorter than timeout": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-interval", "2m"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with zero interval": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-interval", "0s"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with unknown action": {
+			cmdLine:    []string{"rpc", "watchdog", "-password", "P@ssw0rd", "-action", "reboot"},
+			wantResult: utils.InvalidUserInput,
+		},
+		"should fail with extra arguments": {
+			cmdLine:    []string{"rpc", "watchdog", "start"},
+			wantResult: utils.IncorrectCommandLineParameters,
+		},
+		"should return help requested": {
+			cmdLine:    []string{"rpc", "watchdog", "-h"},
+			wantResult: utils.HelpRequested,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var pr utils.PasswordReader = MockPRSuccess
+			if tc.passwordFail {
+				pr = MockPRFail
+			}
+			flags := NewFlags(tc.cmdLine, pr)
+			gotResult := flags.ParseFlags()
+			assert.Equal(t, tc.wantResult, gotResult)
+			if tc.wantResult == nil {
+				assert.True(t, flags.Local)
+				assert.NotEmpty(t, flags.Password)
+				assert.Equal(t, tc.want, flags.Watchdog)
+			}
+		})
+	}
+}
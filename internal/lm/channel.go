@@ -0,0 +1,339 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	log "github.com/sirupsen/logrus"
+)
+
+// Channel is one APF channel over an LME connection. It carries a single
+// HTTP request and its response, or with OpenStream a TCP connection,
+// independent of other open channels.
+type Channel struct {
+	lme     *LMEConnection
+	id      uint32
+	session *apf.Session
+	// stream channels pass data on to Read as it arrives instead of
+	// collecting a response
+	stream   bool
+	readable chan struct{}
+	// timeout is lmeResponseTimeout when the channel was opened
+	timeout time.Duration
+	// confirmed is only touched by the goroutine reading from the MEI
+	confirmed bool
+	// windowLock guards session.TXWindow, which Send spends and the reader refills
+	windowLock    sync.Mutex
+	windowAdjust  chan struct{}
+	dataLock      sync.Mutex
+	rxWindowUsed  uint32
+	responseFrame responseFramer
+	once          sync.Once
+	closeOnce     sync.Once
+	finished      chan struct{}
+}
+
+func newChannel(lme *LMEConnection, id uint32, session *apf.Session) *Channel {
+	session.Tempdata = []byte{}
+	return &Channel{
+		lme:          lme,
+		id:           id,
+		session:      session,
+		windowAdjust: make(chan struct{}, 1),
+		readable:     make(chan struct{}, 1),
+		timeout:      lmeResponseTimeout,
+		finished:     make(chan struct{}),
+	}
+}
+
+// Send writes data to the channel, splitting it so no message is larger
+// than the HECI buffer or the window AMT granted
+func (c *Channel) Send(data []byte) error {
+	log.Debug("sending message to LME")
+	log.Trace(string(data))
+	maxData := uint32(0)
+	if size := c.lme.Command.Heci.GetBufferSize(); size > apfChannelDataHeaderSize {
+		maxData = size - apfChannelDataHeaderSize
+	}
+	for len(data) > 0 {
+		length, err := c.reserveTXWindow(uint32(len(data)), maxData)
+		if err != nil {
+			return err
+		}
+		var bin_buf bytes.Buffer
+		channelData := apf.ChannelData(c.session.SenderChannel, data[:length])
+		binary.Write(&bin_buf, binary.BigEndian, channelData.MessageType)
+		binary.Write(&bin_buf, binary.BigEndian, channelData.RecipientChannel)
+		binary.Write(&bin_buf, binary.BigEndian, channelData.DataLength)
+		binary.Write(&bin_buf, binary.BigEndian, channelData.Data)
+		if err = c.lme.send(bin_buf.Bytes()); err != nil {
+			return err
+		}
+		data = data[length:]
+	}
+	log.Debug("sent message to LME")
+	return nil
+}
+
+// Receive waits for the response to the request sent on the channel, which
+// is closed once the response is passed on. A connection lost before the
+// response was complete is returned as the error.
+func (c *Channel) Receive() ([]byte, error) {
+	if ok := <-c.session.Status; !ok {
+		return nil, <-c.session.ErrorBuffer
+	}
+	data := <-c.session.DataBuffer
+	if len(data) == 0 {
+		return nil, errors.New("empty response from AMT")
+	}
+	return data, nil
+}
+
+// Write sends p on a stream channel
+func (c *Channel) Write(p []byte) (int, error) {
+	if err := c.Send(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Read returns data AMT sent on a stream channel, and io.EOF once AMT closed
+// the channel and everything was read
+func (c *Channel) Read(p []byte) (int, error) {
+	for {
+		c.dataLock.Lock()
+		n := copy(p, c.session.Tempdata)
+		c.session.Tempdata = c.session.Tempdata[n:]
+		c.dataLock.Unlock()
+		if n > 0 {
+			c.consumed(uint32(n))
+			return n, nil
+		}
+		if c.done() {
+			return 0, io.EOF
+		}
+		select {
+		case <-c.readable:
+		case <-c.finished:
+		}
+	}
+}
+
+// Close gives up on the response and closes the channel. A stream channel
+// stays readable until AMT confirms the close.
+func (c *Channel) Close() error {
+	if !c.stream {
+		c.finish()
+		return nil
+	}
+	c.sendClose()
+	// do not wait forever for AMT to answer
+	time.AfterFunc(c.timeout, func() {
+		c.lme.unregister(c)
+		c.stop()
+	})
+	return nil
+}
+
+// reserveTXWindow takes up to length bytes of the send window, waiting for a
+// CHANNEL_WINDOW_ADJUST when AMT has not granted any
+func (c *Channel) reserveTXWindow(length uint32, maxData uint32) (uint32, error) {
+	timeout := time.NewTimer(c.timeout)
+	defer timeout.Stop()
+	for {
+		c.windowLock.Lock()
+		if c.session.TXWindow > 0 {
+			if length > c.session.TXWindow {
+				length = c.session.TXWindow
+			}
+			if maxData > 0 && length > maxData {
+				length = maxData
+			}
+			c.session.TXWindow -= length
+			c.windowLock.Unlock()
+			return length, nil
+		}
+		c.windowLock.Unlock()
+		log.Trace("waiting for APF window adjust")
+		select {
+		case <-c.windowAdjust:
+		case <-timeout.C:
+			return 0, errors.New("timed out waiting for AMT to open the APF window")
+		}
+	}
+}
+
+func (c *Channel) processWindowAdjust(data []byte) {
+	c.windowLock.Lock()
+	apf.ProcessChannelWindowAdjust(data, c.session)
+	c.windowLock.Unlock()
+	select {
+	case c.windowAdjust <- struct{}{}:
+	default:
+	}
+}
+
+// processData collects the data and returns whether the HTTP response is
+// complete. Consumed bytes are handed back to AMT once half of our receive
+// window is used so large responses keep flowing.
+func (c *Channel) processData(data []byte) bool {
+	c.dataLock.Lock()
+	received := len(c.session.Tempdata)
+	apf.Process(data, c.session)
+	// apf.Process cuts the timer to three seconds, AMT gets the channel's
+	// timeout between messages instead
+	c.session.Timer.Reset(c.timeout)
+	received = len(c.session.Tempdata) - received
+	complete := c.responseFrame.complete(c.session.Tempdata)
+	c.dataLock.Unlock()
+
+	c.rxWindowUsed += uint32(received)
+	if !complete && c.rxWindowUsed >= apf.LME_RX_WINDOW_SIZE/2 {
+		var bin_buf bytes.Buffer
+		windowAdjust := apf.ChannelWindowAdjust(c.session.SenderChannel, c.rxWindowUsed)
+		binary.Write(&bin_buf, binary.BigEndian, windowAdjust)
+		if err := c.lme.send(bin_buf.Bytes()); err != nil {
+			log.Error(err)
+		}
+		c.rxWindowUsed = 0
+	}
+	return complete
+}
+
+// processStreamData queues the data for Read
+func (c *Channel) processStreamData(data []byte) {
+	if len(data) < apfChannelDataHeaderSize {
+		log.Error("short APF channel data message")
+		return
+	}
+	payload := data[apfChannelDataHeaderSize:]
+	if length := binary.BigEndian.Uint32(data[5:9]); int(length) < len(payload) {
+		payload = payload[:length]
+	}
+	c.dataLock.Lock()
+	c.session.Tempdata = append(c.session.Tempdata, payload...)
+	c.dataLock.Unlock()
+	select {
+	case c.readable <- struct{}{}:
+	default:
+	}
+}
+
+// consumed hands bytes read from a stream channel back to AMT once half of
+// our receive window is used
+func (c *Channel) consumed(n uint32) {
+	c.windowLock.Lock()
+	c.rxWindowUsed += n
+	used := c.rxWindowUsed
+	if used < apf.LME_RX_WINDOW_SIZE/2 {
+		c.windowLock.Unlock()
+		return
+	}
+	c.rxWindowUsed = 0
+	c.windowLock.Unlock()
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.BigEndian, apf.ChannelWindowAdjust(c.session.SenderChannel, used))
+	if err := c.lme.send(bin_buf.Bytes()); err != nil {
+		log.Error(err)
+	}
+}
+
+// watch starts the response timer. It also passes on whatever arrived if
+// reading stops before AMT closes the channel.
+func (c *Channel) watch() {
+	c.session.Timer = time.NewTimer(c.timeout)
+	go func() {
+		select {
+		case <-c.session.Timer.C:
+			log.Debug("no complete response from LME, passing on what was received")
+			c.finish()
+		case <-c.finished:
+		}
+	}()
+}
+
+// finish passes on the collected response, closes the channel and reports
+// the close through the status
+func (c *Channel) finish() {
+	c.once.Do(func() {
+		close(c.finished)
+		if c.session.Timer != nil {
+			c.session.Timer.Stop()
+		}
+		c.dataLock.Lock()
+		data := c.session.Tempdata
+		c.session.Tempdata = []byte{}
+		c.dataLock.Unlock()
+		c.session.DataBuffer <- data
+		c.sendClose()
+		c.session.Status <- true
+	})
+}
+
+// sendClose tells AMT the channel is closed, once
+func (c *Channel) sendClose() {
+	c.closeOnce.Do(func() {
+		var bin_buf bytes.Buffer
+		channelData := apf.ChannelClose(c.session.SenderChannel)
+		binary.Write(&bin_buf, binary.BigEndian, channelData.MessageType)
+		binary.Write(&bin_buf, binary.BigEndian, channelData.RecipientChannel)
+		if err := c.lme.send(bin_buf.Bytes()); err != nil {
+			log.Trace(err)
+		}
+	})
+}
+
+// stop ends the channel without passing anything on
+func (c *Channel) stop() {
+	c.once.Do(func() {
+		close(c.finished)
+		if c.session.Timer != nil {
+			c.session.Timer.Stop()
+		}
+	})
+}
+
+func (c *Channel) done() bool {
+	select {
+	case <-c.finished:
+		return true
+	default:
+		return false
+	}
+}
+
+// fail reports a broken connection to whoever waits on the channel. A
+// response is only passed on if it is complete, a partial one would be
+// taken for the whole response.
+func (c *Channel) fail(err error) {
+	if c.confirmed && c.stream {
+		c.stop()
+		return
+	}
+	if c.confirmed {
+		c.dataLock.Lock()
+		complete := c.responseFrame.complete(c.session.Tempdata)
+		c.dataLock.Unlock()
+		if complete {
+			c.finish()
+			return
+		}
+	}
+	c.once.Do(func() {
+		close(c.finished)
+		if c.session.Timer != nil {
+			c.session.Timer.Stop()
+		}
+		c.session.Status <- false
+		c.session.ErrorBuffer <- err
+	})
+}
@@ -0,0 +1,42 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+// apfAddr is the address of one end of an APF channel
+type apfAddr struct {
+	port uint32
+}
+
+func (a apfAddr) Network() string { return "apf" }
+func (a apfAddr) String() string  { return "amt:" + strconv.FormatUint(uint64(a.port), 10) }
+
+// channelConn is a stream channel used as a net.Conn, so TLS and HTTP clients
+// can run over APF. Deadlines are not supported, callers bound requests with
+// timeouts that close the connection instead.
+type channelConn struct {
+	*Channel
+	port uint32
+}
+
+// Dial opens a stream channel to port on AMT and returns it as a net.Conn
+func (lme *LMEConnection) Dial(port uint32) (net.Conn, error) {
+	channel, err := lme.OpenStream(port)
+	if err != nil {
+		return nil, err
+	}
+	return &channelConn{Channel: channel, port: port}, nil
+}
+
+func (c *channelConn) LocalAddr() net.Addr                { return apfAddr{} }
+func (c *channelConn) RemoteAddr() net.Addr               { return apfAddr{port: c.port} }
+func (c *channelConn) SetDeadline(t time.Time) error      { return nil }
+func (c *channelConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *channelConn) SetWriteDeadline(t time.Time) error { return nil }
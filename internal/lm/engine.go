@@ -7,19 +7,41 @@ package lm
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
 	"rpc/pkg/pthi"
+	"sync"
 	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
 	log "github.com/sirupsen/logrus"
 )
 
+// lmeResponseTimeout bounds the wait for a response that is not framed by
+// Content-Length or chunked encoding, and for AMT to open the send window
+var lmeResponseTimeout = 30 * time.Second
+
+// apfChannelDataHeaderSize is the message type, recipient channel and length
+const apfChannelDataHeaderSize = 9
+
+// maxChannels is how many channel numbers are handed out, 1 through 31
+const maxChannels = 31
+
+// amtHTTPPort is where WS-MAN requests go unless a stream names another port
+const amtHTTPPort = 16992
+
 // LMConnection is struct for managing connection to LMS
 type LMEConnection struct {
 	Command    pthi.Command
 	Session    *apf.Session
 	ourChannel int
 	retries    int
+	// sendLock keeps messages of concurrent channels whole on the MEI
+	sendLock       sync.Mutex
+	channelsLock   sync.Mutex
+	channels       map[uint32]*Channel
+	reading        bool
+	forwardedPorts []uint32
 }
 
 func NewLMEConnection(data chan []byte, errors chan error, status chan bool) *LMEConnection {
@@ -66,8 +88,10 @@ func (lme *LMEConnection) Connect() error {
 		lme.ourChannel = channel
 	}
 
+	lme.register(newChannel(lme, uint32(lme.ourChannel), lme.Session))
+
 	bin_buf := apf.ChannelOpen(lme.ourChannel)
-	err := lme.Command.Send(bin_buf.Bytes(), uint32(bin_buf.Len()))
+	err := lme.send(bin_buf.Bytes())
 	if err != nil {
 		lme.retries = lme.retries + 1
 		if lme.retries < 3 && (err.Error() == "no such device" || err.Error() == "The device is not connected.") {
@@ -87,24 +111,216 @@ func (lme *LMEConnection) Connect() error {
 	return nil
 }
 
-// Send writes data to LMS TCP Socket
+// Send writes data to the channel opened by Connect
 func (lme *LMEConnection) Send(data []byte) error {
-	log.Debug("sending message to LME")
-	log.Trace(string(data))
-	var bin_buf bytes.Buffer
+	return lme.current().Send(data)
+}
+
+// OpenChannel opens another APF channel next to the ones already open, so
+// independent requests can run at the same time. The first channel starts
+// reading from the MEI; messages are routed to their channel until Close.
+// Connect and Listen are not used together with OpenChannel.
+func (lme *LMEConnection) OpenChannel() (*Channel, error) {
+	return lme.openChannel(amtHTTPPort, false)
+}
+
+// OpenStream opens a channel to port on AMT that carries a TCP connection.
+// Data is read and written through the channel's Read and Write.
+func (lme *LMEConnection) OpenStream(port uint32) (*Channel, error) {
+	return lme.openChannel(port, true)
+}
 
-	channelData := apf.ChannelData(lme.Session.SenderChannel, data)
-	binary.Write(&bin_buf, binary.BigEndian, channelData.MessageType)
-	binary.Write(&bin_buf, binary.BigEndian, channelData.RecipientChannel)
-	binary.Write(&bin_buf, binary.BigEndian, channelData.DataLength)
-	binary.Write(&bin_buf, binary.BigEndian, channelData.Data)
-	lme.Session.TXWindow -= lme.Session.TXWindow // hmmm
-	err := lme.Command.Send(bin_buf.Bytes(), uint32(bin_buf.Len()))
+func (lme *LMEConnection) openChannel(port uint32, stream bool) (*Channel, error) {
+	session := &apf.Session{
+		// buffered so the reader never waits on a caller that gave up
+		DataBuffer:  make(chan []byte, 1),
+		ErrorBuffer: make(chan error, 1),
+		Status:      make(chan bool, 2),
+	}
+	channel, err := lme.allocate(session, stream)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	log.Debug("sent message to LME")
-	return nil
+
+	log.Debug("Sending APF_CHANNEL_OPEN for channel ", channel.id, " to port ", port)
+	bin_buf := channelOpen(channel.id, port)
+	if err = lme.send(bin_buf.Bytes()); err != nil {
+		lme.unregister(channel)
+		channel.stop()
+		return nil, err
+	}
+	timeout := time.NewTimer(lmeResponseTimeout)
+	defer timeout.Stop()
+	select {
+	case opened := <-session.Status:
+		if !opened {
+			return nil, <-session.ErrorBuffer
+		}
+	case <-timeout.C:
+		lme.unregister(channel)
+		channel.stop()
+		return nil, errors.New("timed out waiting for AMT to open the APF channel")
+	}
+	return channel, nil
+}
+
+// allocate registers a watched channel on the next free channel number and
+// starts the reader if it is not running
+func (lme *LMEConnection) allocate(session *apf.Session, stream bool) (*Channel, error) {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	if lme.channels == nil {
+		lme.channels = map[uint32]*Channel{}
+	}
+	for i := 0; i < maxChannels; i++ {
+		lme.ourChannel = lme.ourChannel%maxChannels + 1
+		if _, busy := lme.channels[uint32(lme.ourChannel)]; busy {
+			continue
+		}
+		channel := newChannel(lme, uint32(lme.ourChannel), session)
+		channel.stream = stream
+		if !stream {
+			channel.watch()
+		}
+		lme.channels[channel.id] = channel
+		if !lme.reading {
+			lme.reading = true
+			go lme.serve()
+		}
+		return channel, nil
+	}
+	return nil, errors.New("no free APF channel")
+}
+
+func (lme *LMEConnection) register(channel *Channel) {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	if lme.channels == nil {
+		lme.channels = map[uint32]*Channel{}
+	}
+	lme.channels[channel.id] = channel
+}
+
+func (lme *LMEConnection) unregister(channel *Channel) {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	if lme.channels[channel.id] == channel {
+		delete(lme.channels, channel.id)
+	}
+}
+
+func (lme *LMEConnection) channel(id uint32) *Channel {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	return lme.channels[id]
+}
+
+// current returns the channel used by Connect, Send and Listen, starting a
+// new one on the same channel number once the last response was passed on
+func (lme *LMEConnection) current() *Channel {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	if lme.channels == nil {
+		lme.channels = map[uint32]*Channel{}
+	}
+	channel := lme.channels[uint32(lme.ourChannel)]
+	if channel == nil || channel.done() {
+		channel = newChannel(lme, uint32(lme.ourChannel), lme.Session)
+		lme.channels[channel.id] = channel
+	}
+	return channel
+}
+
+// channelOpen builds an APF_CHANNEL_OPEN for port on AMT
+func channelOpen(channel uint32, port uint32) bytes.Buffer {
+	open := apf.ChannelOpen(int(channel))
+	if port == amtHTTPPort {
+		return open
+	}
+	message := apf.APF_CHANNEL_OPEN_MESSAGE{}
+	binary.Read(&open, binary.BigEndian, &message)
+	message.ConnectedPort = port
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.BigEndian, message)
+	return bin_buf
+}
+
+// ForwardedPorts returns the ports AMT asked the host to forward while the
+// connection was set up
+func (lme *LMEConnection) ForwardedPorts() []uint32 {
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	return append([]uint32{}, lme.forwardedPorts...)
+}
+
+// processGlobalRequest answers AMT's tcpip-forward and cancel-tcpip-forward
+// requests and remembers the ports AMT serves
+func (lme *LMEConnection) processGlobalRequest(data []byte) bytes.Buffer {
+	var reply bytes.Buffer
+	reader := bytes.NewReader(data[1:])
+	var nameLength uint32
+	binary.Read(reader, binary.BigEndian, &nameLength)
+	if int(nameLength) > reader.Len() {
+		log.Error("malformed APF global request")
+		return reply
+	}
+	name := make([]byte, nameLength)
+	reader.Read(name)
+	var wantReply uint8
+	var addressLength uint32
+	binary.Read(reader, binary.BigEndian, &wantReply)
+	binary.Read(reader, binary.BigEndian, &addressLength)
+	if int(addressLength) > reader.Len() {
+		log.Error("malformed APF global request")
+		return reply
+	}
+	reader.Seek(int64(addressLength), io.SeekCurrent)
+	var port uint32
+	binary.Read(reader, binary.BigEndian, &port)
+	log.Debug("received APF_GLOBAL_REQUEST ", string(name), " for port ", port)
+
+	lme.channelsLock.Lock()
+	defer lme.channelsLock.Unlock()
+	switch string(name) {
+	case apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST:
+		known := false
+		for _, forwarded := range lme.forwardedPorts {
+			known = known || forwarded == port
+		}
+		if !known {
+			lme.forwardedPorts = append(lme.forwardedPorts, port)
+		}
+		if wantReply != 0 {
+			binary.Write(&reply, binary.BigEndian, apf.TcpForwardReplySuccess(port))
+		}
+	case apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST:
+		for i, forwarded := range lme.forwardedPorts {
+			if forwarded == port {
+				lme.forwardedPorts = append(lme.forwardedPorts[:i], lme.forwardedPorts[i+1:]...)
+				break
+			}
+		}
+		if wantReply != 0 {
+			binary.Write(&reply, binary.BigEndian, uint8(apf.APF_REQUEST_SUCCESS))
+		}
+	}
+	// AMT announces udp-send-to last and expects no reply to it
+	return reply
+}
+
+// process handles a message that does not belong to a channel
+func (lme *LMEConnection) process(data []byte) bytes.Buffer {
+	if data[0] == apf.APF_GLOBAL_REQUEST {
+		return lme.processGlobalRequest(data)
+	}
+	return apf.Process(data, lme.Session)
+}
+
+// send writes one APF message to the MEI
+func (lme *LMEConnection) send(message []byte) error {
+	lme.sendLock.Lock()
+	defer lme.sendLock.Unlock()
+	return lme.Command.Send(message, uint32(len(message)))
 }
 
 func (lme *LMEConnection) execute(bin_buf bytes.Buffer) error {
@@ -116,7 +332,7 @@ func (lme *LMEConnection) execute(bin_buf bytes.Buffer) error {
 		} else if err != nil {
 			return err
 		}
-		bin_buf = apf.Process(result, lme.Session)
+		bin_buf = lme.process(result)
 		if bin_buf.Len() == 0 {
 			log.Debug("done EXECUTING.........")
 			break
@@ -125,46 +341,103 @@ func (lme *LMEConnection) execute(bin_buf bytes.Buffer) error {
 	return nil
 }
 
-// Listen reads data from the LMS socket connection
+// Listen reads APF messages until AMT closes the channel opened by Connect.
+// The collected response is passed on as soon as its HTTP framing says it
+// is complete, followed by a status once the channel close has been sent.
 func (lme *LMEConnection) Listen() {
-	go func() {
-		lme.Session.Timer = time.NewTimer(2 * time.Second)
-		<-lme.Session.Timer.C
-		lme.Session.DataBuffer <- lme.Session.Tempdata
-		lme.Session.Tempdata = []byte{}
-		var bin_buf bytes.Buffer
-		// var windowAdjust apf.APF_CHANNEL_WINDOW_ADJUST_MESSAGE
-		// if lme.Session.RXWindow > 1024 { // TODO: Check this
-		// 	windowAdjust = apf.ChannelWindowAdjust(lme.Session.RecipientChannel, lme.Session.RXWindow)
-		// 	lme.Session.RXWindow = 0
-		// 	binary.Write(&bin_buf, binary.BigEndian, windowAdjust.MessageType)
-		// 	binary.Write(&bin_buf, binary.BigEndian, windowAdjust.RecipientChannel)
-		// 	lme.Command.Call(bin_buf.Bytes(), uint32(bin_buf.Len()))
-		// }
-
-		channelData := apf.ChannelClose(lme.Session.SenderChannel)
-		binary.Write(&bin_buf, binary.BigEndian, channelData.MessageType)
-		binary.Write(&bin_buf, binary.BigEndian, channelData.RecipientChannel)
-
-		lme.Command.Send(bin_buf.Bytes(), uint32(bin_buf.Len()))
-		lme.Session.Status <- true
-	}()
+	channel := lme.current()
+	channel.watch()
 	for {
-		result2, bytesRead, err2 := lme.Command.Receive()
-		if bytesRead == 0 || err2 != nil {
+		result, bytesRead, err := lme.Command.Receive()
+		if bytesRead == 0 || err != nil {
 			log.Trace("NO MORE DATA TO READ")
-			break
-		} else {
-			result := apf.Process(result2, lme.Session)
-			if result.Len() != 0 {
-				err2 = lme.execute(result)
-				if err2 != nil {
-					log.Trace(err2)
-				}
-				log.Trace(result)
+			return
+		}
+		if lme.dispatch(result) == channel {
+			return
+		}
+	}
+}
+
+// serve reads from the MEI for the channels opened with OpenChannel until
+// the connection fails or is closed, then fails the channels still open
+func (lme *LMEConnection) serve() {
+	for {
+		result, bytesRead, err := lme.Command.Receive()
+		if bytesRead == 0 || err != nil {
+			if err == nil {
+				err = errors.New("empty response from AMT")
+			}
+			log.Trace("stopped reading from LME: ", err)
+			lme.channelsLock.Lock()
+			channels := lme.channels
+			lme.channels = map[uint32]*Channel{}
+			lme.reading = false
+			lme.channelsLock.Unlock()
+			for _, channel := range channels {
+				channel.fail(err)
+			}
+			return
+		}
+		lme.dispatch(result)
+	}
+}
+
+// dispatch routes a message from AMT to the channel it is addressed to and
+// returns the channel if AMT closed it
+func (lme *LMEConnection) dispatch(result []byte) *Channel {
+	switch result[0] {
+	case apf.APF_CHANNEL_OPEN_CONFIRMATION, apf.APF_CHANNEL_OPEN_FAILURE,
+		apf.APF_CHANNEL_WINDOW_ADJUST, apf.APF_CHANNEL_DATA, apf.APF_CHANNEL_CLOSE:
+	default:
+		reply := lme.process(result)
+		if reply.Len() != 0 {
+			if err := lme.send(reply.Bytes()); err != nil {
+				log.Trace(err)
 			}
+			log.Trace(reply)
+		}
+		return nil
+	}
+	if len(result) < 5 {
+		log.Error("short APF channel message")
+		return nil
+	}
+	channel := lme.channel(binary.BigEndian.Uint32(result[1:5]))
+	if channel == nil {
+		log.Debug("ignoring APF message ", result[0], " for a channel that is not open")
+		return nil
+	}
+	switch result[0] {
+	case apf.APF_CHANNEL_OPEN_CONFIRMATION:
+		channel.confirmed = true
+		apf.Process(result, channel.session)
+	case apf.APF_CHANNEL_OPEN_FAILURE:
+		channel.confirmed = true
+		lme.unregister(channel)
+		channel.stop()
+		apf.Process(result, channel.session)
+	case apf.APF_CHANNEL_WINDOW_ADJUST:
+		log.Trace("received APF_CHANNEL_WINDOW_ADJUST")
+		channel.processWindowAdjust(result)
+	case apf.APF_CHANNEL_DATA:
+		if channel.stream {
+			channel.processStreamData(result)
+		} else if channel.processData(result) {
+			channel.finish()
 		}
+	case apf.APF_CHANNEL_CLOSE:
+		// either AMT answered our close or it is done sending
+		lme.unregister(channel)
+		if channel.stream {
+			channel.sendClose()
+			channel.stop()
+		} else {
+			channel.finish()
+		}
+		return channel
 	}
+	return nil
 }
 
 // Close closes the LMS socket connection
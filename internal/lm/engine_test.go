@@ -5,9 +5,22 @@
 package lm
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
 	"rpc/pkg/pthi"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
 	"github.com/stretchr/testify/assert"
@@ -16,6 +29,8 @@ import (
 type MockHECICommands struct{}
 
 var message []byte
+var messages [][]byte
+var sent [][]byte
 var sendBytesWritten uint32
 var sendError error
 var initError error
@@ -23,6 +38,8 @@ var bufferSize uint32
 
 func resetMock() {
 	message = []byte{}
+	messages = nil
+	sent = nil
 	sendBytesWritten = 12
 	sendError = nil
 	initError = nil
@@ -32,9 +49,19 @@ func resetMock() {
 func (c *MockHECICommands) Init(useLME bool, useWD bool) error { return initError }
 func (c *MockHECICommands) GetBufferSize() uint32              { return bufferSize } // MaxMessageLength
 func (c *MockHECICommands) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
+	sent = append(sent, append([]byte{}, buffer...))
+	if sendBytesWritten == 0 {
+		return uint32(len(buffer)), sendError
+	}
 	return sendBytesWritten, sendError
 }
 func (c *MockHECICommands) ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error) {
+	// queued messages are read once each before falling back to message
+	if len(messages) > 0 {
+		next := messages[0]
+		messages = messages[1:]
+		return uint32(copy(buffer, next)), nil
+	}
 	for i := 0; i < len(message) && i < len(buffer); i++ {
 		buffer[i] = message[i]
 	}
@@ -113,12 +140,61 @@ func Test_Send(t *testing.T) {
 
 	lme := &LMEConnection{
 		Command:    pthiVar,
-		Session:    &apf.Session{},
+		Session:    &apf.Session{TXWindow: 4096},
 		ourChannel: 1,
 	}
 	data := []byte("hello")
 	err := lme.Send(data)
 	assert.NoError(t, err)
+	assert.Equal(t, uint32(4091), lme.Session.TXWindow)
+}
+
+func Test_SendSplitsToWindow(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	bufferSize = 19
+
+	lme := &LMEConnection{
+		Command:    pthiVar,
+		Session:    &apf.Session{SenderChannel: 3, TXWindow: 25},
+		ourChannel: 1,
+	}
+	// 25 bytes fit the window in frames of at most 10 bytes, the rest waits
+	// for AMT to adjust the window
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		var adjust bytes.Buffer
+		binary.Write(&adjust, binary.BigEndian, apf.ChannelWindowAdjust(1, 100))
+		lme.dispatch(adjust.Bytes())
+	}()
+	err := lme.Send([]byte("0123456789abcdefghijklmnopqrstuvwxyz"))
+	assert.NoError(t, err)
+
+	payload := ""
+	for _, frame := range sent {
+		assert.Equal(t, uint8(apf.APF_CHANNEL_DATA), frame[0])
+		assert.Equal(t, uint32(3), binary.BigEndian.Uint32(frame[1:5]))
+		assert.LessOrEqual(t, len(frame), 19)
+		payload += string(frame[9:])
+	}
+	assert.Equal(t, "0123456789abcdefghijklmnopqrstuvwxyz", payload)
+	assert.Equal(t, uint32(89), lme.Session.TXWindow)
+}
+
+func Test_SendWindowTimeout(t *testing.T) {
+	resetMock()
+	timeout := lmeResponseTimeout
+	lmeResponseTimeout = 10 * time.Millisecond
+	defer func() { lmeResponseTimeout = timeout }()
+
+	lme := &LMEConnection{
+		Command:    pthiVar,
+		Session:    &apf.Session{},
+		ourChannel: 1,
+	}
+	err := lme.Send([]byte("hello"))
+	assert.Error(t, err)
+	assert.Empty(t, sent)
 }
 func Test_Connect(t *testing.T) {
 	resetMock()
@@ -157,9 +233,97 @@ func Test_Listen(t *testing.T) {
 		},
 		ourChannel: 1,
 	}
-	message = []byte{0x94, 0x01}
+	messages = [][]byte{{0x94, 0x01}}
 	defer lme.Close()
+	lme.Listen()
+}
+
+func channelDataMessage(channel uint32, payload string) []byte {
+	var data bytes.Buffer
+	message := apf.ChannelData(channel, []byte(payload))
+	binary.Write(&data, binary.BigEndian, message.MessageType)
+	binary.Write(&data, binary.BigEndian, message.RecipientChannel)
+	binary.Write(&data, binary.BigEndian, message.DataLength)
+	binary.Write(&data, binary.BigEndian, message.Data)
+	return data.Bytes()
+}
+
+func channelCloseMessage(channel uint32) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.BigEndian, apf.ChannelClose(channel))
+	return data.Bytes()
+}
+
+func newListeningLME() *LMEConnection {
+	return &LMEConnection{
+		Command: pthiVar,
+		Session: &apf.Session{
+			SenderChannel: 7,
+			DataBuffer:    make(chan []byte),
+			ErrorBuffer:   make(chan error),
+			Status:        make(chan bool),
+		},
+		ourChannel: 1,
+	}
+}
+
+func Test_ListenDeliversFramedResponse(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	body := strings.Repeat("x", 3000)
+	response := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	messages = [][]byte{
+		channelDataMessage(1, response[:1200]),
+		channelDataMessage(1, response[1200:2400]),
+		channelDataMessage(1, response[2400:]),
+		channelCloseMessage(1),
+	}
+	lme := newListeningLME()
+	done := make(chan struct{})
+	go func() {
+		lme.Listen()
+		close(done)
+	}()
+
+	assert.Equal(t, response, string(<-lme.Session.DataBuffer))
+	assert.True(t, <-lme.Session.Status)
+	<-done
+
+	// half the receive window was used before the end, so AMT got it back
+	assert.Len(t, sent, 2)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_WINDOW_ADJUST), sent[0][0])
+	assert.Equal(t, uint32(7), binary.BigEndian.Uint32(sent[0][1:5]))
+	assert.Equal(t, uint32(2400), binary.BigEndian.Uint32(sent[0][5:9]))
+	assert.Equal(t, uint8(apf.APF_CHANNEL_CLOSE), sent[1][0])
+}
+
+func Test_ListenDeliversOnChannelClose(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	response := "HTTP/1.1 200 OK\r\n\r\nunframed"
+	messages = [][]byte{
+		channelDataMessage(1, response),
+		channelCloseMessage(1),
+	}
+	lme := newListeningLME()
 	go lme.Listen()
+
+	assert.Equal(t, response, string(<-lme.Session.DataBuffer))
+	assert.True(t, <-lme.Session.Status)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_CLOSE), sent[len(sent)-1][0])
+}
+
+func Test_ListenTimeout(t *testing.T) {
+	resetMock()
+	timeout := lmeResponseTimeout
+	lmeResponseTimeout = 10 * time.Millisecond
+	defer func() { lmeResponseTimeout = timeout }()
+	lme := newListeningLME()
+	// nothing to read, so the timer passes on the empty response
+	lme.Listen()
+
+	assert.Empty(t, <-lme.Session.DataBuffer)
+	assert.True(t, <-lme.Session.Status)
 }
 
 func Test_Close(t *testing.T) {
@@ -189,3 +353,242 @@ func Test_CloseWithChannel(t *testing.T) {
 	err := lme.Close()
 	assert.NoError(t, err)
 }
+
+func newEmulatedLME(t *testing.T) *LMEConnection {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"uuid":"12345678-9abc-def0-1234-56789abcdef0"}`), 0600))
+	lme := &LMEConnection{
+		Command: pthi.Command{Heci: pthi.NewEmulator(stateFile)},
+		Session: &apf.Session{},
+	}
+	assert.NoError(t, lme.Initialize())
+	return lme
+}
+
+func Test_OpenChannelConcurrent(t *testing.T) {
+	lme := newEmulatedLME(t)
+	defer lme.Close()
+
+	var wg sync.WaitGroup
+	responses := make([][]byte, 6)
+	errs := make([]error, len(responses))
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			channel, err := lme.OpenChannel()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			request := fmt.Sprintf("POST /wsman HTTP/1.1\r\nHost: localhost\r\nContent-Length: 1\r\n\r\n%d", i)
+			if err = channel.Send([]byte(request)); err != nil {
+				errs[i] = err
+				return
+			}
+			responses[i], errs[i] = channel.Receive()
+		}(i)
+	}
+	wg.Wait()
+	for i := range responses {
+		assert.NoError(t, errs[i])
+		assert.True(t, strings.HasPrefix(string(responses[i]), "HTTP/1.1 200 OK"))
+	}
+
+	// channels are handed back once AMT confirms the close
+	assert.Eventually(t, func() bool {
+		lme.channelsLock.Lock()
+		defer lme.channelsLock.Unlock()
+		return len(lme.channels) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func Test_OpenChannelFailsOnClose(t *testing.T) {
+	lme := newEmulatedLME(t)
+	channel, err := lme.OpenChannel()
+	assert.NoError(t, err)
+	lme.Close()
+
+	// the reader passes on why it stopped when the MEI goes away
+	_, err = channel.Receive()
+	assert.ErrorIs(t, err, pthi.ErrEmulatorClosed)
+	_, err = lme.OpenChannel()
+	assert.Error(t, err)
+}
+
+func channelOpenConfirmationMessage(channel uint32) []byte {
+	var data bytes.Buffer
+	binary.Write(&data, binary.BigEndian, apf.ChannelOpenReplySuccess(channel, 7))
+	return data.Bytes()
+}
+
+func Test_OpenChannelFailsMidResponse(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	messages = [][]byte{
+		channelOpenConfirmationMessage(1),
+		channelDataMessage(1, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial"),
+	}
+	lme := &LMEConnection{
+		Command: pthiVar,
+		Session: &apf.Session{},
+	}
+	channel, err := lme.OpenChannel()
+	assert.NoError(t, err)
+
+	// the mock has nothing more to read, the partial response is not passed on
+	response, err := channel.Receive()
+	assert.EqualError(t, err, "empty response from AMT")
+	assert.Nil(t, response)
+}
+
+func Test_ChannelTimeoutBetweenMessages(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	timeout := lmeResponseTimeout
+	lmeResponseTimeout = 10 * time.Millisecond
+	defer func() { lmeResponseTimeout = timeout }()
+	lme := newListeningLME()
+	lme.Command = pthi.Command{Heci: &blockingHECI{
+		messages: [][]byte{channelDataMessage(1, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial")},
+		closed:   make(chan struct{}),
+	}}
+	defer lme.Command.Heci.Close()
+	go lme.Listen()
+
+	// the channel's timeout applies after data arrived, not the three
+	// seconds apf.Process sets
+	select {
+	case data := <-lme.Session.DataBuffer:
+		assert.Equal(t, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial", string(data))
+	case <-time.After(time.Second):
+		t.Fatal("the response timer was not re-armed to the channel timeout")
+	}
+	assert.True(t, <-lme.Session.Status)
+}
+
+// blockingHECI returns its messages, then blocks until closed like an MEI
+// AMT does not answer on
+type blockingHECI struct {
+	MockHECICommands
+	messages [][]byte
+	closed   chan struct{}
+}
+
+func (c *blockingHECI) ReceiveMessage(buffer []byte, done *uint32) (uint32, error) {
+	if len(c.messages) > 0 {
+		next := c.messages[0]
+		c.messages = c.messages[1:]
+		return uint32(copy(buffer, next)), nil
+	}
+	<-c.closed
+	return 0, nil
+}
+
+func (c *blockingHECI) Close() { close(c.closed) }
+
+func Test_OpenChannelFailure(t *testing.T) {
+	resetMock()
+	sendBytesWritten = 0
+	var failure bytes.Buffer
+	binary.Write(&failure, binary.BigEndian, apf.APF_CHANNEL_OPEN_FAILURE_MESSAGE{
+		MessageType:      apf.APF_CHANNEL_OPEN_FAILURE,
+		RecipientChannel: 1,
+		ReasonCode:       apf.OPEN_FAILURE_REASON_CONNECT_FAILED,
+	})
+	messages = [][]byte{failure.Bytes()}
+	lme := &LMEConnection{
+		Command: pthiVar,
+		Session: &apf.Session{},
+	}
+	_, err := lme.OpenChannel()
+	assert.Error(t, err)
+	// the reader stops once the mock has nothing more to read
+	assert.Eventually(t, func() bool {
+		lme.channelsLock.Lock()
+		defer lme.channelsLock.Unlock()
+		return !lme.reading && len(lme.channels) == 0
+	}, time.Second, time.Millisecond)
+}
+
+func Test_ForwardedPorts(t *testing.T) {
+	lme := newEmulatedLME(t)
+	defer lme.Close()
+	assert.Equal(t, []uint32{16992, 16993}, lme.ForwardedPorts())
+
+	var cancel bytes.Buffer
+	name := apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_CANCEL_REQUEST
+	binary.Write(&cancel, binary.BigEndian, uint8(apf.APF_GLOBAL_REQUEST))
+	binary.Write(&cancel, binary.BigEndian, uint32(len(name)))
+	cancel.WriteString(name)
+	binary.Write(&cancel, binary.BigEndian, uint8(1))
+	binary.Write(&cancel, binary.BigEndian, uint32(3))
+	cancel.WriteString("::1")
+	binary.Write(&cancel, binary.BigEndian, uint32(16993))
+	reply := lme.process(cancel.Bytes())
+	assert.Equal(t, []byte{apf.APF_REQUEST_SUCCESS}, reply.Bytes())
+	assert.Equal(t, []uint32{16992}, lme.ForwardedPorts())
+}
+
+func Test_OpenStream(t *testing.T) {
+	lme := newEmulatedLME(t)
+	defer lme.Close()
+
+	channel, err := lme.OpenStream(16993)
+	assert.NoError(t, err)
+	_, err = channel.Write([]byte("POST /wsman HTTP/1.1\r\nHost: localhost\r\nContent-Length: 0\r\n\r\n"))
+	assert.NoError(t, err)
+	response, err := http.ReadResponse(bufio.NewReader(channel), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "<a:Envelope")
+
+	// the channel reads to the end once AMT confirms the close
+	assert.NoError(t, channel.Close())
+	rest, err := io.ReadAll(channel)
+	assert.NoError(t, err)
+	assert.Empty(t, rest)
+}
+
+func Test_Dial(t *testing.T) {
+	lme := newEmulatedLME(t)
+	defer lme.Close()
+
+	conn, err := lme.Dial(16993)
+	assert.NoError(t, err)
+	assert.Equal(t, "amt:16993", conn.RemoteAddr().String())
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) { return conn, nil },
+	}}
+	response, err := client.Post("http://localhost:16993/wsman", "application/soap+xml", strings.NewReader("<a:Envelope/>"))
+	assert.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "<a:Envelope")
+	assert.NoError(t, conn.Close())
+}
+
+func Test_Forward(t *testing.T) {
+	lme := newEmulatedLME(t)
+	defer lme.Close()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	forwarded := make(chan error)
+	go func() { forwarded <- lme.Forward(listener, 16992) }()
+
+	client := http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for i := 0; i < 3; i++ {
+		response, err := client.Post("http://"+listener.Addr().String()+"/wsman", "application/soap+xml", strings.NewReader("<a:Envelope/>"))
+		assert.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), "<a:Envelope")
+	}
+
+	listener.Close()
+	assert.NoError(t, <-forwarded)
+}
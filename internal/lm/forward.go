@@ -0,0 +1,51 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"errors"
+	"io"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Forward accepts connections on listener and tunnels each one to port on
+// AMT over its own APF channel. It returns nil once the listener is closed.
+func (lme *LMEConnection) Forward(listener net.Listener, port uint32) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go lme.tunnel(conn, port)
+	}
+}
+
+// tunnel copies between conn and a stream channel until AMT closes the
+// channel. When the client stops sending the channel is closed, and AMT's
+// close ends the connection after the rest of the answer was passed on.
+func (lme *LMEConnection) tunnel(conn net.Conn, port uint32) {
+	defer conn.Close()
+	channel, err := lme.OpenStream(port)
+	if err != nil {
+		log.Error("Failed to open an APF channel to port ", port, ": ", err)
+		return
+	}
+	log.Debug("forwarding ", conn.RemoteAddr(), " to AMT port ", port, " on channel ", channel.id)
+	go func() {
+		if _, err := io.Copy(channel, conn); err != nil {
+			log.Trace(err)
+		}
+		channel.Close()
+	}()
+	if _, err = io.Copy(conn, channel); err != nil {
+		log.Trace(err)
+	}
+	log.Debug("closed connection from ", conn.RemoteAddr(), " to AMT port ", port)
+}
@@ -0,0 +1,80 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"strconv"
+)
+
+var headerEnd = []byte("\r\n\r\n")
+var lineEnd = []byte("\r\n")
+
+// responseFramer tells when the HTTP response collected from an APF channel
+// is complete. It remembers how far it got so each new chunk of channel data
+// is only scanned once.
+type responseFramer struct {
+	bodyStart     int
+	contentLength int64
+	chunked       bool
+	// next chunk size line of a chunked body
+	chunkStart int
+}
+
+// complete reports whether data holds a whole response. Responses framed by
+// neither Content-Length nor chunked encoding end when AMT closes the channel.
+func (f *responseFramer) complete(data []byte) bool {
+	if f.bodyStart == 0 {
+		end := bytes.Index(data, headerEnd)
+		if end < 0 {
+			return false
+		}
+		response, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data[:end+len(headerEnd)])), nil)
+		if err != nil {
+			return false
+		}
+		response.Body.Close()
+		f.bodyStart = end + len(headerEnd)
+		f.contentLength = response.ContentLength
+		f.chunked = len(response.TransferEncoding) > 0 && response.TransferEncoding[0] == "chunked"
+		f.chunkStart = f.bodyStart
+	}
+	if f.chunked {
+		return f.chunkedComplete(data)
+	}
+	if f.contentLength < 0 {
+		return false
+	}
+	return int64(len(data)-f.bodyStart) >= f.contentLength
+}
+
+func (f *responseFramer) chunkedComplete(data []byte) bool {
+	for {
+		sizeEnd := bytes.Index(data[f.chunkStart:], lineEnd)
+		if sizeEnd < 0 {
+			return false
+		}
+		sizeLine := data[f.chunkStart : f.chunkStart+sizeEnd]
+		if extension := bytes.IndexByte(sizeLine, ';'); extension >= 0 {
+			sizeLine = sizeLine[:extension]
+		}
+		size, err := strconv.ParseInt(string(bytes.TrimSpace(sizeLine)), 16, 64)
+		if err != nil || size < 0 {
+			// let http.ReadResponse report the malformed body
+			return true
+		}
+		if size == 0 {
+			// the last chunk is followed by optional trailers and an empty line
+			return bytes.Contains(data[f.chunkStart:], headerEnd)
+		}
+		next := f.chunkStart + sizeEnd + len(lineEnd) + int(size) + len(lineEnd)
+		if len(data) < next {
+			return false
+		}
+		f.chunkStart = next
+	}
+}
@@ -0,0 +1,82 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseFramerComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		parts    []string
+		complete bool
+	}{
+		{
+			name:     "headers not finished",
+			parts:    []string{"HTTP/1.1 200 OK\r\nContent-Length: 2\r\n"},
+			complete: false,
+		},
+		{
+			name:     "content length body missing bytes",
+			parts:    []string{"HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n", "abc"},
+			complete: false,
+		},
+		{
+			name:     "content length body complete",
+			parts:    []string{"HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\n", "abc", "de"},
+			complete: true,
+		},
+		{
+			name:     "no body expected",
+			parts:    []string{"HTTP/1.1 204 No Content\r\n\r\n"},
+			complete: true,
+		},
+		{
+			name:     "digest challenge",
+			parts:    []string{"HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: Digest realm=\"Digest:A3829B3827DE4D33D4449B366831FD01\", nonce=\"2a4c\", qop=\"auth\"\r\nContent-Length: 0\r\n\r\n"},
+			complete: true,
+		},
+		{
+			name:     "chunked body in progress",
+			parts:    []string{"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n", "5\r\nhel", "lo\r\n", "6;ext=1\r\n world\r\n"},
+			complete: false,
+		},
+		{
+			name:     "chunked last chunk without final line",
+			parts:    []string{"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n", "5\r\nhello\r\n0\r\n"},
+			complete: false,
+		},
+		{
+			name:     "chunked body complete",
+			parts:    []string{"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n", "5\r\nhello\r\n", "0\r\n\r\n"},
+			complete: true,
+		},
+		{
+			name:     "chunked body with trailer",
+			parts:    []string{"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\nX-Trailer: 1\r\n\r\n"},
+			complete: true,
+		},
+		{
+			name:     "unframed body waits for close",
+			parts:    []string{"HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\n\r\n", "body"},
+			complete: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			framer := responseFramer{}
+			data := []byte{}
+			complete := false
+			for _, part := range tt.parts {
+				data = append(data, part...)
+				complete = framer.complete(data)
+			}
+			assert.Equal(t, tt.complete, complete)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Transports rpc can use to reach AMT on this host
+const (
+	// TransportAuto uses LMS when it answers and falls back to LME
+	TransportAuto = "auto"
+	// TransportLMS talks to the Intel LMS service over TCP
+	TransportLMS = "lms"
+	// TransportLME talks to AMT directly over APF on the MEI device
+	TransportLME = "lme"
+)
+
+// lmsProbeTimeout bounds the whole LMS probe, a host without LMS should not
+// hold up the fallback to LME
+var lmsProbeTimeout = 2 * time.Second
+
+// identifyRequest is the unauthenticated WS-MAN Identify request, any WS-MAN
+// service answers it with an IdentifyResponse
+const identifyRequest = `<?xml version="1.0" encoding="UTF-8"?><s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:wsmid="http://schemas.dmtf.org/wbem/wsman/identity/1/wsmanidentity.xsd"><s:Header/><s:Body><wsmid:Identify/></s:Body></s:Envelope>`
+
+// IsTransport reports whether name is one of the transports rpc knows
+func IsTransport(name string) bool {
+	switch name {
+	case TransportAuto, TransportLMS, TransportLME:
+		return true
+	}
+	return false
+}
+
+// ProbeLMS checks that LMS is listening on address:port and that the service
+// behind it answers a WS-MAN Identify request. AMT configured to require
+// authentication for Identify answers with a digest challenge, which is
+// accepted as well.
+func ProbeLMS(address string, port string) error {
+	client := http.Client{
+		Timeout:   lmsProbeTimeout,
+		Transport: &http.Transport{},
+	}
+	endpoint := "http://" + net.JoinHostPort(address, port) + "/wsman"
+	res, err := client.Post(endpoint, "application/soap+xml; charset=utf-8", strings.NewReader(identifyRequest))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	if err != nil {
+		return err
+	}
+	switch {
+	case res.StatusCode == http.StatusOK && bytes.Contains(body, []byte("IdentifyResponse")):
+		return nil
+	case res.StatusCode == http.StatusUnauthorized && strings.HasPrefix(res.Header.Get("WWW-Authenticate"), "Digest"):
+		return nil
+	}
+	return fmt.Errorf("%s did not answer the WS-MAN Identify request: %s", endpoint, res.Status)
+}
+
+// SelectTransport resolves mode to the transport to use. lms and lme are
+// taken as given, auto (or empty) probes LMS on address:port and falls back
+// to LME when it does not answer.
+func SelectTransport(mode string, address string, port string) (string, error) {
+	transport := mode
+	switch mode {
+	case TransportLMS, TransportLME:
+	case "", TransportAuto:
+		transport = TransportLMS
+		if err := ProbeLMS(address, port); err != nil {
+			log.Debug("LMS not available, falling back to LME: ", err)
+			transport = TransportLME
+		}
+	default:
+		return "", fmt.Errorf("unknown transport %q", mode)
+	}
+	log.WithField("transport", transport).Debug("using " + strings.ToUpper(transport) + " to reach AMT")
+	return transport, nil
+}
@@ -0,0 +1,108 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package lm
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const identifyResponse = `<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:b="http://schemas.dmtf.org/wbem/wsman/identity/1/wsmanidentity.xsd"><a:Header></a:Header><a:Body><b:IdentifyResponse><b:ProtocolVersion>http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd</b:ProtocolVersion><b:ProductVendor>Intel(r)</b:ProductVendor><b:ProductVersion>AMT 16.1</b:ProductVersion></b:IdentifyResponse></a:Body></a:Envelope>`
+
+// lmsServer starts a WS-MAN endpoint answering with handler and returns the
+// address and port it listens on
+func lmsServer(t *testing.T, handler http.HandlerFunc) (string, string) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	address, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	return address, port
+}
+
+func identifyHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.URL.Path != "/wsman" || !strings.Contains(string(body), "Identify") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte(identifyResponse))
+}
+
+// closedPort returns a local port nothing listens on
+func closedPort(t *testing.T) string {
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+	return port
+}
+
+func TestProbeLMS(t *testing.T) {
+	address, port := lmsServer(t, identifyHandler)
+	assert.NoError(t, ProbeLMS(address, port))
+}
+
+func TestProbeLMSDigestChallenge(t *testing.T) {
+	address, port := lmsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Digest realm="Digest:F3EB554784E729164447A89F60B641C5", nonce="abc", qop="auth"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	assert.NoError(t, ProbeLMS(address, port))
+}
+
+func TestProbeLMSNotWSMAN(t *testing.T) {
+	address, port := lmsServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+	assert.Error(t, ProbeLMS(address, port))
+}
+
+func TestProbeLMSNotListening(t *testing.T) {
+	assert.Error(t, ProbeLMS("127.0.0.1", closedPort(t)))
+}
+
+func TestSelectTransport(t *testing.T) {
+	address, port := lmsServer(t, identifyHandler)
+	down := closedPort(t)
+	tests := []struct {
+		name     string
+		mode     string
+		port     string
+		expected string
+		wantErr  bool
+	}{
+		{name: "auto with LMS", mode: TransportAuto, port: port, expected: TransportLMS},
+		{name: "default with LMS", mode: "", port: port, expected: TransportLMS},
+		{name: "auto without LMS", mode: TransportAuto, port: down, expected: TransportLME},
+		{name: "forced lms", mode: TransportLMS, port: down, expected: TransportLMS},
+		{name: "forced lme", mode: TransportLME, port: port, expected: TransportLME},
+		{name: "unknown", mode: "usb", port: port, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := SelectTransport(tt.mode, address, tt.port)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, transport)
+		})
+	}
+}
+
+func TestIsTransport(t *testing.T) {
+	assert.True(t, IsTransport(TransportAuto))
+	assert.True(t, IsTransport(TransportLMS))
+	assert.True(t, IsTransport(TransportLME))
+	assert.False(t, IsTransport(""))
+	assert.False(t, IsTransport("usb"))
+}
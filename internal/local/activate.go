@@ -26,7 +26,7 @@ func (service *ProvisioningService) Activate() error {
 
 	controlMode, err := service.amtCommand.GetControlMode()
 	if err != nil {
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 	if controlMode != 0 {
 		log.Error("Device is already activated")
@@ -39,7 +39,7 @@ func (service *ProvisioningService) Activate() error {
 	lsa, err := service.amtCommand.GetLocalSystemAccount()
 	if err != nil {
 		log.Error(err)
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 	service.interfacedWsmanMessage.SetupWsmanClient(lsa.Username, lsa.Password, log.GetLevel() == log.TraceLevel)
 
@@ -62,6 +62,13 @@ func (service *ProvisioningService) ActivateACM() error {
 	if err != nil {
 		return utils.ActivationFailed
 	}
+	// Fix the PKI DNS suffix first when one is given,
+	// AMT matches it against the provisioning certificate domain
+	if service.flags.DNS != "" {
+		if err = service.matchDNSSuffix(service.flags.DNS); err != nil {
+			return err
+		}
+	}
 	// Check provisioning certificate is accepted by AMT
 	err = service.CompareCertHashes(fingerPrint)
 	if err != nil {
@@ -102,7 +109,7 @@ func (service *ProvisioningService) ActivateACM() error {
 	if err != nil {
 		controlMode, err := service.amtCommand.GetControlMode()
 		if err != nil {
-			return utils.AMTConnectionFailed
+			return amtError(err, utils.AMTConnectionFailed)
 		}
 		if controlMode != 2 {
 			return utils.ActivationFailed
@@ -7,9 +7,11 @@ package local
 
 import (
 	"crypto/x509"
+	"fmt"
 	amt2 "rpc/internal/amt"
 	"rpc/internal/certs"
 	"rpc/internal/flags"
+	"rpc/pkg/pthi"
 	"rpc/pkg/utils"
 	"testing"
 
@@ -42,6 +44,13 @@ func TestActivation(t *testing.T) {
 		mockControlModeErr = nil
 	})
 
+	t.Run("returns the AMT status when GetControlMode reports one", func(t *testing.T) {
+		mockControlModeErr = fmt.Errorf("error getting control mode: %w", pthi.AMT_STATUS_NOT_READY.Err())
+		err := lps.Activate()
+		assert.Equal(t, pthi.AMT_STATUS_NOT_READY.CustomError(), err)
+		mockControlModeErr = nil
+	})
+
 	t.Run("returns UnableToActivate when already activated", func(t *testing.T) {
 		mockControlMode = 1
 		err := lps.Activate()
@@ -56,6 +65,13 @@ func TestActivation(t *testing.T) {
 		mockLocalSystemAccountErr = nil
 	})
 
+	t.Run("returns the AMT status when GetLocalSystemAccount reports one", func(t *testing.T) {
+		mockLocalSystemAccountErr = pthi.AMT_STATUS_INVALID_PROVISIONING_STATE.Err()
+		err := lps.Activate()
+		assert.Equal(t, pthi.AMT_STATUS_INVALID_PROVISIONING_STATE.CustomError(), err)
+		mockLocalSystemAccountErr = nil
+	})
+
 }
 
 func TestActivateCCM(t *testing.T) {
@@ -102,6 +118,22 @@ func TestActivateACM(t *testing.T) {
 	}
 	err := lps.ActivateACM()
 	assert.NoError(t, err)
+
+	t.Run("sets the DNS suffix before comparing cert hashes", func(t *testing.T) {
+		mockSetDNSSuffixCalledWith = ""
+		lps.flags.DNS = "vprodemo.com"
+		err := lps.ActivateACM()
+		assert.NoError(t, err)
+		assert.Equal(t, "vprodemo.com", mockSetDNSSuffixCalledWith)
+	})
+	t.Run("returns DNSSuffixConfigurationFailed when the suffix cannot be set", func(t *testing.T) {
+		mockSetDNSSuffixErr = errTestError
+		lps.flags.DNS = "vprodemo.com"
+		err := lps.ActivateACM()
+		assert.Equal(t, utils.DNSSuffixConfigurationFailed, err)
+		mockSetDNSSuffixErr = nil
+		lps.flags.DNS = ""
+	})
 }
 
 func TestInjectCertsErrors(t *testing.T) {
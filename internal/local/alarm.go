@@ -0,0 +1,137 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type AlarmEntry struct {
+	Name               string    `json:"name"`
+	StartTime          time.Time `json:"startTime"`
+	Interval           string    `json:"interval,omitempty"`
+	DeleteOnCompletion bool      `json:"deleteOnCompletion"`
+}
+
+// ConfigureAlarm adds or deletes an alarm, then lists the alarms AMT keeps
+func (service *ProvisioningService) ConfigureAlarm() error {
+	alarms, err := service.interfacedWsmanMessage.GetAlarms()
+	if err != nil {
+		log.Error("Failed to read the alarms: ", err)
+		return utils.AlarmConfigurationFailed
+	}
+	switch service.flags.Alarm.Action {
+	case utils.SubCommandAdd:
+		err = service.addAlarm(alarms)
+	case utils.SubCommandDelete:
+		err = service.deleteAlarm(alarms)
+	}
+	if err != nil {
+		return err
+	}
+	if service.flags.Alarm.Action != utils.SubCommandList {
+		if alarms, err = service.interfacedWsmanMessage.GetAlarms(); err != nil {
+			log.Error("Failed to read the alarms: ", err)
+			return utils.AlarmConfigurationFailed
+		}
+	}
+	service.printAlarms(alarms)
+	return nil
+}
+
+// addAlarm refuses a name that is taken and a sixth alarm, which AMT would
+// refuse without saying why
+func (service *ProvisioningService) addAlarm(alarms []amt.Alarm) error {
+	for _, alarm := range alarms {
+		if alarm.Name == service.flags.Alarm.Name {
+			log.Error("an alarm named ", alarm.Name, " already exists")
+			return utils.AlarmConfigurationFailed
+		}
+	}
+	if len(alarms) >= amt.MaxAlarms {
+		log.Error("AMT keeps up to ", amt.MaxAlarms, " alarms, delete one first")
+		return utils.AlarmConfigurationFailed
+	}
+	alarm := amt.Alarm{
+		Name:               service.flags.Alarm.Name,
+		StartTime:          service.flags.Alarm.Start,
+		Interval:           service.flags.Alarm.Interval,
+		DeleteOnCompletion: service.flags.Alarm.DeleteOnCompletion,
+	}
+	if err := service.interfacedWsmanMessage.AddAlarm(alarm); err != nil {
+		log.Error("Failed to add the alarm: ", err)
+		return amtError(err, utils.AlarmConfigurationFailed)
+	}
+	log.Info("Added alarm ", alarm.Name)
+	return nil
+}
+
+func (service *ProvisioningService) deleteAlarm(alarms []amt.Alarm) error {
+	for _, alarm := range alarms {
+		if alarm.Name != service.flags.Alarm.Name {
+			continue
+		}
+		if err := service.interfacedWsmanMessage.DeleteAlarm(alarm.Name); err != nil {
+			log.Error("Failed to delete the alarm: ", err)
+			return utils.AlarmConfigurationFailed
+		}
+		log.Info("Deleted alarm ", alarm.Name)
+		return nil
+	}
+	log.Error("there is no alarm named ", service.flags.Alarm.Name)
+	return utils.AlarmConfigurationFailed
+}
+
+func (service *ProvisioningService) printAlarms(alarms []amt.Alarm) {
+	entries := []AlarmEntry{}
+	for _, alarm := range alarms {
+		entries = append(entries, AlarmEntry{
+			Name:               alarm.Name,
+			StartTime:          alarm.StartTime.Local(),
+			Interval:           formatAlarmInterval(alarm.Interval),
+			DeleteOnCompletion: alarm.DeleteOnCompletion,
+		})
+	}
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	service.PrintOutput("Alarms:")
+	for _, entry := range entries {
+		line := fmt.Sprintf("  %-32s %s", entry.Name, entry.StartTime.Format("2006-01-02 15:04 MST"))
+		if entry.Interval != "" {
+			line += "  every " + entry.Interval
+		}
+		if entry.DeleteOnCompletion {
+			line += "  (deleted on completion)"
+		}
+		service.PrintOutput(line)
+	}
+}
+
+// formatAlarmInterval writes an interval the way -interval takes it: whole
+// days as 7d, anything else as a duration without zero units
+func formatAlarmInterval(interval time.Duration) string {
+	if interval == 0 {
+		return ""
+	}
+	if interval%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", interval/(24*time.Hour))
+	}
+	text := strings.TrimSuffix(interval.String(), "0s")
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
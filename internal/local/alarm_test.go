@@ -0,0 +1,115 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureAlarm(t *testing.T) {
+	start := time.Date(2024, 5, 2, 2, 0, 0, 0, time.UTC)
+	existing := amt.Alarm{Name: "patching", StartTime: start, Interval: 24 * time.Hour}
+	tests := []struct {
+		name            string
+		alarm           flags.AlarmFlags
+		setupMocks      func(*MockWSMAN)
+		expectedErr     error
+		expectedAdded   amt.Alarm
+		expectedDeleted string
+	}{
+		{
+			name:  "lists the alarms",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandList},
+			setupMocks: func(mock *MockWSMAN) {
+				mockAlarms = []amt.Alarm{existing}
+			},
+		},
+		{
+			name:          "adds an alarm",
+			alarm:         flags.AlarmFlags{Action: utils.SubCommandAdd, Name: "weekly", Start: start, Interval: 7 * 24 * time.Hour, DeleteOnCompletion: true},
+			setupMocks:    func(mock *MockWSMAN) {},
+			expectedAdded: amt.Alarm{Name: "weekly", StartTime: start, Interval: 7 * 24 * time.Hour, DeleteOnCompletion: true},
+		},
+		{
+			name:  "refuses a name that is taken",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandAdd, Name: "patching"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockAlarms = []amt.Alarm{existing}
+			},
+			expectedErr: utils.AlarmConfigurationFailed,
+		},
+		{
+			name:  "refuses a sixth alarm",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandAdd, Name: "f"},
+			setupMocks: func(mock *MockWSMAN) {
+				for _, name := range []string{"a", "b", "c", "d", "e"} {
+					mockAlarms = append(mockAlarms, amt.Alarm{Name: name, StartTime: start})
+				}
+			},
+			expectedErr: utils.AlarmConfigurationFailed,
+		},
+		{
+			name:  "fails when AMT refuses the alarm",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandAdd, Name: "weekly"},
+			setupMocks: func(mock *MockWSMAN) {
+				errAddAlarm = errors.New("AddAlarm failed")
+			},
+			expectedErr:   utils.AlarmConfigurationFailed,
+			expectedAdded: amt.Alarm{Name: "weekly"},
+		},
+		{
+			name:  "deletes an alarm",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandDelete, Name: "patching"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockAlarms = []amt.Alarm{existing}
+			},
+			expectedDeleted: "patching",
+		},
+		{
+			name:        "fails to delete an unknown alarm",
+			alarm:       flags.AlarmFlags{Action: utils.SubCommandDelete, Name: "patching"},
+			setupMocks:  func(mock *MockWSMAN) {},
+			expectedErr: utils.AlarmConfigurationFailed,
+		},
+		{
+			name:  "fails when the alarms cannot be read",
+			alarm: flags.AlarmFlags{Action: utils.SubCommandList},
+			setupMocks: func(mock *MockWSMAN) {
+				errGetAlarms = errors.New("unreachable")
+			},
+			expectedErr: utils.AlarmConfigurationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockAlarms, errGetAlarms, errAddAlarm, errDeleteAlarm = []amt.Alarm{}, nil, nil, nil
+			mockAddedAlarm, mockDeletedAlarm = amt.Alarm{}, ""
+			service, _, mockWsman := setupProvisioningService()
+			tt.setupMocks(mockWsman)
+			service.flags.Alarm = tt.alarm
+			service.flags.JsonOutput = true
+			err := service.ConfigureAlarm()
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedAdded, mockAddedAlarm)
+			assert.Equal(t, tt.expectedDeleted, mockDeletedAlarm)
+		})
+	}
+}
+
+func TestFormatAlarmInterval(t *testing.T) {
+	assert.Equal(t, "", formatAlarmInterval(0))
+	assert.Equal(t, "1d", formatAlarmInterval(24*time.Hour))
+	assert.Equal(t, "12h", formatAlarmInterval(12*time.Hour))
+	assert.Equal(t, "1h30m", formatAlarmInterval(90*time.Minute))
+	assert.Equal(t, "45m", formatAlarmInterval(45*time.Minute))
+}
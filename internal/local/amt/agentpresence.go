@@ -0,0 +1,183 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"rpc/pkg/pthi"
+
+	"github.com/google/uuid"
+)
+
+// go-wsman-messages does not cover AMT_AgentPresenceWatchdog, so its
+// messages go through postAMT
+
+const agentPresenceWatchdogClass = "AMT_AgentPresenceWatchdog"
+
+// WatchdogState is a CurrentTimerState of AMT_AgentPresenceWatchdog, the
+// values are bits so AddAction can match on them
+type WatchdogState int
+
+const (
+	WatchdogNotStarted WatchdogState = 1
+	WatchdogStopped    WatchdogState = 2
+	WatchdogRunning    WatchdogState = 4
+	WatchdogExpired    WatchdogState = 8
+	WatchdogSuspended  WatchdogState = 16
+)
+
+func (s WatchdogState) String() string {
+	switch s {
+	case WatchdogNotStarted:
+		return "not started"
+	case WatchdogStopped:
+		return "stopped"
+	case WatchdogRunning:
+		return "running"
+	case WatchdogExpired:
+		return "expired"
+	case WatchdogSuspended:
+		return "suspended"
+	}
+	return "unknown"
+}
+
+// AgentPresenceWatchdog is an AMT_AgentPresenceWatchdog instance
+type AgentPresenceWatchdog struct {
+	DeviceID          string        `xml:"DeviceID"`
+	CurrentTimerState WatchdogState `xml:"CurrentTimerState"`
+	TimeoutInterval   int           `xml:"TimeoutInterval"`
+	RecoveryInterval  int           `xml:"RecoveryInterval"`
+}
+
+type agentPresenceResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		EnumerationContext string                  `xml:"EnumerateResponse>EnumerationContext"`
+		Items              []AgentPresenceWatchdog `xml:"PullResponse>Items>AMT_AgentPresenceWatchdog"`
+		Output             struct {
+			XMLName               xml.Name
+			ReturnValue           int    `xml:"ReturnValue"`
+			SessionSequenceNumber uint32 `xml:"SessionSequenceNumber"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+// AgentPresenceDeviceID encodes an agent GUID the way AMT expects it in DeviceID
+func AgentPresenceDeviceID(agentID string) (string, error) {
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(id[:]), nil
+}
+
+type agentPresence struct {
+	g *GoWSMANMessages
+}
+
+func (a *agentPresence) post(action string, deviceID string, body string) (agentPresenceResponse, error) {
+	selectors := ""
+	if deviceID != "" {
+		selectors = fmt.Sprintf(`<w:Selector Name="CreationClassName">%s</w:Selector><w:Selector Name="DeviceID">%s</w:Selector>`, agentPresenceWatchdogClass, deviceID) +
+			`<w:Selector Name="SystemCreationClassName">CIM_ComputerSystem</w:Selector><w:Selector Name="SystemName">Intel(r) AMT</w:Selector>`
+	}
+	response := agentPresenceResponse{}
+	raw, err := a.g.postAMT(action, agentPresenceWatchdogClass, selectors, body)
+	if err != nil {
+		return response, err
+	}
+	if err = xml.Unmarshal(raw, &response); err != nil {
+		return response, err
+	}
+	return response, nil
+}
+
+func (a *agentPresence) invoke(method string, deviceID string, inputs string) (agentPresenceResponse, error) {
+	action := amtResourceURIBase + agentPresenceWatchdogClass + "/" + method
+	body := fmt.Sprintf(`<h:%s_INPUT xmlns:h="%s%s">%s</h:%s_INPUT>`, method, amtResourceURIBase, agentPresenceWatchdogClass, inputs, method)
+	response, err := a.post(action, deviceID, body)
+	if err != nil {
+		return response, err
+	}
+	// method return values are PT status codes
+	if err = pthi.Status(response.Body.Output.ReturnValue).Err(); err != nil {
+		return response, fmt.Errorf("%s failed: %w", method, err)
+	}
+	return response, nil
+}
+
+func (a *agentPresence) list() ([]AgentPresenceWatchdog, error) {
+	response, err := a.post(wsmanActionEnumerate, "", `<Enumerate xmlns="http://schemas.xmlsoap.org/ws/2004/09/enumeration" />`)
+	if err != nil {
+		return nil, err
+	}
+	pull := fmt.Sprintf(`<Pull xmlns="http://schemas.xmlsoap.org/ws/2004/09/enumeration"><EnumerationContext>%s</EnumerationContext><MaxElements>999</MaxElements><MaxCharacters>99999</MaxCharacters></Pull>`, response.Body.EnumerationContext)
+	response, err = a.post(wsmanActionPull, "", pull)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body.Items, nil
+}
+
+func (a *agentPresence) create(deviceID string, timeout, recovery uint16) error {
+	body := fmt.Sprintf(`<h:%s xmlns:h="%s%s"><h:CreationClassName>%s</h:CreationClassName><h:DeviceID>%s</h:DeviceID><h:RecoveryInterval>%d</h:RecoveryInterval><h:SystemCreationClassName>CIM_ComputerSystem</h:SystemCreationClassName><h:SystemName>Intel(r) AMT</h:SystemName><h:TimeoutInterval>%d</h:TimeoutInterval></h:%s>`,
+		agentPresenceWatchdogClass, amtResourceURIBase, agentPresenceWatchdogClass, agentPresenceWatchdogClass, deviceID, recovery, timeout, agentPresenceWatchdogClass)
+	_, err := a.post(wsmanActionCreate, "", body)
+	return err
+}
+
+func (a *agentPresence) delete(deviceID string) error {
+	_, err := a.post(wsmanActionDelete, deviceID, "")
+	return err
+}
+
+func (g *GoWSMANMessages) agentPresence() *agentPresence {
+	return &agentPresence{g: g}
+}
+
+func (g *GoWSMANMessages) GetAgentPresenceWatchdogs() ([]AgentPresenceWatchdog, error) {
+	return g.agentPresence().list()
+}
+
+func (g *GoWSMANMessages) CreateAgentPresenceWatchdog(deviceID string, timeout, recovery uint16) error {
+	return g.agentPresence().create(deviceID, timeout, recovery)
+}
+
+func (g *GoWSMANMessages) DeleteAgentPresenceWatchdog(deviceID string) error {
+	return g.agentPresence().delete(deviceID)
+}
+
+// AddAgentPresenceWatchdogAction makes AMT log an event when the watchdog
+// goes from oldState to newState
+func (g *GoWSMANMessages) AddAgentPresenceWatchdogAction(deviceID string, oldState, newState WatchdogState) error {
+	inputs := fmt.Sprintf(`<h:OldState>%d</h:OldState><h:NewState>%d</h:NewState><h:EventOnTransition>true</h:EventOnTransition>`, oldState, newState)
+	_, err := g.agentPresence().invoke("AddAction", deviceID, inputs)
+	return err
+}
+
+func (g *GoWSMANMessages) DeleteAllAgentPresenceWatchdogActions(deviceID string) error {
+	_, err := g.agentPresence().invoke("DeleteAllActions", deviceID, "")
+	return err
+}
+
+// RegisterAgentPresenceWatchdog registers this host as the agent of the
+// watchdog and returns the initial sequence number of the session
+func (g *GoWSMANMessages) RegisterAgentPresenceWatchdog(deviceID string) (uint32, error) {
+	response, err := g.agentPresence().invoke("RegisterAgent", deviceID, "")
+	if err != nil {
+		return 0, err
+	}
+	return response.Body.Output.SessionSequenceNumber, nil
+}
+
+// AssertAgentPresenceShutdown tells AMT the agent is stopping on purpose
+func (g *GoWSMANMessages) AssertAgentPresenceShutdown(deviceID string, sequenceNumber uint32) error {
+	_, err := g.agentPresence().invoke("AssertShutdown", deviceID, fmt.Sprintf(`<h:SequenceNumber>%d</h:SequenceNumber>`, sequenceNumber))
+	return err
+}
@@ -0,0 +1,110 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"errors"
+	"rpc/pkg/pthi"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWSMan records the posted envelopes and answers with canned bodies
+type fakeWSMan struct {
+	requests  []string
+	responses []string
+	err       error
+}
+
+func (f *fakeWSMan) Post(msg string) ([]byte, error) {
+	f.requests = append(f.requests, msg)
+	if f.err != nil {
+		return nil, f.err
+	}
+	response := f.responses[0]
+	f.responses = f.responses[1:]
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:g="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AgentPresenceWatchdog"><a:Header></a:Header><a:Body>` + response + `</a:Body></a:Envelope>`), nil
+}
+func (f *fakeWSMan) Connect() error           { return nil }
+func (f *fakeWSMan) Send(data []byte) error   { return nil }
+func (f *fakeWSMan) Receive() ([]byte, error) { return nil, nil }
+func (f *fakeWSMan) CloseConnection() error   { return nil }
+
+func newAgentPresenceTarget(responses ...string) (*GoWSMANMessages, *fakeWSMan) {
+	fake := &fakeWSMan{responses: responses}
+	g := NewGoWSMANMessages("localhost", "16992", "lms", TLSOptions{})
+	g.wsmanMessages.Client = fake
+	return g, fake
+}
+
+func TestAgentPresenceDeviceID(t *testing.T) {
+	deviceID, err := AgentPresenceDeviceID("12345678-9abc-def0-1234-56789abcdef0")
+	assert.NoError(t, err)
+	assert.Equal(t, "EjRWeJq83vASNFZ4mrze8A==", deviceID)
+
+	_, err = AgentPresenceDeviceID("rpc")
+	assert.Error(t, err)
+}
+
+func TestGetAgentPresenceWatchdogs(t *testing.T) {
+	g, fake := newAgentPresenceTarget(
+		`<g:EnumerateResponse><g:EnumerationContext>07000000-0000-0000-0000-000000000000</g:EnumerationContext></g:EnumerateResponse>`,
+		`<g:PullResponse><g:Items><g:AMT_AgentPresenceWatchdog><g:CurrentTimerState>4</g:CurrentTimerState><g:DeviceID>EjRWeJq83vASNFZ4mrze8A==</g:DeviceID><g:RecoveryInterval>120</g:RecoveryInterval><g:TimeoutInterval>120</g:TimeoutInterval></g:AMT_AgentPresenceWatchdog></g:Items></g:PullResponse>`,
+	)
+	watchdogs, err := g.GetAgentPresenceWatchdogs()
+	assert.NoError(t, err)
+	assert.Equal(t, []AgentPresenceWatchdog{{DeviceID: "EjRWeJq83vASNFZ4mrze8A==", CurrentTimerState: WatchdogRunning, TimeoutInterval: 120, RecoveryInterval: 120}}, watchdogs)
+	assert.Len(t, fake.requests, 2)
+	assert.Contains(t, fake.requests[0], "<a:Action>http://schemas.xmlsoap.org/ws/2004/09/enumeration/Enumerate</a:Action>")
+	assert.Contains(t, fake.requests[1], "<EnumerationContext>07000000-0000-0000-0000-000000000000</EnumerationContext>")
+	assert.Contains(t, fake.requests[1], "<a:MessageID>1</a:MessageID>")
+}
+
+func TestCreateAndDeleteAgentPresenceWatchdog(t *testing.T) {
+	g, fake := newAgentPresenceTarget(`<g:ResourceCreated></g:ResourceCreated>`, ``)
+	err := g.CreateAgentPresenceWatchdog("EjRWeJq83vASNFZ4mrze8A==", 300, 120)
+	assert.NoError(t, err)
+	assert.Contains(t, fake.requests[0], "<h:DeviceID>EjRWeJq83vASNFZ4mrze8A==</h:DeviceID>")
+	assert.Contains(t, fake.requests[0], "<h:TimeoutInterval>300</h:TimeoutInterval>")
+	assert.Contains(t, fake.requests[0], "<h:RecoveryInterval>120</h:RecoveryInterval>")
+
+	err = g.DeleteAgentPresenceWatchdog("EjRWeJq83vASNFZ4mrze8A==")
+	assert.NoError(t, err)
+	assert.Contains(t, fake.requests[1], "<a:Action>http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete</a:Action>")
+	assert.Contains(t, fake.requests[1], `<w:Selector Name="DeviceID">EjRWeJq83vASNFZ4mrze8A==</w:Selector>`)
+}
+
+func TestAgentPresenceWatchdogMethods(t *testing.T) {
+	g, fake := newAgentPresenceTarget(
+		`<g:DeleteAllActions_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:DeleteAllActions_OUTPUT>`,
+		`<g:AddAction_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:AddAction_OUTPUT>`,
+		`<g:RegisterAgent_OUTPUT><g:SessionSequenceNumber>7</g:SessionSequenceNumber><g:ReturnValue>0</g:ReturnValue></g:RegisterAgent_OUTPUT>`,
+		`<g:AssertShutdown_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:AssertShutdown_OUTPUT>`,
+	)
+	deviceID := "EjRWeJq83vASNFZ4mrze8A=="
+	assert.NoError(t, g.DeleteAllAgentPresenceWatchdogActions(deviceID))
+	assert.NoError(t, g.AddAgentPresenceWatchdogAction(deviceID, WatchdogRunning, WatchdogExpired))
+	assert.Contains(t, fake.requests[1], "<h:OldState>4</h:OldState><h:NewState>8</h:NewState><h:EventOnTransition>true</h:EventOnTransition>")
+	sequenceNumber, err := g.RegisterAgentPresenceWatchdog(deviceID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), sequenceNumber)
+	assert.Contains(t, fake.requests[2], "<a:Action>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AgentPresenceWatchdog/RegisterAgent</a:Action>")
+	assert.NoError(t, g.AssertAgentPresenceShutdown(deviceID, 8))
+	assert.Contains(t, fake.requests[3], "<h:SequenceNumber>8</h:SequenceNumber>")
+}
+
+func TestAgentPresenceWatchdogMethodErrors(t *testing.T) {
+	g, _ := newAgentPresenceTarget(`<g:RegisterAgent_OUTPUT><g:ReturnValue>16</g:ReturnValue></g:RegisterAgent_OUTPUT>`)
+	_, err := g.RegisterAgentPresenceWatchdog("EjRWeJq83vASNFZ4mrze8A==")
+	assert.ErrorIs(t, err, pthi.AMT_STATUS_NOT_PERMITTED.Err())
+	assert.EqualError(t, err, "RegisterAgent failed: Error 1016: AMT_STATUS_NOT_PERMITTED: "+pthi.AMT_STATUS_NOT_PERMITTED.Description())
+
+	g, fake := newAgentPresenceTarget()
+	fake.err = errors.New("wsman.Fault")
+	err = g.DeleteAllAgentPresenceWatchdogActions("EjRWeJq83vASNFZ4mrze8A==")
+	assert.EqualError(t, err, "wsman.Fault")
+}
@@ -0,0 +1,131 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"rpc/pkg/pthi"
+	"strconv"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/alarmclock"
+)
+
+// MaxAlarms is the number of IPS_AlarmClockOccurrence instances AMT keeps,
+// AddAlarm fails once they exist
+const MaxAlarms = 5
+
+// Alarm is an IPS_AlarmClockOccurrence, a time at which AMT powers the
+// device on
+type Alarm struct {
+	Name               string
+	StartTime          time.Time
+	Interval           time.Duration
+	DeleteOnCompletion bool
+}
+
+// alarmPullResponse decodes IPS_AlarmClockOccurrence items again, as the
+// library types StartTime and Interval as plain strings and loses the
+// Datetime and Interval elements inside them
+type alarmPullResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Items   []struct {
+		InstanceID         string
+		StartTime          string `xml:"StartTime>Datetime"`
+		Interval           string `xml:"Interval>Interval"`
+		DeleteOnCompletion bool
+	} `xml:"Body>PullResponse>Items>IPS_AlarmClockOccurrence"`
+}
+
+// GetAlarms returns the alarms set in AMT
+func (g *GoWSMANMessages) GetAlarms() ([]Alarm, error) {
+	response, err := g.wsmanMessages.IPS.AlarmClockOccurrence.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	response, err = g.wsmanMessages.IPS.AlarmClockOccurrence.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return nil, err
+	}
+	pulled := alarmPullResponse{}
+	if err = xml.Unmarshal([]byte(response.XMLOutput), &pulled); err != nil {
+		return nil, err
+	}
+	alarms := []Alarm{}
+	for _, item := range pulled.Items {
+		alarm := Alarm{Name: item.InstanceID, DeleteOnCompletion: item.DeleteOnCompletion}
+		if alarm.StartTime, err = parseCIMDatetime(item.StartTime); err != nil {
+			return nil, fmt.Errorf("alarm %s has an invalid start time %s", item.InstanceID, item.StartTime)
+		}
+		if item.Interval != "" {
+			if alarm.Interval, err = ParseCIMInterval(item.Interval); err != nil {
+				return nil, fmt.Errorf("alarm %s: %w", item.InstanceID, err)
+			}
+		}
+		alarms = append(alarms, alarm)
+	}
+	return alarms, nil
+}
+
+// AddAlarm creates an alarm with AMT_AlarmClockService.AddAlarm. AMT keeps
+// the interval in minutes.
+func (g *GoWSMANMessages) AddAlarm(alarm Alarm) error {
+	response, err := g.wsmanMessages.AMT.AlarmClockService.AddAlarm(alarmclock.AlarmClockOccurrence{
+		InstanceID:         alarm.Name,
+		ElementName:        alarm.Name,
+		StartTime:          alarm.StartTime,
+		Interval:           int(alarm.Interval / time.Minute),
+		DeleteOnCompletion: alarm.DeleteOnCompletion,
+	})
+	if err != nil {
+		return err
+	}
+	// method return values are PT status codes
+	if err = pthi.Status(response.Body.AddAlarmOutput.ReturnValue).Err(); err != nil {
+		return fmt.Errorf("AddAlarm failed: %w", err)
+	}
+	return nil
+}
+
+// DeleteAlarm removes the alarm named name
+func (g *GoWSMANMessages) DeleteAlarm(name string) error {
+	_, err := g.wsmanMessages.IPS.AlarmClockOccurrence.Delete(name)
+	return err
+}
+
+// parseCIMDatetime reads an xs:dateTime, which AMT sends in UTC with or
+// without the zone
+func parseCIMDatetime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15:04:05", value)
+}
+
+var cimIntervalPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseCIMInterval reads an xs:duration as AMT reports alarm intervals, for
+// example P1DT0H0M
+func ParseCIMInterval(value string) (time.Duration, error) {
+	match := cimIntervalPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || value[len(value)-1] == 'T' {
+		return 0, fmt.Errorf("invalid interval %s", value)
+	}
+	interval := time.Duration(0)
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+1] == "" {
+			continue
+		}
+		count, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %s", value)
+		}
+		interval += time.Duration(count) * unit
+	}
+	return interval, nil
+}
@@ -0,0 +1,61 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCIMInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"P1DT0H0M":  24 * time.Hour,
+		"P0DT1H30M": 90 * time.Minute,
+		"PT15M":     15 * time.Minute,
+		"P7D":       7 * 24 * time.Hour,
+		"PT0S":      0,
+	}
+	for value, want := range tests {
+		interval, err := ParseCIMInterval(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, want, interval, value)
+	}
+	for _, value := range []string{"", "P", "PT", "1D", "P1H"} {
+		_, err := ParseCIMInterval(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestAlarms(t *testing.T) {
+	g, requests := sequenceServer(t,
+		enumerateResponse("07000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items>`+
+			`<h:IPS_AlarmClockOccurrence xmlns:h="http://intel.com/wbem/wscim/1/ips-schema/1/IPS_AlarmClockOccurrence"><h:DeleteOnCompletion>false</h:DeleteOnCompletion><h:ElementName>patching</h:ElementName><h:InstanceID>patching</h:InstanceID><h:Interval><b:Interval xmlns:b="http://schemas.dmtf.org/wbem/wscim/1/common">P1DT0H0M</b:Interval></h:Interval><h:StartTime><b:Datetime xmlns:b="http://schemas.dmtf.org/wbem/wscim/1/common">2024-05-02T00:00:00Z</b:Datetime></h:StartTime></h:IPS_AlarmClockOccurrence>`+
+			`<h:IPS_AlarmClockOccurrence xmlns:h="http://intel.com/wbem/wscim/1/ips-schema/1/IPS_AlarmClockOccurrence"><h:DeleteOnCompletion>true</h:DeleteOnCompletion><h:InstanceID>once</h:InstanceID><h:StartTime><b:Datetime xmlns:b="http://schemas.dmtf.org/wbem/wscim/1/common">2024-05-03T08:15:00</b:Datetime></h:StartTime></h:IPS_AlarmClockOccurrence>`+
+			`</g:Items></g:PullResponse>`,
+		`<g:AddAlarm_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:AddAlarm_OUTPUT>`,
+		`<g:AddAlarm_OUTPUT><g:ReturnValue>2075</g:ReturnValue></g:AddAlarm_OUTPUT>`,
+		``,
+	)
+	alarms, err := g.GetAlarms()
+	assert.NoError(t, err)
+	assert.Equal(t, []Alarm{
+		{Name: "patching", StartTime: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), Interval: 24 * time.Hour},
+		{Name: "once", StartTime: time.Date(2024, 5, 3, 8, 15, 0, 0, time.UTC), DeleteOnCompletion: true},
+	}, alarms)
+
+	alarm := Alarm{Name: "weekly", StartTime: time.Date(2024, 5, 4, 2, 0, 0, 0, time.UTC), Interval: 7 * 24 * time.Hour}
+	assert.NoError(t, g.AddAlarm(alarm))
+	assert.Contains(t, (*requests)[2], "<s:InstanceID")
+	assert.Contains(t, (*requests)[2], "2024-05-04T02:00:00Z")
+	assert.Contains(t, (*requests)[2], "P7DT0H0M")
+	assert.Error(t, g.AddAlarm(alarm))
+
+	assert.NoError(t, g.DeleteAlarm("weekly"))
+	assert.Contains(t, (*requests)[4], `<w:Selector Name="Name">weekly</w:Selector>`)
+}
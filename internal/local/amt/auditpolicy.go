@@ -0,0 +1,69 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"rpc/pkg/pthi"
+)
+
+// go-wsman-messages does not cover AMT_AuditPolicyRule either
+
+const auditPolicyRuleClass = "AMT_AuditPolicyRule"
+
+// AuditedEvent is an audit log event, an application ID and an event ID as
+// they appear in the audit log records
+type AuditedEvent struct {
+	AppID   uint16
+	EventID uint16
+}
+
+type auditPolicyRuleResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		AuditApplicationEventID []uint32 `xml:"AMT_AuditPolicyRule>AuditApplicationEventID"`
+		ReturnValue             int      `xml:"SetAuditPolicy_OUTPUT>ReturnValue"`
+	} `xml:"Body"`
+}
+
+// GetAuditPolicy returns the events AMT writes to the audit log
+func (g *GoWSMANMessages) GetAuditPolicy() ([]AuditedEvent, error) {
+	raw, err := g.postAMT(wsmanActionGet, auditPolicyRuleClass, "", "")
+	if err != nil {
+		return nil, err
+	}
+	response := auditPolicyRuleResponse{}
+	if err = xml.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+	events := []AuditedEvent{}
+	// each ID holds the application ID in the upper and the event ID in the lower 16 bits
+	for _, id := range response.Body.AuditApplicationEventID {
+		events = append(events, AuditedEvent{AppID: uint16(id >> 16), EventID: uint16(id)})
+	}
+	return events, nil
+}
+
+// SetAuditPolicy enables or disables auditing of event
+func (g *GoWSMANMessages) SetAuditPolicy(event AuditedEvent, enable bool) error {
+	action := amtResourceURIBase + auditPolicyRuleClass + "/SetAuditPolicy"
+	body := fmt.Sprintf(`<h:SetAuditPolicy_INPUT xmlns:h="%s%s"><h:Enable>%t</h:Enable><h:AuditedAppID>%d</h:AuditedAppID><h:EventID>%d</h:EventID><h:PolicyType>0</h:PolicyType></h:SetAuditPolicy_INPUT>`,
+		amtResourceURIBase, auditPolicyRuleClass, enable, event.AppID, event.EventID)
+	raw, err := g.postAMT(action, auditPolicyRuleClass, "", body)
+	if err != nil {
+		return err
+	}
+	response := auditPolicyRuleResponse{}
+	if err = xml.Unmarshal(raw, &response); err != nil {
+		return err
+	}
+	// method return values are PT status codes
+	if err = pthi.Status(response.Body.ReturnValue).Err(); err != nil {
+		return fmt.Errorf("SetAuditPolicy failed: %w", err)
+	}
+	return nil
+}
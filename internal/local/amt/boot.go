@@ -0,0 +1,135 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
+)
+
+const (
+	bootSettingDataClass = "AMT_BootSettingData"
+	// BootConfigSettingID is the CIM_BootConfigSetting AMT applies on the next boot
+	BootConfigSettingID = "Intel(r) AMT: Boot Configuration 0"
+	// BootConfigRoleIsNext makes a boot configuration the one for the next boot
+	BootConfigRoleIsNext = 1
+)
+
+// UEFI boot parameter types for One Click Recovery, from the AMT SDK
+const (
+	UEFIBootParamNetworkDevicePath uint16 = 1
+	UEFIBootParamHTTPSUserName     uint16 = 12
+	UEFIBootParamHTTPSPassword     uint16 = 13
+)
+
+// UEFIBootParameter is one TLV of AMT_BootSettingData.UEFIBootParametersArray
+type UEFIBootParameter struct {
+	Type  uint16
+	Value []byte
+}
+
+// EncodeUEFIBootParameters packs parameters as AMT expects them: each one is
+// the Intel vendor ID, the type and the length, little endian, then the value.
+// The array is sent base64 encoded.
+func EncodeUEFIBootParameters(parameters []UEFIBootParameter) string {
+	encoded := []byte{}
+	for _, parameter := range parameters {
+		encoded = binary.LittleEndian.AppendUint16(encoded, 0x8086)
+		encoded = binary.LittleEndian.AppendUint16(encoded, parameter.Type)
+		encoded = binary.LittleEndian.AppendUint32(encoded, uint32(len(parameter.Value)))
+		encoded = append(encoded, parameter.Value...)
+	}
+	return base64.StdEncoding.EncodeToString(encoded)
+}
+
+// BootOptions are the AMT_BootSettingData values rpc sets for a one-time
+// boot, everything else is cleared
+type BootOptions struct {
+	UseIDER            bool
+	IDERBootDevice     boot.IDERBootDevice
+	UEFIBootParameters []UEFIBootParameter
+}
+
+// bootSettingDataRequest replaces the UEFI boot parameters of the library
+// request, which types the base64 array as []int. The fields after it are
+// repeated so they stay in schema order.
+type bootSettingDataRequest struct {
+	boot.BootSettingDataRequest
+	UEFIBootParametersArray string `xml:"h:UEFIBootParametersArray,omitempty"`
+	UEFIBootNumberOfParams  int    `xml:"h:UEFIBootNumberOfParams"`
+	RPEEnabled              bool   `xml:"h:RPEEnabled"`
+	PlatformErase           bool   `xml:"h:PlatformErase"`
+}
+
+type bootSettingDataResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Settings boot.BootSettingDataResponse `xml:"AMT_BootSettingData"`
+	} `xml:"Body"`
+}
+
+func (g *GoWSMANMessages) GetBootCapabilities() (boot.BootCapabilitiesResponse, error) {
+	response, err := g.wsmanMessages.AMT.BootCapabilities.Get()
+	if err != nil {
+		return boot.BootCapabilitiesResponse{}, err
+	}
+	return response.Body.BootCapabilitiesGetResponse, nil
+}
+
+func (g *GoWSMANMessages) GetBootSettingData() (boot.BootSettingDataResponse, error) {
+	response, err := g.wsmanMessages.AMT.BootSettingData.Get()
+	if err != nil {
+		return boot.BootSettingDataResponse{}, err
+	}
+	return response.Body.BootSettingDataGetResponse, nil
+}
+
+// SetBootSettingData writes options to AMT_BootSettingData. It is posted
+// directly so the UEFI boot parameters go out base64 encoded.
+func (g *GoWSMANMessages) SetBootSettingData(options BootOptions) error {
+	request := bootSettingDataRequest{
+		BootSettingDataRequest: boot.BootSettingDataRequest{
+			H:              amtResourceURIBase + bootSettingDataClass,
+			InstanceID:     "Intel(r) AMT:BootSettingData 0",
+			ElementName:    "Intel(r) AMT Boot Configuration Settings",
+			UseIDER:        options.UseIDER,
+			IDERBootDevice: options.IDERBootDevice,
+		},
+		UEFIBootNumberOfParams: len(options.UEFIBootParameters),
+	}
+	if len(options.UEFIBootParameters) > 0 {
+		request.UEFIBootParametersArray = EncodeUEFIBootParameters(options.UEFIBootParameters)
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+	raw, err := g.postAMT(wsmanActionPut, bootSettingDataClass, "", string(body))
+	if err != nil {
+		return err
+	}
+	response := bootSettingDataResponse{}
+	if err = xml.Unmarshal(raw, &response); err != nil {
+		return err
+	}
+	if response.Body.Settings.InstanceID == "" {
+		return fmt.Errorf("AMT did not accept the boot settings")
+	}
+	return nil
+}
+
+func (g *GoWSMANMessages) SetBootConfigRole(role int) (cimBoot.Response, error) {
+	return g.wsmanMessages.CIM.BootService.SetBootConfigRole(BootConfigSettingID, role)
+}
+
+func (g *GoWSMANMessages) ChangeBootOrder(source cimBoot.Source) (cimBoot.Response, error) {
+	return g.wsmanMessages.CIM.BootConfigSetting.ChangeBootOrder(source)
+}
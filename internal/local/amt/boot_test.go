@@ -0,0 +1,71 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeUEFIBootParameters(t *testing.T) {
+	encoded := EncodeUEFIBootParameters([]UEFIBootParameter{
+		{Type: UEFIBootParamNetworkDevicePath, Value: []byte("https://a")},
+		{Type: UEFIBootParamHTTPSUserName, Value: []byte("u")},
+	})
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	assert.NoError(t, err)
+	expected := []byte{0x86, 0x80, 0x01, 0x00, 0x09, 0x00, 0x00, 0x00}
+	expected = append(expected, []byte("https://a")...)
+	expected = append(expected, 0x86, 0x80, 0x0c, 0x00, 0x01, 0x00, 0x00, 0x00, 'u')
+	assert.Equal(t, expected, raw)
+	assert.Equal(t, "", EncodeUEFIBootParameters(nil))
+}
+
+func TestBootConfiguration(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<h:AMT_BootCapabilities xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_BootCapabilities"><h:ForcePXEBoot>true</h:ForcePXEBoot><h:ForceUEFIHTTPSBoot>true</h:ForceUEFIHTTPSBoot><h:IDER>true</h:IDER></h:AMT_BootCapabilities>`,
+		`<h:AMT_BootSettingData xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_BootSettingData"><h:InstanceID>Intel(r) AMT:BootSettingData 0</h:InstanceID><h:UEFIHTTPSBootEnabled>true</h:UEFIHTTPSBootEnabled></h:AMT_BootSettingData>`,
+		`<h:AMT_BootSettingData xmlns:h="http://intel.com/wbem/wscim/1/amt-schema/1/AMT_BootSettingData"><h:InstanceID>Intel(r) AMT:BootSettingData 0</h:InstanceID></h:AMT_BootSettingData>`,
+		`<g:SetBootConfigRole_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:SetBootConfigRole_OUTPUT>`,
+		`<g:ChangeBootOrder_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:ChangeBootOrder_OUTPUT>`,
+		`<g:Fault></g:Fault>`,
+	)
+	capabilities, err := g.GetBootCapabilities()
+	assert.NoError(t, err)
+	assert.True(t, capabilities.ForcePXEBoot)
+	assert.True(t, capabilities.ForceUEFIHTTPSBoot)
+	assert.False(t, capabilities.ForceCDorDVDBoot)
+
+	settings, err := g.GetBootSettingData()
+	assert.NoError(t, err)
+	assert.True(t, settings.UEFIHTTPSBootEnabled)
+
+	parameters := []UEFIBootParameter{{Type: UEFIBootParamNetworkDevicePath, Value: []byte("https://server/image.efi")}}
+	assert.NoError(t, g.SetBootSettingData(BootOptions{UseIDER: true, IDERBootDevice: boot.CDBoot, UEFIBootParameters: parameters}))
+	put := (*requests)[2]
+	assert.Contains(t, put, "http://schemas.xmlsoap.org/ws/2004/09/transfer/Put")
+	assert.Contains(t, put, "<h:UseIDER>true</h:UseIDER>")
+	assert.Contains(t, put, "<h:IDERBootDevice>1</h:IDERBootDevice>")
+	assert.Contains(t, put, "<h:UEFIBootParametersArray>"+EncodeUEFIBootParameters(parameters)+"</h:UEFIBootParametersArray><h:UEFIBootNumberOfParams>1</h:UEFIBootNumberOfParams>")
+	assert.Equal(t, 1, strings.Count(put, "<h:UEFIBootNumberOfParams>"))
+
+	role, err := g.SetBootConfigRole(BootConfigRoleIsNext)
+	assert.NoError(t, err)
+	assert.Equal(t, cimBoot.ReturnValueCompletedNoError, role.Body.SetBootConfigRole_OUTPUT.ReturnValue)
+	assert.Contains(t, (*requests)[3], BootConfigSettingID)
+
+	order, err := g.ChangeBootOrder(cimBoot.OCRUEFIHTTPS)
+	assert.NoError(t, err)
+	assert.Equal(t, cimBoot.ReturnValueCompletedNoError, order.Body.ChangeBootOrder_OUTPUT.ReturnValue)
+	assert.Contains(t, (*requests)[4], string(cimBoot.OCRUEFIHTTPS))
+
+	assert.Error(t, g.SetBootSettingData(BootOptions{}))
+}
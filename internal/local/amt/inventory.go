@@ -0,0 +1,92 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/bios"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/card"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/chassis"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/mediaaccess"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/physical"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/processor"
+)
+
+// HardwareInventory is the hardware AMT reports through CIM, read from the
+// firmware and independent of the operating system
+type HardwareInventory struct {
+	PlatformGUID string
+	Chassis      []chassis.PackageResponse
+	Cards        []card.PackageResponse
+	BIOS         []bios.BiosElement
+	Processors   []processor.PackageResponse
+	Memory       []physical.PhysicalMemory
+	Storage      []mediaaccess.MediaAccessDevice
+}
+
+// GetHardwareInventory enumerates and pulls the CIM classes that describe the
+// platform, its boards, BIOS, processors, memory and storage
+func (g *GoWSMANMessages) GetHardwareInventory() (HardwareInventory, error) {
+	inventory := HardwareInventory{}
+	var err error
+	if inventory.PlatformGUID, err = g.GetPlatformGUID(); err != nil {
+		return inventory, err
+	}
+
+	chassisResponse, err := g.wsmanMessages.CIM.Chassis.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if chassisResponse, err = g.wsmanMessages.CIM.Chassis.Pull(chassisResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.Chassis = chassisResponse.Body.PullResponse.PackageItems
+
+	cardResponse, err := g.wsmanMessages.CIM.Card.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if cardResponse, err = g.wsmanMessages.CIM.Card.Pull(cardResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.Cards = cardResponse.Body.PullResponse.CardItems
+
+	biosResponse, err := g.wsmanMessages.CIM.BIOSElement.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if biosResponse, err = g.wsmanMessages.CIM.BIOSElement.Pull(biosResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.BIOS = biosResponse.Body.PullResponse.BiosElementItems
+
+	processorResponse, err := g.wsmanMessages.CIM.Processor.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if processorResponse, err = g.wsmanMessages.CIM.Processor.Pull(processorResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.Processors = processorResponse.Body.PullResponse.PackageItems
+
+	memoryResponse, err := g.wsmanMessages.CIM.PhysicalMemory.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if memoryResponse, err = g.wsmanMessages.CIM.PhysicalMemory.Pull(memoryResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.Memory = memoryResponse.Body.PullResponse.MemoryItems
+
+	storageResponse, err := g.wsmanMessages.CIM.MediaAccessDevice.Enumerate()
+	if err != nil {
+		return inventory, err
+	}
+	if storageResponse, err = g.wsmanMessages.CIM.MediaAccessDevice.Pull(storageResponse.Body.EnumerateResponse.EnumerationContext); err != nil {
+		return inventory, err
+	}
+	inventory.Storage = storageResponse.Body.PullResponse.MediaAccessDevices
+	return inventory, nil
+}
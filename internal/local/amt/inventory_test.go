@@ -0,0 +1,51 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func enumerateResponse(context string) string {
+	return `<g:EnumerateResponse><g:EnumerationContext>` + context + `</g:EnumerationContext></g:EnumerateResponse>`
+}
+
+func TestGetHardwareInventory(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:CIM_ComputerSystemPackage><g:PlatformGUID>0B3C4A6E8F1D2E3F4A5B6C7D8E9F0A1B</g:PlatformGUID></g:CIM_ComputerSystemPackage>`,
+		enumerateResponse("01000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_Chassis><g:ChassisPackageType>3</g:ChassisPackageType><g:Manufacturer>Intel Corporation</g:Manufacturer><g:SerialNumber>CH123</g:SerialNumber></g:CIM_Chassis></g:Items></g:PullResponse>`,
+		enumerateResponse("02000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_Card><g:ElementName>Managed System Base Board</g:ElementName><g:Model>NUC11TNBi5</g:Model></g:CIM_Card></g:Items></g:PullResponse>`,
+		enumerateResponse("03000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_BIOSElement><g:Version>TNTGL357.0064</g:Version><g:PrimaryBIOS>true</g:PrimaryBIOS><g:ReleaseDate><g:Datetime>2022-06-14T00:00:00Z</g:Datetime></g:ReleaseDate></g:CIM_BIOSElement></g:Items></g:PullResponse>`,
+		enumerateResponse("04000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_Processor><g:DeviceID>CPU 0</g:DeviceID><g:MaxClockSpeed>8300</g:MaxClockSpeed></g:CIM_Processor></g:Items></g:PullResponse>`,
+		enumerateResponse("05000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_PhysicalMemory><g:BankLabel>BANK 0</g:BankLabel><g:Capacity>8589934592</g:Capacity></g:CIM_PhysicalMemory><g:CIM_PhysicalMemory><g:BankLabel>BANK 2</g:BankLabel><g:Capacity>8589934592</g:Capacity></g:CIM_PhysicalMemory></g:Items></g:PullResponse>`,
+		enumerateResponse("06000000-0000-0000-0000-000000000000"),
+		`<g:PullResponse><g:Items><g:CIM_MediaAccessDevice><g:DeviceID>MEDIA DEV 0</g:DeviceID><g:MaxMediaSize>500107608</g:MaxMediaSize></g:CIM_MediaAccessDevice></g:Items></g:PullResponse>`,
+		`<g:CIM_ComputerSystemPackage></g:CIM_ComputerSystemPackage>`,
+		`<g:EnumerateResponse>`,
+	)
+	inventory, err := g.GetHardwareInventory()
+	assert.NoError(t, err)
+	assert.Equal(t, "0B3C4A6E8F1D2E3F4A5B6C7D8E9F0A1B", inventory.PlatformGUID)
+	assert.Equal(t, "CH123", inventory.Chassis[0].SerialNumber)
+	assert.Equal(t, "NUC11TNBi5", inventory.Cards[0].Model)
+	assert.Equal(t, "2022-06-14T00:00:00Z", inventory.BIOS[0].ReleaseDate.DateTime)
+	assert.Equal(t, 8300, inventory.Processors[0].MaxClockSpeed)
+	assert.Len(t, inventory.Memory, 2)
+	assert.Equal(t, 8589934592, inventory.Memory[1].Capacity)
+	assert.Equal(t, "MEDIA DEV 0", inventory.Storage[0].DeviceID)
+	assert.Contains(t, (*requests)[2], "01000000-0000-0000-0000-000000000000")
+	assert.Contains(t, (*requests)[12], "CIM_MediaAccessDevice")
+
+	_, err = g.GetHardwareInventory()
+	assert.Error(t, err)
+}
@@ -8,37 +8,33 @@ package amt
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"rpc/internal/lm"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 )
 
-// LocalTransport - Your custom net.Conn implementation
+// maxLocalChannels bounds the APF channels a LocalTransport keeps open at
+// once, AMT refuses to open more than a few
+const maxLocalChannels = 4
+
+// LocalTransport is an http.RoundTripper that sends requests to AMT over
+// LME. Each request gets its own APF channel, so it is safe for concurrent use.
 type LocalTransport struct {
-	local  lm.LocalMananger
-	data   chan []byte
-	errors chan error
-	status chan bool
+	local    *lm.LMEConnection
+	channels chan struct{}
 }
 
 func NewLocalTransport() *LocalTransport {
-	lmDataChannel := make(chan []byte)
-	lmErrorChannel := make(chan error)
-	lmStatus := make(chan bool)
 	lm := &LocalTransport{
-		local:  lm.NewLMEConnection(lmDataChannel, lmErrorChannel, lmStatus),
-		data:   lmDataChannel,
-		errors: lmErrorChannel,
-		status: lmStatus,
+		local:    lm.NewLMEConnection(make(chan []byte), make(chan error), make(chan bool)),
+		channels: make(chan struct{}, maxLocalChannels),
 	}
-	// defer lm.local.Close()
-	// defer close(lmDataChannel)
-	// defer close(lmErrorChannel)
-	// defer close(lmStatus)
-
 	err := lm.local.Initialize()
 	if err != nil {
 		logrus.Error(err)
@@ -49,45 +45,38 @@ func NewLocalTransport() *LocalTransport {
 
 // Custom dialer function
 func (l *LocalTransport) RoundTrip(r *http.Request) (*http.Response, error) {
-	//Something comes here...Maybe
-	go l.local.Listen()
-
-	// send channel open
-	err := l.local.Connect()
-
+	// Serialize the HTTP request to raw form
+	rawRequest, err := serializeHTTPRequest(r)
 	if err != nil {
 		logrus.Error(err)
 		return nil, err
 	}
-	// wait for channel open confirmation
-	<-l.status
-	logrus.Trace("Channel open confirmation received")
 
-	// Serialize the HTTP request to raw form
-	rawRequest, err := serializeHTTPRequest(r)
+	l.channels <- struct{}{}
+	defer func() { <-l.channels }()
+	channel, err := l.local.OpenChannel()
 	if err != nil {
 		logrus.Error(err)
 		return nil, err
 	}
+	logrus.Trace("Channel open confirmation received")
 
-	var responseReader *bufio.Reader
 	// send our data to LMX
-	err = l.local.Send(rawRequest)
+	err = channel.Send(rawRequest)
 	if err != nil {
 		logrus.Error(err)
+		channel.Close()
 		return nil, err
 	}
 
-	for dataFromLM := range l.data {
-		if len(dataFromLM) > 0 {
-			logrus.Debug("received data from LME")
-			logrus.Trace(string(dataFromLM))
-
-			// /<-l.status
-			responseReader = bufio.NewReader(bytes.NewReader(dataFromLM))
-			break
-		}
+	dataFromLM, err := channel.Receive()
+	if err != nil {
+		logrus.Error(err)
+		return nil, err
 	}
+	logrus.Debug("received data from LME")
+	logrus.Trace(string(dataFromLM))
+	responseReader := bufio.NewReader(bytes.NewReader(dataFromLM))
 
 	response, err := http.ReadResponse(responseReader, r)
 	if err != nil {
@@ -98,6 +87,25 @@ func (l *LocalTransport) RoundTrip(r *http.Request) (*http.Response, error) {
 	return response, nil
 }
 
+// DialContext opens a stream channel to the AMT port in address, so an
+// http.Transport can run TLS to AMT over LME
+func (l *LocalTransport) DialContext(ctx context.Context, network string, address string) (net.Conn, error) {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return l.local.Dial(uint32(number))
+}
+
+// Close releases the MEI connection, failing requests still in flight
+func (l *LocalTransport) Close() error {
+	return l.local.Close()
+}
+
 func serializeHTTPRequest(r *http.Request) ([]byte, error) {
 	var reqBuffer bytes.Buffer
 
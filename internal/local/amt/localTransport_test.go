@@ -0,0 +1,195 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"rpc/internal/lm"
+	"rpc/pkg/heci"
+	"rpc/pkg/pthi"
+	"rpc/pkg/utils"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalTransportConcurrent(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"wsmanResponse":"<a:Envelope></a:Envelope>"}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+
+	transport := NewLocalTransport()
+	defer transport.Close()
+	client := http.Client{Transport: transport}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+	errs := make([]error, len(bodies))
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := client.Post("http://localhost:16992/wsman", "application/soap+xml", strings.NewReader("<a:Envelope/>"))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer response.Body.Close()
+			body, err := io.ReadAll(response.Body)
+			bodies[i], errs[i] = string(body), err
+		}(i)
+	}
+	wg.Wait()
+	for i := range bodies {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "<a:Envelope></a:Envelope>", bodies[i])
+	}
+}
+
+// overlapHECI holds back the first request sent on an APF channel until a
+// request is sent on another channel, so AMT only answers once both
+// requests are in flight over the one connection
+type overlapHECI struct {
+	heci.Interface
+	lock    sync.Mutex
+	held    []byte
+	overlap chan struct{}
+}
+
+func (h *overlapHECI) SendMessage(buffer []byte, done *uint32) (uint32, error) {
+	if buffer[0] != apf.APF_CHANNEL_DATA {
+		return h.Interface.SendMessage(buffer, done)
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.held == nil {
+		h.held = append([]byte{}, buffer...)
+		return uint32(len(buffer)), nil
+	}
+	if !bytes.Equal(h.held[1:5], buffer[1:5]) {
+		select {
+		case <-h.overlap:
+		default:
+			close(h.overlap)
+			if _, err := h.Interface.SendMessage(h.held, done); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return h.Interface.SendMessage(buffer, done)
+}
+
+func TestLocalTransportOverlappingRoundTrips(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"wsmanResponse":"<a:Envelope></a:Envelope>"}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+
+	transport := NewLocalTransport()
+	defer transport.Close()
+	overlap := &overlapHECI{Interface: transport.local.Command.Heci, overlap: make(chan struct{})}
+	transport.local.Command.Heci = overlap
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			request, err := http.NewRequest(http.MethodPost, "http://localhost:16992/wsman", strings.NewReader("<a:Envelope/>"))
+			if err != nil {
+				results <- err
+				return
+			}
+			response, err := transport.RoundTrip(request)
+			if err != nil {
+				results <- err
+				return
+			}
+			response.Body.Close()
+			results <- nil
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-results:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out, the first request is only answered once a second one is in flight")
+		}
+	}
+	select {
+	case <-overlap.overlap:
+	default:
+		t.Fatal("the requests did not overlap")
+	}
+}
+
+func TestLocalTransportClosed(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+
+	transport := NewLocalTransport()
+	assert.NoError(t, transport.Close())
+	client := http.Client{Transport: transport}
+	_, err := client.Post("http://localhost:16992/wsman", "application/soap+xml", strings.NewReader("<a:Envelope/>"))
+	assert.Error(t, err)
+}
+
+func TestSetupWsmanClientTransport(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"wsmanResponse":"<a:Envelope></a:Envelope>"}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+	t.Setenv(TLSPinFileEnvVar, filepath.Join(t.TempDir(), "tls-pins.json"))
+
+	g := NewGoWSMANMessages("", "", lm.TransportLME, TLSOptions{})
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	defer g.local.Close()
+	assert.Equal(t, lm.TransportLME, g.Transport())
+	target := g.wsmanMessages.Client.(*client.Target)
+	assert.Equal(t, reportErrors{g.local}, target.Transport)
+	// the emulated AMT has no local TLS settings
+	assert.False(t, *g.useTLS)
+
+	// setting the client up again keeps the LME connection
+	local := g.local
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	assert.Same(t, local, g.local)
+
+	g = NewGoWSMANMessages("", "", lm.TransportLMS, TLSOptions{})
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	assert.Equal(t, lm.TransportLMS, g.Transport())
+	assert.Nil(t, g.local)
+	assert.Equal(t, utils.LMSAddress, g.target)
+}
+
+func TestLocalTransportDialContext(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+
+	transport := NewLocalTransport()
+	defer transport.Close()
+	conn, err := transport.DialContext(context.Background(), "tcp", "localhost:16993")
+	assert.NoError(t, err)
+	assert.Equal(t, "amt:16993", conn.RemoteAddr().String())
+	assert.NoError(t, conn.Close())
+
+	_, err = transport.DialContext(context.Background(), "tcp", "localhost")
+	assert.Error(t, err)
+}
@@ -0,0 +1,208 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/auditlog"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/messagelog"
+)
+
+// eventRecordSize is the size of an AMT_MessageLog record: a timestamp, the
+// platform event trap fields and 8 bytes of event data
+const eventRecordSize = 21
+
+// EventLogRecord is a decoded AMT_MessageLog (hardware event log) record
+type EventLogRecord struct {
+	Time            time.Time
+	DeviceAddress   uint8
+	EventSensorType uint8
+	EventType       uint8
+	EventOffset     uint8
+	EventSourceType uint8
+	EventSeverity   uint8
+	SensorNumber    uint8
+	Entity          uint8
+	EntityInstance  uint8
+	EventData       []byte
+}
+
+// DecodeEventRecord decodes a base64 record of AMT_MessageLog.GetRecords
+func DecodeEventRecord(record string) (EventLogRecord, error) {
+	raw, err := base64.StdEncoding.DecodeString(record)
+	if err != nil {
+		return EventLogRecord{}, err
+	}
+	if len(raw) < eventRecordSize {
+		return EventLogRecord{}, fmt.Errorf("event record is %d bytes, expected %d", len(raw), eventRecordSize)
+	}
+	return EventLogRecord{
+		Time:            time.Unix(int64(binary.LittleEndian.Uint32(raw[0:4])), 0).UTC(),
+		DeviceAddress:   raw[4],
+		EventSensorType: raw[5],
+		EventType:       raw[6],
+		EventOffset:     raw[7],
+		EventSourceType: raw[8],
+		EventSeverity:   raw[9],
+		SensorNumber:    raw[10],
+		Entity:          raw[11],
+		EntityInstance:  raw[12],
+		EventData:       raw[13:eventRecordSize],
+	}, nil
+}
+
+// sensorTypes are the IPMI sensor type codes
+var sensorTypes = map[uint8]string{
+	1: "Temperature", 2: "Voltage", 3: "Current", 4: "Fan", 5: "Physical Security",
+	6: "Platform Security", 7: "Processor", 8: "Power Supply", 9: "Power Unit",
+	10: "Cooling Device", 11: "Other Units", 12: "Memory", 13: "Drive Slot",
+	14: "POST Memory Resize", 15: "System Firmware Progress", 16: "Event Logging Disabled",
+	17: "Watchdog", 18: "System Event", 19: "Critical Interrupt", 20: "Button/Switch",
+	21: "Module/Board", 22: "Microcontroller/Coprocessor", 23: "Add-in Card", 24: "Chassis",
+	25: "Chip Set", 26: "Other FRU", 27: "Cable/Interconnect", 28: "Terminator",
+	29: "System Boot Initiated", 30: "Boot Error", 31: "OS Boot", 32: "OS Critical Stop",
+	33: "Slot/Connector", 34: "System ACPI Power State", 35: "Watchdog", 36: "Platform Alert",
+	37: "Entity Presence", 38: "Monitor ASIC", 39: "LAN", 40: "Management Subsystem Health",
+	41: "Battery", 42: "Session Audit", 43: "Version Change", 44: "FRU State",
+}
+
+// entities are the IPMI entity ID codes
+var entities = map[uint8]string{
+	0: "Unspecified", 1: "Other", 2: "Unknown", 3: "Processor", 4: "Disk", 5: "Peripheral Bay",
+	6: "System Management Module", 7: "System Board", 8: "Memory Module", 9: "Processor Module",
+	10: "Power Supply", 11: "Add-in Card", 12: "Front Panel Board", 13: "Back Panel Board",
+	14: "Power System Board", 15: "Drive Backplane", 16: "System Internal Expansion Board",
+	17: "Other System Board", 18: "Processor Board", 19: "Power Unit", 20: "Power Module",
+	21: "Power Management", 22: "Chassis Back Panel Board", 23: "System Chassis", 24: "Sub-Chassis",
+	25: "Other Chassis Board", 26: "Disk Drive Bay", 27: "Peripheral Bay", 28: "Device Bay",
+	29: "Fan", 30: "Cooling Unit", 31: "Cable/Interconnect", 32: "Memory Device",
+	33: "System Management Software", 34: "BIOS", 35: "Operating System", 36: "System Bus",
+	37: "Group", 38: "Remote Management Communication Device", 39: "External Environment", 40: "Battery",
+}
+
+// severities are the platform event trap severities
+var severities = map[uint8]string{
+	0: "Unspecified", 1: "Monitor", 2: "Information", 4: "OK", 8: "Non-critical", 16: "Critical", 32: "Non-recoverable",
+}
+
+// firmwareErrors and firmwareProgress describe the second event data byte of
+// System Firmware Progress events, for offsets 0 (error) and 2 (progress)
+var firmwareErrors = map[uint8]string{
+	0: "Unspecified firmware error", 1: "No system memory is installed", 2: "No usable system memory",
+	3: "Unrecoverable hard disk failure", 4: "Unrecoverable system board failure",
+	5: "Unrecoverable diskette failure", 6: "Unrecoverable hard disk controller failure",
+	7: "Unrecoverable keyboard failure", 8: "Removable boot media not found",
+	9: "Unrecoverable video controller failure", 10: "No video device detected",
+	11: "Firmware ROM corruption detected", 12: "CPU voltage mismatch", 13: "CPU speed matching failure",
+}
+
+var firmwareProgress = map[uint8]string{
+	0: "Unspecified", 1: "Memory initialization", 2: "Hard disk initialization",
+	3: "Secondary processor initialization", 4: "User authentication", 5: "User initiated system setup",
+	6: "USB resource configuration", 7: "PCI resource configuration", 8: "Option ROM initialization",
+	9: "Video initialization", 10: "Cache initialization", 11: "SMBus initialization",
+	12: "Keyboard controller initialization", 13: "Management controller initialization",
+	14: "Docking station attachment", 15: "Enabling docking station", 16: "Docking station ejection",
+	17: "Disabling docking station", 18: "Calling operating system wake-up vector",
+	19: "Starting operating system boot process", 20: "Baseboard initialization",
+	22: "Floppy initialization", 23: "Keyboard test", 24: "Pointing device test",
+	25: "Primary processor initialization",
+}
+
+func lookup(names map[uint8]string, value uint8) string {
+	if name, ok := names[value]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", value)
+}
+
+func (r EventLogRecord) SensorType() string { return lookup(sensorTypes, r.EventSensorType) }
+func (r EventLogRecord) EntityName() string { return lookup(entities, r.Entity) }
+func (r EventLogRecord) Severity() string   { return lookup(severities, r.EventSeverity) }
+
+// Description explains the event where the sensor type defines its data,
+// and falls back to the event offset otherwise
+func (r EventLogRecord) Description() string {
+	if r.EventSensorType == 15 && len(r.EventData) > 1 {
+		switch r.EventOffset {
+		case 0:
+			return lookup(firmwareErrors, r.EventData[1])
+		case 1:
+			return "System firmware hang"
+		case 2:
+			return lookup(firmwareProgress, r.EventData[1])
+		}
+	}
+	return fmt.Sprintf("Event offset %d", r.EventOffset)
+}
+
+// GetEventLogRecords pages through AMT_MessageLog with GetRecords and
+// returns the records from since on, oldest first. AMT keeps the newest
+// record first, so paging stops at the first page that reaches before since.
+func (g *GoWSMANMessages) GetEventLogRecords(since time.Time) ([]EventLogRecord, error) {
+	records := []EventLogRecord{}
+	identifier := 1
+	for {
+		response, err := g.wsmanMessages.AMT.MessageLog.GetRecords(identifier)
+		if err != nil {
+			return nil, err
+		}
+		output := response.Body.GetRecordsResponse
+		if output.ReturnValue == messagelog.GetRecordsReturnValueNoRecordExistsInLog {
+			break
+		}
+		if output.ReturnValue != messagelog.GetRecordsReturnValueCompletedWithNoError {
+			return nil, fmt.Errorf("GetRecords failed: %s", output.ReturnValue.String())
+		}
+		reachedSince := false
+		for _, raw := range output.RecordArray {
+			record, err := DecodeEventRecord(raw)
+			if err != nil {
+				return nil, err
+			}
+			if record.Time.Before(since) {
+				reachedSince = true
+				continue
+			}
+			records = append(records, record)
+		}
+		if output.NoMoreRecords || len(output.RecordArray) == 0 || reachedSince {
+			break
+		}
+		identifier = output.IterationIdentifier
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, nil
+}
+
+// GetAuditLogRecords pages through AMT_AuditLog with ReadRecords from
+// startIndex, 1 being the oldest record, and returns the records oldest first
+// with the index of the last one
+func (g *GoWSMANMessages) GetAuditLogRecords(startIndex int) ([]auditlog.AuditLogRecord, int, error) {
+	records := []auditlog.AuditLogRecord{}
+	for {
+		response, err := g.wsmanMessages.AMT.AuditLog.ReadRecords(startIndex)
+		if err != nil {
+			return nil, 0, err
+		}
+		output := response.Body.ReadRecordsResponse
+		if output.ReturnValue != 0 {
+			return nil, 0, fmt.Errorf("ReadRecords failed with status %d", output.ReturnValue)
+		}
+		records = append(records, response.Body.DecodedRecordsResponse...)
+		startIndex += output.RecordsReturned
+		if output.RecordsReturned == 0 || startIndex > output.TotalRecordCount {
+			break
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, startIndex - 1, nil
+}
@@ -0,0 +1,130 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventRecord(timestamp uint32, sensorType, offset, severity, entity uint8, data ...byte) string {
+	raw := binary.LittleEndian.AppendUint32(nil, timestamp)
+	raw = append(raw, 0, sensorType, 0x6f, offset, 0x68, severity, 0, entity, 0)
+	eventData := make([]byte, 8)
+	copy(eventData, data)
+	return base64.StdEncoding.EncodeToString(append(raw, eventData...))
+}
+
+func auditRecord(timestamp uint32, appID, eventID uint16) string {
+	raw := binary.BigEndian.AppendUint16(nil, appID)
+	raw = binary.BigEndian.AppendUint16(raw, eventID)
+	// a local initiator, the timestamp, no network address and no extended data
+	raw = append(raw, 2)
+	raw = binary.BigEndian.AppendUint32(raw, timestamp)
+	raw = append(raw, 0, 0, 0)
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+func TestDecodeEventRecord(t *testing.T) {
+	record, err := DecodeEventRecord(eventRecord(1714557600, 15, 2, 2, 34, 0x40, 0x13))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), record.Time)
+	assert.Equal(t, "System Firmware Progress", record.SensorType())
+	assert.Equal(t, "BIOS", record.EntityName())
+	assert.Equal(t, "Information", record.Severity())
+	assert.Equal(t, "Starting operating system boot process", record.Description())
+
+	record, err = DecodeEventRecord(eventRecord(1714557600, 15, 0, 16, 34, 0x00, 0x01))
+	assert.NoError(t, err)
+	assert.Equal(t, "Critical", record.Severity())
+	assert.Equal(t, "No system memory is installed", record.Description())
+
+	record, err = DecodeEventRecord(eventRecord(1714557600, 99, 3, 3, 200))
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown (99)", record.SensorType())
+	assert.Equal(t, "Unknown (200)", record.EntityName())
+	assert.Equal(t, "Event offset 3", record.Description())
+
+	_, err = DecodeEventRecord(base64.StdEncoding.EncodeToString([]byte{1, 2, 3}))
+	assert.Error(t, err)
+	_, err = DecodeEventRecord("not base64")
+	assert.Error(t, err)
+}
+
+func TestGetEventLogRecords(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:GetRecords_OUTPUT><g:IterationIdentifier>3</g:IterationIdentifier><g:NoMoreRecords>false</g:NoMoreRecords><g:RecordArray>`+eventRecord(1714557660, 15, 2, 2, 34)+`</g:RecordArray><g:RecordArray>`+eventRecord(1714557600, 15, 2, 2, 34)+`</g:RecordArray><g:ReturnValue>0</g:ReturnValue></g:GetRecords_OUTPUT>`,
+		`<g:GetRecords_OUTPUT><g:IterationIdentifier>4</g:IterationIdentifier><g:NoMoreRecords>true</g:NoMoreRecords><g:RecordArray>`+eventRecord(1714557500, 6, 0, 8, 7)+`</g:RecordArray><g:ReturnValue>0</g:ReturnValue></g:GetRecords_OUTPUT>`,
+		`<g:GetRecords_OUTPUT><g:ReturnValue>3</g:ReturnValue></g:GetRecords_OUTPUT>`,
+		`<g:GetRecords_OUTPUT><g:ReturnValue>1</g:ReturnValue></g:GetRecords_OUTPUT>`,
+	)
+	records, err := g.GetEventLogRecords(time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, int64(1714557500), records[0].Time.Unix())
+	assert.Equal(t, int64(1714557660), records[2].Time.Unix())
+	assert.Contains(t, (*requests)[1], "<h:IterationIdentifier>3</h:IterationIdentifier>")
+
+	records, err = g.GetEventLogRecords(time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = g.GetEventLogRecords(time.Time{})
+	assert.Error(t, err)
+}
+
+func TestGetEventLogRecordsSince(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:GetRecords_OUTPUT><g:IterationIdentifier>3</g:IterationIdentifier><g:NoMoreRecords>false</g:NoMoreRecords><g:RecordArray>`+eventRecord(1714557660, 15, 2, 2, 34)+`</g:RecordArray><g:RecordArray>`+eventRecord(1714557600, 15, 2, 2, 34)+`</g:RecordArray><g:ReturnValue>0</g:ReturnValue></g:GetRecords_OUTPUT>`,
+	)
+	// the page reaches before since, so the older pages are not read
+	records, err := g.GetEventLogRecords(time.Unix(1714557660, 0))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, int64(1714557660), records[0].Time.Unix())
+	assert.Len(t, *requests, 1)
+}
+
+func TestGetAuditLogRecords(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:ReadRecords_OUTPUT><g:TotalRecordCount>3</g:TotalRecordCount><g:RecordsReturned>2</g:RecordsReturned><g:EventRecords>`+auditRecord(1714557600, 16, 0)+`</g:EventRecords><g:EventRecords>`+auditRecord(1714557660, 16, 1)+`</g:EventRecords><g:ReturnValue>0</g:ReturnValue></g:ReadRecords_OUTPUT>`,
+		`<g:ReadRecords_OUTPUT><g:TotalRecordCount>3</g:TotalRecordCount><g:RecordsReturned>1</g:RecordsReturned><g:EventRecords>`+auditRecord(1714557720, 19, 0)+`</g:EventRecords><g:ReturnValue>0</g:ReturnValue></g:ReadRecords_OUTPUT>`,
+		`<g:ReadRecords_OUTPUT><g:ReturnValue>1</g:ReturnValue></g:ReadRecords_OUTPUT>`,
+	)
+	records, last, err := g.GetAuditLogRecords(1)
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, 3, last)
+	assert.Equal(t, "Security Admin", records[0].AuditApp)
+	assert.Equal(t, 1, records[1].EventID)
+	assert.Equal(t, 19, records[2].AuditAppID)
+	assert.Equal(t, "Local", records[2].Initiator)
+	assert.Contains(t, (*requests)[1], "<h:StartIndex>3</h:StartIndex>")
+
+	_, _, err = g.GetAuditLogRecords(1)
+	assert.Error(t, err)
+}
+
+func TestAuditPolicy(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<h:AMT_AuditPolicyRule><h:AuditApplicationEventID>1048576</h:AuditApplicationEventID><h:AuditApplicationEventID>1245185</h:AuditApplicationEventID></h:AMT_AuditPolicyRule>`,
+		`<h:SetAuditPolicy_OUTPUT><h:ReturnValue>0</h:ReturnValue></h:SetAuditPolicy_OUTPUT>`,
+		`<h:SetAuditPolicy_OUTPUT><h:ReturnValue>36</h:ReturnValue></h:SetAuditPolicy_OUTPUT>`,
+	)
+	events, err := g.GetAuditPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, []AuditedEvent{{AppID: 16, EventID: 0}, {AppID: 19, EventID: 1}}, events)
+	assert.Contains(t, (*requests)[0], "AMT_AuditPolicyRule")
+
+	assert.NoError(t, g.SetAuditPolicy(AuditedEvent{AppID: 19, EventID: 0}, true))
+	assert.Contains(t, (*requests)[1], "<h:Enable>true</h:Enable><h:AuditedAppID>19</h:AuditedAppID><h:EventID>0</h:EventID>")
+
+	assert.Error(t, g.SetAuditPolicy(AuditedEvent{AppID: 19, EventID: 0}, false))
+}
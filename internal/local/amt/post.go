@@ -0,0 +1,47 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// messages for AMT classes that go-wsman-messages does not cover, or covers
+// with the wrong types, are built here and posted through the same wsman client
+
+const (
+	amtResourceURIBase    = "http://intel.com/wbem/wscim/1/amt-schema/1/"
+	wsmanAnonymousAddress = "http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous"
+	wsmanActionGet        = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Get"
+	wsmanActionPut        = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Put"
+	wsmanActionCreate     = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	wsmanActionDelete     = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	wsmanActionEnumerate  = "http://schemas.xmlsoap.org/ws/2004/09/enumeration/Enumerate"
+	wsmanActionPull       = "http://schemas.xmlsoap.org/ws/2004/09/enumeration/Pull"
+)
+
+// wsmanEnvelope wraps body in a WS-MAN envelope for action on the AMT class,
+// selectors is the inner XML of the selector set and may be empty
+func wsmanEnvelope(action string, class string, messageID int, selectors string, body string) string {
+	var header strings.Builder
+	header.WriteString(`<Header>`)
+	fmt.Fprintf(&header, `<a:Action>%s</a:Action><a:To>/wsman</a:To><w:ResourceURI>%s%s</w:ResourceURI><a:MessageID>%d</a:MessageID>`, action, amtResourceURIBase, class, messageID)
+	fmt.Fprintf(&header, `<a:ReplyTo><a:Address>%s</a:Address></a:ReplyTo><w:OperationTimeout>PT60S</w:OperationTimeout>`, wsmanAnonymousAddress)
+	if selectors != "" {
+		header.WriteString(`<w:SelectorSet>` + selectors + `</w:SelectorSet>`)
+	}
+	header.WriteString(`</Header>`)
+	return `<?xml version="1.0" encoding="utf-8"?><Envelope xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xmlns:xsd="http://www.w3.org/2001/XMLSchema" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns="http://www.w3.org/2003/05/soap-envelope">` +
+		header.String() + `<Body>` + body + `</Body></Envelope>`
+}
+
+// postAMT posts a message for an AMT class through the wsman client
+func (g *GoWSMANMessages) postAMT(action string, class string, selectors string, body string) ([]byte, error) {
+	envelope := wsmanEnvelope(action, class, g.messageID, selectors, body)
+	g.messageID++
+	return g.wsmanMessages.Client.Post(envelope)
+}
@@ -0,0 +1,26 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostAMT(t *testing.T) {
+	g, fake := newAgentPresenceTarget(``, ``)
+	_, err := g.postAMT(wsmanActionGet, auditPolicyRuleClass, "", "")
+	assert.NoError(t, err)
+	_, err = g.postAMT(wsmanActionDelete, agentPresenceWatchdogClass, `<w:Selector Name="DeviceID">1</w:Selector>`, "")
+	assert.NoError(t, err)
+
+	assert.Contains(t, fake.requests[0], "<w:ResourceURI>http://intel.com/wbem/wscim/1/amt-schema/1/AMT_AuditPolicyRule</w:ResourceURI><a:MessageID>0</a:MessageID>")
+	assert.NotContains(t, fake.requests[0], "<w:SelectorSet>")
+	// the message ID is shared by all classes
+	assert.Contains(t, fake.requests[1], "<a:MessageID>1</a:MessageID>")
+	assert.Contains(t, fake.requests[1], `<w:SelectorSet><w:Selector Name="DeviceID">1</w:Selector></w:SelectorSet>`)
+}
@@ -0,0 +1,242 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TLSPinFileEnvVar overrides where pinned AMT TLS certificate fingerprints
+// are kept
+const TLSPinFileEnvVar = "RPC_TLS_PIN_FILE"
+
+// LocalPinName is the pin file entry of the AMT on this host
+const LocalPinName = "local"
+
+// localTLSInstanceID is the AMT_TLSSettingData instance for local connections
+const localTLSInstanceID = "Intel(r) AMT LMS TLS Settings"
+
+// unreachableStatus marks responses standing in for a failed round trip
+const unreachableStatus = "502 AMT unreachable"
+
+// TLSOptions selects how the AMT TLS certificate is trusted. Without any of
+// them the fingerprint pinned for the device, by configure tls or an earlier
+// trust-on-first-use, has to match.
+type TLSOptions struct {
+	// Pin is the SHA-256 fingerprint of the certificate, hex with optional colons
+	Pin string
+	// CABundle is a PEM file with the CAs the certificate must chain to
+	CABundle string
+	// TOFU trusts and pins the certificate when none is pinned yet
+	TOFU bool
+}
+
+// Fingerprint returns the SHA-256 fingerprint of a DER certificate as rpc
+// pins it
+func Fingerprint(der []byte) string {
+	hash := sha256.Sum256(der)
+	return hex.EncodeToString(hash[:])
+}
+
+func tlsPinFile() string {
+	if file := os.Getenv(TLSPinFileEnvVar); file != "" {
+		return file
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "rpc", "tls-pins.json")
+}
+
+func loadTLSPins() (map[string]string, error) {
+	pins := map[string]string{}
+	data, err := os.ReadFile(tlsPinFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return pins, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// LoadTLSPin returns the fingerprint pinned for name, or "" if there is none
+func LoadTLSPin(name string) (string, error) {
+	pins, err := loadTLSPins()
+	if err != nil {
+		return "", err
+	}
+	return pins[name], nil
+}
+
+// SaveTLSPin pins fingerprint for name, replacing an earlier pin
+func SaveTLSPin(name string, fingerprint string) error {
+	pins, err := loadTLSPins()
+	if err != nil {
+		return err
+	}
+	pins[name] = normalizeFingerprint(fingerprint)
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := tlsPinFile()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0600)
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
+
+// trusted reports whether the options or the pin file say how to trust the
+// certificate of name
+func (o TLSOptions) trusted(name string) bool {
+	if o.Pin != "" || o.CABundle != "" || o.TOFU {
+		return true
+	}
+	pin, _ := LoadTLSPin(name)
+	return pin != ""
+}
+
+// config returns the client TLS configuration for the AMT pinned as name.
+// AMT is reached through localhost or an APF channel, so its certificate
+// never matches the host name; the chain or the fingerprint is checked
+// instead.
+func (o TLSOptions) config(name string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+	}
+	if o.CABundle != "" {
+		bundle, err := os.ReadFile(o.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificates found in %s", o.CABundle)
+		}
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+		return config, nil
+	}
+
+	pin := normalizeFingerprint(o.Pin)
+	if pin == "" {
+		var err error
+		if pin, err = LoadTLSPin(name); err != nil {
+			return nil, err
+		}
+	}
+	var pinLock sync.Mutex
+	config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("AMT sent no TLS certificate")
+		}
+		fingerprint := Fingerprint(rawCerts[0])
+		pinLock.Lock()
+		defer pinLock.Unlock()
+		switch {
+		case pin != "":
+			if fingerprint != pin {
+				return fmt.Errorf("AMT TLS certificate %s does not match the pinned fingerprint %s", fingerprint, pin)
+			}
+		case o.TOFU:
+			logrus.Info("trusting AMT TLS certificate ", fingerprint, " on first use")
+			if err := SaveTLSPin(name, fingerprint); err != nil {
+				return err
+			}
+			pin = fingerprint
+		default:
+			return fmt.Errorf("AMT TLS certificate %s is not trusted, pass -tls-pin, -tls-ca or -tls-tofu", fingerprint)
+		}
+		return nil
+	}
+	return config, nil
+}
+
+// newTransport returns the transport for 16993 trusting the AMT pinned as
+// name. If the options are unusable it falls back to the system roots, which
+// an AMT certificate does not chain to, so requests fail instead of going out
+// unverified.
+func (o TLSOptions) newTransport(name string) *http.Transport {
+	config, err := o.config(name)
+	if err != nil {
+		logrus.Error("unable to set up TLS: ", err)
+		config = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return &http.Transport{TLSClientConfig: config}
+}
+
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCerts[i])
+		if err != nil {
+			return err
+		}
+		certs[i] = cert
+	}
+	if len(certs) == 0 {
+		return errors.New("AMT sent no TLS certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// reportErrors passes requests on to the wrapped transport. The wsman client
+// dereferences the response when a round trip fails, so failures come back
+// as a 502 response carrying the error instead.
+type reportErrors struct {
+	http.RoundTripper
+}
+
+func (r reportErrors) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := r.RoundTripper.RoundTrip(request)
+	if err == nil {
+		return response, nil
+	}
+	return &http.Response{
+		Status:     unreachableStatus,
+		StatusCode: http.StatusBadGateway,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(err.Error())),
+		Request:    request,
+	}, nil
+}
@@ -0,0 +1,141 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"rpc/internal/certs"
+	"strings"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/tls"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTLSServer(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<a:Envelope/>"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// getWith makes one request to server trusting it as options say
+func getWith(server *httptest.Server, options TLSOptions) error {
+	config, err := options.config(LocalPinName)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func TestTLSPins(t *testing.T) {
+	t.Setenv(TLSPinFileEnvVar, filepath.Join(t.TempDir(), "rpc", "tls-pins.json"))
+	pin, err := LoadTLSPin(LocalPinName)
+	assert.NoError(t, err)
+	assert.Empty(t, pin)
+
+	assert.NoError(t, SaveTLSPin(LocalPinName, "AB:CD:EF"))
+	assert.NoError(t, SaveTLSPin("amt.example.com", "0123"))
+	pin, err = LoadTLSPin(LocalPinName)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", pin)
+	pin, err = LoadTLSPin("amt.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "0123", pin)
+}
+
+func TestTLSOptionsPin(t *testing.T) {
+	t.Setenv(TLSPinFileEnvVar, filepath.Join(t.TempDir(), "tls-pins.json"))
+	server := newTLSServer(t)
+	fingerprint := Fingerprint(server.Certificate().Raw)
+
+	assert.NoError(t, getWith(server, TLSOptions{Pin: strings.ToUpper(fingerprint)}))
+	err := getWith(server, TLSOptions{Pin: strings.Repeat("00", 32)})
+	assert.ErrorContains(t, err, "does not match the pinned fingerprint")
+
+	// nothing pinned yet
+	assert.False(t, TLSOptions{}.trusted(LocalPinName))
+	err = getWith(server, TLSOptions{})
+	assert.ErrorContains(t, err, "is not trusted")
+
+	// trust on first use pins the certificate for later runs
+	assert.NoError(t, getWith(server, TLSOptions{TOFU: true}))
+	pin, err := LoadTLSPin(LocalPinName)
+	assert.NoError(t, err)
+	assert.Equal(t, fingerprint, pin)
+	assert.True(t, TLSOptions{}.trusted(LocalPinName))
+	assert.NoError(t, getWith(server, TLSOptions{}))
+
+	// a pinned certificate is not replaced on first use
+	assert.NoError(t, SaveTLSPin(LocalPinName, strings.Repeat("00", 32)))
+	err = getWith(server, TLSOptions{TOFU: true})
+	assert.ErrorContains(t, err, "does not match the pinned fingerprint")
+}
+
+func TestTLSOptionsCABundle(t *testing.T) {
+	server := newTLSServer(t)
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	assert.NoError(t, getWith(server, TLSOptions{CABundle: bundle}))
+
+	root, err := certs.NewRootComposite()
+	assert.NoError(t, err)
+	other := filepath.Join(t.TempDir(), "other.pem")
+	assert.NoError(t, os.WriteFile(other, []byte(root.Pem), 0600))
+	assert.Error(t, getWith(server, TLSOptions{CABundle: other}))
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	assert.NoError(t, os.WriteFile(empty, []byte("nothing"), 0600))
+	_, err = TLSOptions{CABundle: empty}.config(LocalPinName)
+	assert.Error(t, err)
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestReportErrors(t *testing.T) {
+	target := client.NewWsman(client.Parameters{Target: "localhost", Username: "admin", Password: "P@ssw0rd", UseDigest: true})
+	target.Transport = reportErrors{failingTransport{}}
+	_, err := target.Post("<a:Envelope/>")
+	assert.ErrorContains(t, err, unreachableStatus)
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestUseLocalTLS(t *testing.T) {
+	remote := tls.SettingDataResponse{InstanceID: "Intel(r) AMT 802.3 TLS Settings", Enabled: true}
+	tests := []struct {
+		name     string
+		local    tls.SettingDataResponse
+		trusted  bool
+		expected bool
+	}{
+		{name: "disabled", local: tls.SettingDataResponse{InstanceID: localTLSInstanceID}, trusted: true},
+		{name: "TLS only", local: tls.SettingDataResponse{InstanceID: localTLSInstanceID, Enabled: true}, expected: true},
+		{name: "non-TLS allowed", local: tls.SettingDataResponse{InstanceID: localTLSInstanceID, Enabled: true, AcceptNonSecureConnections: true}},
+		{name: "non-TLS allowed and trusted", local: tls.SettingDataResponse{InstanceID: localTLSInstanceID, Enabled: true, AcceptNonSecureConnections: true}, trusted: true, expected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, useLocalTLS([]tls.SettingDataResponse{remote, tt.local}, tt.trusted))
+		})
+	}
+	assert.False(t, useLocalTLS([]tls.SettingDataResponse{remote}, true))
+}
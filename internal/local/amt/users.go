@@ -0,0 +1,139 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"rpc/pkg/pthi"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+)
+
+// go-wsman-messages builds AMT_AuthorizationService requests with the
+// realms nested in RealmValue elements and without the Ex inputs, and does
+// not decode the method outputs
+
+const authorizationServiceClass = "AMT_AuthorizationService"
+
+// UserACLEntry is an AMT digest user, Handle identifies it to AMT
+type UserACLEntry struct {
+	Handle           int
+	Username         string
+	AccessPermission authorization.AccessPermission
+	Realms           []authorization.RealmValues
+	Enabled          bool
+}
+
+type authorizationResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Output struct {
+			XMLName          xml.Name
+			ReturnValue      int                            `xml:"ReturnValue"`
+			TotalCount       int                            `xml:"TotalCount"`
+			Handles          []int                          `xml:"Handles"`
+			Handle           int                            `xml:"Handle"`
+			DigestUsername   string                         `xml:"DigestUsername"`
+			AccessPermission authorization.AccessPermission `xml:"AccessPermission"`
+			Realms           []authorization.RealmValues    `xml:"Realms"`
+			Enabled          bool                           `xml:"Enabled"`
+		} `xml:",any"`
+	} `xml:"Body"`
+}
+
+func (g *GoWSMANMessages) invokeAuthorization(method string, inputs string) (authorizationResponse, error) {
+	action := amtResourceURIBase + authorizationServiceClass + "/" + method
+	body := fmt.Sprintf(`<h:%s_INPUT xmlns:h="%s%s">%s</h:%s_INPUT>`, method, amtResourceURIBase, authorizationServiceClass, inputs, method)
+	response := authorizationResponse{}
+	raw, err := g.postAMT(action, authorizationServiceClass, "", body)
+	if err != nil {
+		return response, err
+	}
+	if err = xml.Unmarshal(raw, &response); err != nil {
+		return response, err
+	}
+	// method return values are PT status codes
+	if err = pthi.Status(response.Body.Output.ReturnValue).Err(); err != nil {
+		return response, fmt.Errorf("%s failed: %w", method, err)
+	}
+	return response, nil
+}
+
+// GetUserACLEntries returns the digest users, Kerberos entries are left out
+func (g *GoWSMANMessages) GetUserACLEntries() ([]UserACLEntry, error) {
+	handles := []int{}
+	// AMT returns the handles in pages, StartIndex counts from 1
+	for {
+		response, err := g.invokeAuthorization(authorization.EnumerateUserACLEntries, fmt.Sprintf(`<h:StartIndex>%d</h:StartIndex>`, len(handles)+1))
+		if err != nil {
+			return nil, err
+		}
+		handles = append(handles, response.Body.Output.Handles...)
+		if len(response.Body.Output.Handles) == 0 || len(handles) >= response.Body.Output.TotalCount {
+			break
+		}
+	}
+	entries := []UserACLEntry{}
+	for _, handle := range handles {
+		inputs := fmt.Sprintf(`<h:Handle>%d</h:Handle>`, handle)
+		response, err := g.invokeAuthorization(authorization.GetUserACLEntryEx, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if response.Body.Output.DigestUsername == "" {
+			continue
+		}
+		entry := UserACLEntry{
+			Handle:           handle,
+			Username:         response.Body.Output.DigestUsername,
+			AccessPermission: response.Body.Output.AccessPermission,
+			Realms:           response.Body.Output.Realms,
+		}
+		if response, err = g.invokeAuthorization(authorization.GetACLEnabledState, inputs); err != nil {
+			return nil, err
+		}
+		entry.Enabled = response.Body.Output.Enabled
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// AddUserACLEntry adds a digest user and returns its handle. digestPassword
+// is the base64 encoded MD5 of username:realm:password.
+func (g *GoWSMANMessages) AddUserACLEntry(entry UserACLEntry, digestPassword string) (int, error) {
+	response, err := g.invokeAuthorization(authorization.AddUserACLEntryEx, userACLEntryInputs(entry, digestPassword))
+	if err != nil {
+		return 0, err
+	}
+	return response.Body.Output.Handle, nil
+}
+
+// UpdateUserACLEntry replaces the user with entry.Handle. AMT needs the
+// digest password again, as it is bound to the username.
+func (g *GoWSMANMessages) UpdateUserACLEntry(entry UserACLEntry, digestPassword string) error {
+	inputs := fmt.Sprintf(`<h:Handle>%d</h:Handle>`, entry.Handle) + userACLEntryInputs(entry, digestPassword)
+	_, err := g.invokeAuthorization(authorization.UpdateUserACLEntryEx, inputs)
+	return err
+}
+
+// RemoveUserACLEntry deletes the user with handle
+func (g *GoWSMANMessages) RemoveUserACLEntry(handle int) error {
+	_, err := g.invokeAuthorization(authorization.RemoveUserACLEntry, fmt.Sprintf(`<h:Handle>%d</h:Handle>`, handle))
+	return err
+}
+
+func userACLEntryInputs(entry UserACLEntry, digestPassword string) string {
+	var inputs strings.Builder
+	inputs.WriteString(`<h:DigestUsername>`)
+	xml.EscapeText(&inputs, []byte(entry.Username))
+	fmt.Fprintf(&inputs, `</h:DigestUsername><h:DigestPassword>%s</h:DigestPassword><h:AccessPermission>%d</h:AccessPermission>`, digestPassword, entry.AccessPermission)
+	for _, realm := range entry.Realms {
+		fmt.Fprintf(&inputs, `<h:Realms>%d</h:Realms>`, realm)
+	}
+	return inputs.String()
+}
@@ -0,0 +1,57 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package amt
+
+import (
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserACLEntries(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:EnumerateUserAclEntries_OUTPUT><g:TotalCount>2</g:TotalCount><g:HandlesCount>2</g:HandlesCount><g:Handles>3</g:Handles><g:Handles>4</g:Handles><g:ReturnValue>0</g:ReturnValue></g:EnumerateUserAclEntries_OUTPUT>`,
+		`<g:GetUserAclEntryEx_OUTPUT><g:DigestUsername>operator</g:DigestUsername><g:AccessPermission>1</g:AccessPermission><g:Realms>2</g:Realms><g:Realms>4</g:Realms><g:ReturnValue>0</g:ReturnValue></g:GetUserAclEntryEx_OUTPUT>`,
+		`<g:GetAclEnabledState_OUTPUT><g:Enabled>true</g:Enabled><g:ReturnValue>0</g:ReturnValue></g:GetAclEnabledState_OUTPUT>`,
+		// a Kerberos entry has no digest username
+		`<g:GetUserAclEntryEx_OUTPUT><g:KerberosUserSid>AQUAAAAAAAU=</g:KerberosUserSid><g:AccessPermission>2</g:AccessPermission><g:Realms>3</g:Realms><g:ReturnValue>0</g:ReturnValue></g:GetUserAclEntryEx_OUTPUT>`,
+		`<g:AddUserAclEntryEx_OUTPUT><g:Handle>5</g:Handle><g:ReturnValue>0</g:ReturnValue></g:AddUserAclEntryEx_OUTPUT>`,
+		`<g:AddUserAclEntryEx_OUTPUT><g:ReturnValue>2058</g:ReturnValue></g:AddUserAclEntryEx_OUTPUT>`,
+		`<g:UpdateUserAclEntryEx_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:UpdateUserAclEntryEx_OUTPUT>`,
+		`<g:RemoveUserAclEntry_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:RemoveUserAclEntry_OUTPUT>`,
+	)
+	users, err := g.GetUserACLEntries()
+	assert.NoError(t, err)
+	assert.Equal(t, []UserACLEntry{{
+		Handle:           3,
+		Username:         "operator",
+		AccessPermission: authorization.AccessPermissionNetworkAccessOnly,
+		Realms:           []authorization.RealmValues{authorization.RealmValuesRedirectionRealm, authorization.RealmValuesHardwareAssetRealm},
+		Enabled:          true,
+	}}, users)
+	assert.Contains(t, (*requests)[0], "<h:StartIndex>1</h:StartIndex>")
+	assert.Contains(t, (*requests)[1], "<h:Handle>3</h:Handle>")
+
+	user := UserACLEntry{
+		Username:         "a<b",
+		AccessPermission: authorization.AccessPermissionLocalAndNetworkAccess,
+		Realms:           []authorization.RealmValues{authorization.RealmValuesRedirectionRealm},
+	}
+	handle, err := g.AddUserACLEntry(user, "ZGlnZXN0")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, handle)
+	assert.Contains(t, (*requests)[4], "<h:DigestUsername>a&lt;b</h:DigestUsername><h:DigestPassword>ZGlnZXN0</h:DigestPassword><h:AccessPermission>2</h:AccessPermission><h:Realms>2</h:Realms>")
+	_, err = g.AddUserACLEntry(user, "ZGlnZXN0")
+	assert.Error(t, err)
+
+	user.Handle = 5
+	assert.NoError(t, g.UpdateUserACLEntry(user, "ZGlnZXN0"))
+	assert.Contains(t, (*requests)[6], "<h:Handle>5</h:Handle><h:DigestUsername>")
+
+	assert.NoError(t, g.RemoveUserACLEntry(5))
+	assert.Contains(t, (*requests)[7], "AMT_AuthorizationService/RemoveUserAclEntry")
+}
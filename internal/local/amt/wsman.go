@@ -6,29 +6,47 @@
 package amt
 
 import (
+	"context"
 	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"rpc/internal/lm"
 	"rpc/pkg/utils"
+	"strings"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/auditlog"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/ethernetport"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/general"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/managementpresence"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publickey"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publicprivate"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/redirection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/setupandconfiguration"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/timesynchronization"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/tls"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/userinitiatedconnection"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/wifiportconfiguration"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/concrete"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/credential"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/kvm"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/models"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/service"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/software"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/wifi"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/client"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/hostbasedsetup"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/ieee8021x"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/optin"
+	"github.com/sirupsen/logrus"
 )
 
 type WSMANer interface {
@@ -80,30 +98,281 @@ type WSMANer interface {
 	GetRedirectionService() (response redirection.Response, err error)
 	GetIpsOptInService() (response optin.Response, err error)
 	PutIpsOptInService(request optin.OptInServiceRequest) (response optin.Response, err error)
+	StartOptIn() (response optin.Response, err error)
+	SendOptInCode(code int) (response optin.Response, err error)
+	CancelOptIn() (response optin.Response, err error)
+	GetEnvironmentDetectionSettings() (response environmentdetection.Response, err error)
+	PutEnvironmentDetectionSettings(request environmentdetection.EnvironmentDetectionSettingDataRequest) (response environmentdetection.Response, err error)
+	// Remote access
+	GetMPSSAP() ([]managementpresence.ManagementRemoteResponse, error)
+	GetRemoteAccessPolicies() ([]remoteaccess.RemoteAccessPolicyRuleResponse, error)
+	GetRemoteAccessPolicyAppliesToMPS() ([]remoteaccess.RemoteAccessPolicyAppliesToMPSResponse, error)
+	AddRemoteAccessPolicyRule(remoteAccessPolicyRule remoteaccess.RemoteAccessPolicyRuleRequest, mpsName string) (response remoteaccess.Response, err error)
+	AddMPS(mpServer remoteaccess.AddMpServerRequest) (response remoteaccess.Response, err error)
+	DeleteMPSSAP(name string) error
+	DeleteRemoteAccessPolicyRule(policyRuleName string) error
+	GetUserInitiatedConnectionService() (response userinitiatedconnection.Response, err error)
+	RequestUserInitiatedConnectionStateChange(requestedState userinitiatedconnection.RequestedState) (response userinitiatedconnection.Response, err error)
+	// Agent presence watchdog
+	GetAgentPresenceWatchdogs() ([]AgentPresenceWatchdog, error)
+	CreateAgentPresenceWatchdog(deviceID string, timeout, recovery uint16) error
+	DeleteAgentPresenceWatchdog(deviceID string) error
+	AddAgentPresenceWatchdogAction(deviceID string, oldState, newState WatchdogState) error
+	DeleteAllAgentPresenceWatchdogActions(deviceID string) error
+	RegisterAgentPresenceWatchdog(deviceID string) (uint32, error)
+	AssertAgentPresenceShutdown(deviceID string, sequenceNumber uint32) error
+	Transport() string
+	PinName() string
+	// Remote target
+	GetPlatformGUID() (string, error)
+	GetSoftwareIdentities() ([]software.SoftwareIdentity, error)
+	// Power
+	GetPowerState() (service.CIM_AssociatedPowerManagementService, error)
+	RequestPowerStateChange(powerState power.PowerState) (power.Response, error)
+	GetBootCapabilities() (boot.BootCapabilitiesResponse, error)
+	GetBootSettingData() (boot.BootSettingDataResponse, error)
+	SetBootSettingData(options BootOptions) error
+	SetBootConfigRole(role int) (cimBoot.Response, error)
+	ChangeBootOrder(source cimBoot.Source) (cimBoot.Response, error)
+	GetEventLogRecords(since time.Time) ([]EventLogRecord, error)
+	GetAuditLogRecords(startIndex int) ([]auditlog.AuditLogRecord, int, error)
+	GetAuditPolicy() ([]AuditedEvent, error)
+	SetAuditPolicy(event AuditedEvent, enable bool) error
+	GetHardwareInventory() (HardwareInventory, error)
+	GetAlarms() ([]Alarm, error)
+	AddAlarm(alarm Alarm) error
+	DeleteAlarm(name string) error
+	GetUserACLEntries() ([]UserACLEntry, error)
+	AddUserACLEntry(entry UserACLEntry, digestPassword string) (int, error)
+	UpdateUserACLEntry(entry UserACLEntry, digestPassword string) error
+	RemoveUserACLEntry(handle int) error
 }
 
 type GoWSMANMessages struct {
 	wsmanMessages wsman.Messages
 	target        string
+	port          string
+	mode          string
+	transport     string
+	local         *LocalTransport
+	tlsOptions    TLSOptions
+	useTLS        *bool
+	tlsTransport  *http.Transport
+	remote        bool
+	messageID     int
+}
+
+// TransportNetwork is reported for a remote target reached over the network
+const TransportNetwork = "network"
+
+// NewGoWSMANMessages returns a client that reaches AMT through the LMS at
+// lmsAddress:lmsPort or over LME, as chosen by transport (auto, lms or lme).
+// tlsOptions say how to trust AMT once local TLS is in use.
+func NewGoWSMANMessages(lmsAddress string, lmsPort string, transport string, tlsOptions TLSOptions) *GoWSMANMessages {
+	if lmsAddress == "" {
+		lmsAddress = utils.LMSAddress
+	}
+	if lmsPort == "" {
+		lmsPort = utils.LMSPort
+	}
+	return &GoWSMANMessages{
+		target:     lmsAddress,
+		port:       lmsPort,
+		mode:       transport,
+		tlsOptions: tlsOptions,
+	}
 }
 
-func NewGoWSMANMessages(lmsAddress string) *GoWSMANMessages {
-	return &GoWSMANMessages{
-		target: lmsAddress,
+// NewTargetWSMANMessages returns a client for the AMT at target, host[:port],
+// reached directly over the network with digest authentication. Port 16993,
+// or any of tlsOptions, selects TLS.
+func NewTargetWSMANMessages(target string, tlsOptions TLSOptions) (*GoWSMANMessages, error) {
+	host, port, err := utils.ParseTarget(target)
+	if err != nil {
+		return nil, err
 	}
+	useTLS := port == client.TLSPort || tlsOptions != (TLSOptions{})
+	return &GoWSMANMessages{
+		target:     host,
+		port:       port,
+		transport:  TransportNetwork,
+		tlsOptions: tlsOptions,
+		useTLS:     &useTLS,
+		remote:     true,
+	}, nil
 }
 
 func (g *GoWSMANMessages) SetupWsmanClient(username string, password string, logAMTMessages bool) {
+	if g.transport == "" {
+		transport, err := lm.SelectTransport(g.mode, g.target, g.port)
+		if err != nil {
+			logrus.Error(err)
+			transport = lm.TransportLMS
+		}
+		g.transport = transport
+	}
+	if g.useTLS == nil {
+		useTLS := g.detectTLS(username, password, logAMTMessages)
+		g.useTLS = &useTLS
+	}
+	g.wsmanMessages = g.newMessages(username, password, logAMTMessages, *g.useTLS)
+}
 
+// newMessages builds the wsman client for the selected transport, on 16993
+// with TLS or on 16992 without
+func (g *GoWSMANMessages) newMessages(username string, password string, logAMTMessages bool, useTLS bool) wsman.Messages {
+	host := g.target
+	if strings.Contains(host, ":") {
+		// IPv6 literal, the client puts the host into the endpoint URL as is
+		host = "[" + host + "]"
+	}
 	clientParams := client.Parameters{
-		Target:         g.target,
+		Target:         host,
 		Username:       username,
 		Password:       password,
 		UseDigest:      true,
-		UseTLS:         false,
+		UseTLS:         useTLS,
 		LogAMTMessages: logAMTMessages,
 	}
-	g.wsmanMessages = wsman.NewMessages(clientParams)
+	messages := wsman.NewMessages(clientParams)
+	target := messages.Client.(*client.Target)
+	if g.transport == lm.TransportLME && g.local == nil {
+		// reuse the LME connection when the client is set up again
+		g.local = NewLocalTransport()
+	}
+	switch {
+	case g.remote && !useTLS:
+		target.Transport = reportErrors{&http.Transport{DialContext: g.dialTarget}}
+	case useTLS:
+		if g.tlsTransport == nil {
+			g.tlsTransport = g.newTLSTransport()
+		}
+		target.Transport = reportErrors{g.tlsTransport}
+	case g.transport == lm.TransportLME:
+		target.Transport = reportErrors{g.local}
+	default:
+		target.Transport = reportErrors{target.Transport}
+	}
+	return messages
+}
+
+// newTLSTransport returns the transport for 16993, over LME when that is the
+// selected transport
+func (g *GoWSMANMessages) newTLSTransport() *http.Transport {
+	if g.remote {
+		transport := g.tlsOptions.newTransport(g.target)
+		transport.DialContext = g.dialTarget
+		return transport
+	}
+	transport := g.tlsOptions.newTransport(LocalPinName)
+	if g.transport == lm.TransportLME {
+		transport.DialContext = g.local.DialContext
+		transport.MaxConnsPerHost = maxLocalChannels
+	}
+	return transport
+}
+
+// dialTarget connects to the remote target's port whatever port the wsman
+// client put in the endpoint
+func (g *GoWSMANMessages) dialTarget(ctx context.Context, network string, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, net.JoinHostPort(g.target, g.port))
+}
+
+// detectTLS reads the local TLS settings over 16992. If 16992 cannot be
+// reached at all AMT is taken to accept only TLS.
+func (g *GoWSMANMessages) detectTLS(username string, password string, logAMTMessages bool) bool {
+	g.wsmanMessages = g.newMessages(username, password, logAMTMessages, false)
+	enumerateRsp, err := g.EnumerateTLSSettingData()
+	if err == nil {
+		var pullRsp tls.Response
+		pullRsp, err = g.PullTLSSettingData(enumerateRsp.Body.EnumerateResponse.EnumerationContext)
+		if err == nil {
+			useTLS := useLocalTLS(pullRsp.Body.PullResponse.SettingDataItems, g.tlsOptions.trusted(LocalPinName))
+			logrus.Debug("using local TLS: ", useTLS)
+			return useTLS
+		}
+	}
+	if strings.Contains(err.Error(), unreachableStatus) {
+		logrus.Debug("AMT does not answer on ", client.NonTLSPort, ", using TLS: ", err)
+		return true
+	}
+	logrus.Debug("unable to read the TLS settings: ", err)
+	return false
+}
+
+// useLocalTLS decides from the TLS settings whether local connections use
+// TLS. AMT takes only TLS locally once it is enabled without non-TLS
+// connections, and rpc also uses it when it is enabled and the certificate
+// can be trusted.
+func useLocalTLS(settings []tls.SettingDataResponse, trusted bool) bool {
+	for _, setting := range settings {
+		if setting.InstanceID == localTLSInstanceID && setting.Enabled {
+			return !setting.AcceptNonSecureConnections || trusted
+		}
+	}
+	return false
+}
+
+// Transport returns the transport SetupWsmanClient picked, lms or lme, or
+// network for a remote target
+func (g *GoWSMANMessages) Transport() string {
+	return g.transport
+}
+
+// PinName returns the name the AMT TLS certificate is pinned under
+func (g *GoWSMANMessages) PinName() string {
+	if g.remote {
+		return g.target
+	}
+	return LocalPinName
+}
+
+// GetPlatformGUID returns the platform GUID of the device as reported by
+// CIM_ComputerSystemPackage, the same bytes the host interface reports as
+// UUID, in hex
+func (g *GoWSMANMessages) GetPlatformGUID() (string, error) {
+	response, err := g.wsmanMessages.CIM.ComputerSystemPackage.Get()
+	if err != nil {
+		return "", err
+	}
+	return response.Body.GetResponse.PlatformGUID, nil
+}
+
+// GetSoftwareIdentities returns the firmware versions AMT reports as
+// CIM_SoftwareIdentity, named like the host interface code versions
+func (g *GoWSMANMessages) GetSoftwareIdentities() ([]software.SoftwareIdentity, error) {
+	response, err := g.wsmanMessages.CIM.SoftwareIdentity.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	response, err = g.wsmanMessages.CIM.SoftwareIdentity.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body.PullResponse.SoftwareIdentityItems, nil
+}
+
+// GetPowerState returns the CIM_AssociatedPowerManagementService of the
+// managed system, which carries its current power state
+func (g *GoWSMANMessages) GetPowerState() (service.CIM_AssociatedPowerManagementService, error) {
+	response, err := g.wsmanMessages.CIM.ServiceAvailableToElement.Enumerate()
+	if err != nil {
+		return service.CIM_AssociatedPowerManagementService{}, err
+	}
+	response, err = g.wsmanMessages.CIM.ServiceAvailableToElement.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return service.CIM_AssociatedPowerManagementService{}, err
+	}
+	items := response.Body.PullResponse.AssociatedPowerManagementService
+	if len(items) == 0 {
+		return service.CIM_AssociatedPowerManagementService{}, errors.New("AMT reports no power management service")
+	}
+	return items[0], nil
+}
+
+func (g *GoWSMANMessages) RequestPowerStateChange(powerState power.PowerState) (power.Response, error) {
+	return g.wsmanMessages.CIM.PowerManagementService.RequestPowerStateChange(powerState)
 }
 
 func (g *GoWSMANMessages) GetGeneralSettings() (general.Response, error) {
@@ -365,3 +634,76 @@ func (g *GoWSMANMessages) GetIpsOptInService() (response optin.Response, err err
 func (g *GoWSMANMessages) PutIpsOptInService(request optin.OptInServiceRequest) (response optin.Response, err error) {
 	return g.wsmanMessages.IPS.OptInService.Put(request)
 }
+
+func (g *GoWSMANMessages) StartOptIn() (response optin.Response, err error) {
+	return g.wsmanMessages.IPS.OptInService.StartOptIn()
+}
+
+func (g *GoWSMANMessages) SendOptInCode(code int) (response optin.Response, err error) {
+	return g.wsmanMessages.IPS.OptInService.SendOptInCode(code)
+}
+
+func (g *GoWSMANMessages) CancelOptIn() (response optin.Response, err error) {
+	return g.wsmanMessages.IPS.OptInService.CancelOptIn()
+}
+
+func (g *GoWSMANMessages) GetEnvironmentDetectionSettings() (response environmentdetection.Response, err error) {
+	return g.wsmanMessages.AMT.EnvironmentDetectionSettingData.Get()
+}
+
+func (g *GoWSMANMessages) PutEnvironmentDetectionSettings(request environmentdetection.EnvironmentDetectionSettingDataRequest) (response environmentdetection.Response, err error) {
+	return g.wsmanMessages.AMT.EnvironmentDetectionSettingData.Put(request)
+}
+func (g *GoWSMANMessages) GetMPSSAP() ([]managementpresence.ManagementRemoteResponse, error) {
+	response, err := g.wsmanMessages.AMT.ManagementPresenceRemoteSAP.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	response, err = g.wsmanMessages.AMT.ManagementPresenceRemoteSAP.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body.PullResponse.ManagementRemoteItems, nil
+}
+func (g *GoWSMANMessages) GetRemoteAccessPolicies() ([]remoteaccess.RemoteAccessPolicyRuleResponse, error) {
+	response, err := g.wsmanMessages.AMT.RemoteAccessPolicyRule.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	response, err = g.wsmanMessages.AMT.RemoteAccessPolicyRule.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body.PullResponse.RemotePolicyRuleItems, nil
+}
+func (g *GoWSMANMessages) GetRemoteAccessPolicyAppliesToMPS() ([]remoteaccess.RemoteAccessPolicyAppliesToMPSResponse, error) {
+	response, err := g.wsmanMessages.AMT.RemoteAccessPolicyAppliesToMPS.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	response, err = g.wsmanMessages.AMT.RemoteAccessPolicyAppliesToMPS.Pull(response.Body.EnumerateResponse.EnumerationContext)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body.PullResponse.PolicyAppliesItems, nil
+}
+func (g *GoWSMANMessages) AddRemoteAccessPolicyRule(remoteAccessPolicyRule remoteaccess.RemoteAccessPolicyRuleRequest, mpsName string) (response remoteaccess.Response, err error) {
+	return g.wsmanMessages.AMT.RemoteAccessService.AddRemoteAccessPolicyRule(remoteAccessPolicyRule, mpsName)
+}
+func (g *GoWSMANMessages) AddMPS(mpServer remoteaccess.AddMpServerRequest) (response remoteaccess.Response, err error) {
+	return g.wsmanMessages.AMT.RemoteAccessService.AddMPS(mpServer)
+}
+func (g *GoWSMANMessages) DeleteMPSSAP(name string) error {
+	_, err := g.wsmanMessages.AMT.ManagementPresenceRemoteSAP.Delete(name)
+	return err
+}
+func (g *GoWSMANMessages) DeleteRemoteAccessPolicyRule(policyRuleName string) error {
+	_, err := g.wsmanMessages.AMT.RemoteAccessPolicyRule.Delete(policyRuleName)
+	return err
+}
+func (g *GoWSMANMessages) GetUserInitiatedConnectionService() (response userinitiatedconnection.Response, err error) {
+	return g.wsmanMessages.AMT.UserInitiatedConnectionService.Get()
+}
+func (g *GoWSMANMessages) RequestUserInitiatedConnectionStateChange(requestedState userinitiatedconnection.RequestedState) (response userinitiatedconnection.Response, err error) {
+	return g.wsmanMessages.AMT.UserInitiatedConnectionService.RequestStateChange(requestedState)
+}
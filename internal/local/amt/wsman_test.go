@@ -4,3 +4,134 @@
  **********************************************************************/
 
 package amt
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const platformGUIDResponse = `<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:h="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ComputerSystemPackage"><a:Header></a:Header><a:Body><h:CIM_ComputerSystemPackage><h:PlatformGUID>C7FD9A2356A3374BB2C7C60DBB3F1A6E</h:PlatformGUID></h:CIM_ComputerSystemPackage></a:Body></a:Envelope>`
+
+func platformGUIDHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") == "" {
+		w.Header().Set("WWW-Authenticate", `Digest realm="Digest:F3EB554784E729164447A89F60B641C5", nonce="abc", qop="auth"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(platformGUIDResponse))
+}
+
+func TestNewTargetWSMANMessages(t *testing.T) {
+	g, err := NewTargetWSMANMessages("192.168.1.20", TLSOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, TransportNetwork, g.Transport())
+	assert.Equal(t, "192.168.1.20", g.PinName())
+	assert.False(t, *g.useTLS)
+
+	g, err = NewTargetWSMANMessages("[fe80::1]:16993", TLSOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "fe80::1", g.PinName())
+	assert.True(t, *g.useTLS)
+
+	g, err = NewTargetWSMANMessages("amt.example.com", TLSOptions{TOFU: true})
+	assert.NoError(t, err)
+	assert.True(t, *g.useTLS)
+
+	_, err = NewTargetWSMANMessages(":16992", TLSOptions{})
+	assert.Error(t, err)
+}
+
+func TestRemoteTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(platformGUIDHandler))
+	defer server.Close()
+
+	g, err := NewTargetWSMANMessages(server.Listener.Addr().String(), TLSOptions{})
+	assert.NoError(t, err)
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	guid, err := g.GetPlatformGUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "C7FD9A2356A3374BB2C7C60DBB3F1A6E", guid)
+}
+
+func TestRemoteTargetTLS(t *testing.T) {
+	t.Setenv(TLSPinFileEnvVar, filepath.Join(t.TempDir(), "tls-pins.json"))
+	server := httptest.NewTLSServer(http.HandlerFunc(platformGUIDHandler))
+	defer server.Close()
+	host, _, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	// the certificate is not trusted yet, the test server port does not
+	// select TLS by itself
+	g, err := NewTargetWSMANMessages(server.Listener.Addr().String(), TLSOptions{})
+	assert.NoError(t, err)
+	useTLS := true
+	g.useTLS = &useTLS
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	_, err = g.GetPlatformGUID()
+	assert.ErrorContains(t, err, "is not trusted")
+
+	pin := Fingerprint(server.Certificate().Raw)
+	g, err = NewTargetWSMANMessages(server.Listener.Addr().String(), TLSOptions{Pin: pin})
+	assert.NoError(t, err)
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	guid, err := g.GetPlatformGUID()
+	assert.NoError(t, err)
+	assert.Equal(t, "C7FD9A2356A3374BB2C7C60DBB3F1A6E", guid)
+
+	// trusting on first use pins the certificate under the target host
+	g, err = NewTargetWSMANMessages(server.Listener.Addr().String(), TLSOptions{TOFU: true})
+	assert.NoError(t, err)
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	_, err = g.GetPlatformGUID()
+	assert.NoError(t, err)
+	saved, err := LoadTLSPin(host)
+	assert.NoError(t, err)
+	assert.Equal(t, pin, saved)
+}
+
+// sequenceServer answers each request with the next of bodies, wrapped in an
+// envelope, and records the requests
+func sequenceServer(t *testing.T, bodies ...string) (*GoWSMANMessages, *[]string) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(request))
+		body := bodies[0]
+		bodies = bodies[1:]
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope" xmlns:g="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM"><a:Header></a:Header><a:Body>` + body + `</a:Body></a:Envelope>`))
+	}))
+	t.Cleanup(server.Close)
+	g, err := NewTargetWSMANMessages(server.Listener.Addr().String(), TLSOptions{})
+	assert.NoError(t, err)
+	g.SetupWsmanClient("admin", "P@ssw0rd", false)
+	return g, &requests
+}
+
+func TestPowerState(t *testing.T) {
+	g, requests := sequenceServer(t,
+		`<g:EnumerateResponse><g:EnumerationContext>09000000-0000-0000-0000-000000000000</g:EnumerationContext></g:EnumerateResponse>`,
+		`<g:PullResponse><g:Items><g:CIM_AssociatedPowerManagementService><g:AvailableRequestedPowerStates>8</g:AvailableRequestedPowerStates><g:PowerState>2</g:PowerState></g:CIM_AssociatedPowerManagementService></g:Items></g:PullResponse>`,
+		`<g:RequestPowerStateChange_OUTPUT><g:ReturnValue>0</g:ReturnValue></g:RequestPowerStateChange_OUTPUT>`,
+		`<g:EnumerateResponse><g:EnumerationContext>0A000000-0000-0000-0000-000000000000</g:EnumerationContext></g:EnumerateResponse>`,
+		`<g:PullResponse><g:Items></g:Items></g:PullResponse>`,
+	)
+	state, err := g.GetPowerState()
+	assert.NoError(t, err)
+	assert.Equal(t, service.PowerStateOn, state.PowerState)
+	assert.Contains(t, (*requests)[0], "CIM_ServiceAvailableToElement")
+
+	response, err := g.RequestPowerStateChange(power.MasterBusReset)
+	assert.NoError(t, err)
+	assert.Equal(t, power.ReturnValueCompletedWithNoError, response.Body.RequestPowerStateChangeResponse.ReturnValue)
+	assert.Contains(t, (*requests)[2], "<h:PowerState>10</h:PowerState>")
+
+	_, err = g.GetPowerState()
+	assert.Error(t, err)
+}
@@ -21,19 +21,11 @@ func (service *ProvisioningService) ChangeAMTPassword() (err error) {
 		return err
 	}
 
-	challenge := client.AuthChallenge{
-		Username: utils.AMTUserName,
-		Password: service.flags.NewPassword,
-		Realm:    generalSettings.Body.GetResponse.DigestRealm,
-	}
-
-	hashedMessage := challenge.HashCredentials()
-	bytes, err := hex.DecodeString(hashedMessage)
+	encodedMessage, err := digestPassword(utils.AMTUserName, service.flags.NewPassword, generalSettings.Body.GetResponse.DigestRealm)
 	if err != nil {
 		log.Error("Failed to decode hex string")
 		return
 	}
-	encodedMessage := base64.StdEncoding.EncodeToString(bytes)
 
 	response, err := service.interfacedWsmanMessage.UpdateAMTPassword(encodedMessage)
 	log.Trace(response)
@@ -45,3 +37,18 @@ func (service *ProvisioningService) ChangeAMTPassword() (err error) {
 	log.Info("Successfully updated AMT Password.")
 	return nil
 }
+
+// digestPassword is how AMT takes a digest password: the base64 encoded MD5
+// of username:realm:password
+func digestPassword(username string, password string, realm string) (string, error) {
+	challenge := client.AuthChallenge{
+		Username: username,
+		Password: password,
+		Realm:    realm,
+	}
+	bytes, err := hex.DecodeString(challenge.HashCredentials())
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,246 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	cimService "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/service"
+	log "github.com/sirupsen/logrus"
+)
+
+// minHTTPSBootVersion is the first AMT major version with UEFI HTTPS boot
+const minHTTPSBootVersion = 16
+
+type bootTarget struct {
+	source    cimBoot.Source
+	supported func(capabilities boot.BootCapabilitiesResponse) bool
+}
+
+// bootTargets maps the boot subcommands to their CIM_BootSourceSetting and
+// the AMT_BootCapabilities property that has to be set for them
+var bootTargets = map[string]bootTarget{
+	utils.SubCommandBootPXE: {
+		source:    cimBoot.PXE,
+		supported: func(c boot.BootCapabilitiesResponse) bool { return c.ForcePXEBoot },
+	},
+	utils.SubCommandBootHDD: {
+		source:    cimBoot.HardDrive,
+		supported: func(c boot.BootCapabilitiesResponse) bool { return c.ForceHardDriveBoot },
+	},
+	utils.SubCommandBootCD: {
+		source:    cimBoot.CD,
+		supported: func(c boot.BootCapabilitiesResponse) bool { return c.ForceCDorDVDBoot },
+	},
+	utils.SubCommandBootIDER: {
+		supported: func(c boot.BootCapabilitiesResponse) bool { return c.IDER },
+	},
+	utils.SubCommandBootHTTPS: {
+		source:    cimBoot.OCRUEFIHTTPS,
+		supported: func(c boot.BootCapabilitiesResponse) bool { return c.ForceUEFIHTTPSBoot },
+	},
+}
+
+type BootCapabilities struct {
+	PXE   bool `json:"pxe"`
+	HDD   bool `json:"hdd"`
+	CD    bool `json:"cd"`
+	IDER  bool `json:"ider"`
+	HTTPS bool `json:"https"`
+}
+
+type BootStatus struct {
+	Capabilities BootCapabilities `json:"capabilities"`
+	Target       string           `json:"target,omitempty"`
+	Reset        string           `json:"reset,omitempty"`
+	Transport    string           `json:"transport"`
+}
+
+// Boot lists the boot capabilities of the device and, unless the subcommand
+// is capabilities, sets the device to boot once from the chosen target
+func (service *ProvisioningService) Boot() error {
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	capabilities, err := service.interfacedWsmanMessage.GetBootCapabilities()
+	if err != nil {
+		log.Error("Failed to get the boot capabilities: ", err)
+		return utils.AMTConnectionFailed
+	}
+	status := BootStatus{
+		Capabilities: BootCapabilities{
+			PXE:   capabilities.ForcePXEBoot,
+			HDD:   capabilities.ForceHardDriveBoot,
+			CD:    capabilities.ForceCDorDVDBoot,
+			IDER:  capabilities.IDER,
+			HTTPS: capabilities.ForceUEFIHTTPSBoot,
+		},
+		Transport: service.interfacedWsmanMessage.Transport(),
+	}
+	if service.flags.SubCommand != utils.SubCommandBootCapabilities {
+		if err = service.setNextBoot(capabilities); err != nil {
+			return err
+		}
+		status.Target = service.flags.SubCommand
+		if service.flags.Boot.Reset {
+			if status.Reset, err = service.resetForBoot(); err != nil {
+				return err
+			}
+		}
+	}
+	service.printBootStatus(status)
+	return nil
+}
+
+// setNextBoot refuses a target the platform does not support, then writes
+// the boot settings, makes the AMT boot configuration the one for the next
+// boot and sets its boot source
+func (service *ProvisioningService) setNextBoot(capabilities boot.BootCapabilitiesResponse) error {
+	target, ok := bootTargets[service.flags.SubCommand]
+	if !ok {
+		return utils.IncorrectCommandLineParameters
+	}
+	if !target.supported(capabilities) {
+		log.Error("the platform does not support booting from ", service.flags.SubCommand)
+		return utils.BootTargetNotSupported
+	}
+	options := amt.BootOptions{}
+	switch service.flags.SubCommand {
+	case utils.SubCommandBootIDER:
+		options.UseIDER = true
+		options.IDERBootDevice = boot.CDBoot
+	case utils.SubCommandBootHTTPS:
+		if err := service.checkHTTPSBoot(); err != nil {
+			return err
+		}
+		options.UEFIBootParameters = httpsBootParameters(service.flags.Boot.URL, service.flags.Boot.HTTPSUsername, service.flags.Boot.HTTPSPassword)
+	}
+	if err := service.interfacedWsmanMessage.SetBootSettingData(options); err != nil {
+		log.Error("Failed to set the boot settings: ", err)
+		return utils.BootConfigurationFailed
+	}
+	response, err := service.interfacedWsmanMessage.SetBootConfigRole(amt.BootConfigRoleIsNext)
+	if err != nil {
+		log.Error("Failed to set the boot configuration role: ", err)
+		return utils.BootConfigurationFailed
+	}
+	if result := response.Body.SetBootConfigRole_OUTPUT.ReturnValue; result != cimBoot.ReturnValueCompletedNoError {
+		log.Error("AMT refused the boot configuration role: ", result.String())
+		return utils.BootConfigurationFailed
+	}
+	// IDE redirection boots from the redirected device, not a boot source
+	if target.source == "" {
+		return nil
+	}
+	response, err = service.interfacedWsmanMessage.ChangeBootOrder(target.source)
+	if err != nil {
+		log.Error("Failed to change the boot order: ", err)
+		return utils.BootConfigurationFailed
+	}
+	if result := response.Body.ChangeBootOrder_OUTPUT.ReturnValue; result != cimBoot.ReturnValueCompletedNoError {
+		log.Error("AMT refused the boot order: ", result.String())
+		return utils.BootConfigurationFailed
+	}
+	return nil
+}
+
+// checkHTTPSBoot refuses UEFI HTTPS boot before AMT 16 or when it is
+// disabled in the BIOS
+func (service *ProvisioningService) checkHTTPSBoot() error {
+	version, err := service.amtCommand.GetVersionDataFromME("AMT", service.flags.AMTTimeoutDuration)
+	if err != nil {
+		log.Error("Failed to get the AMT version: ", err)
+		return utils.AMTConnectionFailed
+	}
+	major, err := strconv.Atoi(strings.Split(version, ".")[0])
+	if err != nil || major < minHTTPSBootVersion {
+		log.Error("UEFI HTTPS boot needs AMT ", minHTTPSBootVersion, " or later, the device has ", version)
+		return utils.BootTargetNotSupported
+	}
+	settings, err := service.interfacedWsmanMessage.GetBootSettingData()
+	if err != nil {
+		log.Error("Failed to get the boot settings: ", err)
+		return utils.AMTConnectionFailed
+	}
+	if !settings.UEFIHTTPSBootEnabled {
+		log.Error("UEFI HTTPS boot is disabled in the BIOS")
+		return utils.BootTargetNotSupported
+	}
+	return nil
+}
+
+func httpsBootParameters(url, username, password string) []amt.UEFIBootParameter {
+	parameters := []amt.UEFIBootParameter{{Type: amt.UEFIBootParamNetworkDevicePath, Value: []byte(url)}}
+	if username != "" {
+		parameters = append(parameters, amt.UEFIBootParameter{Type: amt.UEFIBootParamHTTPSUserName, Value: []byte(username)})
+	}
+	if password != "" {
+		parameters = append(parameters, amt.UEFIBootParameter{Type: amt.UEFIBootParamHTTPSPassword, Value: []byte(password)})
+	}
+	return parameters
+}
+
+// resetForBoot power cycles a running device, or powers on one that is off,
+// so it boots from the new target. It returns the power action it took.
+func (service *ProvisioningService) resetForBoot() (string, error) {
+	state, err := service.interfacedWsmanMessage.GetPowerState()
+	if err != nil {
+		log.Error("Failed to get the power state: ", err)
+		return "", utils.AMTConnectionFailed
+	}
+	action := utils.SubCommandPowerCycle
+	if state.PowerState != cimService.PowerStateOn {
+		action = utils.SubCommandPowerOn
+	}
+	response, err := service.interfacedWsmanMessage.RequestPowerStateChange(powerActions[action])
+	if err != nil {
+		log.Error("Failed to request the power state change: ", err)
+		return "", utils.PowerStateChangeFailed
+	}
+	if result := response.Body.RequestPowerStateChangeResponse.ReturnValue; result != power.ReturnValueCompletedWithNoError {
+		log.Error("AMT refused the power state change: ", result.String())
+		return "", utils.PowerStateChangeFailed
+	}
+	log.Info("Requested power ", action)
+	return action, nil
+}
+
+func (service *ProvisioningService) printBootStatus(status BootStatus) {
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	supported := []string{}
+	for _, target := range []struct {
+		name      string
+		supported bool
+	}{
+		{utils.SubCommandBootPXE, status.Capabilities.PXE},
+		{utils.SubCommandBootHDD, status.Capabilities.HDD},
+		{utils.SubCommandBootCD, status.Capabilities.CD},
+		{utils.SubCommandBootIDER, status.Capabilities.IDER},
+		{utils.SubCommandBootHTTPS, status.Capabilities.HTTPS},
+	} {
+		if target.supported {
+			supported = append(supported, target.name)
+		}
+	}
+	service.PrintOutput("Boot Capabilities	: " + strings.Join(supported, ", "))
+	if status.Target != "" {
+		service.PrintOutput("Next Boot       	: " + status.Target)
+	}
+	if status.Reset != "" {
+		service.PrintOutput("Power           	: " + status.Reset)
+	}
+	service.PrintOutput("Transport       	: " + strings.ToUpper(status.Transport))
+}
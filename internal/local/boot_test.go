@@ -0,0 +1,131 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetBootMocks() {
+	mockBootCapabilities = boot.BootCapabilitiesResponse{ForcePXEBoot: true, ForceHardDriveBoot: true, ForceCDorDVDBoot: true, IDER: true, ForceUEFIHTTPSBoot: true}
+	errBootCapabilities = nil
+	mockBootSettingData = boot.BootSettingDataResponse{UEFIHTTPSBootEnabled: true}
+	errBootSettingData = nil
+	mockBootOptions = amt.BootOptions{}
+	errSetBootSettingData = nil
+	mockBootConfigRoleValue = cimBoot.ReturnValueCompletedNoError
+	errSetBootConfigRole = nil
+	mockBootSource = ""
+	mockChangeBootOrderValue = cimBoot.ReturnValueCompletedNoError
+	errChangeBootOrder = nil
+	mockVersionData = "Version"
+	mockPowerState = service.CIM_AssociatedPowerManagementService{PowerState: service.PowerStateOn}
+	resetPowerMocks()
+}
+
+func TestBoot(t *testing.T) {
+	t.Run("lists the capabilities", func(t *testing.T) {
+		defer resetBootMocks()
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootCapabilities, JsonOutput: true})
+		assert.NoError(t, service.Boot())
+		assert.Equal(t, cimBoot.Source(""), mockBootSource)
+	})
+	t.Run("sets the boot source of each target", func(t *testing.T) {
+		defer resetBootMocks()
+		for _, target := range []string{utils.SubCommandBootPXE, utils.SubCommandBootHDD, utils.SubCommandBootCD} {
+			service := setupService(&flags.Flags{SubCommand: target})
+			assert.NoError(t, service.Boot())
+			assert.Equal(t, bootTargets[target].source, mockBootSource)
+			assert.Equal(t, amt.BootOptions{}, mockBootOptions)
+		}
+	})
+	t.Run("boots from IDER without a boot source", func(t *testing.T) {
+		defer resetBootMocks()
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootIDER})
+		assert.NoError(t, service.Boot())
+		assert.Equal(t, amt.BootOptions{UseIDER: true, IDERBootDevice: boot.CDBoot}, mockBootOptions)
+		assert.Equal(t, cimBoot.Source(""), mockBootSource)
+	})
+	t.Run("boots from a UEFI HTTPS URL on AMT 16", func(t *testing.T) {
+		defer resetBootMocks()
+		mockVersionData = "16.1.25"
+		f := &flags.Flags{SubCommand: utils.SubCommandBootHTTPS}
+		f.Boot.URL = "https://server/image.efi"
+		f.Boot.HTTPSUsername = "user"
+		service := setupService(f)
+		assert.NoError(t, service.Boot())
+		assert.Equal(t, cimBoot.OCRUEFIHTTPS, mockBootSource)
+		assert.Equal(t, []amt.UEFIBootParameter{
+			{Type: amt.UEFIBootParamNetworkDevicePath, Value: []byte("https://server/image.efi")},
+			{Type: amt.UEFIBootParamHTTPSUserName, Value: []byte("user")},
+		}, mockBootOptions.UEFIBootParameters)
+	})
+	t.Run("refuses UEFI HTTPS boot before AMT 16", func(t *testing.T) {
+		defer resetBootMocks()
+		mockVersionData = "15.0.23"
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootHTTPS})
+		assert.Equal(t, utils.BootTargetNotSupported, service.Boot())
+	})
+	t.Run("refuses UEFI HTTPS boot disabled in the BIOS", func(t *testing.T) {
+		defer resetBootMocks()
+		mockVersionData = "16.1.25"
+		mockBootSettingData.UEFIHTTPSBootEnabled = false
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootHTTPS})
+		assert.Equal(t, utils.BootTargetNotSupported, service.Boot())
+	})
+	t.Run("refuses an unsupported target", func(t *testing.T) {
+		defer resetBootMocks()
+		mockBootCapabilities.ForcePXEBoot = false
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootPXE})
+		assert.Equal(t, utils.BootTargetNotSupported, service.Boot())
+		assert.Equal(t, cimBoot.Source(""), mockBootSource)
+	})
+	t.Run("power cycles a running device", func(t *testing.T) {
+		defer resetBootMocks()
+		f := &flags.Flags{SubCommand: utils.SubCommandBootPXE}
+		f.Boot.Reset = true
+		service := setupService(f)
+		assert.NoError(t, service.Boot())
+		assert.Equal(t, power.PowerCycleOffHard, mockRequestedPowerState)
+	})
+	t.Run("powers on a device that is off", func(t *testing.T) {
+		defer resetBootMocks()
+		mockPowerState.PowerState = service.PowerStateOffSoft
+		f := &flags.Flags{SubCommand: utils.SubCommandBootHDD}
+		f.Boot.Reset = true
+		service := setupService(f)
+		assert.NoError(t, service.Boot())
+		assert.Equal(t, power.PowerOn, mockRequestedPowerState)
+	})
+	t.Run("fails when AMT refuses the boot order", func(t *testing.T) {
+		defer resetBootMocks()
+		mockChangeBootOrderValue = cimBoot.ReturnValueInvalidParameter
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootCD})
+		assert.Equal(t, utils.BootConfigurationFailed, service.Boot())
+	})
+	t.Run("fails when the boot settings cannot be set", func(t *testing.T) {
+		defer resetBootMocks()
+		errSetBootSettingData = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootCD})
+		assert.Equal(t, utils.BootConfigurationFailed, service.Boot())
+	})
+	t.Run("fails when the capabilities cannot be read", func(t *testing.T) {
+		defer resetBootMocks()
+		errBootCapabilities = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandBootCapabilities})
+		assert.Equal(t, utils.AMTConnectionFailed, service.Boot())
+	})
+}
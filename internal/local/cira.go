@@ -0,0 +1,106 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/internal/amt"
+	"rpc/pkg/utils"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const remoteStatusConnected = "connected"
+
+// ciraPollInterval is how often the connection status is checked while waiting
+var ciraPollInterval = 2 * time.Second
+
+func (service *ProvisioningService) CIRA() error {
+	switch service.flags.SubCommand {
+	case utils.SubCommandConnect:
+		return service.ConnectCIRA()
+	case utils.SubCommandDisconnect:
+		return service.DisconnectCIRA()
+	case utils.SubCommandStatus:
+		return service.CIRAStatus()
+	}
+	return utils.IncorrectCommandLineParameters
+}
+
+// ConnectCIRA asks AMT to open a user initiated connection to the MPS
+// configured on the device
+func (service *ProvisioningService) ConnectCIRA() error {
+	controlMode, err := service.amtCommand.GetControlMode()
+	if err != nil {
+		log.Error("Failed to get control mode: ", err)
+		return amtError(err, utils.AMTConnectionFailed)
+	}
+	if controlMode == 0 {
+		log.Error("Device is not activated. A user initiated connection requires an activated device with CIRA configured")
+		return utils.CIRAConnectionFailed
+	}
+	if err = service.amtCommand.OpenUserInitiatedConnection(); err != nil {
+		log.Error("Failed to open user initiated connection: ", err)
+		return amtError(err, utils.CIRAConnectionFailed)
+	}
+	log.Info("Requested a user initiated connection. Run 'cira status' to wait for the connection to be established.")
+	return nil
+}
+
+func (service *ProvisioningService) DisconnectCIRA() error {
+	if err := service.amtCommand.CloseUserInitiatedConnection(); err != nil {
+		log.Error("Failed to close user initiated connection: ", err)
+		return amtError(err, utils.CIRAConnectionFailed)
+	}
+	log.Info("Closed the user initiated connection.")
+	return nil
+}
+
+// CIRAStatus polls the remote access connection status until the tunnel
+// is connected or the timeout passes
+func (service *ProvisioningService) CIRAStatus() error {
+	deadline := time.Now().Add(service.flags.CIRATimeout)
+	var status amt.RemoteAccessStatus
+	var err error
+	for {
+		status, err = service.amtCommand.GetRemoteAccessConnectionStatus()
+		if err != nil {
+			log.Error("Failed to get remote access connection status: ", err)
+			return amtError(err, utils.AMTConnectionFailed)
+		}
+		if status.MPSHostname == "" {
+			service.printCIRAStatus(status)
+			log.Error("No MPS is configured on this device")
+			return utils.CIRAConnectionFailed
+		}
+		if status.RemoteStatus == remoteStatusConnected {
+			break
+		}
+		if !time.Now().Add(ciraPollInterval).Before(deadline) {
+			service.printCIRAStatus(status)
+			log.Error("Timed out waiting for the CIRA connection to ", status.MPSHostname)
+			return utils.CIRAConnectionTimeout
+		}
+		log.Debug("CIRA connection is ", status.RemoteStatus, ", waiting")
+		time.Sleep(ciraPollInterval)
+	}
+	service.printCIRAStatus(status)
+	return nil
+}
+
+func (service *ProvisioningService) printCIRAStatus(status amt.RemoteAccessStatus) {
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	service.PrintOutput("RAS Network      	: " + status.NetworkStatus)
+	service.PrintOutput("RAS Remote Status	: " + status.RemoteStatus)
+	service.PrintOutput("RAS Trigger      	: " + status.RemoteTrigger)
+	service.PrintOutput("RAS MPS Hostname 	: " + status.MPSHostname)
+}
@@ -0,0 +1,135 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"rpc/internal/amt"
+	"rpc/internal/flags"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectCIRA(t *testing.T) {
+	tests := []struct {
+		name           string
+		controlMode    int
+		controlModeErr error
+		openErr        error
+		expectedErr    error
+	}{
+		{
+			name:           "GetControlModeError",
+			controlModeErr: assert.AnError,
+			expectedErr:    utils.AMTConnectionFailed,
+		},
+		{
+			name:        "NotActivated",
+			controlMode: 0,
+			expectedErr: utils.CIRAConnectionFailed,
+		},
+		{
+			name:        "OpenError",
+			controlMode: 2,
+			openErr:     assert.AnError,
+			expectedErr: utils.CIRAConnectionFailed,
+		},
+		{
+			name:        "Success",
+			controlMode: 2,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.Command = utils.CommandCIRA
+			f.SubCommand = utils.SubCommandConnect
+			mockControlMode = tc.controlMode
+			mockControlModeErr = tc.controlModeErr
+			mockOpenUserInitiatedConnectionErr = tc.openErr
+
+			service := setupService(f)
+
+			err := service.CIRA()
+			assert.Equal(t, tc.expectedErr, err)
+			mockControlMode = 0
+			mockControlModeErr = nil
+			mockOpenUserInitiatedConnectionErr = nil
+		})
+	}
+}
+
+func TestDisconnectCIRA(t *testing.T) {
+	f := &flags.Flags{}
+	f.Command = utils.CommandCIRA
+	f.SubCommand = utils.SubCommandDisconnect
+	service := setupService(f)
+
+	err := service.CIRA()
+	assert.NoError(t, err)
+
+	mockCloseUserInitiatedConnectionErr = assert.AnError
+	err = service.CIRA()
+	assert.Equal(t, utils.CIRAConnectionFailed, err)
+	mockCloseUserInitiatedConnectionErr = nil
+}
+
+func TestCIRAStatus(t *testing.T) {
+	ciraPollInterval = time.Millisecond
+	defer func() { ciraPollInterval = 2 * time.Second }()
+	tests := []struct {
+		name        string
+		status      amt.RemoteAccessStatus
+		statusErr   error
+		jsonOutput  bool
+		expectedErr error
+	}{
+		{
+			name:        "GetStatusError",
+			statusErr:   assert.AnError,
+			expectedErr: utils.AMTConnectionFailed,
+		},
+		{
+			name:        "NoMPSConfigured",
+			status:      amt.RemoteAccessStatus{RemoteStatus: "not connected"},
+			expectedErr: utils.CIRAConnectionFailed,
+		},
+		{
+			name:        "Timeout",
+			status:      amt.RemoteAccessStatus{RemoteStatus: "connecting", MPSHostname: "mps.example.com"},
+			expectedErr: utils.CIRAConnectionTimeout,
+		},
+		{
+			name:   "Connected",
+			status: amt.RemoteAccessStatus{RemoteStatus: "connected", RemoteTrigger: "user initiated", MPSHostname: "mps.example.com"},
+		},
+		{
+			name:       "ConnectedJSON",
+			status:     amt.RemoteAccessStatus{RemoteStatus: "connected", RemoteTrigger: "user initiated", MPSHostname: "mps.example.com"},
+			jsonOutput: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.Command = utils.CommandCIRA
+			f.SubCommand = utils.SubCommandStatus
+			f.CIRATimeout = 10 * time.Millisecond
+			f.JsonOutput = tc.jsonOutput
+			mockRemoteAcessConnectionStatus = tc.status
+			mockRemoteAcessConnectionStatusErr = tc.statusErr
+
+			service := setupService(f)
+
+			err := service.CIRA()
+			assert.Equal(t, tc.expectedErr, err)
+			mockRemoteAcessConnectionStatus = amt.RemoteAccessStatus{}
+			mockRemoteAcessConnectionStatusErr = nil
+		})
+	}
+}
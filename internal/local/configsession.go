@@ -0,0 +1,65 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"rpc/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	provisioningStateInProvisioning   = 1
+	provisioningStatePostProvisioning = 2
+)
+
+func (service *ProvisioningService) ConfigurationSession() error {
+	switch service.flags.SubCommand {
+	case utils.SubCommandStopConfig:
+		return service.StopConfiguration()
+	case utils.SubCommandStartConfig:
+		return service.StartConfiguration()
+	}
+	return utils.IncorrectCommandLineParameters
+}
+
+// StopConfiguration recovers a device that a failed remote
+// configuration left in the "in provisioning" state
+func (service *ProvisioningService) StopConfiguration() error {
+	state, err := service.amtCommand.GetProvisioningState()
+	if err != nil {
+		log.Error("Failed to get provisioning state: ", err)
+		return amtError(err, utils.AMTConnectionFailed)
+	}
+	if state != provisioningStateInProvisioning {
+		log.Info("No configuration session is pending. Provisioning state: " + utils.InterpretProvisioningState(state))
+		return nil
+	}
+	if err = service.amtCommand.StopConfiguration(); err != nil {
+		log.Error("Failed to stop configuration: ", err)
+		return amtError(err, utils.StopConfigurationFailed)
+	}
+	log.Info("Successfully stopped the pending configuration session.")
+	return nil
+}
+
+func (service *ProvisioningService) StartConfiguration() error {
+	controlMode, err := service.amtCommand.GetControlMode()
+	if err != nil {
+		log.Error("Failed to get control mode: ", err)
+		return amtError(err, utils.AMTConnectionFailed)
+	}
+	if controlMode != 0 {
+		log.Error("Device is already activated. Current device control mode: " + utils.InterpretControlMode(controlMode))
+		return utils.StartConfigurationFailed
+	}
+	if err = service.amtCommand.StartConfiguration(service.flags.ProvisioningOTP); err != nil {
+		log.Error("Failed to start configuration: ", err)
+		return amtError(err, utils.StartConfigurationFailed)
+	}
+	log.Info("Successfully started a configuration session.")
+	return nil
+}
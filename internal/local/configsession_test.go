@@ -0,0 +1,111 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"rpc/internal/flags"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopConfiguration(t *testing.T) {
+	tests := []struct {
+		name                 string
+		provisioningState    int
+		provisioningStateErr error
+		stopErr              error
+		expectedErr          error
+	}{
+		{
+			name:                 "GetProvisioningStateError",
+			provisioningStateErr: assert.AnError,
+			expectedErr:          utils.AMTConnectionFailed,
+		},
+		{
+			name:              "NothingPending",
+			provisioningState: 0,
+		},
+		{
+			name:              "StopConfigurationError",
+			provisioningState: 1,
+			stopErr:           assert.AnError,
+			expectedErr:       utils.StopConfigurationFailed,
+		},
+		{
+			name:              "Success",
+			provisioningState: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.Command = utils.CommandMaintenance
+			f.SubCommand = utils.SubCommandStopConfig
+			mockProvisioningState = tc.provisioningState
+			mockProvisioningStateErr = tc.provisioningStateErr
+			mockStopConfigurationErr = tc.stopErr
+
+			service := setupService(f)
+
+			err := service.ConfigurationSession()
+			assert.Equal(t, tc.expectedErr, err)
+			mockProvisioningState = 0
+			mockProvisioningStateErr = nil
+			mockStopConfigurationErr = nil
+		})
+	}
+}
+
+func TestStartConfiguration(t *testing.T) {
+	tests := []struct {
+		name           string
+		controlMode    int
+		controlModeErr error
+		startErr       error
+		expectedErr    error
+	}{
+		{
+			name:           "GetControlModeError",
+			controlModeErr: assert.AnError,
+			expectedErr:    utils.AMTConnectionFailed,
+		},
+		{
+			name:        "AlreadyActivated",
+			controlMode: 2,
+			expectedErr: utils.StartConfigurationFailed,
+		},
+		{
+			name:        "StartConfigurationError",
+			startErr:    assert.AnError,
+			expectedErr: utils.StartConfigurationFailed,
+		},
+		{
+			name: "Success",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.SubCommand = utils.SubCommandStartConfig
+			f.ProvisioningOTP = "Otp12345"
+			mockControlMode = tc.controlMode
+			mockControlModeErr = tc.controlModeErr
+			mockStartConfigurationErr = tc.startErr
+			service := setupService(f)
+
+			err := service.Configure()
+			assert.Equal(t, tc.expectedErr, err)
+			if tc.controlMode == 0 && tc.controlModeErr == nil {
+				assert.Equal(t, "Otp12345", mockStartConfigurationOTP)
+			}
+			mockControlMode = 0
+			mockControlModeErr = nil
+			mockStartConfigurationErr = nil
+		})
+	}
+}
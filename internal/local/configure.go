@@ -15,10 +15,18 @@ import (
 )
 
 func (service *ProvisioningService) Configure() (err error) {
+	// the DNS suffix is set over the host interface and is
+	// typically needed before the device can be activated
+	if service.flags.SubCommand == utils.SubCommandDNSSuffix {
+		return service.SetDNSSuffix()
+	}
+	if service.flags.SubCommand == utils.SubCommandStopConfig || service.flags.SubCommand == utils.SubCommandStartConfig {
+		return service.ConfigurationSession()
+	}
 	// Check if the device is already activated
 	controlMode, err := service.amtCommand.GetControlMode()
 	if err != nil {
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 	if controlMode == 0 {
 		log.Error("Device is not activated to configure. Please activate the device first.")
@@ -51,6 +59,14 @@ func (service *ProvisioningService) Configure() (err error) {
 			return utils.UnableToConfigure
 		}
 		return service.SetAMTFeatures()
+	case utils.SubCommandAlarm:
+		return service.ConfigureAlarm()
+	case utils.SubCommandUsers:
+		return service.ConfigureUsers()
+	case utils.SubCommandEnvDetection:
+		return service.ConfigureEnvironmentDetection()
+	case utils.SubCommandConfigureCIRA:
+		return service.ConfigureCIRA()
 	default:
 	}
 	return utils.IncorrectCommandLineParameters
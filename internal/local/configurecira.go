@@ -0,0 +1,323 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/managementpresence"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/userinitiatedconnection"
+	log "github.com/sirupsen/logrus"
+)
+
+// ciraTunnelLifeTime is how long, in seconds, a user initiated or alert
+// connection stays open
+const ciraTunnelLifeTime = 300
+
+// ciraPeriodicInterval is how often, in seconds, AMT connects to the MPS
+const ciraPeriodicInterval = 60
+
+type CIRAConfiguration struct {
+	MPSAddress    string   `json:"mpsAddress"`
+	MPSPort       int      `json:"mpsPort"`
+	Policies      []string `json:"policies"`
+	UserInitiated bool     `json:"userInitiated"`
+	Transport     string   `json:"transport"`
+}
+
+// ConfigureCIRA adds the MPS and CIRA policies given to AMT, replacing an MPS
+// with the same address, or removes them
+func (service *ProvisioningService) ConfigureCIRA() error {
+	if service.flags.ConfigureCIRA.Remove {
+		return service.removeCIRA()
+	}
+	cira := service.flags.LocalConfig.CIRA
+	policies := []remoteaccess.RemoteAccessPolicyRuleRequest{
+		{Trigger: remoteaccess.TriggerUserInitiated, TunnelLifeTime: ciraTunnelLifeTime},
+		{Trigger: remoteaccess.TriggerAlert, TunnelLifeTime: ciraTunnelLifeTime},
+		// periodic connections stay open until AMT or the MPS closes them
+		{Trigger: remoteaccess.TriggerPeriodic, ExtendedData: periodicExtendedData(ciraPeriodicInterval)},
+	}
+	// changes lists what has been done to AMT so far, so a failure part way
+	// says what state the device is left in
+	changes := []string{}
+	failed := func() error {
+		if len(changes) > 0 {
+			log.Error("CIRA is only partly configured, AMT keeps these changes: ", strings.Join(changes, "; "), ". Run configure cira again to finish")
+		}
+		return utils.CIRAConfigurationFailed
+	}
+	if _, err := service.GetCertHandle(cira.MPSCert); err != nil {
+		if _, err = service.interfacedWsmanMessage.AddTrustedRootCert(cira.MPSCert); err != nil {
+			log.Error("Failed to add the MPS root certificate: ", err)
+			return utils.CIRAConfigurationFailed
+		}
+		log.Info("Added the MPS root certificate")
+		changes = append(changes, "added the MPS root certificate")
+	}
+
+	mpsSAPs, err := service.interfacedWsmanMessage.GetMPSSAP()
+	if err != nil {
+		log.Error("Failed to get the MPS configuration: ", err)
+		return failed()
+	}
+	for _, mps := range mpsSAPs {
+		// AMT refuses a second MPS with the same address
+		if mps.AccessInfo != cira.MPSAddress {
+			continue
+		}
+		if err = service.removeMPS(mps); err != nil {
+			changes = append(changes, "started removing the MPS "+mps.AccessInfo+" and its policies")
+			return failed()
+		}
+		changes = append(changes, "removed the MPS "+mps.AccessInfo+" and its policies")
+	}
+	// AMT keeps one policy per trigger, so the policies of another MPS have
+	// to go before these can be added
+	existing, err := service.interfacedWsmanMessage.GetRemoteAccessPolicies()
+	if err != nil {
+		log.Error("Failed to get the remote access policies: ", err)
+		return failed()
+	}
+	for _, rule := range existing {
+		for _, policy := range policies {
+			if rule.Trigger != policy.Trigger {
+				continue
+			}
+			if err = service.interfacedWsmanMessage.DeleteRemoteAccessPolicyRule(rule.PolicyRuleName); err != nil {
+				log.Error("Failed to remove the policy ", rule.PolicyRuleName, ": ", err)
+				return failed()
+			}
+			log.Info("Removed the policy ", rule.PolicyRuleName, " of another MPS")
+			changes = append(changes, "removed the policy "+rule.PolicyRuleName)
+		}
+	}
+
+	request := remoteaccess.AddMpServerRequest{
+		AccessInfo: cira.MPSAddress,
+		InfoFormat: mpsInfoFormat(cira.MPSAddress),
+		Port:       cira.MPSPort,
+		AuthMethod: remoteaccess.UsernamePasswordAuthentication,
+		Username:   cira.MPSUsername,
+		Password:   cira.MPSPassword,
+		CommonName: cira.CommonName,
+	}
+	response, err := service.interfacedWsmanMessage.AddMPS(request)
+	if err != nil {
+		log.Error("Failed to add the MPS: ", err)
+		return failed()
+	}
+	if response.Body.AddMpServerResponse.ReturnValue != remoteaccess.ReturnValueSuccess {
+		log.Error("Failed to add the MPS: ", response.Body.AddMpServerResponse.ReturnValue)
+		return failed()
+	}
+	mpsName := selectorValue(response.Body.AddMpServerResponse.MpServer.ReferenceParameters.SelectorSet.Selectors, "Name")
+	if mpsName == "" {
+		log.Error("AMT did not return the name of the added MPS")
+		changes = append(changes, "added the MPS "+cira.MPSAddress)
+		return failed()
+	}
+	log.Info("Added the MPS ", cira.MPSAddress)
+	changes = append(changes, "added the MPS "+cira.MPSAddress)
+
+	for _, policy := range policies {
+		response, err = service.interfacedWsmanMessage.AddRemoteAccessPolicyRule(policy, mpsName)
+		if err != nil {
+			log.Error("Failed to add the ", policy.Trigger, " policy: ", err)
+			return failed()
+		}
+		if response.Body.AddRemotePolicyRuleResponse.ReturnValue != remoteaccess.ReturnValueSuccess {
+			log.Error("Failed to add the ", policy.Trigger, " policy: ", response.Body.AddRemotePolicyRuleResponse.ReturnValue)
+			return failed()
+		}
+		changes = append(changes, "added the "+policy.Trigger.String()+" policy")
+	}
+	if err = service.setUserInitiatedConnection(userinitiatedconnection.BIOSandOSInterfacesEnabled); err != nil {
+		return failed()
+	}
+	log.Info("CIRA configured")
+	return service.printCIRAConfiguration()
+}
+
+// removeCIRA stops user initiated connections, removes the MPS with its
+// policies and, when it is given, the MPS root certificate
+func (service *ProvisioningService) removeCIRA() error {
+	if err := service.setUserInitiatedConnection(userinitiatedconnection.AllInterfacesDisabled); err != nil {
+		return err
+	}
+	if err := service.removeMPSConfiguration(); err != nil {
+		return err
+	}
+	if cert := service.flags.LocalConfig.CIRA.MPSCert; cert != "" {
+		handle, err := service.GetCertHandle(cert)
+		if err != nil {
+			log.Warn("The MPS root certificate is not in AMT")
+		} else if err = service.interfacedWsmanMessage.DeletePublicCert(handle); err != nil {
+			log.Error("Failed to remove the MPS root certificate: ", err)
+			return utils.CIRAConfigurationFailed
+		} else {
+			log.Info("Removed the MPS root certificate")
+		}
+	}
+	log.Info("CIRA configuration removed")
+	return service.printCIRAConfiguration()
+}
+
+// removeMPSConfiguration deletes the policies before the MPS they point to
+func (service *ProvisioningService) removeMPSConfiguration() error {
+	policies, err := service.interfacedWsmanMessage.GetRemoteAccessPolicies()
+	if err != nil {
+		log.Error("Failed to get the remote access policies: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	for _, policy := range policies {
+		if err = service.interfacedWsmanMessage.DeleteRemoteAccessPolicyRule(policy.PolicyRuleName); err != nil {
+			log.Error("Failed to remove the policy ", policy.PolicyRuleName, ": ", err)
+			return utils.CIRAConfigurationFailed
+		}
+		log.Debug("Removed the policy ", policy.PolicyRuleName)
+	}
+	mpsSAPs, err := service.interfacedWsmanMessage.GetMPSSAP()
+	if err != nil {
+		log.Error("Failed to get the MPS configuration: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	for _, mps := range mpsSAPs {
+		if err = service.interfacedWsmanMessage.DeleteMPSSAP(mps.Name); err != nil {
+			log.Error("Failed to remove the MPS ", mps.AccessInfo, ": ", err)
+			return utils.CIRAConfigurationFailed
+		}
+		log.Debug("Removed the MPS ", mps.AccessInfo)
+	}
+	return nil
+}
+
+// removeMPS deletes the policies that point to the MPS before the MPS itself
+func (service *ProvisioningService) removeMPS(mps managementpresence.ManagementRemoteResponse) error {
+	appliesToMPS, err := service.interfacedWsmanMessage.GetRemoteAccessPolicyAppliesToMPS()
+	if err != nil {
+		log.Error("Failed to get the policies of the MPS ", mps.AccessInfo, ": ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	for _, appliesTo := range appliesToMPS {
+		if selectorValue(appliesTo.ManagedElement.ReferenceParameters.SelectorSet.Selectors, "Name") != mps.Name {
+			continue
+		}
+		policy := selectorValue(appliesTo.PolicySet.ReferenceParameters.SelectorSet.Selectors, "PolicyRuleName")
+		if err = service.interfacedWsmanMessage.DeleteRemoteAccessPolicyRule(policy); err != nil {
+			log.Error("Failed to remove the policy ", policy, ": ", err)
+			return utils.CIRAConfigurationFailed
+		}
+		log.Debug("Removed the policy ", policy)
+	}
+	if err = service.interfacedWsmanMessage.DeleteMPSSAP(mps.Name); err != nil {
+		log.Error("Failed to remove the MPS ", mps.AccessInfo, ": ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	log.Info("Removed the MPS ", mps.AccessInfo)
+	return nil
+}
+
+func (service *ProvisioningService) setUserInitiatedConnection(state userinitiatedconnection.RequestedState) error {
+	response, err := service.interfacedWsmanMessage.RequestUserInitiatedConnectionStateChange(state)
+	if err != nil {
+		log.Error("Failed to change the user initiated connection state: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	if response.Body.RequestStateChange_OUTPUT.ReturnValue != userinitiatedconnection.ReturnValueCompletedWithNoError {
+		log.Error("Failed to change the user initiated connection state: ", response.Body.RequestStateChange_OUTPUT.ReturnValue)
+		return utils.CIRAConfigurationFailed
+	}
+	return nil
+}
+
+// printCIRAConfiguration reads back what AMT holds
+func (service *ProvisioningService) printCIRAConfiguration() error {
+	configuration := CIRAConfiguration{Policies: []string{}, Transport: service.interfacedWsmanMessage.Transport()}
+	mpsSAPs, err := service.interfacedWsmanMessage.GetMPSSAP()
+	if err != nil {
+		log.Error("Failed to get the MPS configuration: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	for _, mps := range mpsSAPs {
+		// AMT can hold other MPSs, show the one just configured
+		if configuration.MPSAddress == "" || mps.AccessInfo == service.flags.LocalConfig.CIRA.MPSAddress {
+			configuration.MPSAddress = mps.AccessInfo
+			configuration.MPSPort = mps.Port
+		}
+	}
+	policies, err := service.interfacedWsmanMessage.GetRemoteAccessPolicies()
+	if err != nil {
+		log.Error("Failed to get the remote access policies: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	for _, policy := range policies {
+		configuration.Policies = append(configuration.Policies, policy.Trigger.String())
+	}
+	userInitiated, err := service.interfacedWsmanMessage.GetUserInitiatedConnectionService()
+	if err != nil {
+		log.Error("Failed to get the user initiated connection state: ", err)
+		return utils.CIRAConfigurationFailed
+	}
+	configuration.UserInitiated = userInitiated.Body.GetResponse.EnabledState == userinitiatedconnection.EnabledStateBIOSAndOSInterfacesEnabled
+
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(configuration, "", "  ")
+		fmt.Println(string(outBytes))
+		return nil
+	}
+	mps := "none"
+	if configuration.MPSAddress != "" {
+		mps = net.JoinHostPort(configuration.MPSAddress, strconv.Itoa(configuration.MPSPort))
+	}
+	policyNames := "none"
+	if len(configuration.Policies) > 0 {
+		policyNames = strings.Join(configuration.Policies, ", ")
+	}
+	service.PrintOutput("MPS           	: " + mps)
+	service.PrintOutput("Policies      	: " + policyNames)
+	service.PrintOutput("User Initiated	: " + strconv.FormatBool(configuration.UserInitiated))
+	service.PrintOutput("Transport     	: " + strings.ToUpper(configuration.Transport))
+	return nil
+}
+
+// selectorValue finds a key of a WS-MAN endpoint reference
+func selectorValue(selectors []remoteaccess.SelectorResponse, name string) string {
+	for _, selector := range selectors {
+		if selector.Name == name {
+			return selector.Text
+		}
+	}
+	return ""
+}
+
+func mpsInfoFormat(address string) remoteaccess.MPServerInfoFormat {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return remoteaccess.FQDN
+	case ip.To4() != nil:
+		return remoteaccess.IPv4Address
+	default:
+		return remoteaccess.IPv6Address
+	}
+}
+
+// periodicExtendedData is the base64 of the periodic type 0, a fixed interval,
+// followed by the interval in seconds, both in network order
+func periodicExtendedData(seconds uint32) string {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[4:], seconds)
+	return base64.StdEncoding.EncodeToString(data)
+}
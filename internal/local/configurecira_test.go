@@ -0,0 +1,282 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/config"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/managementpresence"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/userinitiatedconnection"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const testMPSName = "Intel(r) AMT:Management Presence Server 1"
+
+func addMPSResponse(name string) remoteaccess.Response {
+	response := remoteaccess.Response{}
+	response.Body.AddMpServerResponse.MpServer.ReferenceParameters.SelectorSet.Selectors = []remoteaccess.SelectorResponse{
+		{Name: "CreationClassName", Text: "AMT_ManagementPresenceRemoteSAP"},
+		{Name: "Name", Text: name},
+	}
+	return response
+}
+
+func policyAppliesToMPS(policyRuleName, mpsName string) remoteaccess.RemoteAccessPolicyAppliesToMPSResponse {
+	appliesTo := remoteaccess.RemoteAccessPolicyAppliesToMPSResponse{}
+	appliesTo.ManagedElement.ReferenceParameters.SelectorSet.Selectors = []remoteaccess.SelectorResponse{{Name: "Name", Text: mpsName}}
+	appliesTo.PolicySet.ReferenceParameters.SelectorSet.Selectors = []remoteaccess.SelectorResponse{{Name: "PolicyRuleName", Text: policyRuleName}}
+	return appliesTo
+}
+
+var testCIRAConfig = config.CIRA{
+	MPSAddress:  "mps.example.com",
+	MPSPort:     4433,
+	MPSUsername: "admin",
+	MPSPassword: "MPSp@ss",
+	MPSCert:     "MIIBnewRootCert",
+	CommonName:  "mps.example.com",
+}
+
+func TestConfigureCIRA(t *testing.T) {
+	defer func() {
+		mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{}
+		mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{}
+		mockRemoteAccessPolicyRulesAdded = 0
+		mockAddRemoteAccessPolicyRuleResponse = remoteaccess.Response{}
+		mockAddRemoteAccessPolicyRuleErr = nil
+		errAddTrustedRootCert = nil
+	}()
+	addedMPS := remoteaccess.AddMpServerRequest{
+		AccessInfo: "mps.example.com",
+		InfoFormat: remoteaccess.FQDN,
+		Port:       4433,
+		AuthMethod: remoteaccess.UsernamePasswordAuthentication,
+		Username:   "admin",
+		Password:   "MPSp@ss",
+		CommonName: "mps.example.com",
+	}
+	policies := []remoteaccess.RemoteAccessPolicyRuleRequest{
+		{Trigger: remoteaccess.TriggerUserInitiated, TunnelLifeTime: 300},
+		{Trigger: remoteaccess.TriggerAlert, TunnelLifeTime: 300},
+		{Trigger: remoteaccess.TriggerPeriodic, ExtendedData: "AAAAAAAAADw="},
+	}
+	trustedCIRAConfig := testCIRAConfig
+	trustedCIRAConfig.MPSCert = mpsCert.X509Certificate
+	tests := []struct {
+		name                    string
+		remove                  bool
+		cira                    config.CIRA
+		setupMocks              func(*MockWSMAN)
+		expectedErr             error
+		expectedAddedCert       string
+		expectedDeletedPolicies []string
+		expectedDeletedMPSs     []string
+		expectedMPS             remoteaccess.AddMpServerRequest
+		expectedPolicies        []remoteaccess.RemoteAccessPolicyRuleRequest
+		expectedUserInitiated   userinitiatedconnection.RequestedState
+		expectedDeletedCert     string
+		expectedLog             string
+	}{
+		{
+			name: "replaces the MPS with the same address and adds the policies",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{
+					{Name: "Intel(r) AMT:Management Presence Server 0", AccessInfo: "mps.example.com"},
+					{Name: "Intel(r) AMT:Management Presence Server 2", AccessInfo: "other.example.com"},
+				}
+				mockPolicyAppliesToMPSResponse = []remoteaccess.RemoteAccessPolicyAppliesToMPSResponse{
+					policyAppliesToMPS("User Initiated 1", "Intel(r) AMT:Management Presence Server 0"),
+					policyAppliesToMPS("Periodic 5", "Intel(r) AMT:Management Presence Server 2"),
+				}
+			},
+			expectedAddedCert:       "MIIBnewRootCert",
+			expectedDeletedPolicies: []string{"User Initiated 1"},
+			expectedDeletedMPSs:     []string{"Intel(r) AMT:Management Presence Server 0"},
+			expectedMPS:             addedMPS,
+			expectedPolicies:        policies,
+			expectedUserInitiated:   userinitiatedconnection.BIOSandOSInterfacesEnabled,
+		},
+		{
+			name: "removes the policies of another MPS for the same triggers",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: "Intel(r) AMT:Management Presence Server 2", AccessInfo: "other.example.com"}}
+				mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{
+					{PolicyRuleName: "User Initiated 7", Trigger: remoteaccess.TriggerUserInitiated},
+					{PolicyRuleName: "Alert 8", Trigger: remoteaccess.TriggerAlert},
+					{PolicyRuleName: "Periodic 9", Trigger: remoteaccess.TriggerPeriodic},
+				}
+				mockPolicyAppliesToMPSResponse = []remoteaccess.RemoteAccessPolicyAppliesToMPSResponse{
+					policyAppliesToMPS("User Initiated 7", "Intel(r) AMT:Management Presence Server 2"),
+					policyAppliesToMPS("Alert 8", "Intel(r) AMT:Management Presence Server 2"),
+					policyAppliesToMPS("Periodic 9", "Intel(r) AMT:Management Presence Server 2"),
+				}
+			},
+			expectedAddedCert:       "MIIBnewRootCert",
+			expectedDeletedPolicies: []string{"User Initiated 7", "Alert 8", "Periodic 9"},
+			expectedMPS:             addedMPS,
+			expectedPolicies:        policies,
+			expectedUserInitiated:   userinitiatedconnection.BIOSandOSInterfacesEnabled,
+		},
+		{
+			name:                  "keeps a root certificate AMT already trusts",
+			cira:                  trustedCIRAConfig,
+			setupMocks:            func(mock *MockWSMAN) {},
+			expectedMPS:           addedMPS,
+			expectedPolicies:      policies,
+			expectedUserInitiated: userinitiatedconnection.BIOSandOSInterfacesEnabled,
+		},
+		{
+			name: "adds the root certificate before touching the MPS",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: testMPSName, AccessInfo: "mps.example.com"}}
+				errAddTrustedRootCert = errors.New("add failed")
+			},
+			expectedErr:       utils.CIRAConfigurationFailed,
+			expectedAddedCert: "MIIBnewRootCert",
+		},
+		{
+			name: "reports the changes kept when it fails part way",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: "Intel(r) AMT:Management Presence Server 0", AccessInfo: "mps.example.com"}}
+				mockAddRemoteAccessPolicyRuleResponse.Body.AddRemotePolicyRuleResponse.ReturnValue = remoteaccess.ReturnValueDuplicate
+			},
+			expectedErr:         utils.CIRAConfigurationFailed,
+			expectedAddedCert:   "MIIBnewRootCert",
+			expectedDeletedMPSs: []string{"Intel(r) AMT:Management Presence Server 0"},
+			expectedMPS:         addedMPS,
+			expectedPolicies:    policies[:1],
+			expectedLog: "CIRA is only partly configured, AMT keeps these changes: " +
+				"added the MPS root certificate; removed the MPS mps.example.com and its policies; added the MPS mps.example.com. " +
+				"Run configure cira again to finish",
+		},
+		{
+			name: "fails when AMT refuses the MPS",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockAddMPSResponse.Body.AddMpServerResponse.ReturnValue = remoteaccess.ReturnValueInvalidParameter
+			},
+			expectedErr:       utils.CIRAConfigurationFailed,
+			expectedAddedCert: "MIIBnewRootCert",
+			expectedMPS:       addedMPS,
+		},
+		{
+			name: "fails when AMT returns no MPS name",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockAddMPSResponse = remoteaccess.Response{}
+			},
+			expectedErr:       utils.CIRAConfigurationFailed,
+			expectedAddedCert: "MIIBnewRootCert",
+			expectedMPS:       addedMPS,
+		},
+		{
+			name: "fails when a policy cannot be added",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockAddRemoteAccessPolicyRuleErr = errors.New("add failed")
+			},
+			expectedErr:       utils.CIRAConfigurationFailed,
+			expectedAddedCert: "MIIBnewRootCert",
+			expectedMPS:       addedMPS,
+			expectedPolicies:  policies[:1],
+		},
+		{
+			name: "fails when user initiated connections cannot be enabled",
+			cira: testCIRAConfig,
+			setupMocks: func(mock *MockWSMAN) {
+				mockUserInitiatedStateChangeValue = int(userinitiatedconnection.ReturnValueInvalidParameter)
+			},
+			expectedErr:           utils.CIRAConfigurationFailed,
+			expectedAddedCert:     "MIIBnewRootCert",
+			expectedMPS:           addedMPS,
+			expectedPolicies:      policies,
+			expectedUserInitiated: userinitiatedconnection.BIOSandOSInterfacesEnabled,
+		},
+		{
+			name:   "removes the MPS, the policies and the root certificate",
+			remove: true,
+			cira:   config.CIRA{MPSCert: mpsCert.X509Certificate},
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: testMPSName, AccessInfo: "mps.example.com"}}
+				mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{
+					{PolicyRuleName: "User Initiated 1", Trigger: remoteaccess.TriggerUserInitiated},
+					{PolicyRuleName: "Periodic 3", Trigger: remoteaccess.TriggerPeriodic},
+				}
+			},
+			expectedDeletedPolicies: []string{"User Initiated 1", "Periodic 3"},
+			expectedDeletedMPSs:     []string{testMPSName},
+			expectedUserInitiated:   userinitiatedconnection.AllInterfacesDisabled,
+			expectedDeletedCert:     mpsCert.InstanceID,
+		},
+		{
+			name:                  "keeps the root certificate when it is not given",
+			remove:                true,
+			setupMocks:            func(mock *MockWSMAN) {},
+			expectedUserInitiated: userinitiatedconnection.AllInterfacesDisabled,
+		},
+		{
+			name:   "fails when the MPS cannot be removed",
+			remove: true,
+			setupMocks: func(mock *MockWSMAN) {
+				mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: testMPSName}}
+				errDeleteMPSSAP = errors.New("delete failed")
+			},
+			expectedErr:           utils.CIRAConfigurationFailed,
+			expectedDeletedMPSs:   []string{testMPSName},
+			expectedUserInitiated: userinitiatedconnection.AllInterfacesDisabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMPSSAPResponse, mockMPSSAPErr = []managementpresence.ManagementRemoteResponse{}, nil
+			mockRemoteAccessPoliciesResponse, mockRemoteAccessPoliciesErr = []remoteaccess.RemoteAccessPolicyRuleResponse{}, nil
+			mockPolicyAppliesToMPSResponse, mockPolicyAppliesToMPSErr = []remoteaccess.RemoteAccessPolicyAppliesToMPSResponse{}, nil
+			mockAddRemoteAccessPolicyRuleResponse, mockAddRemoteAccessPolicyRuleErr = remoteaccess.Response{}, nil
+			mockAddMPSResponse, errAddMPS = addMPSResponse(testMPSName), nil
+			errDeleteMPSSAP, errDeleteRemoteAccessPolicyRule = nil, nil
+			mockUserInitiatedStateChangeValue, errUserInitiatedStateChange = 0, nil
+			errAddTrustedRootCert, errDeletePublicCert = nil, nil
+			mockAddedTrustedRootCert, mockDeletedPublicCert = "", ""
+			mockDeletedRemoteAccessPolicyRules, mockDeletedMPSSAPs = nil, nil
+			mockAddedMPS, mockAddedRemoteAccessPolicyRules = remoteaccess.AddMpServerRequest{}, nil
+			mockRequestedUserInitiatedState = 0
+			hook := test.NewGlobal()
+			defer hook.Reset()
+			service, _, mockWsman := setupProvisioningService()
+			tt.setupMocks(mockWsman)
+			service.flags.ConfigureCIRA.Remove = tt.remove
+			service.flags.LocalConfig.CIRA = tt.cira
+			service.flags.JsonOutput = true
+			err := service.ConfigureCIRA()
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedAddedCert, mockAddedTrustedRootCert)
+			assert.ElementsMatch(t, tt.expectedDeletedPolicies, mockDeletedRemoteAccessPolicyRules)
+			assert.Equal(t, tt.expectedDeletedMPSs, mockDeletedMPSSAPs)
+			assert.Equal(t, tt.expectedMPS, mockAddedMPS)
+			assert.ElementsMatch(t, tt.expectedPolicies, mockAddedRemoteAccessPolicyRules)
+			assert.Equal(t, tt.expectedUserInitiated, mockRequestedUserInitiatedState)
+			assert.Equal(t, tt.expectedDeletedCert, mockDeletedPublicCert)
+			if tt.expectedLog != "" {
+				assert.Contains(t, hookMessages(hook), tt.expectedLog)
+			}
+		})
+	}
+}
+
+func TestMPSInfoFormat(t *testing.T) {
+	assert.Equal(t, remoteaccess.FQDN, mpsInfoFormat("mps.example.com"))
+	assert.Equal(t, remoteaccess.IPv4Address, mpsInfoFormat("192.168.1.10"))
+	assert.Equal(t, remoteaccess.IPv6Address, mpsInfoFormat("fd00::10"))
+}
@@ -0,0 +1,106 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/optin"
+	log "github.com/sirupsen/logrus"
+)
+
+// optInCodeRejected is what SendOptInCode returns for a wrong code
+const optInCodeRejected = 2066
+
+type ConsentStatus struct {
+	State          string `json:"state"`
+	StateValue     int    `json:"stateValue"`
+	Required       string `json:"required"`
+	CodeTimeout    int    `json:"codeTimeout"`
+	DisplayTimeout int    `json:"displayTimeout"`
+	Action         string `json:"action,omitempty"`
+	Transport      string `json:"transport"`
+}
+
+// Consent starts, answers or cancels a user consent session through
+// IPS_OptInService, then shows the opt-in state
+func (service *ProvisioningService) Consent() error {
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	action := service.flags.SubCommand
+	if action != utils.SubCommandStatus {
+		if err := service.changeConsent(action); err != nil {
+			return err
+		}
+		log.Info("User consent ", action, " succeeded")
+	} else {
+		action = ""
+	}
+	response, err := service.interfacedWsmanMessage.GetIpsOptInService()
+	if err != nil {
+		log.Error("Failed to get the user consent state: ", err)
+		return utils.UserConsentFailed
+	}
+	settings := response.Body.GetAndPutResponse
+	status := ConsentStatus{
+		State:          optin.OptInState(settings.OptInState).String(),
+		StateValue:     settings.OptInState,
+		Required:       optin.OptInRequired(settings.OptInRequired).String(),
+		CodeTimeout:    settings.OptInCodeTimeout,
+		DisplayTimeout: settings.OptInDisplayTimeout,
+		Action:         action,
+		Transport:      service.interfacedWsmanMessage.Transport(),
+	}
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return nil
+	}
+	service.PrintOutput("User Consent    	: " + status.State)
+	service.PrintOutput("Required        	: " + status.Required)
+	service.PrintOutput("Code Timeout    	: " + strconv.Itoa(status.CodeTimeout) + "s")
+	service.PrintOutput("Display Timeout 	: " + strconv.Itoa(status.DisplayTimeout) + "s")
+	service.PrintOutput("Transport       	: " + strings.ToUpper(status.Transport))
+	return nil
+}
+
+func (service *ProvisioningService) changeConsent(action string) error {
+	var response optin.Response
+	var err error
+	var result int
+	switch action {
+	case utils.SubCommandStart:
+		response, err = service.interfacedWsmanMessage.StartOptIn()
+		result = response.Body.StartOptInResponse.ReturnValue
+	case utils.SubCommandSend:
+		response, err = service.interfacedWsmanMessage.SendOptInCode(service.flags.Consent.Code)
+		result = response.Body.SendOptInCodeResponse.ReturnValue
+	case utils.SubCommandCancel:
+		response, err = service.interfacedWsmanMessage.CancelOptIn()
+		result = response.Body.CancelOptInResponse.ReturnValue
+	default:
+		return utils.IncorrectCommandLineParameters
+	}
+	if err != nil {
+		log.Error("Failed to ", action, " user consent: ", err)
+		return utils.UserConsentFailed
+	}
+	switch optin.ReturnValue(result) {
+	case optin.ReturnValueSuccess:
+		return nil
+	case optInCodeRejected:
+		log.Error("AMT rejected the user consent code")
+		return utils.UserConsentCodeRejected
+	case optin.ReturnValueInvalidState:
+		log.Error("AMT refused to ", action, " user consent in the current state, check consent status")
+	default:
+		log.Error("AMT refused to ", action, " user consent: ", optin.ReturnValue(result).String(), " (", result, ")")
+	}
+	return utils.UserConsentFailed
+}
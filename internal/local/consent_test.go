@@ -0,0 +1,75 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/optin"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetConsentMocks() {
+	mockStartOptInValue = 0
+	errStartOptIn = nil
+	mockOptInCode = 0
+	mockSendOptInCodeValue = 0
+	errSendOptInCode = nil
+	mockCancelOptInValue = 0
+	errCancelOptIn = nil
+	mockGetIpsOptInServiceResponse = optin.Response{}
+	mockGetIpsOptInServiceError = nil
+}
+
+func TestConsent(t *testing.T) {
+	t.Run("shows the state", func(t *testing.T) {
+		defer resetConsentMocks()
+		mockGetIpsOptInServiceResponse.Body.GetAndPutResponse.OptInState = int(optin.Displayed)
+		mockGetIpsOptInServiceResponse.Body.GetAndPutResponse.OptInRequired = uint32(optin.OptInRequiredAll)
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandStatus, JsonOutput: true})
+		assert.NoError(t, service.Consent())
+	})
+	t.Run("starts a session", func(t *testing.T) {
+		defer resetConsentMocks()
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandStart})
+		assert.NoError(t, service.Consent())
+	})
+	t.Run("sends the code", func(t *testing.T) {
+		defer resetConsentMocks()
+		f := &flags.Flags{SubCommand: utils.SubCommandSend}
+		f.Consent.Code = 123456
+		service := setupService(f)
+		assert.NoError(t, service.Consent())
+		assert.Equal(t, 123456, mockOptInCode)
+	})
+	t.Run("reports a rejected code", func(t *testing.T) {
+		defer resetConsentMocks()
+		mockSendOptInCodeValue = optInCodeRejected
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandSend})
+		assert.Equal(t, utils.UserConsentCodeRejected, service.Consent())
+	})
+	t.Run("fails to cancel in an invalid state", func(t *testing.T) {
+		defer resetConsentMocks()
+		mockCancelOptInValue = int(optin.ReturnValueInvalidState)
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandCancel})
+		assert.Equal(t, utils.UserConsentFailed, service.Consent())
+	})
+	t.Run("fails when AMT cannot be reached", func(t *testing.T) {
+		defer resetConsentMocks()
+		errStartOptIn = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandStart})
+		assert.Equal(t, utils.UserConsentFailed, service.Consent())
+	})
+	t.Run("fails when the state cannot be read", func(t *testing.T) {
+		defer resetConsentMocks()
+		mockGetIpsOptInServiceError = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandStatus})
+		assert.Equal(t, utils.UserConsentFailed, service.Consent())
+	})
+}
@@ -16,7 +16,7 @@ func (service *ProvisioningService) Deactivate() (err error) {
 	controlMode, err := service.amtCommand.GetControlMode()
 	if err != nil {
 		log.Error(err)
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 	// Deactivate based on the control mode
 	switch controlMode {
@@ -55,13 +55,13 @@ func (service *ProvisioningService) DeactivateACM() (err error) {
 }
 
 func (service *ProvisioningService) DeactivateCCM() (err error) {
-	if service.flags.Password != "" {
+	if service.flags.Password != "" && service.flags.Target == "" {
 		log.Warn("Password not required for CCM deactivation")
 	}
 	status, err := service.amtCommand.Unprovision()
 	if err != nil || status != 0 {
 		log.Error("Status: Failed to deactivate ", err)
-		return utils.DeactivationFailed
+		return amtError(err, utils.DeactivationFailed)
 	}
 	return nil
 }
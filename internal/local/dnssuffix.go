@@ -0,0 +1,51 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"rpc/pkg/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func (service *ProvisioningService) SetDNSSuffix() (err error) {
+	if service.flags.DNS != "" {
+		err = service.amtCommand.SetDNSSuffix(service.flags.DNS)
+		if err != nil {
+			log.Error("Failed to set DNS suffix: ", err)
+			return amtError(err, utils.DNSSuffixConfigurationFailed)
+		}
+		log.Info("Successfully set DNS suffix to " + service.flags.DNS)
+	}
+	if service.flags.HostFQDN != "" {
+		err = service.amtCommand.SetHostFQDN(service.flags.HostFQDN)
+		if err != nil {
+			log.Error("Failed to set host FQDN: ", err)
+			return amtError(err, utils.DNSSuffixConfigurationFailed)
+		}
+		log.Info("Successfully set host FQDN to " + service.flags.HostFQDN)
+	}
+	return nil
+}
+
+// matchDNSSuffix writes the requested PKI DNS suffix when AMT reports a
+// different one, so the provisioning certificate domain can be matched
+func (service *ProvisioningService) matchDNSSuffix(suffix string) error {
+	current, err := service.amtCommand.GetDNSSuffix()
+	if err != nil {
+		log.Error("Failed to get DNS suffix: ", err)
+		return amtError(err, utils.AMTConnectionFailed)
+	}
+	if current == suffix {
+		return nil
+	}
+	log.Info("Updating DNS suffix from '" + current + "' to '" + suffix + "'")
+	if err = service.amtCommand.SetDNSSuffix(suffix); err != nil {
+		log.Error("Failed to set DNS suffix: ", err)
+		return amtError(err, utils.DNSSuffixConfigurationFailed)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"rpc/internal/flags"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDNSSuffix(t *testing.T) {
+	tests := []struct {
+		name           string
+		dns            string
+		hostFQDN       string
+		setSuffixErr   error
+		setHostFQDNErr error
+		expectedErr    error
+	}{
+		{
+			name:     "Success",
+			dns:      "vprodemo.com",
+			hostFQDN: "host.vprodemo.com",
+		},
+		{
+			name:         "SetDNSSuffixError",
+			dns:          "vprodemo.com",
+			setSuffixErr: assert.AnError,
+			expectedErr:  utils.DNSSuffixConfigurationFailed,
+		},
+		{
+			name:           "SetHostFQDNError",
+			hostFQDN:       "host.vprodemo.com",
+			setHostFQDNErr: assert.AnError,
+			expectedErr:    utils.DNSSuffixConfigurationFailed,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.SubCommand = utils.SubCommandDNSSuffix
+			f.DNS = tc.dns
+			f.HostFQDN = tc.hostFQDN
+			mockSetDNSSuffixErr = tc.setSuffixErr
+			mockSetHostFQDNErr = tc.setHostFQDNErr
+			// the device does not need to be activated
+			mockControlMode = 0
+			service := setupService(f)
+
+			err := service.Configure()
+
+			assert.Equal(t, tc.expectedErr, err)
+			mockSetDNSSuffixErr = nil
+			mockSetHostFQDNErr = nil
+		})
+	}
+}
+
+func TestMatchDNSSuffix(t *testing.T) {
+	service := setupService(&flags.Flags{})
+
+	t.Run("does not write a matching suffix", func(t *testing.T) {
+		mockSetDNSSuffixCalledWith = ""
+		err := service.matchDNSSuffix(mockDNSSuffix)
+		assert.NoError(t, err)
+		assert.Equal(t, "", mockSetDNSSuffixCalledWith)
+	})
+	t.Run("writes a different suffix", func(t *testing.T) {
+		mockSetDNSSuffixCalledWith = ""
+		err := service.matchDNSSuffix("vprodemo.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "vprodemo.com", mockSetDNSSuffixCalledWith)
+	})
+	t.Run("returns AMTConnectionFailed on GetDNSSuffix error", func(t *testing.T) {
+		mockDNSSuffixErr = assert.AnError
+		err := service.matchDNSSuffix("vprodemo.com")
+		assert.Equal(t, utils.AMTConnectionFailed, err)
+		mockDNSSuffixErr = nil
+	})
+	t.Run("returns DNSSuffixConfigurationFailed on SetDNSSuffix error", func(t *testing.T) {
+		mockSetDNSSuffixErr = assert.AnError
+		err := service.matchDNSSuffix("vprodemo.com")
+		assert.Equal(t, utils.DNSSuffixConfigurationFailed, err)
+		mockSetDNSSuffixErr = nil
+	})
+}
@@ -0,0 +1,80 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/pkg/utils"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	log "github.com/sirupsen/logrus"
+)
+
+type EnvironmentDetectionStatus struct {
+	Domains   []string `json:"domains"`
+	Transport string   `json:"transport"`
+}
+
+// ConfigureEnvironmentDetection sets the DNS suffixes AMT uses to detect the
+// enterprise network, then shows them
+func (service *ProvisioningService) ConfigureEnvironmentDetection() error {
+	response, err := service.interfacedWsmanMessage.GetEnvironmentDetectionSettings()
+	if err != nil {
+		log.Error("Failed to read the environment detection settings: ", err)
+		return utils.EnvironmentDetectionConfigurationFailed
+	}
+	if service.flags.EnvironmentDetection.Update {
+		settings := response.Body.GetAndPutResponse
+		// Put replaces the instance, so the IPv6 prefixes are written back
+		request := environmentdetection.EnvironmentDetectionSettingDataRequest{
+			ElementName:                settings.ElementName,
+			InstanceID:                 settings.InstanceID,
+			DetectionAlgorithm:         environmentdetection.LocalDomains,
+			DetectionStrings:           service.flags.LocalConfig.EnvironmentDetection.Domains,
+			DetectionIPv6LocalPrefixes: settings.DetectionIPv6LocalPrefixes,
+		}
+		if _, err = service.interfacedWsmanMessage.PutEnvironmentDetectionSettings(request); err != nil {
+			log.Error("Failed to set the environment detection domains: ", err)
+			return utils.EnvironmentDetectionConfigurationFailed
+		}
+		log.Info("Environment detection domains updated")
+		if response, err = service.interfacedWsmanMessage.GetEnvironmentDetectionSettings(); err != nil {
+			log.Error("Failed to read the environment detection settings: ", err)
+			return utils.EnvironmentDetectionConfigurationFailed
+		}
+	}
+	status := EnvironmentDetectionStatus{
+		Domains:   detectionDomains(response),
+		Transport: service.interfacedWsmanMessage.Transport(),
+	}
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return nil
+	}
+	service.PrintOutput("Detection Domains	: " + formatDetectionDomains(status.Domains))
+	service.PrintOutput("Transport        	: " + strings.ToUpper(status.Transport))
+	return nil
+}
+
+// detectionDomains returns the local domains, AMT may also hold remote URLs
+// which rpc does not set
+func detectionDomains(response environmentdetection.Response) []string {
+	settings := response.Body.GetAndPutResponse
+	if settings.DetectionAlgorithm != environmentdetection.LocalDomains || settings.DetectionStrings == nil {
+		return []string{}
+	}
+	return settings.DetectionStrings
+}
+
+func formatDetectionDomains(domains []string) string {
+	if len(domains) == 0 {
+		return "none, AMT always considers itself outside the enterprise"
+	}
+	return strings.Join(domains, ", ")
+}
@@ -0,0 +1,119 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	"github.com/stretchr/testify/assert"
+)
+
+func environmentDetectionResponse(domains ...string) environmentdetection.Response {
+	response := environmentdetection.Response{}
+	response.Body.GetAndPutResponse = environmentdetection.EnvironmentDetectionSettingDataResponse{
+		ElementName:                "Intel(r) AMT Environment Detection Settings",
+		InstanceID:                 "Intel(r) AMT Environment Detection Settings",
+		DetectionStrings:           domains,
+		DetectionIPv6LocalPrefixes: []string{"fd00:0:0:1::/64"},
+	}
+	return response
+}
+
+func TestConfigureEnvironmentDetection(t *testing.T) {
+	defer func() {
+		mockEnvironmentDetection = environmentdetection.Response{}
+		errGetEnvironmentDetection = nil
+		errPutEnvironmentDetection = nil
+	}()
+	tests := []struct {
+		name        string
+		update      bool
+		domains     []string
+		setupMocks  func(*MockWSMAN)
+		expectedErr error
+		expectedPut environmentdetection.EnvironmentDetectionSettingDataRequest
+	}{
+		{
+			name: "shows the domains",
+			setupMocks: func(mock *MockWSMAN) {
+				mockEnvironmentDetection = environmentDetectionResponse("corp.example.com")
+			},
+		},
+		{
+			name:    "sets the domains and keeps the IPv6 prefixes",
+			update:  true,
+			domains: []string{"a.corp", "b.corp"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockEnvironmentDetection = environmentDetectionResponse("old.example.com")
+			},
+			expectedPut: environmentdetection.EnvironmentDetectionSettingDataRequest{
+				ElementName:                "Intel(r) AMT Environment Detection Settings",
+				InstanceID:                 "Intel(r) AMT Environment Detection Settings",
+				DetectionAlgorithm:         environmentdetection.LocalDomains,
+				DetectionStrings:           []string{"a.corp", "b.corp"},
+				DetectionIPv6LocalPrefixes: []string{"fd00:0:0:1::/64"},
+			},
+		},
+		{
+			name:   "clears the domains",
+			update: true,
+			setupMocks: func(mock *MockWSMAN) {
+				mockEnvironmentDetection = environmentDetectionResponse("old.example.com")
+			},
+			expectedPut: environmentdetection.EnvironmentDetectionSettingDataRequest{
+				ElementName:                "Intel(r) AMT Environment Detection Settings",
+				InstanceID:                 "Intel(r) AMT Environment Detection Settings",
+				DetectionAlgorithm:         environmentdetection.LocalDomains,
+				DetectionIPv6LocalPrefixes: []string{"fd00:0:0:1::/64"},
+			},
+		},
+		{
+			name:    "fails when AMT refuses the domains",
+			update:  true,
+			domains: []string{"a.corp"},
+			setupMocks: func(mock *MockWSMAN) {
+				errPutEnvironmentDetection = errors.New("put failed")
+			},
+			expectedErr: utils.EnvironmentDetectionConfigurationFailed,
+			expectedPut: environmentdetection.EnvironmentDetectionSettingDataRequest{
+				DetectionAlgorithm: environmentdetection.LocalDomains,
+				DetectionStrings:   []string{"a.corp"},
+			},
+		},
+		{
+			name: "fails when the settings cannot be read",
+			setupMocks: func(mock *MockWSMAN) {
+				errGetEnvironmentDetection = errors.New("unreachable")
+			},
+			expectedErr: utils.EnvironmentDetectionConfigurationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockEnvironmentDetection, errGetEnvironmentDetection, errPutEnvironmentDetection = environmentdetection.Response{}, nil, nil
+			mockPutEnvironmentDetection = environmentdetection.EnvironmentDetectionSettingDataRequest{}
+			service, _, mockWsman := setupProvisioningService()
+			tt.setupMocks(mockWsman)
+			service.flags.EnvironmentDetection.Update = tt.update
+			service.flags.LocalConfig.EnvironmentDetection.Domains = tt.domains
+			service.flags.JsonOutput = true
+			err := service.ConfigureEnvironmentDetection()
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedPut, mockPutEnvironmentDetection)
+		})
+	}
+}
+
+func TestDetectionDomains(t *testing.T) {
+	assert.Equal(t, []string{"a.corp"}, detectionDomains(environmentDetectionResponse("a.corp")))
+	assert.Equal(t, []string{}, detectionDomains(environmentDetectionResponse()))
+	remote := environmentDetectionResponse("https://probe.example.com")
+	remote.Body.GetAndPutResponse.DetectionAlgorithm = environmentdetection.RemoteURLs
+	assert.Equal(t, []string{}, detectionDomains(remote))
+}
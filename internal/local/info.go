@@ -13,7 +13,11 @@ import (
 	"rpc/pkg/utils"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/ethernetport"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/general"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publickey"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publicprivate"
 	"github.com/sirupsen/logrus"
@@ -28,6 +32,7 @@ type PrivateKeyPairReference struct {
 func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 	dataStruct := make(map[string]interface{})
 	cmd := service.amtCommand
+	usedWsman := false
 
 	// UserCert precheck for provisioning mode and missing password
 	// password is required for the local wsman connection but if device
@@ -99,6 +104,16 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 
 		dataStruct["controlMode"] = utils.InterpretControlMode(result)
 		service.PrintOutput("Control Mode		: " + string(utils.InterpretControlMode(result)))
+
+		state, err := cmd.GetProvisioningState()
+		if err != nil {
+			log.Error(err)
+		}
+		configurationPending := state == provisioningStateInProvisioning
+		dataStruct["configurationPending"] = configurationPending
+		if configurationPending {
+			service.PrintOutput("Configuration Session	: pending")
+		}
 	}
 	if service.flags.AmtInfo.OpState {
 		result, err := cmd.GetChangeEnabled()
@@ -148,6 +163,19 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 		dataStruct["ras"] = result
 
 		service.PrintOutput("RAS Network      	: " + result.NetworkStatus)
+		// the domains are only known over WS-MAN, which needs the password
+		if service.flags.Password != "" {
+			service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, logrus.GetLevel() == logrus.TraceLevel)
+			usedWsman = true
+			response, err := service.interfacedWsmanMessage.GetEnvironmentDetectionSettings()
+			if err != nil {
+				log.Warn("Failed to read the environment detection domains: ", err)
+			} else {
+				domains := detectionDomains(response)
+				dataStruct["environmentDetectionDomains"] = domains
+				service.PrintOutput("RAS Domains      	: " + formatDetectionDomains(domains))
+			}
+		}
 		service.PrintOutput("RAS Remote Status	: " + result.RemoteStatus)
 		service.PrintOutput("RAS Trigger      	: " + result.RemoteTrigger)
 		service.PrintOutput("RAS MPS Hostname 	: " + result.MPSHostname)
@@ -183,6 +211,110 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 		service.PrintOutput("MAC Address  		: " + wireless.MACAddress)
 
 	}
+	if service.flags.AmtInfo.FeatureState {
+		result, err := cmd.GetFeaturesState()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["featuresState"] = result
+
+		service.PrintOutput("IDER Session Open	: " + strconv.FormatBool(result.IDERSessionOpen))
+		service.PrintOutput("SOL Session Open	: " + strconv.FormatBool(result.SOLSessionOpen))
+		service.PrintOutput("System Defense		: " + strconv.FormatBool(result.SystemDefenseActivated))
+		service.PrintOutput("Web UI Enabled		: " + strconv.FormatBool(result.WebUIEnabled))
+	}
+	if service.flags.AmtInfo.ResetReason {
+		result, err := cmd.GetLastHostResetReason()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["lastResetReason"] = result
+
+		service.PrintOutput("Last Reset Reason	: " + result.Reason)
+		if result.RemoteControlTimeStamp != 0 {
+			timestamp := time.Unix(int64(result.RemoteControlTimeStamp), 0).UTC().Format(time.RFC3339)
+			service.PrintOutput("Remote Reset Time	: " + timestamp)
+		}
+	}
+	if service.flags.AmtInfo.PowerPolicy {
+		result, err := cmd.GetCurrentPowerPolicy()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["powerPolicy"] = result
+		service.PrintOutput("Power Policy		: " + result)
+	}
+	if service.flags.AmtInfo.MAC {
+		result, err := cmd.GetMACAddresses()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["macAddresses"] = result
+
+		service.PrintOutput("Dedicated MAC		: " + result.DedicatedMACAddress)
+		service.PrintOutput("Host MAC		: " + result.HostMACAddress)
+	}
+	if service.flags.AmtInfo.Security {
+		result, err := cmd.GetSecurityParameters()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["securityParameters"] = result
+
+		service.PrintOutput("---Security Parameters---")
+		service.PrintOutput("Enterprise Mode		: " + strconv.FormatBool(result.EnterpriseMode))
+		service.PrintOutput("TLS Enabled		: " + strconv.FormatBool(result.TLSEnabled))
+		service.PrintOutput("HW Crypto Enabled	: " + strconv.FormatBool(result.HWCryptoEnabled))
+		service.PrintOutput("Provisioning State	: " + result.ProvisioningState)
+		service.PrintOutput("Network Enabled		: " + strconv.FormatBool(result.NetworkInterfaceEnabled))
+		service.PrintOutput("SOL Enabled		: " + strconv.FormatBool(result.SOLEnabled))
+		service.PrintOutput("IDER Enabled		: " + strconv.FormatBool(result.IDEREnabled))
+		service.PrintOutput("FW Update Enabled	: " + strconv.FormatBool(result.FWUpdateEnabled))
+		service.PrintOutput("Link Is Up		: " + strconv.FormatBool(result.LinkIsUp))
+	}
+	if service.flags.AmtInfo.TLSMode {
+		result, err := cmd.GetProvisioningTLSMode()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["provisioningTLSMode"] = result
+		service.PrintOutput("Provisioning TLS Mode	: " + result)
+	}
+	if service.flags.AmtInfo.ZeroTouch {
+		result, err := cmd.GetZeroTouchEnabled()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["zeroTouchEnabled"] = result
+		service.PrintOutput("Zero Touch Enabled	: " + strconv.FormatBool(result))
+	}
+	if service.flags.AmtInfo.FQDN {
+		result, err := cmd.GetFQDN()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["fqdn"] = result
+
+		service.PrintOutput("FQDN			: " + result.FQDN)
+		service.PrintOutput("Shared FQDN		: " + strconv.FormatBool(result.SharedFQDN))
+		service.PrintOutput("DDNS Update Enabled	: " + strconv.FormatBool(result.DDNSUpdateEnabled))
+	}
+	if service.flags.AmtInfo.EHBC {
+		result, err := cmd.GetEHBCState()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["ehbcEnabled"] = result
+		service.PrintOutput("EHBC Enabled		: " + strconv.FormatBool(result))
+	}
+	if service.flags.AmtInfo.DNSSuffixList {
+		result, err := cmd.GetDNSSuffixList()
+		if err != nil {
+			log.Error(err)
+		}
+		dataStruct["dnsSuffixList"] = result
+		service.PrintOutput("DNS Suffix List		: " + strings.Join(result, ", "))
+	}
 	if service.flags.AmtInfo.Cert {
 		result, err := cmd.GetCertificateHashes()
 		if err != nil {
@@ -215,10 +347,11 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 	}
 	if service.flags.AmtInfo.UserCert {
 		service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, logrus.GetLevel() == logrus.TraceLevel)
-		userCerts, _ := service.interfacedWsmanMessage.GetPublicKeyCerts()
+		usedWsman = true
+		info := service.readUserCertInfo()
 		userCertMap := map[string]publickey.PublicKeyCertificateResponse{}
-		for i := range userCerts {
-			c := userCerts[i]
+		for i := range info.publicKeyCerts {
+			c := info.publicKeyCerts[i]
 			name := GetTokenFromKeyValuePairs(c.Subject, "CN")
 			// CN is not required by spec, but should work
 			// just in case, provide something accurate
@@ -249,6 +382,12 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 		}
 	}
 
+	if usedWsman {
+		transport := service.interfacedWsmanMessage.Transport()
+		log.Debug("WS-MAN transport: ", transport)
+		dataStruct["transport"] = transport
+	}
+
 	if service.flags.JsonOutput {
 		outBytes, err := json.MarshalIndent(dataStruct, "", "  ")
 		output := string(outBytes)
@@ -259,6 +398,53 @@ func (service *ProvisioningService) DisplayAMTInfo() (err error) {
 	}
 	return nil
 }
+
+// userCertInfo is what amtinfo -userCert reads over WS-MAN
+type userCertInfo struct {
+	generalSettings  general.GeneralSettingsResponse
+	ethernetSettings []ethernetport.SettingsResponse
+	publicKeyCerts   []publickey.PublicKeyCertificateResponse
+}
+
+// readUserCertInfo makes the independent WS-MAN calls of -userCert at the
+// same time, over LME each gets its own APF channel. Only the certificates
+// are printed; the settings are read alongside them so -userCert keeps
+// several channels open at once. A failed call is logged and leaves its
+// part empty.
+func (service *ProvisioningService) readUserCertInfo() (info userCertInfo) {
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		response, err := service.interfacedWsmanMessage.GetGeneralSettings()
+		if err != nil {
+			log.Error("Failed to read the general settings: ", err)
+			return
+		}
+		info.generalSettings = response.Body.GetResponse
+	}()
+	go func() {
+		defer wg.Done()
+		settings, err := service.interfacedWsmanMessage.GetEthernetSettings()
+		if err != nil {
+			log.Error("Failed to read the ethernet settings: ", err)
+			return
+		}
+		info.ethernetSettings = settings
+	}()
+	go func() {
+		defer wg.Done()
+		certs, err := service.interfacedWsmanMessage.GetPublicKeyCerts()
+		if err != nil {
+			log.Error("Failed to read the public key certificates: ", err)
+			return
+		}
+		info.publicKeyCerts = certs
+	}()
+	wg.Wait()
+	return info
+}
+
 func (service *ProvisioningService) PrintOutput(message string) {
 	if !service.flags.JsonOutput {
 		fmt.Println(message)
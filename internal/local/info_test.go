@@ -11,7 +11,12 @@ import (
 	"rpc/pkg/utils"
 	"testing"
 
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/ethernetport"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/general"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publickey"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,6 +68,73 @@ func TestDisplayAMTInfo(t *testing.T) {
 		assert.Equal(t, nil, err)
 	})
 
+	t.Run("returns Success with PTHI only flags", func(t *testing.T) {
+		f := flags.NewFlags(nil, MockPRSuccess)
+		f.AmtInfo = flags.AmtInfoFlags{
+			FeatureState:  true,
+			ResetReason:   true,
+			PowerPolicy:   true,
+			MAC:           true,
+			Security:      true,
+			TLSMode:       true,
+			ZeroTouch:     true,
+			FQDN:          true,
+			EHBC:          true,
+			DNSSuffixList: true,
+		}
+		lps := setupService(f)
+		err := lps.DisplayAMTInfo()
+		assert.NoError(t, err)
+	})
+
+	t.Run("returns Success with PTHI only flags and json output", func(t *testing.T) {
+		f := flags.NewFlags(nil, MockPRSuccess)
+		f.AmtInfo.ResetReason = true
+		f.AmtInfo.PowerPolicy = true
+		f.AmtInfo.ZeroTouch = true
+		f.JsonOutput = true
+		mockPowerPolicyErr = errMockStandard
+		lps := setupService(f)
+		err := lps.DisplayAMTInfo()
+		assert.NoError(t, err)
+		mockPowerPolicyErr = nil
+	})
+
+	t.Run("returns Success with RAS domains when the password is given", func(t *testing.T) {
+		defer func() {
+			mockEnvironmentDetection = environmentdetection.Response{}
+			errGetEnvironmentDetection = nil
+		}()
+		f := flags.NewFlags(nil, MockPRSuccess)
+		f.AmtInfo.Ras = true
+		f.Password = "testPassword"
+		f.JsonOutput = true
+		mockEnvironmentDetection = environmentDetectionResponse("corp.example.com")
+		lps := setupService(f)
+		assert.NoError(t, lps.DisplayAMTInfo())
+
+		errGetEnvironmentDetection = errMockStandard
+		assert.NoError(t, lps.DisplayAMTInfo())
+	})
+
+	t.Run("logs the transport whenever WS-MAN is used", func(t *testing.T) {
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		level := logrus.GetLevel()
+		logrus.SetLevel(logrus.DebugLevel)
+		defer logrus.SetLevel(level)
+		f := flags.NewFlags(nil, MockPRSuccess)
+		f.AmtInfo.Ras = true
+		f.JsonOutput = true
+		lps := setupService(f)
+		assert.NoError(t, lps.DisplayAMTInfo())
+		assert.NotContains(t, hookMessages(hook), "WS-MAN transport: lms")
+
+		f.Password = "testPassword"
+		assert.NoError(t, lps.DisplayAMTInfo())
+		assert.Contains(t, hookMessages(hook), "WS-MAN transport: lms")
+	})
+
 	t.Run("returns Success with certs", func(t *testing.T) {
 		f := flags.NewFlags(nil, MockPRSuccess)
 		f.AmtInfo.Cert = true
@@ -145,6 +217,40 @@ func TestDisplayAMTInfo(t *testing.T) {
 	})
 }
 
+func hookMessages(hook *test.Hook) []string {
+	messages := []string{}
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
+
+func TestReadUserCertInfo(t *testing.T) {
+	defer func() {
+		mockGeneralSettings = general.Response{}
+		errMockGeneralSettings = nil
+		getEthernetSettingsResponse = []ethernetport.SettingsResponse{{}}
+		errGetEthernetSettings = nil
+	}()
+	mockGeneralSettings.Body.GetResponse = general.GeneralSettingsResponse{HostName: "host", DomainName: "vprodemo.com"}
+	getEthernetSettingsResponse = []ethernetport.SettingsResponse{{InstanceID: "Intel(r) AMT Ethernet Port Settings 0", IPAddress: "192.168.1.10"}}
+	lps := setupService(&flags.Flags{})
+
+	info := lps.readUserCertInfo()
+	assert.Equal(t, "vprodemo.com", info.generalSettings.DomainName)
+	assert.Equal(t, getEthernetSettingsResponse, info.ethernetSettings)
+	assert.Len(t, info.publicKeyCerts, 3)
+
+	t.Run("leaves out what could not be read", func(t *testing.T) {
+		errMockGeneralSettings = errMockStandard
+		errGetEthernetSettings = errMockStandard
+		info := lps.readUserCertInfo()
+		assert.Equal(t, "", info.generalSettings.HostName)
+		assert.Nil(t, info.ethernetSettings)
+		assert.Len(t, info.publicKeyCerts, 3)
+	})
+}
+
 func TestDecodeAMT(t *testing.T) {
 	testCases := []struct {
 		version string
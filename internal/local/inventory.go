@@ -0,0 +1,240 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+type InventorySystem struct {
+	PlatformGUID string `json:"platformGuid" yaml:"platformGuid"`
+}
+
+type InventoryChassis struct {
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	Model        string `json:"model" yaml:"model"`
+	SerialNumber string `json:"serialNumber" yaml:"serialNumber"`
+	Version      string `json:"version" yaml:"version"`
+	Tag          string `json:"tag" yaml:"tag"`
+	Type         string `json:"type" yaml:"type"`
+}
+
+type InventoryBoard struct {
+	Name         string `json:"name" yaml:"name"`
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	Model        string `json:"model" yaml:"model"`
+	SerialNumber string `json:"serialNumber" yaml:"serialNumber"`
+	Version      string `json:"version" yaml:"version"`
+	Tag          string `json:"tag" yaml:"tag"`
+}
+
+type InventoryBIOS struct {
+	Name         string `json:"name" yaml:"name"`
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	Version      string `json:"version" yaml:"version"`
+	ReleaseDate  string `json:"releaseDate" yaml:"releaseDate"`
+	Primary      bool   `json:"primary" yaml:"primary"`
+}
+
+type InventoryProcessor struct {
+	DeviceID             string `json:"deviceId" yaml:"deviceId"`
+	Role                 string `json:"role" yaml:"role"`
+	Family               int    `json:"family" yaml:"family"`
+	Stepping             string `json:"stepping" yaml:"stepping"`
+	MaxClockSpeedMHz     int    `json:"maxClockSpeedMHz" yaml:"maxClockSpeedMHz"`
+	CurrentClockSpeedMHz int    `json:"currentClockSpeedMHz" yaml:"currentClockSpeedMHz"`
+	UpgradeMethod        string `json:"upgradeMethod" yaml:"upgradeMethod"`
+	Status               string `json:"status" yaml:"status"`
+	HealthState          string `json:"healthState" yaml:"healthState"`
+}
+
+type InventoryMemory struct {
+	BankLabel          string `json:"bankLabel" yaml:"bankLabel"`
+	Manufacturer       string `json:"manufacturer" yaml:"manufacturer"`
+	PartNumber         string `json:"partNumber" yaml:"partNumber"`
+	SerialNumber       string `json:"serialNumber" yaml:"serialNumber"`
+	CapacityBytes      int    `json:"capacityBytes" yaml:"capacityBytes"`
+	MemoryType         string `json:"memoryType" yaml:"memoryType"`
+	FormFactor         int    `json:"formFactor" yaml:"formFactor"`
+	ConfiguredSpeedMHz int    `json:"configuredSpeedMHz" yaml:"configuredSpeedMHz"`
+	MaxSpeedMHz        int    `json:"maxSpeedMHz" yaml:"maxSpeedMHz"`
+}
+
+type InventoryStorage struct {
+	DeviceID       string `json:"deviceId" yaml:"deviceId"`
+	Name           string `json:"name" yaml:"name"`
+	MaxMediaSizeKB int    `json:"maxMediaSizeKB" yaml:"maxMediaSizeKB"`
+	Security       string `json:"security" yaml:"security"`
+	EnabledState   string `json:"enabledState" yaml:"enabledState"`
+}
+
+// Inventory is the document rpc inventory exports, one section per kind of
+// hardware
+type Inventory struct {
+	System     InventorySystem      `json:"system" yaml:"system"`
+	Chassis    []InventoryChassis   `json:"chassis" yaml:"chassis"`
+	Boards     []InventoryBoard     `json:"boards" yaml:"boards"`
+	BIOS       []InventoryBIOS      `json:"bios" yaml:"bios"`
+	Processors []InventoryProcessor `json:"processors" yaml:"processors"`
+	Memory     []InventoryMemory    `json:"memory" yaml:"memory"`
+	Storage    []InventoryStorage   `json:"storage" yaml:"storage"`
+}
+
+// Inventory reads the hardware inventory AMT reports through CIM and prints
+// it in the format chosen with -format
+func (service *ProvisioningService) Inventory() error {
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	hardware, err := service.interfacedWsmanMessage.GetHardwareInventory()
+	if err != nil {
+		log.Error("Failed to read the hardware inventory: ", err)
+		return utils.InventoryFailed
+	}
+	inventory := newInventory(hardware)
+	switch service.flags.Inventory.Format {
+	case flags.InventoryFormatYAML:
+		outBytes, err := yaml.Marshal(inventory)
+		if err != nil {
+			log.Error("Failed to encode the inventory: ", err)
+			return utils.InventoryFailed
+		}
+		fmt.Print(string(outBytes))
+	case flags.InventoryFormatCSV:
+		if err = writeInventoryCSV(csv.NewWriter(os.Stdout), inventory); err != nil {
+			log.Error("Failed to encode the inventory: ", err)
+			return utils.InventoryFailed
+		}
+	default:
+		outBytes, _ := json.MarshalIndent(inventory, "", "  ")
+		fmt.Println(string(outBytes))
+	}
+	return nil
+}
+
+func newInventory(hardware amt.HardwareInventory) Inventory {
+	inventory := Inventory{
+		System:     InventorySystem{PlatformGUID: hardware.PlatformGUID},
+		Chassis:    []InventoryChassis{},
+		Boards:     []InventoryBoard{},
+		BIOS:       []InventoryBIOS{},
+		Processors: []InventoryProcessor{},
+		Memory:     []InventoryMemory{},
+		Storage:    []InventoryStorage{},
+	}
+	for _, c := range hardware.Chassis {
+		inventory.Chassis = append(inventory.Chassis, InventoryChassis{
+			Manufacturer: c.Manufacturer,
+			Model:        c.Model,
+			SerialNumber: c.SerialNumber,
+			Version:      c.Version,
+			Tag:          c.Tag,
+			Type:         c.ChassisPackageType.String(),
+		})
+	}
+	for _, c := range hardware.Cards {
+		inventory.Boards = append(inventory.Boards, InventoryBoard{
+			Name:         c.ElementName,
+			Manufacturer: c.Manufacturer,
+			Model:        c.Model,
+			SerialNumber: c.SerialNumber,
+			Version:      c.Version,
+			Tag:          c.Tag,
+		})
+	}
+	for _, b := range hardware.BIOS {
+		inventory.BIOS = append(inventory.BIOS, InventoryBIOS{
+			Name:         b.Name,
+			Manufacturer: b.Manufacturer,
+			Version:      b.Version,
+			ReleaseDate:  b.ReleaseDate.DateTime,
+			Primary:      b.PrimaryBIOS,
+		})
+	}
+	for _, p := range hardware.Processors {
+		inventory.Processors = append(inventory.Processors, InventoryProcessor{
+			DeviceID:             p.DeviceID,
+			Role:                 p.Role,
+			Family:               p.Family,
+			Stepping:             p.Stepping,
+			MaxClockSpeedMHz:     p.MaxClockSpeed,
+			CurrentClockSpeedMHz: p.CurrentClockSpeed,
+			UpgradeMethod:        p.UpgradeMethod.String(),
+			Status:               p.CPUStatus.String(),
+			HealthState:          p.HealthState.String(),
+		})
+	}
+	for _, m := range hardware.Memory {
+		inventory.Memory = append(inventory.Memory, InventoryMemory{
+			BankLabel:          m.BankLabel,
+			Manufacturer:       m.Manufacturer,
+			PartNumber:         strings.TrimSpace(m.PartNumber),
+			SerialNumber:       m.SerialNumber,
+			CapacityBytes:      m.Capacity,
+			MemoryType:         m.MemoryType.String(),
+			FormFactor:         m.FormFactor,
+			ConfiguredSpeedMHz: m.ConfiguredMemoryClockSpeed,
+			MaxSpeedMHz:        m.MaxMemorySpeed,
+		})
+	}
+	for _, d := range hardware.Storage {
+		inventory.Storage = append(inventory.Storage, InventoryStorage{
+			DeviceID:       d.DeviceID,
+			Name:           d.ElementName,
+			MaxMediaSizeKB: d.MaxMediaSize,
+			Security:       d.Security.String(),
+			EnabledState:   d.EnabledState.String(),
+		})
+	}
+	return inventory
+}
+
+// writeInventoryCSV writes one row per property: the section, the index of
+// the entry in it, the property and its value. The names are those of the
+// JSON document so both exports read the same.
+func writeInventoryCSV(writer *csv.Writer, inventory Inventory) error {
+	rows := [][]string{{"section", "index", "property", "value"}}
+	document := reflect.ValueOf(inventory)
+	for i := 0; i < document.NumField(); i++ {
+		section := jsonName(document.Type().Field(i))
+		entries := document.Field(i)
+		if entries.Kind() != reflect.Slice {
+			rows = append(rows, inventoryRows(section, 0, entries)...)
+			continue
+		}
+		for index := 0; index < entries.Len(); index++ {
+			rows = append(rows, inventoryRows(section, index, entries.Index(index))...)
+		}
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func inventoryRows(section string, index int, entry reflect.Value) [][]string {
+	rows := [][]string{}
+	for i := 0; i < entry.NumField(); i++ {
+		value := fmt.Sprint(entry.Field(i).Interface())
+		rows = append(rows, []string{section, strconv.Itoa(index), jsonName(entry.Type().Field(i)), value})
+	}
+	return rows
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,78 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/bios"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/chassis"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/physical"
+	"github.com/stretchr/testify/assert"
+)
+
+var testHardwareInventory = amt.HardwareInventory{
+	PlatformGUID: "0B3C4A6E8F1D2E3F4A5B6C7D8E9F0A1B",
+	Chassis:      []chassis.PackageResponse{{Manufacturer: "Intel Corporation", SerialNumber: "CH123", ChassisPackageType: 3}},
+	BIOS:         []bios.BiosElement{{Version: "TNTGL357.0064", PrimaryBIOS: true, ReleaseDate: bios.Time{DateTime: "2022-06-14T00:00:00Z"}}},
+	Memory: []physical.PhysicalMemory{
+		{BankLabel: "BANK 0", PartNumber: "M471A1K43DB1-CWE   ", Capacity: 8589934592},
+		{BankLabel: "BANK 2", Capacity: 8589934592},
+	},
+}
+
+func TestInventory(t *testing.T) {
+	defer func() {
+		mockHardwareInventory = amt.HardwareInventory{}
+		errHardwareInventory = nil
+	}()
+	mockHardwareInventory = testHardwareInventory
+	for _, format := range []string{flags.InventoryFormatJSON, flags.InventoryFormatYAML, flags.InventoryFormatCSV} {
+		t.Run("prints "+format, func(t *testing.T) {
+			f := &flags.Flags{}
+			f.Inventory.Format = format
+			service := setupService(f)
+			assert.NoError(t, service.Inventory())
+		})
+	}
+	t.Run("fails when the inventory cannot be read", func(t *testing.T) {
+		errHardwareInventory = errors.New("unreachable")
+		f := &flags.Flags{}
+		f.Inventory.Format = flags.InventoryFormatJSON
+		service := setupService(f)
+		assert.Equal(t, utils.InventoryFailed, service.Inventory())
+	})
+}
+
+func TestNewInventory(t *testing.T) {
+	inventory := newInventory(testHardwareInventory)
+	assert.Equal(t, "0B3C4A6E8F1D2E3F4A5B6C7D8E9F0A1B", inventory.System.PlatformGUID)
+	assert.Equal(t, "CH123", inventory.Chassis[0].SerialNumber)
+	assert.Equal(t, "2022-06-14T00:00:00Z", inventory.BIOS[0].ReleaseDate)
+	assert.Equal(t, "M471A1K43DB1-CWE", inventory.Memory[0].PartNumber)
+	assert.Len(t, inventory.Memory, 2)
+	assert.Empty(t, inventory.Boards)
+	assert.NotNil(t, inventory.Boards)
+}
+
+func TestWriteInventoryCSV(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, writeInventoryCSV(csv.NewWriter(buffer), newInventory(testHardwareInventory)))
+	rows, err := csv.NewReader(buffer).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"section", "index", "property", "value"}, rows[0])
+	assert.Contains(t, rows, []string{"system", "0", "platformGuid", "0B3C4A6E8F1D2E3F4A5B6C7D8E9F0A1B"})
+	assert.Contains(t, rows, []string{"chassis", "0", "serialNumber", "CH123"})
+	assert.Contains(t, rows, []string{"bios", "0", "primary", "true"})
+	assert.Contains(t, rows, []string{"memory", "1", "bankLabel", "BANK 2"})
+	assert.Contains(t, rows, []string{"memory", "1", "capacityBytes", "8589934592"})
+}
@@ -0,0 +1,78 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"rpc/internal/lm"
+	"rpc/pkg/utils"
+	"strconv"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// lmsListen opens the local ports that are forwarded to AMT
+var lmsListen = net.Listen
+
+// notifyLMSStop delivers the signals that stop forwarding
+var notifyLMSStop = func(c chan<- os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+}
+
+// LMS listens on the AMT ports and tunnels every connection to AMT over its
+// own LME channel, standing in for Intel LMS until it is stopped
+func (service *ProvisioningService) LMS() error {
+	lme := lm.NewLMEConnection(make(chan []byte), make(chan error), make(chan bool))
+	if err := lme.Initialize(); err != nil {
+		log.Error("Failed to connect to LME: ", err)
+		var customErr utils.CustomError
+		if errors.As(err, &customErr) {
+			return customErr
+		}
+		return utils.LMSForwardingFailed
+	}
+	defer lme.Close()
+	announced := lme.ForwardedPorts()
+	log.Debug("AMT asked to forward ports ", announced)
+
+	forwardErrors := make(chan error, len(service.flags.LMS.Ports))
+	for _, port := range service.flags.LMS.Ports {
+		isAnnounced := false
+		for _, announcedPort := range announced {
+			isAnnounced = isAnnounced || announcedPort == port
+		}
+		if !isAnnounced {
+			log.Debug("AMT did not announce port ", port, ", it may refuse connections to it")
+		}
+		address := net.JoinHostPort(service.flags.LMS.ListenAddress, strconv.Itoa(int(port)))
+		listener, err := lmsListen("tcp", address)
+		if err != nil {
+			log.Error("Failed to listen on ", address, ": ", err)
+			return utils.LMSForwardingFailed
+		}
+		defer listener.Close()
+		log.Info("Forwarding ", listener.Addr(), " to AMT port ", port)
+		go func(listener net.Listener, port uint32) {
+			forwardErrors <- lme.Forward(listener, port)
+		}(listener, port)
+	}
+
+	stop := make(chan os.Signal, 1)
+	notifyLMSStop(stop)
+	defer signal.Stop(stop)
+	select {
+	case sig := <-stop:
+		log.Info("Received ", sig, ", stopping port forwarding")
+		return nil
+	case err := <-forwardErrors:
+		log.Error("Port forwarding stopped: ", err)
+		return utils.LMSForwardingFailed
+	}
+}
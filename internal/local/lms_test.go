@@ -0,0 +1,82 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"rpc/internal/flags"
+	"rpc/pkg/heci"
+	"rpc/pkg/pthi"
+	"rpc/pkg/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupLMSService(t *testing.T, ports ...uint32) ProvisioningService {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	assert.NoError(t, os.WriteFile(stateFile, []byte(`{"wsmanResponse":"<a:Envelope></a:Envelope>"}`), 0600))
+	t.Setenv(pthi.EmulatorEnvVar, stateFile)
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+	f := &flags.Flags{}
+	f.Command = utils.CommandLMS
+	f.LMS = flags.LMSFlags{ListenAddress: "127.0.0.1", Ports: ports}
+	return setupService(f)
+}
+
+func TestLMS(t *testing.T) {
+	listen := lmsListen
+	notifyStop := notifyLMSStop
+	defer func() {
+		lmsListen = listen
+		notifyLMSStop = notifyStop
+	}()
+
+	t.Run("forwards connections until stopped", func(t *testing.T) {
+		service := setupLMSService(t, 16992)
+		listening := make(chan net.Listener, 1)
+		lmsListen = func(network, address string) (net.Listener, error) {
+			assert.Equal(t, "127.0.0.1:16992", address)
+			// any free port stands in for the AMT port
+			listener, err := net.Listen(network, "127.0.0.1:0")
+			listening <- listener
+			return listener, err
+		}
+		stop := make(chan struct{})
+		notifyLMSStop = func(c chan<- os.Signal) {
+			go func() {
+				<-stop
+				c <- os.Interrupt
+			}()
+		}
+		result := make(chan error)
+		go func() { result <- service.LMS() }()
+
+		listener := <-listening
+		response, err := http.Post("http://"+listener.Addr().String()+"/wsman", "application/soap+xml", strings.NewReader("<a:Envelope/>"))
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, "<a:Envelope></a:Envelope>", string(body))
+
+		close(stop)
+		assert.NoError(t, <-result)
+		_, err = net.Dial("tcp", listener.Addr().String())
+		assert.Error(t, err)
+	})
+	t.Run("fails when a port cannot be opened", func(t *testing.T) {
+		service := setupLMSService(t, 16992)
+		lmsListen = func(network, address string) (net.Listener, error) {
+			return nil, assert.AnError
+		}
+		assert.Equal(t, utils.LMSForwardingFailed, service.LMS())
+	})
+}
@@ -0,0 +1,280 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"syscall"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/auditlog"
+	log "github.com/sirupsen/logrus"
+)
+
+// notifyLogsStop delivers the signals that end logs -follow
+var notifyLogsStop = func(c chan<- os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+}
+
+type EventLogEntry struct {
+	Time        time.Time `json:"time"`
+	Severity    string    `json:"severity"`
+	SensorType  string    `json:"sensorType"`
+	Entity      string    `json:"entity"`
+	Description string    `json:"description"`
+	EventData   string    `json:"eventData"`
+}
+
+type AuditLogEntry struct {
+	Time       time.Time `json:"time"`
+	AppID      int       `json:"appId"`
+	App        string    `json:"app"`
+	EventID    int       `json:"eventId"`
+	Event      string    `json:"event"`
+	Initiator  string    `json:"initiator"`
+	NetAddress string    `json:"netAddress,omitempty"`
+}
+
+type AuditedEvent struct {
+	AppID   int    `json:"appId"`
+	App     string `json:"app"`
+	EventID int    `json:"eventId"`
+	Event   string `json:"event"`
+}
+
+type AuditPolicy struct {
+	AuditedEvents []AuditedEvent `json:"auditedEvents"`
+}
+
+// logEntry is a record of either log, reduced to what Logs needs to filter
+// and print it
+type logEntry struct {
+	time   time.Time
+	text   string
+	record interface{}
+}
+
+func (e logEntry) key() string {
+	return e.time.String() + e.text
+}
+
+// logFollower reads the event or audit log and remembers where it stopped,
+// so each read of -follow only asks AMT for records newer than the last one
+type logFollower struct {
+	service *ProvisioningService
+	audit   bool
+	// newest is the time of the newest record read, starting at -since, and
+	// seen holds the records at that time, a new record can share its second
+	newest time.Time
+	seen   map[string]bool
+	// auditIndex is the index of the newest audit log record read
+	auditIndex int
+}
+
+func newLogFollower(service *ProvisioningService) *logFollower {
+	return &logFollower{
+		service: service,
+		audit:   service.flags.SubCommand == utils.SubCommandAudit,
+		newest:  service.flags.Logs.Since,
+		seen:    map[string]bool{},
+	}
+}
+
+// read returns the records from -since on that an earlier read did not return
+func (f *logFollower) read() ([]logEntry, error) {
+	var entries []logEntry
+	var err error
+	if f.audit {
+		entries, err = f.readAuditLog()
+	} else {
+		entries, err = f.service.readEventLog(f.newest)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fresh := []logEntry{}
+	for _, entry := range entries {
+		if entry.time.Before(f.newest) || f.seen[entry.key()] {
+			continue
+		}
+		if entry.time.After(f.newest) {
+			f.newest = entry.time
+			f.seen = map[string]bool{}
+		}
+		f.seen[entry.key()] = true
+		fresh = append(fresh, entry)
+	}
+	return fresh, nil
+}
+
+// readAuditLog reads the audit log from the newest record read on. When that
+// record is no longer at its index the log was cleared or wrapped around, so
+// it is read again from the start.
+func (f *logFollower) readAuditLog() ([]logEntry, error) {
+	if f.auditIndex > 0 {
+		entries, last, err := f.service.readAuditLog(f.auditIndex)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 && f.seen[entries[0].key()] {
+			f.auditIndex = last
+			return entries, nil
+		}
+	}
+	entries, last, err := f.service.readAuditLog(1)
+	if err != nil {
+		return nil, err
+	}
+	f.auditIndex = last
+	return entries, nil
+}
+
+// Logs prints the records of the event or audit log, and keeps printing new
+// ones with -follow. logs audit -configure shows or changes the audit policy.
+func (service *ProvisioningService) Logs() error {
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	if service.flags.Logs.Configure {
+		return service.configureAuditPolicy()
+	}
+	follower := newLogFollower(service)
+	entries, err := follower.read()
+	if err != nil {
+		log.Error("Failed to read the ", service.flags.SubCommand, " log: ", err)
+		return utils.LogReadFailed
+	}
+	service.printLogEntries(entries)
+	if !service.flags.Logs.Follow {
+		return nil
+	}
+	stop := make(chan os.Signal, 1)
+	notifyLogsStop(stop)
+	defer signal.Stop(stop)
+	ticker := time.NewTicker(service.flags.Logs.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case sig := <-stop:
+			log.Info("Received ", sig, ", stopping")
+			return nil
+		case <-ticker.C:
+			entries, err := follower.read()
+			if err != nil {
+				log.Warn("Failed to read the ", service.flags.SubCommand, " log, retrying: ", err)
+				continue
+			}
+			service.printLogEntries(entries)
+		}
+	}
+}
+
+func (service *ProvisioningService) printLogEntries(entries []logEntry) {
+	for _, entry := range entries {
+		if service.flags.JsonOutput {
+			outBytes, _ := json.Marshal(entry.record)
+			fmt.Println(string(outBytes))
+		} else {
+			fmt.Println(entry.time.Format(time.RFC3339) + "  " + entry.text)
+		}
+	}
+}
+
+func (service *ProvisioningService) readEventLog(since time.Time) ([]logEntry, error) {
+	records, err := service.interfacedWsmanMessage.GetEventLogRecords(since)
+	if err != nil {
+		return nil, err
+	}
+	entries := []logEntry{}
+	for _, record := range records {
+		entry := EventLogEntry{
+			Time:        record.Time,
+			Severity:    record.Severity(),
+			SensorType:  record.SensorType(),
+			Entity:      record.EntityName(),
+			Description: record.Description(),
+			EventData:   hex.EncodeToString(record.EventData),
+		}
+		text := fmt.Sprintf("%-15s %-26s %-20s %s", entry.Severity, entry.SensorType, entry.Entity, entry.Description)
+		entries = append(entries, logEntry{time: record.Time, text: text, record: entry})
+	}
+	return entries, nil
+}
+
+// readAuditLog returns the audit log records from startIndex on and the index
+// of the last one
+func (service *ProvisioningService) readAuditLog(startIndex int) ([]logEntry, int, error) {
+	records, last, err := service.interfacedWsmanMessage.GetAuditLogRecords(startIndex)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []logEntry{}
+	for _, record := range records {
+		entry := AuditLogEntry{
+			Time:       record.Time.UTC(),
+			AppID:      record.AuditAppID,
+			App:        record.AuditApp,
+			EventID:    record.EventID,
+			Event:      record.Event,
+			Initiator:  record.Initiator,
+			NetAddress: record.NetAddress,
+		}
+		text := fmt.Sprintf("%-24s %-40s %s %s", entry.App, fmt.Sprintf("%s (%d:%d)", entry.Event, entry.AppID, entry.EventID), entry.Initiator, entry.NetAddress)
+		entries = append(entries, logEntry{time: entry.Time, text: text, record: entry})
+	}
+	return entries, last, nil
+}
+
+// configureAuditPolicy enables and disables the audited events given with
+// -enable and -disable, then shows the events AMT audits
+func (service *ProvisioningService) configureAuditPolicy() error {
+	changes := []struct {
+		ids    []flags.AuditEventID
+		enable bool
+	}{
+		{service.flags.Logs.Enable, true},
+		{service.flags.Logs.Disable, false},
+	}
+	for _, change := range changes {
+		for _, id := range change.ids {
+			event := amt.AuditedEvent{AppID: id.AppID, EventID: id.EventID}
+			if err := service.interfacedWsmanMessage.SetAuditPolicy(event, change.enable); err != nil {
+				log.Error("Failed to change auditing of ", id.AppID, ":", id.EventID, ": ", err)
+				return utils.AuditPolicyConfigurationFailed
+			}
+		}
+	}
+	events, err := service.interfacedWsmanMessage.GetAuditPolicy()
+	if err != nil {
+		log.Error("Failed to read the audit policy: ", err)
+		return utils.AuditPolicyConfigurationFailed
+	}
+	policy := AuditPolicy{AuditedEvents: []AuditedEvent{}}
+	for _, event := range events {
+		appID, eventID := int(event.AppID), int(event.EventID)
+		policy.AuditedEvents = append(policy.AuditedEvents, AuditedEvent{
+			AppID:   appID,
+			App:     auditlog.AMTAuditStringTable[appID],
+			EventID: eventID,
+			Event:   auditlog.AMTAuditStringTable[appID*100+eventID],
+		})
+	}
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(policy, "", "  ")
+		fmt.Println(string(outBytes))
+		return nil
+	}
+	service.PrintOutput("Audited Events:")
+	for _, event := range policy.AuditedEvents {
+		service.PrintOutput(fmt.Sprintf("  %-7s %-24s %s", fmt.Sprintf("%d:%d", event.AppID, event.EventID), event.App, event.Event))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"os"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/auditlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetLogsMocks() {
+	mockEventLogRecords = []amt.EventLogRecord{}
+	mockEventLogSince = time.Time{}
+	errEventLogRecords = nil
+	mockAuditLogRecords = []auditlog.AuditLogRecord{}
+	mockAuditLogStartIndexes = []int{}
+	errAuditLogRecords = nil
+	mockAuditPolicy = []amt.AuditedEvent{{AppID: 16, EventID: 0}}
+	errGetAuditPolicy = nil
+	mockAuditPolicyChanges = map[amt.AuditedEvent]bool{}
+	errSetAuditPolicy = nil
+}
+
+func TestLogs(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	t.Run("prints the event log", func(t *testing.T) {
+		defer resetLogsMocks()
+		mockEventLogRecords = []amt.EventLogRecord{{Time: start, EventSensorType: 15, EventOffset: 2, EventData: []byte{0, 0x13}}}
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandEvents, JsonOutput: true})
+		assert.NoError(t, service.Logs())
+	})
+	t.Run("prints the audit log", func(t *testing.T) {
+		defer resetLogsMocks()
+		mockAuditLogRecords = []auditlog.AuditLogRecord{{Time: start, AuditAppID: 16, AuditApp: "Security Admin", Initiator: "Local"}}
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandAudit})
+		assert.NoError(t, service.Logs())
+	})
+	t.Run("fails when the log cannot be read", func(t *testing.T) {
+		defer resetLogsMocks()
+		errAuditLogRecords = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandAudit})
+		assert.Equal(t, utils.LogReadFailed, service.Logs())
+	})
+	t.Run("follows until stopped", func(t *testing.T) {
+		defer resetLogsMocks()
+		notifyStop := notifyLogsStop
+		defer func() { notifyLogsStop = notifyStop }()
+		notifyLogsStop = func(c chan<- os.Signal) { c <- os.Interrupt }
+		f := &flags.Flags{SubCommand: utils.SubCommandEvents}
+		f.Logs.Follow = true
+		f.Logs.Interval = time.Millisecond
+		service := setupService(f)
+		assert.NoError(t, service.Logs())
+	})
+	t.Run("configures the audit policy", func(t *testing.T) {
+		defer resetLogsMocks()
+		f := &flags.Flags{SubCommand: utils.SubCommandAudit}
+		f.Logs.Configure = true
+		f.Logs.Enable = []flags.AuditEventID{{AppID: 19, EventID: 0}}
+		f.Logs.Disable = []flags.AuditEventID{{AppID: 16, EventID: 1}}
+		service := setupService(f)
+		assert.NoError(t, service.Logs())
+		assert.Equal(t, map[amt.AuditedEvent]bool{{AppID: 19, EventID: 0}: true, {AppID: 16, EventID: 1}: false}, mockAuditPolicyChanges)
+	})
+	t.Run("fails when AMT refuses the audit policy", func(t *testing.T) {
+		defer resetLogsMocks()
+		errSetAuditPolicy = errors.New("refused")
+		f := &flags.Flags{SubCommand: utils.SubCommandAudit}
+		f.Logs.Configure = true
+		f.Logs.Enable = []flags.AuditEventID{{AppID: 19, EventID: 0}}
+		service := setupService(f)
+		assert.Equal(t, utils.AuditPolicyConfigurationFailed, service.Logs())
+	})
+}
+
+func TestLogFollower(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	t.Run("reads the event log from the newest record on", func(t *testing.T) {
+		defer resetLogsMocks()
+		f := &flags.Flags{SubCommand: utils.SubCommandEvents}
+		f.Logs.Since = start
+		service := setupService(f)
+		follower := newLogFollower(&service)
+		mockEventLogRecords = []amt.EventLogRecord{
+			{Time: start.Add(-time.Minute), EventSensorType: 15},
+			{Time: start, EventSensorType: 15},
+			{Time: start.Add(time.Minute), EventSensorType: 15},
+		}
+		entries, err := follower.read()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, start, mockEventLogSince)
+
+		// a record in the same second as the newest one is still new
+		mockEventLogRecords = append(mockEventLogRecords[2:], amt.EventLogRecord{Time: start.Add(time.Minute), EventSensorType: 6})
+		entries, err = follower.read()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, start.Add(time.Minute), mockEventLogSince)
+
+		entries, err = follower.read()
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+	t.Run("reads the audit log from the newest record on", func(t *testing.T) {
+		defer resetLogsMocks()
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandAudit})
+		follower := newLogFollower(&service)
+		mockAuditLogRecords = []auditlog.AuditLogRecord{
+			{Time: start, AuditAppID: 16, AuditApp: "Security Admin"},
+			{Time: start.Add(time.Minute), AuditAppID: 16, AuditApp: "Security Admin", EventID: 1},
+		}
+		entries, err := follower.read()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 2)
+
+		mockAuditLogRecords = append(mockAuditLogRecords, auditlog.AuditLogRecord{Time: start.Add(2 * time.Minute), AuditAppID: 19, AuditApp: "Network Time"})
+		entries, err = follower.read()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, 19, entries[0].record.(AuditLogEntry).AppID)
+		assert.Equal(t, []int{1, 2}, mockAuditLogStartIndexes)
+	})
+	t.Run("reads the audit log again after it was cleared", func(t *testing.T) {
+		defer resetLogsMocks()
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandAudit})
+		follower := newLogFollower(&service)
+		mockAuditLogRecords = []auditlog.AuditLogRecord{
+			{Time: start, AuditAppID: 16, AuditApp: "Security Admin"},
+			{Time: start.Add(time.Minute), AuditAppID: 16, AuditApp: "Security Admin", EventID: 1},
+		}
+		_, err := follower.read()
+		assert.NoError(t, err)
+
+		mockAuditLogRecords = []auditlog.AuditLogRecord{{Time: start.Add(2 * time.Minute), AuditAppID: 16, AuditApp: "Security Admin", EventID: 20}}
+		entries, err := follower.read()
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, []int{1, 2, 1}, mockAuditLogStartIndexes)
+	})
+}
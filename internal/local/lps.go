@@ -36,6 +36,11 @@ func NewProvisioningService(flags *flags.Flags) ProvisioningService {
 		Host:   utils.LMSAddress + ":" + utils.LMSPort,
 		Path:   "/wsman",
 	}
+	tlsOptions := amt.TLSOptions{
+		Pin:      flags.TLSPin,
+		CABundle: flags.TLSCABundle,
+		TOFU:     flags.TLSTOFU,
+	}
 	return ProvisioningService{
 		flags:                  flags,
 		serverURL:              serverURL,
@@ -43,7 +48,7 @@ func NewProvisioningService(flags *flags.Flags) ProvisioningService {
 		amtCommand:             internalAMT.NewAMTCommand(),
 		handlesWithCerts:       make(map[string]string),
 		networker:              &RealOSNetworker{},
-		interfacedWsmanMessage: amt.NewGoWSMANMessages(flags.LMSAddress),
+		interfacedWsmanMessage: amt.NewGoWSMANMessages(flags.LMSAddress, flags.LMSPort, flags.Transport, tlsOptions),
 	}
 
 }
@@ -51,6 +56,11 @@ func NewProvisioningService(flags *flags.Flags) ProvisioningService {
 func ExecuteCommand(flags *flags.Flags) error {
 	var err error
 	service := NewProvisioningService(flags)
+	if flags.Target != "" {
+		if err := service.useTarget(flags.Target); err != nil {
+			return err
+		}
+	}
 	switch flags.Command {
 	case utils.CommandActivate:
 		err = service.Activate()
@@ -60,11 +70,38 @@ func ExecuteCommand(flags *flags.Flags) error {
 		err = service.Deactivate()
 	case utils.CommandConfigure:
 		err = service.Configure()
+	case utils.CommandMaintenance:
+		err = service.ConfigurationSession()
 	case utils.CommandVersion:
 		err = service.DisplayVersion()
+	case utils.CommandCIRA:
+		err = service.CIRA()
+	case utils.CommandWatchdog:
+		err = service.Watchdog()
+	case utils.CommandLMS:
+		err = service.LMS()
+	case utils.CommandPower:
+		err = service.Power()
+	case utils.CommandBoot:
+		err = service.Boot()
+	case utils.CommandLogs:
+		err = service.Logs()
+	case utils.CommandInventory:
+		err = service.Inventory()
+	case utils.CommandConsent:
+		err = service.Consent()
 	}
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// amtError returns the AMT PT status behind err so the exit code tells the
+// firmware's reason apart, or fallback when the call failed for another reason
+func amtError(err error, fallback utils.CustomError) error {
+	if status, ok := utils.AmtPtStatus(err); ok {
+		return status
+	}
+	return fallback
+}
@@ -11,24 +11,35 @@ import (
 	"net/http"
 	amt2 "rpc/internal/amt"
 	"rpc/internal/flags"
+	"rpc/internal/local/amt"
 	"rpc/pkg/utils"
 	"testing"
 	"time"
 
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/auditlog"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/boot"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/environmentdetection"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/ethernetport"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/general"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/managementpresence"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publickey"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/publicprivate"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/redirection"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/setupandconfiguration"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/timesynchronization"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/tls"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/userinitiatedconnection"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/wifiportconfiguration"
+	cimBoot "github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/boot"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/concrete"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/credential"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/kvm"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/models"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/service"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/software"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/wifi"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/common"
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/hostbasedsetup"
@@ -102,6 +113,47 @@ func (m MockWSMAN) PutIpsOptInService(request optin.OptInServiceRequest) (respon
 	return PutIpsOptInServiceResponse, PutIpsOptInServiceError
 }
 
+var mockStartOptInValue = 0
+var errStartOptIn error = nil
+
+func (m MockWSMAN) StartOptIn() (response optin.Response, err error) {
+	response.Body.StartOptInResponse.ReturnValue = mockStartOptInValue
+	return response, errStartOptIn
+}
+
+var mockOptInCode int
+var mockSendOptInCodeValue = 0
+var errSendOptInCode error = nil
+
+func (m MockWSMAN) SendOptInCode(code int) (response optin.Response, err error) {
+	mockOptInCode = code
+	response.Body.SendOptInCodeResponse.ReturnValue = mockSendOptInCodeValue
+	return response, errSendOptInCode
+}
+
+var mockCancelOptInValue = 0
+var errCancelOptIn error = nil
+
+func (m MockWSMAN) CancelOptIn() (response optin.Response, err error) {
+	response.Body.CancelOptInResponse.ReturnValue = mockCancelOptInValue
+	return response, errCancelOptIn
+}
+
+var mockEnvironmentDetection = environmentdetection.Response{}
+var errGetEnvironmentDetection error = nil
+
+func (m MockWSMAN) GetEnvironmentDetectionSettings() (response environmentdetection.Response, err error) {
+	return mockEnvironmentDetection, errGetEnvironmentDetection
+}
+
+var mockPutEnvironmentDetection environmentdetection.EnvironmentDetectionSettingDataRequest
+var errPutEnvironmentDetection error = nil
+
+func (m MockWSMAN) PutEnvironmentDetectionSettings(request environmentdetection.EnvironmentDetectionSettingDataRequest) (response environmentdetection.Response, err error) {
+	mockPutEnvironmentDetection = request
+	return response, errPutEnvironmentDetection
+}
+
 var mockGetRedirectionServiceError error = nil
 var mockGetRedirectionServiceResponse redirection.Response
 
@@ -271,6 +323,189 @@ func (m MockWSMAN) SetupMEBX(password string) (setupandconfiguration.Response, e
 
 func (m MockWSMAN) SetupWsmanClient(username string, password string, logAMTMessages bool) {}
 
+func (m MockWSMAN) Transport() string { return "lms" }
+func (m MockWSMAN) PinName() string   { return amt.LocalPinName }
+
+var mockPlatformGUID = "c7fd9a2356a3374bb2c7c60dbb3f1a6e"
+var errPlatformGUID error = nil
+
+func (m MockWSMAN) GetPlatformGUID() (string, error) {
+	return mockPlatformGUID, errPlatformGUID
+}
+
+var mockSoftwareIdentities = []software.SoftwareIdentity{
+	{InstanceID: "AMT", VersionString: "16.1.25"},
+	{InstanceID: "Build Number", VersionString: "2049"},
+	{InstanceID: "Sku", VersionString: "16392"},
+}
+var errSoftwareIdentities error = nil
+
+func (m MockWSMAN) GetSoftwareIdentities() ([]software.SoftwareIdentity, error) {
+	return mockSoftwareIdentities, errSoftwareIdentities
+}
+
+var mockPowerState = service.CIM_AssociatedPowerManagementService{PowerState: service.PowerStateOn}
+var errGetPowerState error = nil
+
+func (m MockWSMAN) GetPowerState() (service.CIM_AssociatedPowerManagementService, error) {
+	return mockPowerState, errGetPowerState
+}
+
+var mockPowerStateChangeValue = power.ReturnValueCompletedWithNoError
+var errPowerStateChange error = nil
+var mockRequestedPowerState power.PowerState
+
+func (m MockWSMAN) RequestPowerStateChange(powerState power.PowerState) (power.Response, error) {
+	mockRequestedPowerState = powerState
+	response := power.Response{}
+	response.Body.RequestPowerStateChangeResponse.ReturnValue = mockPowerStateChangeValue
+	return response, errPowerStateChange
+}
+
+var mockBootCapabilities = boot.BootCapabilitiesResponse{ForcePXEBoot: true, ForceHardDriveBoot: true, ForceCDorDVDBoot: true, IDER: true, ForceUEFIHTTPSBoot: true}
+var errBootCapabilities error = nil
+
+func (m MockWSMAN) GetBootCapabilities() (boot.BootCapabilitiesResponse, error) {
+	return mockBootCapabilities, errBootCapabilities
+}
+
+var mockBootSettingData = boot.BootSettingDataResponse{UEFIHTTPSBootEnabled: true}
+var errBootSettingData error = nil
+
+func (m MockWSMAN) GetBootSettingData() (boot.BootSettingDataResponse, error) {
+	return mockBootSettingData, errBootSettingData
+}
+
+var mockBootOptions amt.BootOptions
+var errSetBootSettingData error = nil
+
+func (m MockWSMAN) SetBootSettingData(options amt.BootOptions) error {
+	mockBootOptions = options
+	return errSetBootSettingData
+}
+
+var mockBootConfigRoleValue = cimBoot.ReturnValueCompletedNoError
+var errSetBootConfigRole error = nil
+
+func (m MockWSMAN) SetBootConfigRole(role int) (cimBoot.Response, error) {
+	response := cimBoot.Response{}
+	response.Body.SetBootConfigRole_OUTPUT.ReturnValue = mockBootConfigRoleValue
+	return response, errSetBootConfigRole
+}
+
+var mockBootSource cimBoot.Source
+var mockChangeBootOrderValue = cimBoot.ReturnValueCompletedNoError
+var errChangeBootOrder error = nil
+
+func (m MockWSMAN) ChangeBootOrder(source cimBoot.Source) (cimBoot.Response, error) {
+	mockBootSource = source
+	response := cimBoot.Response{}
+	response.Body.ChangeBootOrder_OUTPUT.ReturnValue = mockChangeBootOrderValue
+	return response, errChangeBootOrder
+}
+
+var mockEventLogRecords = []amt.EventLogRecord{}
+var mockEventLogSince time.Time
+var errEventLogRecords error = nil
+
+func (m MockWSMAN) GetEventLogRecords(since time.Time) ([]amt.EventLogRecord, error) {
+	mockEventLogSince = since
+	return mockEventLogRecords, errEventLogRecords
+}
+
+var mockAuditLogRecords = []auditlog.AuditLogRecord{}
+var mockAuditLogStartIndexes = []int{}
+var errAuditLogRecords error = nil
+
+// GetAuditLogRecords returns mockAuditLogRecords as a log whose first record
+// has index 1
+func (m MockWSMAN) GetAuditLogRecords(startIndex int) ([]auditlog.AuditLogRecord, int, error) {
+	mockAuditLogStartIndexes = append(mockAuditLogStartIndexes, startIndex)
+	if startIndex > len(mockAuditLogRecords) {
+		return []auditlog.AuditLogRecord{}, startIndex - 1, errAuditLogRecords
+	}
+	return mockAuditLogRecords[startIndex-1:], len(mockAuditLogRecords), errAuditLogRecords
+}
+
+var mockAuditPolicy = []amt.AuditedEvent{{AppID: 16, EventID: 0}}
+var errGetAuditPolicy error = nil
+
+func (m MockWSMAN) GetAuditPolicy() ([]amt.AuditedEvent, error) {
+	return mockAuditPolicy, errGetAuditPolicy
+}
+
+var mockAuditPolicyChanges = map[amt.AuditedEvent]bool{}
+var errSetAuditPolicy error = nil
+
+func (m MockWSMAN) SetAuditPolicy(event amt.AuditedEvent, enable bool) error {
+	mockAuditPolicyChanges[event] = enable
+	return errSetAuditPolicy
+}
+
+var mockHardwareInventory = amt.HardwareInventory{}
+var errHardwareInventory error = nil
+
+func (m MockWSMAN) GetHardwareInventory() (amt.HardwareInventory, error) {
+	return mockHardwareInventory, errHardwareInventory
+}
+
+var mockAlarms = []amt.Alarm{}
+var errGetAlarms error = nil
+
+func (m MockWSMAN) GetAlarms() ([]amt.Alarm, error) {
+	return mockAlarms, errGetAlarms
+}
+
+var mockAddedAlarm amt.Alarm
+var errAddAlarm error = nil
+
+func (m MockWSMAN) AddAlarm(alarm amt.Alarm) error {
+	mockAddedAlarm = alarm
+	return errAddAlarm
+}
+
+var mockDeletedAlarm string
+var errDeleteAlarm error = nil
+
+func (m MockWSMAN) DeleteAlarm(name string) error {
+	mockDeletedAlarm = name
+	return errDeleteAlarm
+}
+
+var mockUsers = []amt.UserACLEntry{}
+var errGetUsers error = nil
+
+func (m MockWSMAN) GetUserACLEntries() ([]amt.UserACLEntry, error) {
+	return mockUsers, errGetUsers
+}
+
+var mockAddedUser amt.UserACLEntry
+var mockUserDigestPassword string
+var errAddUser error = nil
+
+func (m MockWSMAN) AddUserACLEntry(entry amt.UserACLEntry, digestPassword string) (int, error) {
+	mockAddedUser = entry
+	mockUserDigestPassword = digestPassword
+	return 1, errAddUser
+}
+
+var mockUpdatedUser amt.UserACLEntry
+var errUpdateUser error = nil
+
+func (m MockWSMAN) UpdateUserACLEntry(entry amt.UserACLEntry, digestPassword string) error {
+	mockUpdatedUser = entry
+	mockUserDigestPassword = digestPassword
+	return errUpdateUser
+}
+
+var mockRemovedUser int
+var errRemoveUser error = nil
+
+func (m MockWSMAN) RemoveUserACLEntry(handle int) error {
+	mockRemovedUser = handle
+	return errRemoveUser
+}
+
 var mockGeneralSettings = general.Response{}
 var errMockGeneralSettings error = nil
 
@@ -331,8 +566,10 @@ func (m MockWSMAN) DeletePublicPrivateKeyPair(instanceId string) error {
 }
 
 var errDeletePublicCert error = nil
+var mockDeletedPublicCert string
 
 func (m MockWSMAN) DeletePublicCert(instanceId string) error {
+	mockDeletedPublicCert = instanceId
 	return errDeletePublicCert
 }
 
@@ -581,8 +818,10 @@ func (m MockWSMAN) DeleteWiFiSetting(instanceId string) error {
 }
 
 var errAddTrustedRootCert error = nil
+var mockAddedTrustedRootCert string
 
 func (m MockWSMAN) AddTrustedRootCert(caCert string) (string, error) {
+	mockAddedTrustedRootCert = caCert
 	return "rootCertHandle", errAddTrustedRootCert
 }
 
@@ -628,6 +867,125 @@ func (m MockWSMAN) PutEthernetSettings(ethernetport.SettingsRequest, string) (et
 	return putEthernetResponse, nil
 }
 
+var mockMPSSAPErr error = nil
+var mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{}
+
+func (m MockWSMAN) GetMPSSAP() ([]managementpresence.ManagementRemoteResponse, error) {
+	return mockMPSSAPResponse, mockMPSSAPErr
+}
+
+var mockRemoteAccessPoliciesErr error = nil
+var mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{}
+
+func (m MockWSMAN) GetRemoteAccessPolicies() ([]remoteaccess.RemoteAccessPolicyRuleResponse, error) {
+	return mockRemoteAccessPoliciesResponse, mockRemoteAccessPoliciesErr
+}
+
+var mockPolicyAppliesToMPSErr error = nil
+var mockPolicyAppliesToMPSResponse = []remoteaccess.RemoteAccessPolicyAppliesToMPSResponse{}
+
+func (m MockWSMAN) GetRemoteAccessPolicyAppliesToMPS() ([]remoteaccess.RemoteAccessPolicyAppliesToMPSResponse, error) {
+	return mockPolicyAppliesToMPSResponse, mockPolicyAppliesToMPSErr
+}
+
+var mockAddRemoteAccessPolicyRuleErr error = nil
+var mockAddRemoteAccessPolicyRuleResponse remoteaccess.Response
+var mockRemoteAccessPolicyRulesAdded = 0
+var mockAddedRemoteAccessPolicyRules = []remoteaccess.RemoteAccessPolicyRuleRequest{}
+
+func (m MockWSMAN) AddRemoteAccessPolicyRule(remoteAccessPolicyRule remoteaccess.RemoteAccessPolicyRuleRequest, mpsName string) (remoteaccess.Response, error) {
+	mockRemoteAccessPolicyRulesAdded++
+	mockAddedRemoteAccessPolicyRules = append(mockAddedRemoteAccessPolicyRules, remoteAccessPolicyRule)
+	return mockAddRemoteAccessPolicyRuleResponse, mockAddRemoteAccessPolicyRuleErr
+}
+
+var mockAddedMPS remoteaccess.AddMpServerRequest
+var mockAddMPSResponse remoteaccess.Response
+var errAddMPS error = nil
+
+func (m MockWSMAN) AddMPS(mpServer remoteaccess.AddMpServerRequest) (remoteaccess.Response, error) {
+	mockAddedMPS = mpServer
+	return mockAddMPSResponse, errAddMPS
+}
+
+var mockDeletedMPSSAPs = []string{}
+var errDeleteMPSSAP error = nil
+
+func (m MockWSMAN) DeleteMPSSAP(name string) error {
+	mockDeletedMPSSAPs = append(mockDeletedMPSSAPs, name)
+	return errDeleteMPSSAP
+}
+
+var mockDeletedRemoteAccessPolicyRules = []string{}
+var errDeleteRemoteAccessPolicyRule error = nil
+
+func (m MockWSMAN) DeleteRemoteAccessPolicyRule(policyRuleName string) error {
+	mockDeletedRemoteAccessPolicyRules = append(mockDeletedRemoteAccessPolicyRules, policyRuleName)
+	return errDeleteRemoteAccessPolicyRule
+}
+
+var mockUserInitiatedConnectionService = userinitiatedconnection.Response{}
+var errGetUserInitiatedConnectionService error = nil
+
+func (m MockWSMAN) GetUserInitiatedConnectionService() (userinitiatedconnection.Response, error) {
+	return mockUserInitiatedConnectionService, errGetUserInitiatedConnectionService
+}
+
+var mockRequestedUserInitiatedState userinitiatedconnection.RequestedState
+var mockUserInitiatedStateChangeValue = 0
+var errUserInitiatedStateChange error = nil
+
+func (m MockWSMAN) RequestUserInitiatedConnectionStateChange(requestedState userinitiatedconnection.RequestedState) (response userinitiatedconnection.Response, err error) {
+	mockRequestedUserInitiatedState = requestedState
+	response.Body.RequestStateChange_OUTPUT.ReturnValue = userinitiatedconnection.ReturnValue(mockUserInitiatedStateChangeValue)
+	return response, errUserInitiatedStateChange
+}
+
+var mockAgentPresenceWatchdogsErr error = nil
+var mockAgentPresenceWatchdogs = []amt.AgentPresenceWatchdog{}
+
+func (m MockWSMAN) GetAgentPresenceWatchdogs() ([]amt.AgentPresenceWatchdog, error) {
+	return mockAgentPresenceWatchdogs, mockAgentPresenceWatchdogsErr
+}
+
+var mockCreateAgentPresenceWatchdogErr error = nil
+
+func (m MockWSMAN) CreateAgentPresenceWatchdog(deviceID string, timeout, recovery uint16) error {
+	return mockCreateAgentPresenceWatchdogErr
+}
+
+var mockDeleteAgentPresenceWatchdogErr error = nil
+var mockAgentPresenceWatchdogsDeleted = 0
+
+func (m MockWSMAN) DeleteAgentPresenceWatchdog(deviceID string) error {
+	mockAgentPresenceWatchdogsDeleted++
+	return mockDeleteAgentPresenceWatchdogErr
+}
+
+var mockAddAgentPresenceWatchdogActionErr error = nil
+
+func (m MockWSMAN) AddAgentPresenceWatchdogAction(deviceID string, oldState, newState amt.WatchdogState) error {
+	return mockAddAgentPresenceWatchdogActionErr
+}
+
+func (m MockWSMAN) DeleteAllAgentPresenceWatchdogActions(deviceID string) error {
+	return nil
+}
+
+var mockRegisterAgentPresenceWatchdogErr error = nil
+
+func (m MockWSMAN) RegisterAgentPresenceWatchdog(deviceID string) (uint32, error) {
+	return 1, mockRegisterAgentPresenceWatchdogErr
+}
+
+var mockAssertAgentPresenceShutdownErr error = nil
+var mockAgentPresenceShutdownSequence uint32 = 0
+
+func (m MockWSMAN) AssertAgentPresenceShutdown(deviceID string, sequenceNumber uint32) error {
+	mockAgentPresenceShutdownSequence = sequenceNumber
+	return mockAssertAgentPresenceShutdownErr
+}
+
 // Mock the AMT Hardware
 type MockAMT struct{}
 
@@ -643,10 +1001,11 @@ func (c MockAMT) Initialize() error {
 	return nil
 }
 
+var mockVersionData = "Version"
 var mockVersionDataErr error = nil
 
 func (c MockAMT) GetVersionDataFromME(key string, amtTimeout time.Duration) (string, error) {
-	return "Version", mockVersionDataErr
+	return mockVersionData, mockVersionDataErr
 }
 func (c MockAMT) GetChangeEnabled() (amt2.ChangeEnabledResponse, error) {
 	return mockChangeEnabledResponse, errMockChangeEnabled
@@ -733,6 +1092,124 @@ var mockUnprovisionErr error = nil
 
 func (c MockAMT) Unprovision() (int, error) { return mockUnprovisionCode, mockUnprovisionErr }
 
+var mockFeaturesState = amt2.FeaturesState{SOLSessionOpen: true}
+var mockFeaturesStateErr error = nil
+
+func (c MockAMT) GetFeaturesState() (amt2.FeaturesState, error) {
+	return mockFeaturesState, mockFeaturesStateErr
+}
+
+var mockHostResetReason = amt2.HostResetReason{Reason: "other"}
+var mockHostResetReasonErr error = nil
+
+func (c MockAMT) GetLastHostResetReason() (amt2.HostResetReason, error) {
+	return mockHostResetReason, mockHostResetReasonErr
+}
+
+var mockPowerPolicy = "Always on (S0-S5)"
+var mockPowerPolicyErr error = nil
+
+func (c MockAMT) GetCurrentPowerPolicy() (string, error) { return mockPowerPolicy, mockPowerPolicyErr }
+
+var mockMACAddresses = amt2.MACAddresses{DedicatedMACAddress: "00:00:00:00:00:00", HostMACAddress: "01:02:03:04:05:06"}
+var mockMACAddressesErr error = nil
+
+func (c MockAMT) GetMACAddresses() (amt2.MACAddresses, error) {
+	return mockMACAddresses, mockMACAddressesErr
+}
+
+var mockSecurityParameters = amt2.SecurityParameters{ProvisioningState: "pre-provisioning"}
+var mockSecurityParametersErr error = nil
+
+func (c MockAMT) GetSecurityParameters() (amt2.SecurityParameters, error) {
+	return mockSecurityParameters, mockSecurityParametersErr
+}
+
+var mockProvisioningTLSMode = "pki"
+var mockProvisioningTLSModeErr error = nil
+
+func (c MockAMT) GetProvisioningTLSMode() (string, error) {
+	return mockProvisioningTLSMode, mockProvisioningTLSModeErr
+}
+
+var mockZeroTouchEnabled = true
+var mockZeroTouchEnabledErr error = nil
+
+func (c MockAMT) GetZeroTouchEnabled() (bool, error) {
+	return mockZeroTouchEnabled, mockZeroTouchEnabledErr
+}
+
+var mockFQDN = amt2.FQDN{FQDN: "host.dns.org"}
+var mockFQDNErr error = nil
+
+func (c MockAMT) GetFQDN() (amt2.FQDN, error) { return mockFQDN, mockFQDNErr }
+
+var mockEHBCState = false
+var mockEHBCStateErr error = nil
+
+func (c MockAMT) GetEHBCState() (bool, error) { return mockEHBCState, mockEHBCStateErr }
+
+var mockDNSSuffixList = []string{"dns.org"}
+var mockDNSSuffixListErr error = nil
+
+func (c MockAMT) GetDNSSuffixList() ([]string, error) { return mockDNSSuffixList, mockDNSSuffixListErr }
+
+var mockSetDNSSuffixErr error = nil
+var mockSetDNSSuffixCalledWith = ""
+
+func (c MockAMT) SetDNSSuffix(suffix string) error {
+	mockSetDNSSuffixCalledWith = suffix
+	return mockSetDNSSuffixErr
+}
+
+var mockSetHostFQDNErr error = nil
+
+func (c MockAMT) SetHostFQDN(string) error { return mockSetHostFQDNErr }
+
+var mockProvisioningState = 0
+var mockProvisioningStateErr error = nil
+
+func (c MockAMT) GetProvisioningState() (int, error) {
+	return mockProvisioningState, mockProvisioningStateErr
+}
+
+var mockStopConfigurationErr error = nil
+
+func (c MockAMT) StopConfiguration() error { return mockStopConfigurationErr }
+
+var mockStartConfigurationErr error = nil
+var mockStartConfigurationOTP = ""
+
+func (c MockAMT) StartConfiguration(otp string) error {
+	mockStartConfigurationOTP = otp
+	return mockStartConfigurationErr
+}
+
+var mockOpenUserInitiatedConnectionErr error = nil
+
+func (c MockAMT) OpenUserInitiatedConnection() error { return mockOpenUserInitiatedConnectionErr }
+
+var mockCloseUserInitiatedConnectionErr error = nil
+
+func (c MockAMT) CloseUserInitiatedConnection() error { return mockCloseUserInitiatedConnectionErr }
+
+var mockWatchdogRequired = true
+var mockWatchdogHeartbeatErr error = nil
+var mockWatchdogHeartbeats = 0
+
+func (c MockAMT) SendWatchdogHeartbeat(timeout uint16) (bool, error) {
+	mockWatchdogHeartbeats++
+	return mockWatchdogRequired, mockWatchdogHeartbeatErr
+}
+
+var mockStopWatchdogTimerErr error = nil
+var mockWatchdogStopped = false
+
+func (c MockAMT) StopWatchdogTimer() error {
+	mockWatchdogStopped = true
+	return mockStopWatchdogTimerErr
+}
+
 type ResponseFuncArray []func(w http.ResponseWriter, r *http.Request)
 
 func setupService(f *flags.Flags) ProvisioningService {
@@ -16,7 +16,7 @@ func (service *ProvisioningService) SetMebx() (err error) {
 	controlMode, err := service.amtCommand.GetControlMode()
 	if err != nil {
 		log.Error("Failed to get control mode:", err)
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 
 	// Check if the control mode is ACM (Admin Control Mode)
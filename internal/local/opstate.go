@@ -26,7 +26,7 @@ func (service *ProvisioningService) CheckAndEnableAMT(skipIPRenewal bool) error
 	rsp, err := service.amtCommand.GetChangeEnabled()
 	if err != nil {
 		log.Error(err)
-		return utils.AMTConnectionFailed
+		return amtError(err, utils.AMTConnectionFailed)
 	}
 	if !rsp.IsNewInterfaceVersion() {
 		log.Debug("this AMT version does not support SetAmtOperationalState")
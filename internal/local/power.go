@@ -0,0 +1,82 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/pkg/utils"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	log "github.com/sirupsen/logrus"
+)
+
+// powerActions maps the power subcommands to CIM_PowerManagementService
+// power states
+var powerActions = map[string]power.PowerState{
+	utils.SubCommandPowerOn:    power.PowerOn,
+	utils.SubCommandPowerOff:   power.PowerOffHard,
+	utils.SubCommandReset:      power.MasterBusReset,
+	utils.SubCommandPowerCycle: power.PowerCycleOffHard,
+	utils.SubCommandSoftOff:    power.PowerOffSoft,
+	utils.SubCommandHibernate:  power.Hibernate,
+}
+
+type PowerStatus struct {
+	PowerState      string `json:"powerState"`
+	PowerStateValue int    `json:"powerStateValue"`
+	Action          string `json:"action,omitempty"`
+	Transport       string `json:"transport"`
+}
+
+// Power shows the power state of the device and, unless the subcommand is
+// status, first asks AMT to change it
+func (service *ProvisioningService) Power() error {
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	action := service.flags.SubCommand
+	if action != utils.SubCommandStatus {
+		powerState, ok := powerActions[action]
+		if !ok {
+			return utils.IncorrectCommandLineParameters
+		}
+		response, err := service.interfacedWsmanMessage.RequestPowerStateChange(powerState)
+		if err != nil {
+			log.Error("Failed to request the power state change: ", err)
+			return utils.PowerStateChangeFailed
+		}
+		result := response.Body.RequestPowerStateChangeResponse.ReturnValue
+		if result != power.ReturnValueCompletedWithNoError {
+			log.Error("AMT refused the power state change: ", result.String())
+			return utils.PowerStateChangeFailed
+		}
+		log.Info("Requested power ", action)
+	} else {
+		action = ""
+	}
+	state, err := service.interfacedWsmanMessage.GetPowerState()
+	if err != nil {
+		log.Error("Failed to get the power state: ", err)
+		return utils.AMTConnectionFailed
+	}
+	service.printPowerStatus(PowerStatus{
+		PowerState:      state.PowerState.String(),
+		PowerStateValue: int(state.PowerState),
+		Action:          action,
+		Transport:       service.interfacedWsmanMessage.Transport(),
+	})
+	return nil
+}
+
+func (service *ProvisioningService) printPowerStatus(status PowerStatus) {
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	service.PrintOutput(fmt.Sprintf("Power State     	: %s (%d)", status.PowerState, status.PowerStateValue))
+	service.PrintOutput("Transport       	: " + strings.ToUpper(status.Transport))
+}
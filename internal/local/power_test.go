@@ -0,0 +1,60 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/cim/power"
+	"github.com/stretchr/testify/assert"
+)
+
+func resetPowerMocks() {
+	mockPowerStateChangeValue = power.ReturnValueCompletedWithNoError
+	errPowerStateChange = nil
+	errGetPowerState = nil
+	mockRequestedPowerState = 0
+}
+
+func TestPower(t *testing.T) {
+	t.Run("shows the power state", func(t *testing.T) {
+		defer resetPowerMocks()
+		f := &flags.Flags{SubCommand: utils.SubCommandStatus, JsonOutput: true}
+		service := setupService(f)
+		assert.NoError(t, service.Power())
+		assert.Equal(t, power.PowerState(0), mockRequestedPowerState)
+	})
+	t.Run("maps the actions to power states", func(t *testing.T) {
+		defer resetPowerMocks()
+		for action, state := range powerActions {
+			f := &flags.Flags{SubCommand: action}
+			service := setupService(f)
+			assert.NoError(t, service.Power())
+			assert.Equal(t, state, mockRequestedPowerState)
+		}
+	})
+	t.Run("fails when AMT refuses the change", func(t *testing.T) {
+		defer resetPowerMocks()
+		mockPowerStateChangeValue = power.ReturnValueInvalidStateTransition
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandReset})
+		assert.Equal(t, utils.PowerStateChangeFailed, service.Power())
+	})
+	t.Run("fails when the request fails", func(t *testing.T) {
+		defer resetPowerMocks()
+		errPowerStateChange = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandPowerOff})
+		assert.Equal(t, utils.PowerStateChangeFailed, service.Power())
+	})
+	t.Run("fails when the state cannot be read", func(t *testing.T) {
+		defer resetPowerMocks()
+		errGetPowerState = errors.New("unreachable")
+		service := setupService(&flags.Flags{SubCommand: utils.SubCommandStatus})
+		assert.Equal(t, utils.AMTConnectionFailed, service.Power())
+	})
+}
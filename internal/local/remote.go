@@ -0,0 +1,224 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	internalAMT "rpc/internal/amt"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// remoteCommand stands in for the host interface (PTHI) when rpc manages a
+// remote target. The device UUID, control mode, firmware versions and
+// unprovisioning come from their WS-MAN equivalents; everything else only
+// exists on the host and fails with NotAvailableOnRemoteTarget.
+type remoteCommand struct {
+	wsman   amt.WSMANer
+	flags   *flags.Flags
+	connect bool
+}
+
+func newRemoteCommand(wsman amt.WSMANer, flags *flags.Flags) *remoteCommand {
+	return &remoteCommand{wsman: wsman, flags: flags}
+}
+
+// useTarget points the service at the AMT at target, host[:port], instead of
+// the one on this host
+func (service *ProvisioningService) useTarget(target string) error {
+	tlsOptions := amt.TLSOptions{
+		Pin:      service.flags.TLSPin,
+		CABundle: service.flags.TLSCABundle,
+		TOFU:     service.flags.TLSTOFU,
+	}
+	wsman, err := amt.NewTargetWSMANMessages(target, tlsOptions)
+	if err != nil {
+		log.Error(err)
+		return utils.IncorrectCommandLineParameters
+	}
+	service.serverURL.Host = target
+	service.interfacedWsmanMessage = wsman
+	service.amtCommand = newRemoteCommand(wsman, service.flags)
+	return nil
+}
+
+// setup connects the WS-MAN client as admin the first time it is needed,
+// asking for the AMT password if it was not given
+func (r *remoteCommand) setup() error {
+	if r.connect {
+		return nil
+	}
+	if r.flags.Password == "" {
+		if err := r.flags.ReadPasswordFromUser(); err != nil {
+			return utils.MissingOrIncorrectPassword
+		}
+	}
+	r.wsman.SetupWsmanClient("admin", r.flags.Password, log.GetLevel() == log.TraceLevel)
+	r.connect = true
+	return nil
+}
+
+// hostOnly logs and returns the error for a request that needs the host
+func hostOnly(request string) error {
+	log.Error(request, " is not available with -target, it needs the host interface of the device")
+	return utils.NotAvailableOnRemoteTarget
+}
+
+func (r *remoteCommand) Initialize() error {
+	return r.setup()
+}
+
+func (r *remoteCommand) GetVersionDataFromME(key string, amtTimeout time.Duration) (string, error) {
+	if err := r.setup(); err != nil {
+		return "", err
+	}
+	identities, err := r.wsman.GetSoftwareIdentities()
+	if err != nil {
+		return "", err
+	}
+	for _, identity := range identities {
+		if identity.InstanceID == key {
+			return identity.VersionString, nil
+		}
+	}
+	return "", fmt.Errorf("AMT does not report %s", key)
+}
+
+func (r *remoteCommand) GetUUID() (string, error) {
+	if err := r.setup(); err != nil {
+		return "", err
+	}
+	guid, err := r.wsman.GetPlatformGUID()
+	if err != nil {
+		return "", err
+	}
+	raw, err := hex.DecodeString(guid)
+	if err != nil || len(raw) != 16 {
+		return "", errors.New("invalid platform GUID " + guid)
+	}
+	return internalAMT.UUIDString(raw), nil
+}
+
+func (r *remoteCommand) GetControlMode() (int, error) {
+	if err := r.setup(); err != nil {
+		return -1, err
+	}
+	response, err := r.wsman.GetHostBasedSetupService()
+	if err != nil {
+		return -1, err
+	}
+	return int(response.Body.GetResponse.CurrentControlMode), nil
+}
+
+// GetProvisioningState reports a device that answers with a control mode as
+// provisioned, a remote target is reached with its admin credentials
+func (r *remoteCommand) GetProvisioningState() (int, error) {
+	mode, err := r.GetControlMode()
+	if err != nil {
+		return -1, err
+	}
+	if mode == 0 {
+		return 0, nil
+	}
+	return provisioningStatePostProvisioning, nil
+}
+
+// Unprovision fully unprovisions the target, in client control mode WS-MAN
+// takes the same request as in admin control mode
+func (r *remoteCommand) Unprovision() (int, error) {
+	if err := r.setup(); err != nil {
+		return -1, err
+	}
+	response, err := r.wsman.Unprovision(1)
+	if err != nil {
+		return -1, err
+	}
+	return int(response.Body.Unprovision_OUTPUT.ReturnValue), nil
+}
+
+func (r *remoteCommand) GetChangeEnabled() (internalAMT.ChangeEnabledResponse, error) {
+	return 0, hostOnly("AMT operational state")
+}
+func (r *remoteCommand) EnableAMT() error  { return hostOnly("Enabling AMT") }
+func (r *remoteCommand) DisableAMT() error { return hostOnly("Disabling AMT") }
+func (r *remoteCommand) GetOSDNSSuffix() (string, error) {
+	return "", hostOnly("The OS DNS suffix")
+}
+func (r *remoteCommand) GetDNSSuffix() (string, error) {
+	return "", hostOnly("The DNS suffix")
+}
+func (r *remoteCommand) GetCertificateHashes() ([]internalAMT.CertHashEntry, error) {
+	return nil, hostOnly("Reading the certificate hashes")
+}
+func (r *remoteCommand) GetRemoteAccessConnectionStatus() (internalAMT.RemoteAccessStatus, error) {
+	return internalAMT.RemoteAccessStatus{}, hostOnly("The remote access status")
+}
+func (r *remoteCommand) GetLANInterfaceSettings(useWireless bool) (internalAMT.InterfaceSettings, error) {
+	return internalAMT.InterfaceSettings{}, hostOnly("Reading the LAN settings")
+}
+func (r *remoteCommand) GetLocalSystemAccount() (internalAMT.LocalSystemAccount, error) {
+	return internalAMT.LocalSystemAccount{}, hostOnly("The local system account")
+}
+func (r *remoteCommand) GetFeaturesState() (internalAMT.FeaturesState, error) {
+	return internalAMT.FeaturesState{}, hostOnly("The feature state")
+}
+func (r *remoteCommand) GetLastHostResetReason() (internalAMT.HostResetReason, error) {
+	return internalAMT.HostResetReason{}, hostOnly("The last host reset reason")
+}
+func (r *remoteCommand) GetCurrentPowerPolicy() (string, error) {
+	return "", hostOnly("The power policy")
+}
+func (r *remoteCommand) GetMACAddresses() (internalAMT.MACAddresses, error) {
+	return internalAMT.MACAddresses{}, hostOnly("Reading the MAC addresses")
+}
+func (r *remoteCommand) GetSecurityParameters() (internalAMT.SecurityParameters, error) {
+	return internalAMT.SecurityParameters{}, hostOnly("Reading the security parameters")
+}
+func (r *remoteCommand) GetProvisioningTLSMode() (string, error) {
+	return "", hostOnly("The provisioning TLS mode")
+}
+func (r *remoteCommand) GetZeroTouchEnabled() (bool, error) {
+	return false, hostOnly("The zero touch state")
+}
+func (r *remoteCommand) GetFQDN() (internalAMT.FQDN, error) {
+	return internalAMT.FQDN{}, hostOnly("Reading the FQDN")
+}
+func (r *remoteCommand) GetEHBCState() (bool, error) {
+	return false, hostOnly("The EHBC state")
+}
+func (r *remoteCommand) GetDNSSuffixList() ([]string, error) {
+	return nil, hostOnly("The DHCP DNS suffix list")
+}
+func (r *remoteCommand) SetDNSSuffix(suffix string) error {
+	return hostOnly("Setting the DNS suffix")
+}
+func (r *remoteCommand) SetHostFQDN(fqdn string) error {
+	return hostOnly("Setting the host FQDN")
+}
+func (r *remoteCommand) StopConfiguration() error {
+	return hostOnly("Stopping a configuration session")
+}
+func (r *remoteCommand) StartConfiguration(otp string) error {
+	return hostOnly("Starting a configuration session")
+}
+func (r *remoteCommand) OpenUserInitiatedConnection() error {
+	return hostOnly("Opening a CIRA connection")
+}
+func (r *remoteCommand) CloseUserInitiatedConnection() error {
+	return hostOnly("Closing a CIRA connection")
+}
+func (r *remoteCommand) SendWatchdogHeartbeat(timeout uint16) (bool, error) {
+	return false, hostOnly("The watchdog heartbeat")
+}
+func (r *remoteCommand) StopWatchdogTimer() error {
+	return hostOnly("Stopping the watchdog")
+}
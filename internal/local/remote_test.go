@@ -0,0 +1,100 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/ips/hostbasedsetup"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteCommand(t *testing.T) {
+	f := &flags.Flags{Password: "P@ssw0rd"}
+	cmd := newRemoteCommand(MockWSMAN{}, f)
+
+	t.Run("reads the UUID from the platform GUID", func(t *testing.T) {
+		uuid, err := cmd.GetUUID()
+		assert.NoError(t, err)
+		assert.Equal(t, "239afdc7-a356-4b37-b2c7-c60dbb3f1a6e", uuid)
+	})
+	t.Run("rejects an invalid platform GUID", func(t *testing.T) {
+		orig := mockPlatformGUID
+		mockPlatformGUID = "c7fd"
+		defer func() { mockPlatformGUID = orig }()
+		_, err := cmd.GetUUID()
+		assert.Error(t, err)
+	})
+	t.Run("reads versions from the software identities", func(t *testing.T) {
+		version, err := cmd.GetVersionDataFromME("AMT", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "16.1.25", version)
+		sku, err := cmd.GetVersionDataFromME("Sku", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, "16392", sku)
+		_, err = cmd.GetVersionDataFromME("Flash", 0)
+		assert.Error(t, err)
+	})
+	t.Run("reads the control mode and provisioning state", func(t *testing.T) {
+		orig := mockGetHostBasedSetupService
+		defer func() { mockGetHostBasedSetupService = orig }()
+		mockGetHostBasedSetupService.Body.GetResponse.CurrentControlMode = hostbasedsetup.CurrentControlMode(1)
+		mode, err := cmd.GetControlMode()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, mode)
+		state, err := cmd.GetProvisioningState()
+		assert.NoError(t, err)
+		assert.Equal(t, provisioningStatePostProvisioning, state)
+
+		mockGetHostBasedSetupService.Body.GetResponse.CurrentControlMode = 0
+		state, err = cmd.GetProvisioningState()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, state)
+	})
+	t.Run("passes on WS-MAN errors", func(t *testing.T) {
+		errGetHostBasedSetupService = errors.New("unreachable")
+		defer func() { errGetHostBasedSetupService = nil }()
+		_, err := cmd.GetControlMode()
+		assert.Error(t, err)
+	})
+	t.Run("unprovisions over WS-MAN", func(t *testing.T) {
+		status, err := cmd.Unprovision()
+		assert.NoError(t, err)
+		assert.Equal(t, mockACMUnprovisionValue, status)
+	})
+	t.Run("refuses host interface requests", func(t *testing.T) {
+		_, err := cmd.GetCertificateHashes()
+		assert.Equal(t, utils.NotAvailableOnRemoteTarget, err)
+		assert.Equal(t, utils.NotAvailableOnRemoteTarget, cmd.StartConfiguration("otp"))
+	})
+}
+
+func TestRemoteCommandPassword(t *testing.T) {
+	f := flags.NewFlags(nil, MockPRSuccess)
+	cmd := newRemoteCommand(MockWSMAN{}, f)
+	assert.NoError(t, cmd.Initialize())
+	assert.Equal(t, utils.TestPassword, f.Password)
+
+	f = flags.NewFlags(nil, MockPRFail)
+	cmd = newRemoteCommand(MockWSMAN{}, f)
+	assert.Equal(t, utils.MissingOrIncorrectPassword, cmd.Initialize())
+}
+
+func TestUseTarget(t *testing.T) {
+	f := &flags.Flags{Target: "192.168.1.20:16993", TLSTOFU: true}
+	service := NewProvisioningService(f)
+	assert.NoError(t, service.useTarget(f.Target))
+	assert.Equal(t, "192.168.1.20:16993", service.serverURL.Host)
+	assert.Equal(t, amt.TransportNetwork, service.interfacedWsmanMessage.Transport())
+	assert.Equal(t, "192.168.1.20", service.interfacedWsmanMessage.PinName())
+	assert.IsType(t, &remoteCommand{}, service.amtCommand)
+
+	assert.Equal(t, utils.IncorrectCommandLineParameters, service.useTarget("192.168.1.20:0"))
+}
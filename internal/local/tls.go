@@ -6,9 +6,11 @@
 package local
 
 import (
+	"encoding/base64"
 	"os"
 	"rpc/internal/certs"
 	"rpc/internal/flags"
+	"rpc/internal/local/amt"
 	"rpc/pkg/utils"
 	"strings"
 
@@ -132,6 +134,11 @@ func (service *ProvisioningService) ConfigureTLSWithEA() error {
 	if err != nil {
 		return err
 	}
+	if der, decodeErr := base64.StdEncoding.DecodeString(eaResponse.Response.Certificate); decodeErr == nil {
+		service.pinTLSCertificate(amt.Fingerprint(der))
+	} else {
+		log.Warn("unable to pin the AMT TLS certificate: ", decodeErr)
+	}
 	return nil
 }
 
@@ -183,9 +190,20 @@ func (service *ProvisioningService) ConfigureTLSWithSelfSignedCert() error {
 	if err != nil {
 		return err
 	}
+	service.pinTLSCertificate(clientComposite.Fingerprint)
 	return nil
 }
 
+// pinTLSCertificate remembers the certificate rpc gave AMT, so later
+// commands trust it once they switch to TLS
+func (service *ProvisioningService) pinTLSCertificate(fingerprint string) {
+	if err := amt.SaveTLSPin(service.interfacedWsmanMessage.PinName(), fingerprint); err != nil {
+		log.Warn("unable to pin the AMT TLS certificate: ", err)
+		return
+	}
+	log.Info("pinned AMT TLS certificate ", fingerprint)
+}
+
 func (service *ProvisioningService) GetDERKey(handles Handles) (derKey string, err error) {
 	var keyPairs []publicprivate.PublicPrivateKeyPair
 	keyPairs, err = service.interfacedWsmanMessage.GetPublicPrivateKeyPairs()
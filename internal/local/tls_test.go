@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"rpc/internal/flags"
+	"rpc/internal/local/amt"
 	"rpc/pkg/utils"
 	"strings"
 	"testing"
@@ -62,6 +64,7 @@ var publicPrivateKeyPair = []publicprivate.PublicPrivateKeyPair{
 }
 
 func TestConfigureTLSWithEA(t *testing.T) {
+	t.Setenv(amt.TLSPinFileEnvVar, filepath.Join(t.TempDir(), "tls-pins.json"))
 	tests := []struct {
 		name               string
 		setupMocks         func(*MockWSMAN)
@@ -158,6 +161,8 @@ func TestConfigureTLSWithEA(t *testing.T) {
 }
 
 func TestConfigureTLSWithSelfSignedCert(t *testing.T) {
+	pinFile := filepath.Join(t.TempDir(), "tls-pins.json")
+	t.Setenv(amt.TLSPinFileEnvVar, pinFile)
 	tests := []struct {
 		name          string
 		setupMocks    func(*MockWSMAN)
@@ -210,6 +215,12 @@ func TestConfigureTLSWithSelfSignedCert(t *testing.T) {
 			},
 			expectedError: utils.WSMANMessageError,
 		},
+		{
+			name: "Success",
+			setupMocks: func(mock *MockWSMAN) {
+				mockCreateTLSCredentialContextErr = nil
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -219,8 +230,12 @@ func TestConfigureTLSWithSelfSignedCert(t *testing.T) {
 			err := service.ConfigureTLSWithSelfSignedCert()
 			if tt.expectedError != nil {
 				assert.ErrorIs(t, err, tt.expectedError)
+				assert.NoFileExists(t, pinFile)
 			} else {
 				assert.NoError(t, err)
+				pin, err := amt.LoadTLSPin(amt.LocalPinName)
+				assert.NoError(t, err)
+				assert.Len(t, pin, 64)
 			}
 		})
 	}
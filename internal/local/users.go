@@ -0,0 +1,184 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+	log "github.com/sirupsen/logrus"
+)
+
+type UserEntry struct {
+	Username string   `json:"username"`
+	Realms   []string `json:"realms"`
+	Access   string   `json:"access"`
+	Enabled  bool     `json:"enabled"`
+}
+
+// ConfigureUsers adds, updates or deletes a digest user, then lists the
+// digest users AMT keeps
+func (service *ProvisioningService) ConfigureUsers() error {
+	users, err := service.interfacedWsmanMessage.GetUserACLEntries()
+	if err != nil {
+		log.Error("Failed to read the users: ", err)
+		return utils.UserConfigurationFailed
+	}
+	switch service.flags.Users.Action {
+	case utils.SubCommandAdd:
+		err = service.addUser(users)
+	case utils.SubCommandUpdate:
+		err = service.updateUser(users)
+	case utils.SubCommandDelete:
+		err = service.deleteUser(users)
+	}
+	if err != nil {
+		return err
+	}
+	if service.flags.Users.Action != utils.SubCommandList {
+		if users, err = service.interfacedWsmanMessage.GetUserACLEntries(); err != nil {
+			log.Error("Failed to read the users: ", err)
+			return utils.UserConfigurationFailed
+		}
+	}
+	service.printUsers(users)
+	return nil
+}
+
+func findUser(users []amt.UserACLEntry, username string) (amt.UserACLEntry, bool) {
+	for _, user := range users {
+		if user.Username == username {
+			return user, true
+		}
+	}
+	return amt.UserACLEntry{}, false
+}
+
+func (service *ProvisioningService) addUser(users []amt.UserACLEntry) error {
+	if _, found := findUser(users, service.flags.Users.Username); found {
+		log.Error("a user named ", service.flags.Users.Username, " already exists, use update to change it")
+		return utils.UserConfigurationFailed
+	}
+	user := amt.UserACLEntry{Username: service.flags.Users.Username}
+	service.applyUserFlags(&user)
+	password, err := service.userDigestPassword()
+	if err != nil {
+		return err
+	}
+	if _, err = service.interfacedWsmanMessage.AddUserACLEntry(user, password); err != nil {
+		log.Error("Failed to add the user: ", err)
+		return amtError(err, utils.UserConfigurationFailed)
+	}
+	log.Info("Added user ", user.Username)
+	return nil
+}
+
+// updateUser keeps the realms and access of the user unless they are set
+func (service *ProvisioningService) updateUser(users []amt.UserACLEntry) error {
+	user, found := findUser(users, service.flags.Users.Username)
+	if !found {
+		log.Error("there is no user named ", service.flags.Users.Username)
+		return utils.UserConfigurationFailed
+	}
+	service.applyUserFlags(&user)
+	password, err := service.userDigestPassword()
+	if err != nil {
+		return err
+	}
+	if err = service.interfacedWsmanMessage.UpdateUserACLEntry(user, password); err != nil {
+		log.Error("Failed to update the user: ", err)
+		return amtError(err, utils.UserConfigurationFailed)
+	}
+	log.Info("Updated user ", user.Username)
+	return nil
+}
+
+func (service *ProvisioningService) deleteUser(users []amt.UserACLEntry) error {
+	user, found := findUser(users, service.flags.Users.Username)
+	if !found {
+		log.Error("there is no user named ", service.flags.Users.Username)
+		return utils.UserConfigurationFailed
+	}
+	if err := service.interfacedWsmanMessage.RemoveUserACLEntry(user.Handle); err != nil {
+		log.Error("Failed to delete the user: ", err)
+		return amtError(err, utils.UserConfigurationFailed)
+	}
+	log.Info("Deleted user ", user.Username)
+	return nil
+}
+
+func (service *ProvisioningService) applyUserFlags(user *amt.UserACLEntry) {
+	if len(service.flags.Users.Realms) > 0 {
+		user.Realms = []authorization.RealmValues{}
+		for _, realm := range service.flags.Users.Realms {
+			user.Realms = append(user.Realms, flags.UserRealms[realm])
+		}
+	}
+	if service.flags.Users.Access != "" {
+		user.AccessPermission = flags.UserAccessPermissions[service.flags.Users.Access]
+	}
+}
+
+// userDigestPassword hashes the user password with the digest realm of AMT
+func (service *ProvisioningService) userDigestPassword() (string, error) {
+	generalSettings, err := service.interfacedWsmanMessage.GetGeneralSettings()
+	if err != nil {
+		log.Error("Failed to read the digest realm: ", err)
+		return "", utils.UserConfigurationFailed
+	}
+	password, err := digestPassword(service.flags.Users.Username, service.flags.Users.UserPassword, generalSettings.Body.GetResponse.DigestRealm)
+	if err != nil {
+		log.Error("Failed to hash the user password: ", err)
+		return "", utils.UserConfigurationFailed
+	}
+	return password, nil
+}
+
+func (service *ProvisioningService) printUsers(users []amt.UserACLEntry) {
+	entries := []UserEntry{}
+	for _, user := range users {
+		entry := UserEntry{Username: user.Username, Realms: []string{}, Enabled: user.Enabled}
+		for _, realm := range user.Realms {
+			entry.Realms = append(entry.Realms, userRealmName(realm))
+		}
+		for name, access := range flags.UserAccessPermissions {
+			if access == user.AccessPermission {
+				entry.Access = name
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(entries, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	service.PrintOutput("Users:")
+	for _, entry := range entries {
+		line := fmt.Sprintf("  %-16s %-8s %s", entry.Username, entry.Access, strings.Join(entry.Realms, ","))
+		if !entry.Enabled {
+			line += "  (disabled)"
+		}
+		service.PrintOutput(line)
+	}
+}
+
+// userRealmName is the -realms name of realm, or its number for realms
+// rpc does not hand out
+func userRealmName(realm authorization.RealmValues) string {
+	for name, value := range flags.UserRealms {
+		if value == realm {
+			return name
+		}
+	}
+	return strconv.Itoa(int(realm))
+}
@@ -0,0 +1,143 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"errors"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/authorization"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureUsers(t *testing.T) {
+	existing := amt.UserACLEntry{
+		Handle:           3,
+		Username:         "operator",
+		AccessPermission: authorization.AccessPermissionNetworkAccessOnly,
+		Realms:           []authorization.RealmValues{authorization.RealmValuesRedirectionRealm, authorization.RealmValuesHardwareAssetRealm},
+		Enabled:          true,
+	}
+	updated := existing
+	updated.AccessPermission = authorization.AccessPermissionLocalAccessOnly
+	helpdeskDigest, _ := digestPassword("helpdesk", "Us3r!pass", mockGeneralSettings.Body.GetResponse.DigestRealm)
+	operatorDigest, _ := digestPassword("operator", "Us3r!pass", mockGeneralSettings.Body.GetResponse.DigestRealm)
+	tests := []struct {
+		name            string
+		users           flags.UsersFlags
+		setupMocks      func(*MockWSMAN)
+		expectedErr     error
+		expectedAdded   amt.UserACLEntry
+		expectedDigest  string
+		expectedUpdated amt.UserACLEntry
+		expectedRemoved int
+	}{
+		{
+			name:  "lists the users",
+			users: flags.UsersFlags{Action: utils.SubCommandList},
+			setupMocks: func(mock *MockWSMAN) {
+				mockUsers = []amt.UserACLEntry{existing, {Handle: 4, Username: "legacy", Realms: []authorization.RealmValues{authorization.RealmValuesReservedRealm1}}}
+			},
+		},
+		{
+			name: "adds a user",
+			users: flags.UsersFlags{
+				Action:       utils.SubCommandAdd,
+				Username:     "helpdesk",
+				UserPassword: "Us3r!pass",
+				Realms:       []string{"redirection", "ptadmin"},
+				Access:       "any",
+			},
+			setupMocks: func(mock *MockWSMAN) {},
+			expectedAdded: amt.UserACLEntry{
+				Username:         "helpdesk",
+				AccessPermission: authorization.AccessPermissionLocalAndNetworkAccess,
+				Realms:           []authorization.RealmValues{authorization.RealmValuesRedirectionRealm, authorization.RealmValuesPTAdministrationRealm},
+			},
+			expectedDigest: helpdeskDigest,
+		},
+		{
+			name:  "refuses a name that is taken",
+			users: flags.UsersFlags{Action: utils.SubCommandAdd, Username: "operator", UserPassword: "Us3r!pass"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockUsers = []amt.UserACLEntry{existing}
+			},
+			expectedErr: utils.UserConfigurationFailed,
+		},
+		{
+			name:  "fails when AMT refuses the user",
+			users: flags.UsersFlags{Action: utils.SubCommandAdd, Username: "helpdesk", UserPassword: "Us3r!pass"},
+			setupMocks: func(mock *MockWSMAN) {
+				errAddUser = errors.New("AddUserAclEntryEx failed")
+			},
+			expectedErr:    utils.UserConfigurationFailed,
+			expectedAdded:  amt.UserACLEntry{Username: "helpdesk"},
+			expectedDigest: helpdeskDigest,
+		},
+		{
+			name:  "updates the access and keeps the realms",
+			users: flags.UsersFlags{Action: utils.SubCommandUpdate, Username: "operator", UserPassword: "Us3r!pass", Access: "local"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockUsers = []amt.UserACLEntry{existing}
+			},
+			expectedDigest:  operatorDigest,
+			expectedUpdated: updated,
+		},
+		{
+			name:        "fails to update an unknown user",
+			users:       flags.UsersFlags{Action: utils.SubCommandUpdate, Username: "operator", UserPassword: "Us3r!pass"},
+			setupMocks:  func(mock *MockWSMAN) {},
+			expectedErr: utils.UserConfigurationFailed,
+		},
+		{
+			name:  "deletes a user",
+			users: flags.UsersFlags{Action: utils.SubCommandDelete, Username: "operator"},
+			setupMocks: func(mock *MockWSMAN) {
+				mockUsers = []amt.UserACLEntry{existing}
+			},
+			expectedRemoved: 3,
+		},
+		{
+			name:        "fails to delete an unknown user",
+			users:       flags.UsersFlags{Action: utils.SubCommandDelete, Username: "operator"},
+			setupMocks:  func(mock *MockWSMAN) {},
+			expectedErr: utils.UserConfigurationFailed,
+		},
+		{
+			name:  "fails when the users cannot be read",
+			users: flags.UsersFlags{Action: utils.SubCommandList},
+			setupMocks: func(mock *MockWSMAN) {
+				errGetUsers = errors.New("unreachable")
+			},
+			expectedErr: utils.UserConfigurationFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUsers, errGetUsers, errAddUser, errUpdateUser, errRemoveUser = []amt.UserACLEntry{}, nil, nil, nil, nil
+			mockAddedUser, mockUpdatedUser, mockUserDigestPassword, mockRemovedUser = amt.UserACLEntry{}, amt.UserACLEntry{}, "", 0
+			service, _, mockWsman := setupProvisioningService()
+			tt.setupMocks(mockWsman)
+			service.flags.Users = tt.users
+			service.flags.JsonOutput = true
+			err := service.ConfigureUsers()
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedAdded, mockAddedUser)
+			assert.Equal(t, tt.expectedDigest, mockUserDigestPassword)
+			assert.Equal(t, tt.expectedUpdated, mockUpdatedUser)
+			assert.Equal(t, tt.expectedRemoved, mockRemovedUser)
+		})
+	}
+}
+
+func TestUserRealmName(t *testing.T) {
+	assert.Equal(t, "redirection", userRealmName(authorization.RealmValuesRedirectionRealm))
+	assert.Equal(t, "localsystem", userRealmName(authorization.RealmValuesLocalSystemRealm))
+	assert.Equal(t, "22", userRealmName(authorization.RealmValuesReservedRealm1))
+}
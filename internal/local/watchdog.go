@@ -0,0 +1,201 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/utils"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	log "github.com/sirupsen/logrus"
+)
+
+// notifyWatchdogStop delivers the signals that end the heartbeat loop
+var notifyWatchdogStop = func(c chan<- os.Signal) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+}
+
+type WatchdogStatus struct {
+	AgentID   string   `json:"agentId"`
+	DeviceID  string   `json:"deviceId"`
+	Timeout   int      `json:"timeout"`
+	Interval  string   `json:"interval"`
+	Actions   []string `json:"actions"`
+	Transport string   `json:"transport"`
+}
+
+// Watchdog registers this host with AMT_AgentPresenceWatchdog and keeps it
+// alive with heartbeats over the watchdog HECI client until it is stopped
+func (service *ProvisioningService) Watchdog() error {
+	controlMode, err := service.amtCommand.GetControlMode()
+	if err != nil {
+		log.Error("Failed to get control mode: ", err)
+		return amtError(err, utils.AMTConnectionFailed)
+	}
+	if controlMode == 0 {
+		log.Error("Device is not activated. The agent presence watchdog requires an activated device")
+		return utils.WatchdogRegistrationFailed
+	}
+	deviceID, err := amt.AgentPresenceDeviceID(service.flags.Watchdog.AgentID)
+	if err != nil {
+		log.Error("Invalid agent id: ", err)
+		return utils.InvalidUserInput
+	}
+	timeout := uint16(service.flags.Watchdog.Timeout / time.Second)
+
+	service.interfacedWsmanMessage.SetupWsmanClient("admin", service.flags.Password, log.GetLevel() == log.TraceLevel)
+	for _, action := range service.flags.Watchdog.Actions {
+		if action != flags.WatchdogActionMPS {
+			continue
+		}
+		if err = service.enableAlertConnection(); err != nil {
+			return err
+		}
+	}
+	sequenceNumber, err := service.registerWatchdog(deviceID, timeout)
+	if err != nil {
+		log.Error("Failed to register the agent presence watchdog: ", err)
+		return amtError(err, utils.WatchdogRegistrationFailed)
+	}
+	service.printWatchdogStatus(WatchdogStatus{
+		AgentID:   service.flags.Watchdog.AgentID,
+		DeviceID:  deviceID,
+		Timeout:   int(timeout),
+		Interval:  service.flags.Watchdog.Interval.String(),
+		Actions:   service.flags.Watchdog.Actions,
+		Transport: service.interfacedWsmanMessage.Transport(),
+	})
+
+	stop := make(chan os.Signal, 1)
+	notifyWatchdogStop(stop)
+	defer signal.Stop(stop)
+	ticker := time.NewTicker(service.flags.Watchdog.Interval)
+	defer ticker.Stop()
+	for {
+		required, err := service.amtCommand.SendWatchdogHeartbeat(timeout)
+		if err != nil {
+			log.Error("Failed to send watchdog heartbeat: ", err)
+			return amtError(err, utils.WatchdogHeartbeatFailed)
+		}
+		if !required {
+			log.Warn("AMT reports the watchdog is no longer required, stopping")
+			return service.shutdownWatchdog(deviceID, sequenceNumber)
+		}
+		log.Debug("Sent watchdog heartbeat")
+		select {
+		case sig := <-stop:
+			log.Info("Received ", sig, ", stopping the watchdog")
+			return service.shutdownWatchdog(deviceID, sequenceNumber)
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerWatchdog replaces any watchdog left behind by an earlier run of the
+// same agent and returns the sequence number of the new session
+func (service *ProvisioningService) registerWatchdog(deviceID string, timeout uint16) (uint32, error) {
+	watchdogs, err := service.interfacedWsmanMessage.GetAgentPresenceWatchdogs()
+	if err != nil {
+		return 0, err
+	}
+	for _, watchdog := range watchdogs {
+		if watchdog.DeviceID != deviceID {
+			continue
+		}
+		log.Debug("Removing the existing watchdog in state ", watchdog.CurrentTimerState)
+		if err = service.interfacedWsmanMessage.DeleteAgentPresenceWatchdog(deviceID); err != nil {
+			return 0, err
+		}
+	}
+	if err = service.interfacedWsmanMessage.CreateAgentPresenceWatchdog(deviceID, timeout, timeout); err != nil {
+		return 0, err
+	}
+	if err = service.interfacedWsmanMessage.DeleteAllAgentPresenceWatchdogActions(deviceID); err != nil {
+		return 0, err
+	}
+	// the event logged on expiry is also what triggers an alert connection
+	if err = service.interfacedWsmanMessage.AddAgentPresenceWatchdogAction(deviceID, amt.WatchdogRunning, amt.WatchdogExpired); err != nil {
+		return 0, err
+	}
+	return service.interfacedWsmanMessage.RegisterAgentPresenceWatchdog(deviceID)
+}
+
+// enableAlertConnection makes sure an alert policy exists so AMT connects to
+// the MPS when the watchdog expires
+func (service *ProvisioningService) enableAlertConnection() error {
+	mpsSAPs, err := service.interfacedWsmanMessage.GetMPSSAP()
+	if err != nil {
+		log.Error("Failed to get the MPS configuration: ", err)
+		return utils.WSMANMessageError
+	}
+	if len(mpsSAPs) == 0 {
+		log.Error("No MPS is configured on this device, the mps action requires CIRA")
+		return utils.WatchdogRegistrationFailed
+	}
+	policies, err := service.interfacedWsmanMessage.GetRemoteAccessPolicies()
+	if err != nil {
+		log.Error("Failed to get the remote access policies: ", err)
+		return utils.WSMANMessageError
+	}
+	for _, policy := range policies {
+		if policy.Trigger == remoteaccess.TriggerAlert {
+			log.Debug("Alert policy already exists: ", policy.PolicyRuleName)
+			return nil
+		}
+	}
+	request := remoteaccess.RemoteAccessPolicyRuleRequest{
+		Trigger:        remoteaccess.TriggerAlert,
+		TunnelLifeTime: 300,
+	}
+	response, err := service.interfacedWsmanMessage.AddRemoteAccessPolicyRule(request, mpsSAPs[0].Name)
+	if err != nil {
+		log.Error("Failed to add the alert policy: ", err)
+		return utils.WSMANMessageError
+	}
+	if response.Body.AddRemotePolicyRuleResponse.ReturnValue != 0 {
+		log.Error("Failed to add the alert policy: ", response.Body.AddRemotePolicyRuleResponse.ReturnValue)
+		return utils.WatchdogRegistrationFailed
+	}
+	log.Info("Added an alert policy for ", mpsSAPs[0].AccessInfo)
+	return nil
+}
+
+// shutdownWatchdog stops the timer and tells AMT the agent went away on
+// purpose, so the expiry actions do not run
+func (service *ProvisioningService) shutdownWatchdog(deviceID string, sequenceNumber uint32) error {
+	if err := service.amtCommand.StopWatchdogTimer(); err != nil {
+		log.Error("Failed to stop the watchdog timer: ", err)
+		return amtError(err, utils.WatchdogHeartbeatFailed)
+	}
+	if err := service.interfacedWsmanMessage.AssertAgentPresenceShutdown(deviceID, sequenceNumber+1); err != nil {
+		log.Error("Failed to assert agent shutdown: ", err)
+		return amtError(err, utils.WatchdogHeartbeatFailed)
+	}
+	log.Info("Watchdog stopped")
+	return nil
+}
+
+func (service *ProvisioningService) printWatchdogStatus(status WatchdogStatus) {
+	if service.flags.JsonOutput {
+		outBytes, _ := json.MarshalIndent(status, "", "  ")
+		fmt.Println(string(outBytes))
+		return
+	}
+	service.PrintOutput("Agent ID        	: " + status.AgentID)
+	service.PrintOutput("Device ID       	: " + status.DeviceID)
+	service.PrintOutput(fmt.Sprintf("Timeout         	: %ds", status.Timeout))
+	service.PrintOutput("Interval        	: " + status.Interval)
+	service.PrintOutput(fmt.Sprintf("Actions         	: %v", status.Actions))
+	service.PrintOutput("Transport       	: " + strings.ToUpper(status.Transport))
+}
@@ -0,0 +1,154 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package local
+
+import (
+	"fmt"
+	"os"
+	"rpc/internal/flags"
+	"rpc/internal/local/amt"
+	"rpc/pkg/pthi"
+	"rpc/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/managementpresence"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/wsman/amt/remoteaccess"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupWatchdogService(actions ...string) ProvisioningService {
+	f := &flags.Flags{}
+	f.Command = utils.CommandWatchdog
+	f.Password = "P@ssw0rd"
+	f.Watchdog = flags.WatchdogFlags{
+		AgentID:  flags.DefaultWatchdogAgentID,
+		Timeout:  120 * time.Second,
+		Interval: time.Millisecond,
+		Actions:  append([]string{flags.WatchdogActionLog}, actions...),
+	}
+	return setupService(f)
+}
+
+func resetWatchdogMocks() {
+	mockControlMode = 0
+	mockWatchdogRequired = true
+	mockWatchdogHeartbeatErr = nil
+	mockWatchdogHeartbeats = 0
+	mockWatchdogStopped = false
+	mockStopWatchdogTimerErr = nil
+	mockAgentPresenceWatchdogs = []amt.AgentPresenceWatchdog{}
+	mockAgentPresenceWatchdogsDeleted = 0
+	mockRegisterAgentPresenceWatchdogErr = nil
+	mockAssertAgentPresenceShutdownErr = nil
+	mockAgentPresenceShutdownSequence = 0
+	mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{}
+	mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{}
+	mockRemoteAccessPolicyRulesAdded = 0
+}
+
+func TestWatchdog(t *testing.T) {
+	notifyStop := notifyWatchdogStop
+	defer func() { notifyWatchdogStop = notifyStop }()
+	// interrupt right away so the loop sends a single heartbeat
+	notifyWatchdogStop = func(c chan<- os.Signal) { c <- os.Interrupt }
+	deviceID, _ := amt.AgentPresenceDeviceID(flags.DefaultWatchdogAgentID)
+
+	t.Run("fails when not activated", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 0
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.Equal(t, utils.WatchdogRegistrationFailed, err)
+		assert.Equal(t, 0, mockWatchdogHeartbeats)
+	})
+	t.Run("fails when registration fails", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockRegisterAgentPresenceWatchdogErr = fmt.Errorf("RegisterAgent failed: %w", pthi.AMT_STATUS_NOT_PERMITTED.Err())
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.Equal(t, pthi.AMT_STATUS_NOT_PERMITTED.CustomError(), err)
+		assert.Equal(t, 0, mockWatchdogHeartbeats)
+	})
+	t.Run("replaces an existing watchdog and stops on interrupt", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockAgentPresenceWatchdogs = []amt.AgentPresenceWatchdog{
+			{DeviceID: "other"},
+			{DeviceID: deviceID, CurrentTimerState: amt.WatchdogExpired},
+		}
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, mockAgentPresenceWatchdogsDeleted)
+		assert.Equal(t, 1, mockWatchdogHeartbeats)
+		assert.True(t, mockWatchdogStopped)
+		assert.Equal(t, uint32(2), mockAgentPresenceShutdownSequence)
+	})
+	t.Run("stops when the watchdog is not required", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockWatchdogRequired = false
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.NoError(t, err)
+		assert.True(t, mockWatchdogStopped)
+	})
+	t.Run("returns the AMT status when a heartbeat fails", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockWatchdogHeartbeatErr = fmt.Errorf("error sending watchdog heartbeat: %w", pthi.AMT_STATUS_NOT_READY.Err())
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.Equal(t, pthi.AMT_STATUS_NOT_READY.CustomError(), err)
+		assert.False(t, mockWatchdogStopped)
+	})
+	t.Run("returns heartbeat failure for other errors", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockWatchdogHeartbeatErr = assert.AnError
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.Equal(t, utils.WatchdogHeartbeatFailed, err)
+	})
+	t.Run("fails when the timer cannot be stopped", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockStopWatchdogTimerErr = assert.AnError
+		service := setupWatchdogService()
+		err := service.Watchdog()
+		assert.Equal(t, utils.WatchdogHeartbeatFailed, err)
+	})
+	t.Run("mps action requires an MPS", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		service := setupWatchdogService(flags.WatchdogActionMPS)
+		err := service.Watchdog()
+		assert.Equal(t, utils.WatchdogRegistrationFailed, err)
+		assert.Equal(t, 0, mockRemoteAccessPolicyRulesAdded)
+	})
+	t.Run("mps action adds an alert policy", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: "Intel(r) AMT:Management Presence Server 0", AccessInfo: "mps.example.com"}}
+		mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{{Trigger: remoteaccess.TriggerUserInitiated}}
+		service := setupWatchdogService(flags.WatchdogActionMPS)
+		err := service.Watchdog()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, mockRemoteAccessPolicyRulesAdded)
+	})
+	t.Run("mps action keeps an existing alert policy", func(t *testing.T) {
+		defer resetWatchdogMocks()
+		mockControlMode = 1
+		mockMPSSAPResponse = []managementpresence.ManagementRemoteResponse{{Name: "Intel(r) AMT:Management Presence Server 0"}}
+		mockRemoteAccessPoliciesResponse = []remoteaccess.RemoteAccessPolicyRuleResponse{{Trigger: remoteaccess.TriggerAlert}}
+		service := setupWatchdogService(flags.WatchdogActionMPS)
+		err := service.Watchdog()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, mockRemoteAccessPolicyRulesAdded)
+	})
+}
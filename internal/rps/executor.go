@@ -30,26 +30,29 @@ func NewExecutor(flags flags.Flags) (Executor, error) {
 	lmDataChannel := make(chan []byte)
 	lmErrorChannel := make(chan error)
 
+	lmsAddress, lmsPort := flags.LMSAddress, flags.LMSPort
+	if lmsAddress == "" {
+		lmsAddress = utils.LMSAddress
+	}
+	if lmsPort == "" {
+		lmsPort = utils.LMSPort
+	}
 	client := Executor{
 		server:          NewAMTActivationServer(&flags),
-		localManagement: lm.NewLMSConnection(utils.LMSAddress, utils.LMSPort, lmDataChannel, lmErrorChannel),
+		localManagement: lm.NewLMSConnection(lmsAddress, lmsPort, lmDataChannel, lmErrorChannel),
 		data:            lmDataChannel,
 		errors:          lmErrorChannel,
 	}
 
-	// TEST CONNECTION TO SEE IF LMS EXISTS
-	err := client.localManagement.Connect()
-
+	transport, err := lm.SelectTransport(flags.Transport, lmsAddress, lmsPort)
 	if err != nil {
-		// client.localManagement.Close()
-		log.Trace("LMS not running.  Using LME Connection\n")
+		return client, err
+	}
+	if transport == lm.TransportLME {
 		client.status = make(chan bool)
 		client.localManagement = lm.NewLMEConnection(lmDataChannel, lmErrorChannel, client.status)
 		client.isLME = true
 		client.localManagement.Initialize()
-	} else {
-		log.Trace("Using existing LMS\n")
-		client.localManagement.Close()
 	}
 
 	err = client.server.Connect(flags.SkipCertCheck)
@@ -73,6 +73,35 @@ func (c MockAMT) GetLocalSystemAccount() (amt.LocalSystemAccount, error) {
 func (c MockAMT) Unprovision() (int, error) {
 	return mode, nil
 }
+func (c MockAMT) GetFeaturesState() (amt.FeaturesState, error) {
+	return amt.FeaturesState{}, nil
+}
+func (c MockAMT) GetLastHostResetReason() (amt.HostResetReason, error) {
+	return amt.HostResetReason{}, nil
+}
+func (c MockAMT) GetCurrentPowerPolicy() (string, error) { return "", nil }
+func (c MockAMT) GetMACAddresses() (amt.MACAddresses, error) {
+	return amt.MACAddresses{}, nil
+}
+func (c MockAMT) GetSecurityParameters() (amt.SecurityParameters, error) {
+	return amt.SecurityParameters{}, nil
+}
+func (c MockAMT) GetProvisioningTLSMode() (string, error) { return "", nil }
+func (c MockAMT) GetZeroTouchEnabled() (bool, error)      { return false, nil }
+func (c MockAMT) GetFQDN() (amt.FQDN, error)              { return amt.FQDN{}, nil }
+func (c MockAMT) GetEHBCState() (bool, error)             { return false, nil }
+func (c MockAMT) GetDNSSuffixList() ([]string, error)     { return []string{}, nil }
+func (c MockAMT) SetDNSSuffix(string) error               { return nil }
+func (c MockAMT) SetHostFQDN(string) error                { return nil }
+func (c MockAMT) GetProvisioningState() (int, error)      { return 0, nil }
+func (c MockAMT) StopConfiguration() error                { return nil }
+func (c MockAMT) StartConfiguration(string) error         { return nil }
+func (c MockAMT) OpenUserInitiatedConnection() error      { return nil }
+func (c MockAMT) CloseUserInitiatedConnection() error     { return nil }
+func (c MockAMT) SendWatchdogHeartbeat(timeout uint16) (bool, error) {
+	return true, nil
+}
+func (c MockAMT) StopWatchdogTimer() error { return nil }
 
 var p Payload
 
@@ -0,0 +1,152 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// Capture operations
+const (
+	OpInit    = "init"
+	OpSend    = "send"
+	OpReceive = "receive"
+	OpClose   = "close"
+)
+
+// CaptureRecord is one line of a capture file. Every Init starts a new
+// session; Data holds the bytes written or read (base64 in JSON).
+type CaptureRecord struct {
+	Seq        uint64    `json:"seq"`
+	Time       time.Time `json:"time"`
+	Session    int       `json:"session"`
+	Client     string    `json:"client"`
+	Op         string    `json:"op"`
+	BufferSize uint32    `json:"bufferSize,omitempty"`
+	Data       []byte    `json:"data,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Capture writes the traffic of every wrapped interface to one JSON-lines file
+type Capture struct {
+	mu       sync.Mutex
+	file     *os.File
+	encoder  *json.Encoder
+	seq      uint64
+	sessions int
+}
+
+var activeCapture *Capture
+
+// StartCapture records all HECI traffic of interfaces created afterwards to path
+func StartCapture(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	activeCapture = &Capture{file: file, encoder: json.NewEncoder(file)}
+	return nil
+}
+
+// StopCapture closes the capture file; interfaces created afterwards are not recorded
+func StopCapture() error {
+	if activeCapture == nil {
+		return nil
+	}
+	activeCapture.mu.Lock()
+	defer activeCapture.mu.Unlock()
+	err := activeCapture.file.Close()
+	activeCapture = nil
+	return err
+}
+
+// ActiveCapture returns the capture started with StartCapture, if any
+func ActiveCapture() *Capture {
+	return activeCapture
+}
+
+// Wrap returns an interface that records the traffic of device
+func (c *Capture) Wrap(device Interface) Interface {
+	return &Recorder{device: device, capture: c}
+}
+
+func (c *Capture) nextSession() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessions++
+	return c.sessions
+}
+
+func (c *Capture) write(record CaptureRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	record.Seq = c.seq
+	record.Time = time.Now().UTC()
+	// each line is written straight to the file so a crash keeps the capture
+	c.encoder.Encode(record)
+}
+
+// Recorder is a heci.Interface that forwards to a device and records each call
+type Recorder struct {
+	device  Interface
+	capture *Capture
+	session int
+	client  Client
+}
+
+func (r *Recorder) Init(useLME bool, useWD bool) error {
+	r.client = ClientPTHI
+	if useWD {
+		r.client = ClientWatchdog
+	} else if useLME {
+		r.client = ClientLME
+	}
+	r.session = r.capture.nextSession()
+	err := r.device.Init(useLME, useWD)
+	record := r.record(OpInit, nil, err)
+	if err == nil {
+		record.BufferSize = r.device.GetBufferSize()
+	}
+	r.capture.write(record)
+	return err
+}
+
+func (r *Recorder) GetBufferSize() uint32 {
+	return r.device.GetBufferSize()
+}
+
+func (r *Recorder) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
+	bytesWritten, err = r.device.SendMessage(buffer, done)
+	r.capture.write(r.record(OpSend, buffer, err))
+	return bytesWritten, err
+}
+
+func (r *Recorder) ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error) {
+	bytesRead, err = r.device.ReceiveMessage(buffer, done)
+	r.capture.write(r.record(OpReceive, buffer[:bytesRead], err))
+	return bytesRead, err
+}
+
+func (r *Recorder) Close() {
+	r.device.Close()
+	r.capture.write(r.record(OpClose, nil, nil))
+}
+
+func (r *Recorder) record(op string, data []byte, err error) CaptureRecord {
+	record := CaptureRecord{
+		Session: r.session,
+		Client:  r.client.String(),
+		Op:      op,
+		Data:    append([]byte(nil), data...),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
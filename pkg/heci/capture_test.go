@@ -0,0 +1,238 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// echoDevice answers every message with the message reversed
+type echoDevice struct {
+	pending [][]byte
+	initErr error
+}
+
+func (d *echoDevice) Init(useLME bool, useWD bool) error { return d.initErr }
+func (d *echoDevice) GetBufferSize() uint32              { return 5120 }
+func (d *echoDevice) SendMessage(buffer []byte, done *uint32) (uint32, error) {
+	reply := make([]byte, len(buffer))
+	for i, b := range buffer {
+		reply[len(buffer)-1-i] = b
+	}
+	d.pending = append(d.pending, reply)
+	return uint32(len(buffer)), nil
+}
+func (d *echoDevice) ReceiveMessage(buffer []byte, done *uint32) (uint32, error) {
+	if len(d.pending) == 0 {
+		return 0, errors.New("nothing to read")
+	}
+	n := copy(buffer, d.pending[0])
+	d.pending = d.pending[1:]
+	return uint32(n), nil
+}
+func (d *echoDevice) Close() {}
+
+func call(t *testing.T, device Interface, message []byte) ([]byte, error) {
+	size := uint32(len(message))
+	if _, err := device.SendMessage(message, &size); err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, device.GetBufferSize())
+	n, err := device.ReceiveMessage(buffer, &size)
+	return buffer[:n], err
+}
+
+func record(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	assert.NoError(t, StartCapture(path))
+	defer StopCapture()
+
+	pthi := ActiveCapture().Wrap(&echoDevice{})
+	assert.NoError(t, pthi.Init(false, false))
+	reply, err := call(t, pthi, []byte{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 2, 1}, reply)
+	pthi.Close()
+
+	lme := ActiveCapture().Wrap(&echoDevice{initErr: errors.New("no such device")})
+	assert.Error(t, lme.Init(true, false))
+	return path
+}
+
+func TestCaptureFormat(t *testing.T) {
+	path := record(t)
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+	records := []CaptureRecord{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := CaptureRecord{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+	assert.Len(t, records, 5)
+	assert.Equal(t, CaptureRecord{Seq: 1, Session: 1, Client: "pthi", Op: OpInit, BufferSize: 5120}, withoutTime(records[0]))
+	assert.Equal(t, CaptureRecord{Seq: 2, Session: 1, Client: "pthi", Op: OpSend, Data: []byte{1, 2, 3}}, withoutTime(records[1]))
+	assert.Equal(t, CaptureRecord{Seq: 3, Session: 1, Client: "pthi", Op: OpReceive, Data: []byte{3, 2, 1}}, withoutTime(records[2]))
+	assert.Equal(t, OpClose, records[3].Op)
+	assert.Equal(t, CaptureRecord{Seq: 5, Session: 2, Client: "lme", Op: OpInit, Error: "no such device"}, withoutTime(records[4]))
+	assert.False(t, records[0].Time.IsZero())
+}
+
+func withoutTime(record CaptureRecord) CaptureRecord {
+	record.Time = time.Time{}
+	return record
+}
+
+func TestReplay(t *testing.T) {
+	path := record(t)
+
+	pthi := NewReplayer(path)
+	assert.NoError(t, pthi.Init(false, false))
+	assert.Equal(t, uint32(5120), pthi.GetBufferSize())
+	reply, err := call(t, pthi, []byte{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 2, 1}, reply)
+	pthi.Close()
+
+	lme := NewReplayer(path)
+	assert.EqualError(t, lme.Init(true, false), "no such device")
+
+	again := NewReplayer(path)
+	assert.ErrorIs(t, again.Init(false, false), ErrReplayExhausted)
+}
+
+func TestReplayMismatch(t *testing.T) {
+	path := record(t)
+	pthi := NewReplayer(path)
+	assert.NoError(t, pthi.Init(false, false))
+	size := uint32(3)
+	_, err := pthi.SendMessage([]byte{1, 2, 4}, &size)
+	assert.ErrorIs(t, err, ErrReplayMismatch)
+}
+
+func TestReplayReceiveWaitsForSend(t *testing.T) {
+	path := record(t)
+	pthi := NewReplayer(path)
+	assert.NoError(t, pthi.Init(false, false))
+
+	received := make(chan []byte)
+	go func() {
+		buffer := make([]byte, 16)
+		size := uint32(16)
+		n, _ := pthi.ReceiveMessage(buffer, &size)
+		received <- buffer[:n]
+	}()
+	select {
+	case <-received:
+		t.Fatal("receive returned before the recorded send was made")
+	case <-time.After(20 * time.Millisecond):
+	}
+	size := uint32(3)
+	_, err := pthi.SendMessage([]byte{1, 2, 3}, &size)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{3, 2, 1}, <-received)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pthi.Close()
+	}()
+	_, err = pthi.ReceiveMessage(make([]byte, 16), &size)
+	assert.ErrorIs(t, err, ErrReplayClosed)
+}
+
+const wsmanBody = "<Envelope><Body><Get/></Body></Envelope>"
+
+// digestDevice is an LME channel to an AMT that wants WS-MAN requests
+// signed with digest auth
+type digestDevice struct {
+	echoDevice
+}
+
+func (d *digestDevice) SendMessage(buffer []byte, done *uint32) (uint32, error) {
+	reply := "HTTP/1.1 401 Unauthorized\r\nWWW-Authenticate: Digest realm=\"Digest:A3829B3827DE4D33D4449B366831FD01\", nonce=\"3TqDlQ4pAADdOLjsb5sC3w==\", qop=\"auth\"\r\nContent-Length: 0\r\n\r\n"
+	if bytes.Contains(buffer, []byte("Authorization: Digest ")) {
+		reply = "HTTP/1.1 200 OK\r\nContent-Length: 11\r\n\r\n<Envelope/>"
+	}
+	d.pending = append(d.pending, channelData([]byte(reply)))
+	return uint32(len(buffer)), nil
+}
+
+// channelData frames data as an APF_CHANNEL_DATA message
+func channelData(data []byte) []byte {
+	frame := []byte{94, 0, 0, 0, 1, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(frame[5:], uint32(len(data)))
+	return append(frame, data...)
+}
+
+var challenge = regexp.MustCompile(`realm="([^"]+)", nonce="([^"]+)"`)
+
+// wsmanGet posts a WS-MAN request the way the digest client does, first
+// unsigned and then signed with a fresh cnonce once AMT has challenged it
+func wsmanGet(t *testing.T, lme Interface, body string) ([]byte, error) {
+	request := "POST /wsman HTTP/1.1\r\nHost: localhost:16992\r\nContent-Type: application/soap+xml; charset=utf-8\r\n\r\n" + body
+	reply, err := call(t, lme, channelData([]byte(request)))
+	if err != nil {
+		return nil, err
+	}
+	values := challenge.FindSubmatch(reply)
+	assert.NotNil(t, values)
+
+	random := make([]byte, 8)
+	_, err = rand.Read(random)
+	assert.NoError(t, err)
+	cnonce := fmt.Sprintf("%x", random)
+	ha1 := fmt.Sprintf("%x", md5.Sum([]byte("admin:"+string(values[1])+":P@ssw0rd")))
+	ha2 := fmt.Sprintf("%x", md5.Sum([]byte("POST:/wsman")))
+	response := fmt.Sprintf("%x", md5.Sum([]byte(ha1+":"+string(values[2])+":00000001:"+cnonce+":auth:"+ha2)))
+	signed := fmt.Sprintf("POST /wsman HTTP/1.1\r\nHost: localhost:16992\r\nAuthorization: Digest username=\"admin\", realm=\"%s\", nonce=\"%s\", uri=\"/wsman\", response=\"%s\", qop=auth, nc=00000001, cnonce=\"%s\"\r\nContent-Type: application/soap+xml; charset=utf-8\r\n\r\n%s", values[1], values[2], response, cnonce, body)
+	return call(t, lme, channelData([]byte(signed)))
+}
+
+func recordWSMAN(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "capture.jsonl")
+	assert.NoError(t, StartCapture(path))
+	defer StopCapture()
+
+	lme := ActiveCapture().Wrap(&digestDevice{})
+	assert.NoError(t, lme.Init(true, false))
+	reply, err := wsmanGet(t, lme, wsmanBody)
+	assert.NoError(t, err)
+	assert.Contains(t, string(reply), "200 OK")
+	lme.Close()
+	return path
+}
+
+func TestReplayDigestSignedWSMAN(t *testing.T) {
+	path := recordWSMAN(t)
+	lme := NewReplayer(path)
+	assert.NoError(t, lme.Init(true, false))
+	reply, err := wsmanGet(t, lme, wsmanBody)
+	assert.NoError(t, err)
+	assert.Contains(t, string(reply), "200 OK")
+}
+
+func TestReplayDigestSignedWSMANMismatch(t *testing.T) {
+	path := recordWSMAN(t)
+	lme := NewReplayer(path)
+	assert.NoError(t, lme.Init(true, false))
+	_, err := wsmanGet(t, lme, "<Envelope><Body><Put/></Body></Envelope>")
+	assert.ErrorIs(t, err, ErrReplayMismatch)
+}
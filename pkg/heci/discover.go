@@ -0,0 +1,41 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeviceEnvVar selects the MEI device node instead of discovering it
+const DeviceEnvVar = "RPC_MEI_DEVICE"
+
+var (
+	ErrDeviceNotFound = errors.New("MEI device not found")
+	ErrAccessDenied   = errors.New("access to MEI device denied")
+	ErrClientNotFound = errors.New("AMT client not found on MEI device")
+)
+
+// DevicePath overrides discovery and the DeviceEnvVar when set (the -mei flag)
+var DevicePath string
+
+// Candidate is a MEI device considered during discovery
+type Candidate struct {
+	Path   string
+	Reason string
+}
+
+// Discovery reports the device that was selected and why the others were not
+type Discovery struct {
+	Selected Candidate
+	Rejected []Candidate
+}
+
+// ClientUUID formats an ME client GUID in its canonical string form
+func ClientUUID(guid [16]uint8) string {
+	return fmt.Sprintf("%02x%02x%02x%02x-%02x%02x-%02x%02x-%02x%02x-%02x%02x%02x%02x%02x%02x",
+		guid[3], guid[2], guid[1], guid[0], guid[5], guid[4], guid[7], guid[6],
+		guid[8], guid[9], guid[10], guid[11], guid[12], guid[13], guid[14], guid[15])
+}
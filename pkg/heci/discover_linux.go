@@ -0,0 +1,100 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysfsClassDir and devDir are variables so tests can point them at a fake tree
+var (
+	sysfsClassDir = "/sys/class/mei"
+	devDir        = "/dev"
+)
+
+// Discover picks the MEI device exposing the AMTHI or LME client. An explicit
+// DevicePath or DeviceEnvVar wins; otherwise /sys/class/mei is enumerated,
+// falling back to /dev/mei0 when sysfs is not available (e.g. in containers).
+func Discover() (Discovery, error) {
+	if DevicePath != "" {
+		return selectOverride(DevicePath, "set with -mei")
+	}
+	if path := os.Getenv(DeviceEnvVar); path != "" {
+		return selectOverride(path, "set with "+DeviceEnvVar)
+	}
+
+	discovery := Discovery{}
+	entries, err := os.ReadDir(sysfsClassDir)
+	if err != nil || len(entries) == 0 {
+		return selectOverride(Device, "default device, "+sysfsClassDir+" is not available")
+	}
+	var unknown []Candidate
+	for _, entry := range entries {
+		candidate := Candidate{Path: filepath.Join(devDir, entry.Name())}
+		if _, err := os.Stat(candidate.Path); err != nil {
+			candidate.Reason = "device node is missing"
+			discovery.Rejected = append(discovery.Rejected, candidate)
+			continue
+		}
+		clients, err := clientUUIDs(filepath.Join(sysfsClassDir, entry.Name(), "device"))
+		if err != nil || len(clients) == 0 {
+			candidate.Reason = "ME client list is not available"
+			unknown = append(unknown, candidate)
+			continue
+		}
+		if clients[ClientUUID(MEI_IAMTHIF)] || clients[ClientUUID(MEI_LMEIF)] {
+			if discovery.Selected.Path == "" {
+				candidate.Reason = "exposes the AMTHI/LME client"
+				discovery.Selected = candidate
+				continue
+			}
+			candidate.Reason = "exposes the AMTHI/LME client but " + discovery.Selected.Path + " was found first"
+		} else {
+			candidate.Reason = "does not expose the AMTHI or LME client"
+		}
+		discovery.Rejected = append(discovery.Rejected, candidate)
+	}
+	if discovery.Selected.Path == "" && len(unknown) > 0 {
+		// without a client list the first usable node is the best guess
+		discovery.Selected = unknown[0]
+		discovery.Selected.Reason += ", using the first device"
+		unknown = unknown[1:]
+	}
+	discovery.Rejected = append(discovery.Rejected, unknown...)
+	if discovery.Selected.Path == "" {
+		return discovery, fmt.Errorf("%w: no device under %s exposes the AMTHI or LME client", ErrDeviceNotFound, sysfsClassDir)
+	}
+	return discovery, nil
+}
+
+func selectOverride(path, reason string) (Discovery, error) {
+	discovery := Discovery{Selected: Candidate{Path: path, Reason: reason}}
+	if _, err := os.Stat(path); err != nil {
+		return discovery, fmt.Errorf("%w: %s", ErrDeviceNotFound, path)
+	}
+	return discovery, nil
+}
+
+// clientUUIDs lists the ME clients the kernel exposes on the MEI bus as
+// children of the device, named "<bus id>-<client uuid>"
+func clientUUIDs(deviceDir string) (map[string]bool, error) {
+	entries, err := os.ReadDir(deviceDir)
+	if err != nil {
+		return nil, err
+	}
+	clients := map[string]bool{}
+	for _, entry := range entries {
+		if data, err := os.ReadFile(filepath.Join(deviceDir, entry.Name(), "uuid")); err == nil {
+			clients[strings.ToLower(strings.TrimSpace(string(data)))] = true
+		}
+	}
+	return clients, nil
+}
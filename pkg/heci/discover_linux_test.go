@@ -0,0 +1,96 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMEITree builds a sysfs class directory and device nodes; clients maps
+// a device name to the client uuids it exposes, nil meaning no client list
+func fakeMEITree(t *testing.T, clients map[string][]string, missingNodes ...string) {
+	root := t.TempDir()
+	sysfsClassDir = filepath.Join(root, "sys")
+	devDir = filepath.Join(root, "dev")
+	t.Cleanup(func() {
+		sysfsClassDir = "/sys/class/mei"
+		devDir = "/dev"
+	})
+	assert.NoError(t, os.MkdirAll(devDir, 0o755))
+	for name, uuids := range clients {
+		device := filepath.Join(sysfsClassDir, name, "device")
+		assert.NoError(t, os.MkdirAll(device, 0o755))
+		for _, uuid := range uuids {
+			client := filepath.Join(device, "0000:00:16.0-"+uuid)
+			assert.NoError(t, os.MkdirAll(client, 0o755))
+			assert.NoError(t, os.WriteFile(filepath.Join(client, "uuid"), []byte(uuid+"\n"), 0o644))
+		}
+		assert.NoError(t, os.WriteFile(filepath.Join(devDir, name), nil, 0o600))
+	}
+	for _, name := range missingNodes {
+		os.Remove(filepath.Join(devDir, name))
+	}
+}
+
+func TestClientUUID(t *testing.T) {
+	assert.Equal(t, "12f80028-b4b7-4b2d-aca8-46e0ff65814c", ClientUUID(MEI_IAMTHIF))
+	assert.Equal(t, "6733a4db-0476-4e7b-b3af-bcfc29bee7a7", ClientUUID(MEI_LMEIF))
+}
+
+func TestDiscoverSelectsAMTDevice(t *testing.T) {
+	t.Setenv(DeviceEnvVar, "")
+	fakeMEITree(t, map[string][]string{
+		"mei0": {"8e6a6715-9abc-4043-88ef-9e39c6f63e0f"},
+		"mei1": {ClientUUID(MEI_IAMTHIF), ClientUUID(MEI_LMEIF)},
+	})
+	discovery, err := Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(devDir, "mei1"), discovery.Selected.Path)
+	assert.Len(t, discovery.Rejected, 1)
+	assert.Equal(t, filepath.Join(devDir, "mei0"), discovery.Rejected[0].Path)
+	assert.Equal(t, "does not expose the AMTHI or LME client", discovery.Rejected[0].Reason)
+}
+
+func TestDiscoverRejectsMissingNode(t *testing.T) {
+	t.Setenv(DeviceEnvVar, "")
+	fakeMEITree(t, map[string][]string{
+		"mei0": {ClientUUID(MEI_LMEIF)},
+	}, "mei0")
+	discovery, err := Discover()
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+	assert.Equal(t, "device node is missing", discovery.Rejected[0].Reason)
+}
+
+func TestDiscoverWithoutClientList(t *testing.T) {
+	t.Setenv(DeviceEnvVar, "")
+	fakeMEITree(t, map[string][]string{"mei0": nil})
+	discovery, err := Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(devDir, "mei0"), discovery.Selected.Path)
+	assert.Contains(t, discovery.Selected.Reason, "using the first device")
+}
+
+func TestDiscoverOverrides(t *testing.T) {
+	fakeMEITree(t, map[string][]string{"mei0": nil, "mei1": nil})
+	mei1 := filepath.Join(devDir, "mei1")
+
+	t.Setenv(DeviceEnvVar, mei1)
+	discovery, err := Discover()
+	assert.NoError(t, err)
+	assert.Equal(t, mei1, discovery.Selected.Path)
+	assert.Equal(t, "set with "+DeviceEnvVar, discovery.Selected.Reason)
+
+	DevicePath = filepath.Join(devDir, "mei7")
+	defer func() { DevicePath = "" }()
+	_, err = Discover()
+	assert.ErrorIs(t, err, ErrDeviceNotFound)
+}
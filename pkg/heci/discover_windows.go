@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var warnOverride sync.Once
+
+// Discover reports the HECI interface; Windows locates it through SetupAPI
+// so device overrides are not supported
+func Discover() (Discovery, error) {
+	warnIgnoredOverride()
+	return Discovery{Selected: Candidate{Path: "HECI device interface", Reason: "located through SetupAPI"}}, nil
+}
+
+// warnIgnoredOverride tells the user once that -mei and DeviceEnvVar are not
+// used on Windows
+func warnIgnoredOverride() {
+	if DevicePath == "" && os.Getenv(DeviceEnvVar) == "" {
+		return
+	}
+	warnOverride.Do(func() {
+		log.Warn("-mei and ", DeviceEnvVar, " have no effect on Windows, the MEI device is located through SetupAPI")
+	})
+}
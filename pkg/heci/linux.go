@@ -10,6 +10,9 @@ package heci
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"syscall"
 	"unsafe"
@@ -43,16 +46,22 @@ func NewDriver() *Driver {
 }
 
 func (heci *Driver) Init(useLME bool, useWD bool) error {
-	var err error
-	heci.meiDevice, err = os.OpenFile(Device, syscall.O_RDWR, 0)
+	discovery, err := Discover()
 	if err != nil {
-		if err.Error() == "open /dev/mei0: permission denied" {
+		log.Error("AMT not found: MEI/driver is missing or the call to the HECI driver failed")
+		return err
+	}
+	device := discovery.Selected.Path
+	heci.meiDevice, err = os.OpenFile(device, syscall.O_RDWR, 0)
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
 			log.Error("need administrator privileges")
-		} else if err.Error() == "open /dev/mei0: no such file or directory" {
+			return fmt.Errorf("%w: %s", ErrAccessDenied, device)
+		} else if errors.Is(err, fs.ErrNotExist) {
 			log.Error("AMT not found: MEI/driver is missing or the call to the HECI driver failed")
-		} else {
-			log.Error("Cannot open MEI Device")
+			return fmt.Errorf("%w: %s", ErrDeviceNotFound, device)
 		}
+		log.Error("Cannot open MEI Device")
 		return err
 	}
 
@@ -73,6 +82,10 @@ func (heci *Driver) Init(useLME bool, useWD bool) error {
 		}
 	}
 	if err != nil {
+		heci.meiDevice.Close()
+		if errors.Is(err, unix.ENOTTY) {
+			return fmt.Errorf("%w: %s", ErrClientNotFound, device)
+		}
 		return err
 	}
 	t := MEIConnectClientData{}
@@ -0,0 +1,136 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LockDirEnvVar overrides the directory holding the MEI lock files
+const LockDirEnvVar = "RPC_MEI_LOCK_DIR"
+
+// LockTimeoutEnvVar overrides how long to wait for the MEI lock (ex. '30s')
+const LockTimeoutEnvVar = "RPC_MEI_LOCK_TIMEOUT"
+
+const (
+	defaultLockTimeout = 30 * time.Second
+	lockRetryInterval  = 100 * time.Millisecond
+)
+
+var ErrDeviceBusy = errors.New("MEI device is in use by another process")
+
+// LockTimeout is how long AcquireLock waits before giving up with ErrDeviceBusy
+var LockTimeout = lockTimeoutFromEnv()
+
+// Client identifies the ME client a lock guards. AMT accepts a single
+// connection per client, so each client has its own lock and an open LME
+// session does not block PTHI requests made by the same caller.
+type Client int
+
+const (
+	ClientPTHI Client = iota
+	ClientLME
+	ClientWatchdog
+)
+
+func (c Client) String() string {
+	switch c {
+	case ClientLME:
+		return "lme"
+	case ClientWatchdog:
+		return "wd"
+	default:
+		return "pthi"
+	}
+}
+
+// inProcess serializes callers within this process; the file lock only
+// arbitrates between processes on some platforms.
+var inProcess = map[Client]chan struct{}{
+	ClientPTHI:     make(chan struct{}, 1),
+	ClientLME:      make(chan struct{}, 1),
+	ClientWatchdog: make(chan struct{}, 1),
+}
+
+// Lock is an exclusive, advisory lock on one ME client
+type Lock struct {
+	client Client
+	file   *os.File
+}
+
+// AcquireLock takes the lock for client, waiting up to timeout for other
+// callers in this or another process to release it
+func AcquireLock(client Client, timeout time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(timeout)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case inProcess[client] <- struct{}{}:
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: timed out after %s waiting for %s lock", ErrDeviceBusy, timeout, client)
+	}
+
+	path := LockPath(client)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		<-inProcess[client]
+		return nil, err
+	}
+	for {
+		err = lockFile(file)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			<-inProcess[client]
+			return nil, fmt.Errorf("%w: timed out after %s waiting for %s", ErrDeviceBusy, timeout, path)
+		}
+		log.Trace("waiting for MEI lock ", path)
+		time.Sleep(lockRetryInterval)
+	}
+	return &Lock{client: client, file: file}, nil
+}
+
+// Release gives up the lock. It is safe to call on a nil Lock.
+func (l *Lock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	if err := unlockFile(l.file); err != nil {
+		log.Warn("failed to unlock ", l.file.Name(), ": ", err)
+	}
+	l.file.Close()
+	l.file = nil
+	<-inProcess[l.client]
+}
+
+// LockPath returns the lock file used for client
+func LockPath(client Client) string {
+	dir := os.Getenv(LockDirEnvVar)
+	if dir == "" {
+		dir = defaultLockDir()
+	}
+	return filepath.Join(dir, "rpc-mei-"+client.String()+".lock")
+}
+
+func lockTimeoutFromEnv() time.Duration {
+	value := os.Getenv(LockTimeoutEnvVar)
+	if value == "" {
+		return defaultLockTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.Warn("ignoring invalid ", LockTimeoutEnvVar, ": ", value)
+		return defaultLockTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func defaultLockDir() string {
+	if info, err := os.Stat("/run/lock"); err == nil && info.IsDir() {
+		return "/run/lock"
+	}
+	return os.TempDir()
+}
+
+func lockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+}
+
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
@@ -0,0 +1,76 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(LockDirEnvVar, dir)
+	assert.Equal(t, filepath.Join(dir, "rpc-mei-pthi.lock"), LockPath(ClientPTHI))
+	assert.Equal(t, filepath.Join(dir, "rpc-mei-lme.lock"), LockPath(ClientLME))
+	assert.Equal(t, filepath.Join(dir, "rpc-mei-wd.lock"), LockPath(ClientWatchdog))
+}
+
+func TestAcquireLockInProcess(t *testing.T) {
+	t.Setenv(LockDirEnvVar, t.TempDir())
+	lock, err := AcquireLock(ClientPTHI, time.Second)
+	assert.NoError(t, err)
+
+	_, err = AcquireLock(ClientPTHI, 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrDeviceBusy)
+
+	other, err := AcquireLock(ClientLME, 10*time.Millisecond)
+	assert.NoError(t, err)
+	other.Release()
+
+	acquired := make(chan *Lock)
+	go func() {
+		waiting, err := AcquireLock(ClientPTHI, time.Second)
+		assert.NoError(t, err)
+		acquired <- waiting
+	}()
+	time.Sleep(20 * time.Millisecond)
+	lock.Release()
+	(<-acquired).Release()
+}
+
+func TestAcquireLockHeldByAnotherProcess(t *testing.T) {
+	t.Setenv(LockDirEnvVar, t.TempDir())
+	// a separate open file stands in for another process holding the lock
+	file, err := os.OpenFile(LockPath(ClientLME), os.O_CREATE|os.O_RDWR, 0o600)
+	assert.NoError(t, err)
+	defer file.Close()
+	assert.NoError(t, lockFile(file))
+
+	_, err = AcquireLock(ClientLME, 150*time.Millisecond)
+	assert.ErrorIs(t, err, ErrDeviceBusy)
+
+	assert.NoError(t, unlockFile(file))
+	lock, err := AcquireLock(ClientLME, time.Second)
+	assert.NoError(t, err)
+	lock.Release()
+}
+
+func TestReleaseNilLock(t *testing.T) {
+	var lock *Lock
+	assert.NotPanics(t, lock.Release)
+}
+
+func TestLockTimeoutFromEnv(t *testing.T) {
+	t.Setenv(LockTimeoutEnvVar, "")
+	assert.Equal(t, defaultLockTimeout, lockTimeoutFromEnv())
+	t.Setenv(LockTimeoutEnvVar, "5s")
+	assert.Equal(t, 5*time.Second, lockTimeoutFromEnv())
+	t.Setenv(LockTimeoutEnvVar, "soon")
+	assert.Equal(t, defaultLockTimeout, lockTimeoutFromEnv())
+}
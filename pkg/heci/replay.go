@@ -0,0 +1,255 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+package heci
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ReplayEnvVar selects a capture file to play back instead of using the device
+const ReplayEnvVar = "RPC_HECI_REPLAY"
+
+var (
+	ErrReplayMismatch  = errors.New("replayed message differs from the capture")
+	ErrReplayExhausted = errors.New("capture has no more recorded traffic")
+	ErrReplayClosed    = errors.New("replayed HECI session is closed")
+)
+
+// ReplayFile reports the capture file selected through ReplayEnvVar
+func ReplayFile() (string, bool) {
+	path, ok := os.LookupEnv(ReplayEnvVar)
+	return path, ok && path != ""
+}
+
+// replaySession is the recorded traffic between one Init and Close
+type replaySession struct {
+	client     string
+	bufferSize uint32
+	initErr    string
+	sends      []CaptureRecord
+	receives   []CaptureRecord
+	// sendsBefore[i] is how many sends preceded receives[i] in the capture
+	sendsBefore []int
+	claimed     bool
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	sendsDone int
+	received  int
+	closed    bool
+}
+
+type replay struct {
+	mu       sync.Mutex
+	sessions []*replaySession
+}
+
+var (
+	replaysMu sync.Mutex
+	replays   = map[string]*replay{}
+)
+
+// loadReplay parses a capture once so every Replayer created in the
+// process claims sessions from the same recording
+func loadReplay(path string) (*replay, error) {
+	replaysMu.Lock()
+	defer replaysMu.Unlock()
+	if r, ok := replays[path]; ok {
+		return r, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := &replay{}
+	bySession := map[int]*replaySession{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		record := CaptureRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid capture %s line %d: %w", path, line, err)
+		}
+		session, ok := bySession[record.Session]
+		if !ok {
+			session = &replaySession{client: record.Client}
+			session.cond = sync.NewCond(&session.mu)
+			bySession[record.Session] = session
+			r.sessions = append(r.sessions, session)
+		}
+		switch record.Op {
+		case OpInit:
+			session.bufferSize = record.BufferSize
+			session.initErr = record.Error
+		case OpSend:
+			session.sends = append(session.sends, record)
+		case OpReceive:
+			session.receives = append(session.receives, record)
+			session.sendsBefore = append(session.sendsBefore, len(session.sends))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	replays[path] = r
+	return r, nil
+}
+
+// claim hands out the next unused session recorded for client
+func (r *replay) claim(client string) (*replaySession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range r.sessions {
+		if !session.claimed && session.client == client {
+			session.claimed = true
+			return session, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no %s session left", ErrReplayExhausted, client)
+}
+
+// digestValues are the Authorization parameters derived from the random
+// client nonce, so they differ on every run
+var digestValues = [][]byte{[]byte(`cnonce="`), []byte(`response="`)}
+
+// matchesCapture compares a sent frame with the recorded one. WS-MAN requests
+// on LME are signed with a fresh digest cnonce, so the cnonce and response
+// values of their Authorization header are left out of the comparison.
+func matchesCapture(client string, recorded, sent []byte) bool {
+	if bytes.Equal(recorded, sent) {
+		return true
+	}
+	if client != ClientLME.String() || len(recorded) != len(sent) {
+		return false
+	}
+	start := bytes.Index(recorded, []byte("\r\nAuthorization: Digest "))
+	if start < 0 {
+		return false
+	}
+	end := start + 2 + bytes.Index(recorded[start+2:], []byte("\r\n"))
+	if end < start+2 {
+		end = len(recorded)
+	}
+	masked := append([]byte{}, sent...)
+	for _, name := range digestValues {
+		value := bytes.Index(recorded[start:end], name)
+		if value < 0 {
+			continue
+		}
+		for i := start + value + len(name); i < end && recorded[i] != '"'; i++ {
+			masked[i] = recorded[i]
+		}
+	}
+	return bytes.Equal(recorded, masked)
+}
+
+// Replayer is a heci.Interface that plays a capture back. Sends must match
+// the recording byte for byte, apart from the digest values matchesCapture
+// skips on LME, and each recorded receive is returned once the sends that
+// preceded it in the capture have been made.
+type Replayer struct {
+	path    string
+	session *replaySession
+}
+
+func NewReplayer(path string) *Replayer {
+	return &Replayer{path: path}
+}
+
+func (r *Replayer) Init(useLME bool, useWD bool) error {
+	recording, err := loadReplay(r.path)
+	if err != nil {
+		return err
+	}
+	client := ClientPTHI
+	if useWD {
+		client = ClientWatchdog
+	} else if useLME {
+		client = ClientLME
+	}
+	r.session, err = recording.claim(client.String())
+	if err != nil {
+		return err
+	}
+	if r.session.initErr != "" {
+		return errors.New(r.session.initErr)
+	}
+	return nil
+}
+
+func (r *Replayer) GetBufferSize() uint32 {
+	if r.session == nil {
+		return 0
+	}
+	return r.session.bufferSize
+}
+
+func (r *Replayer) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
+	s := r.session
+	if s == nil {
+		return 0, ErrReplayClosed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, ErrReplayClosed
+	}
+	if s.sendsDone >= len(s.sends) {
+		return 0, fmt.Errorf("%w: unexpected %s send of %d bytes", ErrReplayExhausted, s.client, len(buffer))
+	}
+	record := s.sends[s.sendsDone]
+	if !matchesCapture(s.client, record.Data, buffer) {
+		return 0, fmt.Errorf("%w: %s send #%d (capture seq %d)", ErrReplayMismatch, s.client, s.sendsDone+1, record.Seq)
+	}
+	s.sendsDone++
+	s.cond.Broadcast()
+	if record.Error != "" {
+		return 0, errors.New(record.Error)
+	}
+	return uint32(len(buffer)), nil
+}
+
+func (r *Replayer) ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error) {
+	s := r.session
+	if s == nil {
+		return 0, ErrReplayClosed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// past the end of the recording behave like a device with nothing to read
+	for !s.closed && (s.received >= len(s.receives) || s.sendsDone < s.sendsBefore[s.received]) {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return 0, ErrReplayClosed
+	}
+	record := s.receives[s.received]
+	s.received++
+	if record.Error != "" {
+		return 0, errors.New(record.Error)
+	}
+	return uint32(copy(buffer, record.Data)), nil
+}
+
+func (r *Replayer) Close() {
+	s := r.session
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
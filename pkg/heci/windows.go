@@ -53,6 +53,7 @@ func NewDriver() *Driver {
 
 func (heci *Driver) Init(useLME bool, useWD bool) error {
 	var err, err2 error
+	warnIgnoredOverride()
 
 	heci.LMEGUID, err = windows.GUIDFromString("{6733A4DB-0476-4E7B-B3AF-BCFC29BEE7A7}")
 	if err != nil {
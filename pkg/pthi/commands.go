@@ -8,11 +8,19 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"rpc/pkg/heci"
 )
 
 type Command struct {
 	Heci heci.Interface
+	lock *deviceLock
+}
+
+// deviceLock holds the MEI client lock between Open and Close. It is shared
+// by copies of a Command so the value receivers below can update it.
+type deviceLock struct {
+	held *heci.Lock
 }
 
 type Interface interface {
@@ -31,26 +39,96 @@ type Interface interface {
 	GetLANInterfaceSettings(useWireless bool) (LANInterface GetLANInterfaceSettingsResponse, err error)
 	GetLocalSystemAccount() (localAccount GetLocalSystemAccountResponse, err error)
 	Unprovision() (mode int, err error)
+	GetFeaturesState(requestID FeaturesStateRequestID) (response GetFeaturesStateResponse, err error)
+	GetLastHostResetReason() (response GetLastHostResetReasonResponse, err error)
+	GetCurrentPowerPolicy() (policy string, err error)
+	GetMACAddresses() (response GetMACAddressesResponse, err error)
+	GetSecurityParameters() (response GetSecurityParametersResponse, err error)
+	GetProvisioningTLSMode() (mode int, err error)
+	GetZeroTouchEnabled() (enabled bool, err error)
+	GetFQDN() (response GetFQDNResponse, err error)
+	GetEHBCState() (state int, err error)
+	GetDNSSuffixList() (suffixes []string, err error)
+	SetDNSSuffix(suffix string) (Status, error)
+	SetHostFQDN(fqdn string) (Status, error)
+	GetProvisioningState() (state int, err error)
+	StopConfiguration() (Status, error)
+	StartConfiguration() (Status, error)
+	SetProvisioningServerOTP(otp string) (Status, error)
+	OpenUserInitiatedConnection() (Status, error)
+	CloseUserInitiatedConnection() (Status, error)
+	StartWatchdogTimer(timeout uint16) (WatchdogStartTimerResponse, error)
+	StopWatchdogTimer() error
 }
 
 func NewCommand() Command {
+	var device heci.Interface = heci.NewDriver()
+	if stateFile, ok := EmulatorStateFile(); ok {
+		device = NewEmulator(stateFile)
+	} else if captureFile, ok := heci.ReplayFile(); ok {
+		device = heci.NewReplayer(captureFile)
+	}
+	if capture := heci.ActiveCapture(); capture != nil {
+		device = capture.Wrap(device)
+	}
 	return Command{
-		Heci: heci.NewDriver(),
+		Heci: device,
+		lock: &deviceLock{},
 	}
 }
 
 func (pthi Command) Open(useLME bool) error {
+	client := heci.ClientPTHI
+	if useLME {
+		client = heci.ClientLME
+	}
+	if err := pthi.acquire(client); err != nil {
+		return err
+	}
 	err := pthi.Heci.Init(useLME, false)
+	if err != nil {
+		pthi.release()
+	}
 	return err
 }
 
 func (pthi Command) OpenWatchdog() error {
+	if err := pthi.acquire(heci.ClientWatchdog); err != nil {
+		return err
+	}
 	err := pthi.Heci.Init(false, true)
+	if err != nil {
+		pthi.release()
+	}
 	return err
 }
 
 func (pthi Command) Close() {
 	pthi.Heci.Close()
+	pthi.release()
+}
+
+// acquire takes the cross-process lock for client. Commands built without
+// NewCommand (such as test doubles) are not locked.
+func (pthi Command) acquire(client heci.Client) error {
+	if pthi.lock == nil || pthi.lock.held != nil {
+		// reopening without Close keeps the lock already held
+		return nil
+	}
+	held, err := heci.AcquireLock(client, heci.LockTimeout)
+	if err != nil {
+		return err
+	}
+	pthi.lock.held = held
+	return nil
+}
+
+func (pthi Command) release() {
+	if pthi.lock == nil {
+		return
+	}
+	pthi.lock.held.Release()
+	pthi.lock.held = nil
 }
 
 func (pthi Command) Call(command []byte, commandSize uint32) (result []byte, err error) {
@@ -125,6 +203,9 @@ func (pthi Command) GetCodeVersions() (GetCodeVersionsResponse, error) {
 	response := GetCodeVersionsResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return GetCodeVersionsResponse{}, err
+	}
 	binary.Read(buf2, binary.LittleEndian, &response.CodeVersion)
 
 	return response, nil
@@ -144,6 +225,9 @@ func (pthi Command) GetUUID() (uuid string, err error) {
 	response := GetUUIDResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return "", err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.UUID)
 
@@ -164,6 +248,9 @@ func (pthi Command) GetControlMode() (state int, err error) {
 	response := GetControlModeResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return -1, err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.State)
 	return int(response.State), nil
@@ -233,6 +320,9 @@ func (pthi Command) Unprovision() (state int, err error) {
 	response := UnprovisionResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return -1, err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.State)
 	return int(response.State), nil
@@ -265,6 +355,9 @@ func (pthi Command) GetDNSSuffix() (suffix string, err error) {
 	response := GetPKIFQDNSuffixResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return "", err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.Suffix.Length)
 	binary.Read(buf2, binary.LittleEndian, &response.Suffix.Buffer)
@@ -291,6 +384,9 @@ func (pthi Command) enumerateHashHandles() (AMTHashHandles, error) {
 	enumerateResponse := GetHashHandlesResponse{
 		Header: readHeaderResponse(enumerateBuf2),
 	}
+	if err = enumerateResponse.Header.Status.Err(); err != nil {
+		return AMTHashHandles{}, err
+	}
 
 	binary.Read(enumerateBuf2, binary.LittleEndian, &enumerateResponse.HashHandles.Length)
 	binary.Read(enumerateBuf2, binary.LittleEndian, &enumerateResponse.HashHandles.Handles)
@@ -321,6 +417,9 @@ func (pthi Command) GetCertificateHashes(hashHandles AMTHashHandles) (hashEntryL
 		response := GetCertHashEntryResponse{
 			Header: readHeaderResponse(buf2),
 		}
+		if err = response.Header.Status.Err(); err != nil {
+			return []CertHashEntry{}, err
+		}
 
 		binary.Read(buf2, binary.LittleEndian, &response.Hash.IsDefault)
 		binary.Read(buf2, binary.LittleEndian, &response.Hash.IsActive)
@@ -350,6 +449,9 @@ func (pthi Command) GetRemoteAccessConnectionStatus() (RAStatus GetRemoteAccessC
 	response := GetRemoteAccessConnectionStatusResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return GetRemoteAccessConnectionStatusResponse{}, err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.NetworkStatus)
 	binary.Read(buf2, binary.LittleEndian, &response.RemoteStatus)
@@ -380,6 +482,9 @@ func (pthi Command) GetLANInterfaceSettings(useWireless bool) (LANInterface GetL
 	response := GetLANInterfaceSettingsResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return GetLANInterfaceSettingsResponse{}, err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.Enabled)
 	binary.Read(buf2, binary.LittleEndian, &response.Ipv4Address)
@@ -407,9 +512,385 @@ func (pthi Command) GetLocalSystemAccount() (localAccount GetLocalSystemAccountR
 	response := GetLocalSystemAccountResponse{
 		Header: readHeaderResponse(buf2),
 	}
+	if err = response.Header.Status.Err(); err != nil {
+		return GetLocalSystemAccountResponse{}, err
+	}
 
 	binary.Read(buf2, binary.LittleEndian, &response.Account.Username)
 	binary.Read(buf2, binary.LittleEndian, &response.Account.Password)
 
 	return response, nil
 }
+
+// sendRequest sends a request without a body and returns the response
+// header, the response body is left in the buffer
+func (pthi Command) sendRequest(requestCode uint32) (*bytes.Buffer, ResponseMessageHeader, error) {
+	command := GetRequest{
+		Header: CreateRequestHeader(requestCode, 0),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := pthi.Call(bin_buf.Bytes(), GET_REQUEST_SIZE)
+	if err != nil {
+		return nil, ResponseMessageHeader{}, err
+	}
+	buf2 := bytes.NewBuffer(result)
+	return buf2, readHeaderResponse(buf2), nil
+}
+
+// getRequest is sendRequest for getters, the body is only worth decoding
+// when the status is success so any other status is returned as the error
+func (pthi Command) getRequest(requestCode uint32) (*bytes.Buffer, ResponseMessageHeader, error) {
+	buf2, header, err := pthi.sendRequest(requestCode)
+	if err != nil {
+		return nil, header, err
+	}
+	if err = header.Status.Err(); err != nil {
+		return nil, header, err
+	}
+	return buf2, header, nil
+}
+
+func (pthi Command) GetFeaturesState(requestID FeaturesStateRequestID) (response GetFeaturesStateResponse, err error) {
+	commandSize := (uint32)(16)
+	command := GetFeaturesStateRequest{
+		Header:    CreateRequestHeader(GET_FEATURES_STATE_REQUEST, 4),
+		RequestID: requestID,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := pthi.Call(bin_buf.Bytes(), commandSize)
+	if err != nil {
+		return GetFeaturesStateResponse{}, err
+	}
+	buf2 := bytes.NewBuffer(result)
+	response = GetFeaturesStateResponse{
+		Header: readHeaderResponse(buf2),
+	}
+	if err = response.Header.Status.Err(); err != nil {
+		return GetFeaturesStateResponse{}, err
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.RequestID)
+	binary.Read(buf2, binary.LittleEndian, &response.Data)
+
+	return response, nil
+}
+
+func (pthi Command) GetLastHostResetReason() (response GetLastHostResetReasonResponse, err error) {
+	buf2, header, err := pthi.getRequest(GET_LAST_HOST_RESET_REASON_REQUEST)
+	if err != nil {
+		return GetLastHostResetReasonResponse{}, err
+	}
+	response = GetLastHostResetReasonResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.Reason)
+	binary.Read(buf2, binary.LittleEndian, &response.RemoteControlTimeStamp)
+
+	return response, nil
+}
+
+func (pthi Command) GetCurrentPowerPolicy() (policy string, err error) {
+	buf2, header, err := pthi.getRequest(GET_CURRENT_POWER_POLICY_REQUEST)
+	if err != nil {
+		return "", err
+	}
+	response := GetCurrentPowerPolicyResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.PolicyName.Length)
+	binary.Read(buf2, binary.LittleEndian, &response.PolicyName.Buffer)
+
+	if int(response.PolicyName.Length) > 0 {
+		return string(response.PolicyName.Buffer[:response.PolicyName.Length]), nil
+	}
+
+	return "", nil
+}
+
+func (pthi Command) GetMACAddresses() (response GetMACAddressesResponse, err error) {
+	buf2, header, err := pthi.getRequest(GET_MAC_ADDRESSES_REQUEST)
+	if err != nil {
+		return GetMACAddressesResponse{}, err
+	}
+	response = GetMACAddressesResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.DedicatedMac)
+	binary.Read(buf2, binary.LittleEndian, &response.HostMac)
+
+	return response, nil
+}
+
+func (pthi Command) GetSecurityParameters() (response GetSecurityParametersResponse, err error) {
+	buf2, header, err := pthi.getRequest(GET_SECURITY_PARAMETERS_REQUEST)
+	if err != nil {
+		return GetSecurityParametersResponse{}, err
+	}
+	response = GetSecurityParametersResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.EnterpriseMode)
+	binary.Read(buf2, binary.LittleEndian, &response.TLSEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.HWCryptoEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.ProvisioningState)
+	binary.Read(buf2, binary.LittleEndian, &response.NetworkInterfaceEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.SOLEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.IDEREnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.FWUpdateEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.LinkIsUp)
+	binary.Read(buf2, binary.LittleEndian, &response.Reserved)
+
+	return response, nil
+}
+
+func (pthi Command) GetProvisioningTLSMode() (mode int, err error) {
+	buf2, header, err := pthi.getRequest(GET_PROVISIONING_TLS_MODE_REQUEST)
+	if err != nil {
+		return -1, err
+	}
+	response := GetProvisioningTLSModeResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.ProvisioningTLSMode)
+	return int(response.ProvisioningTLSMode), nil
+}
+
+func (pthi Command) GetZeroTouchEnabled() (enabled bool, err error) {
+	buf2, header, err := pthi.getRequest(GET_ZERO_TOUCH_ENABLED_REQUEST)
+	if err != nil {
+		return false, err
+	}
+	response := GetZeroTouchEnabledResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.ZeroTouchEnabled)
+	return response.ZeroTouchEnabled > 0, nil
+}
+
+func (pthi Command) GetFQDN() (response GetFQDNResponse, err error) {
+	buf2, header, err := pthi.getRequest(GET_FQDN_REQUEST)
+	if err != nil {
+		return GetFQDNResponse{}, err
+	}
+	response = GetFQDNResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.SharedFQDN)
+	binary.Read(buf2, binary.LittleEndian, &response.DDNSUpdateEnabled)
+	binary.Read(buf2, binary.LittleEndian, &response.DDNSPeriodicUpdateInterval)
+	binary.Read(buf2, binary.LittleEndian, &response.DDNSTTL)
+	binary.Read(buf2, binary.LittleEndian, &response.FQDN.Length)
+	binary.Read(buf2, binary.LittleEndian, &response.FQDN.Buffer)
+
+	return response, nil
+}
+
+func (pthi Command) GetEHBCState() (state int, err error) {
+	buf2, header, err := pthi.getRequest(GET_EHBC_STATE_REQUEST)
+	if err != nil {
+		return -1, err
+	}
+	response := GetEHBCStateResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.EHBCState)
+	return int(response.EHBCState), nil
+}
+
+// GetDNSSuffixList returns the DNS suffixes AMT learned from DHCP option 15,
+// which are sent as a single buffer of NUL separated names
+func (pthi Command) GetDNSSuffixList() (suffixes []string, err error) {
+	buf2, header, err := pthi.getRequest(GET_DNS_SUFFIX_LIST_REQUEST)
+	if err != nil {
+		return []string{}, err
+	}
+	response := GetDNSSuffixListResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.DataLength)
+	binary.Read(buf2, binary.LittleEndian, &response.Data)
+
+	suffixes = []string{}
+	length := int(response.DataLength)
+	if length > len(response.Data) {
+		length = len(response.Data)
+	}
+	for _, suffix := range bytes.Split(response.Data[:length], []byte{0}) {
+		if len(suffix) > 0 {
+			suffixes = append(suffixes, string(suffix))
+		}
+	}
+	return suffixes, nil
+}
+
+func (pthi Command) SetDNSSuffix(suffix string) (Status, error) {
+	if len(suffix) > HOST_FQDN_MAX_LENGTH {
+		return Status(0), fmt.Errorf("dns suffix longer than %d bytes", HOST_FQDN_MAX_LENGTH)
+	}
+	command := SetDNSSuffixRequest{
+		Header: CreateRequestHeader(SET_DNS_SUFFIX_REQUEST, uint32(2+len(suffix))),
+		Length: uint16(len(suffix)),
+		Suffix: []uint8(suffix),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command.Header)
+	binary.Write(&bin_buf, binary.LittleEndian, command.Length)
+	binary.Write(&bin_buf, binary.LittleEndian, command.Suffix)
+	result, err := pthi.Call(bin_buf.Bytes(), uint32(bin_buf.Len()))
+	if err != nil {
+		return Status(0), err
+	}
+	buf2 := bytes.NewBuffer(result)
+	response := SetResponse{
+		Header: readHeaderResponse(buf2),
+	}
+	return response.Header.Status, nil
+}
+
+func (pthi Command) SetHostFQDN(fqdn string) (Status, error) {
+	if len(fqdn) > HOST_FQDN_MAX_LENGTH {
+		return Status(0), fmt.Errorf("host fqdn longer than %d bytes", HOST_FQDN_MAX_LENGTH)
+	}
+	command := SetHostFQDNRequest{
+		Header: CreateRequestHeader(SET_HOST_FQDN_REQUEST, uint32(2+len(fqdn))),
+		Length: uint16(len(fqdn)),
+		FQDN:   []uint8(fqdn),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command.Header)
+	binary.Write(&bin_buf, binary.LittleEndian, command.Length)
+	binary.Write(&bin_buf, binary.LittleEndian, command.FQDN)
+	result, err := pthi.Call(bin_buf.Bytes(), uint32(bin_buf.Len()))
+	if err != nil {
+		return Status(0), err
+	}
+	buf2 := bytes.NewBuffer(result)
+	response := SetResponse{
+		Header: readHeaderResponse(buf2),
+	}
+	return response.Header.Status, nil
+}
+
+func (pthi Command) GetProvisioningState() (state int, err error) {
+	buf2, header, err := pthi.getRequest(PROVISIONING_STATE_REQUEST)
+	if err != nil {
+		return -1, err
+	}
+	response := GetProvisioningStateResponse{
+		Header: header,
+	}
+
+	binary.Read(buf2, binary.LittleEndian, &response.ProvisioningState)
+	return int(response.ProvisioningState), nil
+}
+
+// StopConfiguration cancels a remote configuration session that
+// left the device in the "in provisioning" state
+func (pthi Command) StopConfiguration() (Status, error) {
+	_, header, err := pthi.sendRequest(STOP_CONFIGURATION_REQUEST)
+	if err != nil {
+		return Status(0), err
+	}
+	return header.Status, nil
+}
+
+func (pthi Command) StartConfiguration() (Status, error) {
+	_, header, err := pthi.sendRequest(START_CONFIGURATION_REQUEST)
+	if err != nil {
+		return Status(0), err
+	}
+	return header.Status, nil
+}
+
+func (pthi Command) SetProvisioningServerOTP(otp string) (Status, error) {
+	if len(otp) > OTP_MAX_LENGTH {
+		return Status(0), fmt.Errorf("otp longer than %d bytes", OTP_MAX_LENGTH)
+	}
+	command := SetProvisioningServerOTPRequest{
+		Header: CreateRequestHeader(SET_PROVISIONING_SERVER_OTP_REQUEST, uint32(2+len(otp))),
+		Length: uint16(len(otp)),
+		OTP:    []uint8(otp),
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command.Header)
+	binary.Write(&bin_buf, binary.LittleEndian, command.Length)
+	binary.Write(&bin_buf, binary.LittleEndian, command.OTP)
+	result, err := pthi.Call(bin_buf.Bytes(), uint32(bin_buf.Len()))
+	if err != nil {
+		return Status(0), err
+	}
+	buf2 := bytes.NewBuffer(result)
+	response := SetResponse{
+		Header: readHeaderResponse(buf2),
+	}
+	return response.Header.Status, nil
+}
+
+func (pthi Command) OpenUserInitiatedConnection() (Status, error) {
+	_, header, err := pthi.sendRequest(OPEN_USER_INITIATED_CONNECTION_REQUEST)
+	if err != nil {
+		return Status(0), err
+	}
+	return header.Status, nil
+}
+
+func (pthi Command) CloseUserInitiatedConnection() (Status, error) {
+	_, header, err := pthi.sendRequest(CLOSE_USER_INITIATED_CONNECTION_REQUEST)
+	if err != nil {
+		return Status(0), err
+	}
+	return header.Status, nil
+}
+
+// StartWatchdogTimer (re)arms the watchdog timer for timeout seconds, it has
+// to be sent on the watchdog client opened with OpenWatchdog
+func (pthi Command) StartWatchdogTimer(timeout uint16) (WatchdogStartTimerResponse, error) {
+	command := WatchdogStartTimerRequest{
+		Command:       WATCHDOG_MANAGEMENT_CONTROL,
+		ByteCount:     WATCHDOG_START_TIMER_BYTECOUNT,
+		SubCommand:    WATCHDOG_START_TIMER_REQUEST,
+		VersionNumber: WATCHDOG_VERSION_NUMBER,
+		Timeout:       timeout,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	result, err := pthi.Call(bin_buf.Bytes(), uint32(bin_buf.Len()))
+	if err != nil {
+		return WatchdogStartTimerResponse{}, err
+	}
+	response := WatchdogStartTimerResponse{}
+	if err = binary.Read(bytes.NewBuffer(result), binary.LittleEndian, &response); err != nil {
+		return WatchdogStartTimerResponse{}, err
+	}
+	if response.Command != WATCHDOG_MANAGEMENT_CONTROL || response.SubCommand != WATCHDOG_START_TIMER_RESPONSE {
+		return WatchdogStartTimerResponse{}, errors.New("unexpected watchdog timer response")
+	}
+	if err = Status(response.Status).Err(); err != nil {
+		return WatchdogStartTimerResponse{}, err
+	}
+	return response, nil
+}
+
+// StopWatchdogTimer disarms the watchdog timer, the firmware does not answer it
+func (pthi Command) StopWatchdogTimer() error {
+	command := WatchdogStopTimerRequest{
+		Command:       WATCHDOG_MANAGEMENT_CONTROL,
+		ByteCount:     0,
+		SubCommand:    WATCHDOG_STOP_TIMER_REQUEST,
+		VersionNumber: WATCHDOG_VERSION_NUMBER,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, command)
+	return pthi.Send(bin_buf.Bytes(), uint32(bin_buf.Len()))
+}
@@ -8,7 +8,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"rpc/pkg/heci"
 	"testing"
+	"time"
 
 	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
 	"github.com/stretchr/testify/assert"
@@ -76,6 +78,31 @@ func TestOpen(t *testing.T) {
 		mockInitErr = nil
 	})
 }
+func TestOpenHoldsDeviceLock(t *testing.T) {
+	t.Setenv(heci.LockDirEnvVar, t.TempDir())
+	timeout := heci.LockTimeout
+	heci.LockTimeout = 50 * time.Millisecond
+	defer func() { heci.LockTimeout = timeout }()
+
+	first := Command{Heci: &MockHECICommands{}, lock: &deviceLock{}}
+	second := Command{Heci: &MockHECICommands{}, lock: &deviceLock{}}
+	assert.NoError(t, first.Open(false))
+	assert.ErrorIs(t, second.Open(false), heci.ErrDeviceBusy)
+	// the LME client has its own lock
+	assert.NoError(t, second.Open(true))
+	second.Close()
+	first.Close()
+	assert.NoError(t, second.Open(false))
+	second.Close()
+
+	t.Run("expect lock released when Init fails", func(t *testing.T) {
+		mockInitErr = errors.New("test error")
+		assert.Error(t, first.Open(false))
+		mockInitErr = nil
+		assert.NoError(t, second.Open(false))
+		second.Close()
+	})
+}
 func TestAMTOperationalState(t *testing.T) {
 	assert.Equal(t, "disabled", AmtDisabled.String())
 	assert.Equal(t, "enabled", AmtEnabled.String())
@@ -138,6 +165,20 @@ func TestGetControlMode(t *testing.T) {
 	assert.Equal(t, 3, result)
 }
 
+func TestGetControlModeStatusError(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetControlModeResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_NOT_READY},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetControlMode()
+	assert.Equal(t, AMT_STATUS_NOT_READY.CustomError(), err)
+	assert.Equal(t, -1, result)
+}
+
 func TestUnprovision(t *testing.T) {
 	numBytes = GET_REQUEST_SIZE + 4
 	prepareMessage := UnprovisionResponse{
@@ -211,7 +252,7 @@ func TestEnumerateHashHandles(t *testing.T) {
 func TestGetCertificateHashes(t *testing.T) { // Needs more work
 	numBytes = 16
 	prepareMessage2 := GetCertHashEntryResponse{
-		Header: ResponseMessageHeader{Status: 1},
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
 		Hash: CertHashEntry{
 			IsDefault:       1,
 			IsActive:        1,
@@ -342,3 +383,378 @@ func TestSetAmtOperationalState(t *testing.T) {
 	assert.NotEmpty(t, result)
 	assert.Equal(t, AMT_STATUS_INVALID_AMT_MODE, result)
 }
+
+func TestGetFeaturesState(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE + 4
+	prepareMessage := GetFeaturesStateResponse{
+		Header:    ResponseMessageHeader{},
+		RequestID: FeaturesStateRedirectionSession,
+		Data:      [3]uint8{1, 0, 0},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetFeaturesState(FeaturesStateRedirectionSession)
+	assert.NoError(t, err)
+	assert.Equal(t, FeaturesStateRedirectionSession, result.RequestID)
+	assert.Equal(t, [3]uint8{1, 0, 0}, result.Data)
+}
+
+func TestGetLastHostResetReason(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetLastHostResetReasonResponse{
+		Header:                 ResponseMessageHeader{},
+		Reason:                 0,
+		RemoteControlTimeStamp: 1700000000,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetLastHostResetReason()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), result.Reason)
+	assert.Equal(t, uint32(1700000000), result.RemoteControlTimeStamp)
+}
+
+func TestGetCurrentPowerPolicy(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetCurrentPowerPolicyResponse{
+		Header: ResponseMessageHeader{},
+		PolicyName: AMTANSIString{
+			Length: 5,
+			Buffer: [1000]uint8{'S', '0', '-', 'S', '5'},
+		},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetCurrentPowerPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, "S0-S5", result)
+}
+
+func TestGetCurrentPowerPolicyStatusError(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetCurrentPowerPolicyResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_INVALID_PROVISIONING_STATE},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetCurrentPowerPolicy()
+	assert.Equal(t, AMT_STATUS_INVALID_PROVISIONING_STATE.CustomError(), err)
+	assert.Equal(t, "", result)
+}
+
+func TestGettersStatusError(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, ResponseMessageHeader{Status: AMT_STATUS_NOT_PERMITTED})
+	// a garbage body must not be decoded when the status is not success
+	bin_buf.Write(bytes.Repeat([]byte{0xff}, 64))
+	message = bin_buf.Bytes()
+
+	getters := map[string]func() error{
+		"GetLastHostResetReason": func() error { _, err := pthi.GetLastHostResetReason(); return err },
+		"GetCurrentPowerPolicy":  func() error { _, err := pthi.GetCurrentPowerPolicy(); return err },
+		"GetMACAddresses":        func() error { _, err := pthi.GetMACAddresses(); return err },
+		"GetSecurityParameters":  func() error { _, err := pthi.GetSecurityParameters(); return err },
+		"GetProvisioningTLSMode": func() error { _, err := pthi.GetProvisioningTLSMode(); return err },
+		"GetZeroTouchEnabled":    func() error { _, err := pthi.GetZeroTouchEnabled(); return err },
+		"GetFQDN":                func() error { _, err := pthi.GetFQDN(); return err },
+		"GetEHBCState":           func() error { _, err := pthi.GetEHBCState(); return err },
+		"GetDNSSuffixList":       func() error { _, err := pthi.GetDNSSuffixList(); return err },
+		"GetProvisioningState":   func() error { _, err := pthi.GetProvisioningState(); return err },
+	}
+	for name, getter := range getters {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, AMT_STATUS_NOT_PERMITTED.CustomError(), getter())
+		})
+	}
+}
+
+func TestGetMACAddresses(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetMACAddressesResponse{
+		Header:       ResponseMessageHeader{},
+		DedicatedMac: [6]uint8{1, 2, 3, 4, 5, 6},
+		HostMac:      [6]uint8{6, 5, 4, 3, 2, 1},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetMACAddresses()
+	assert.NoError(t, err)
+	assert.Equal(t, [6]uint8{1, 2, 3, 4, 5, 6}, result.DedicatedMac)
+	assert.Equal(t, [6]uint8{6, 5, 4, 3, 2, 1}, result.HostMac)
+}
+
+func TestGetSecurityParameters(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetSecurityParametersResponse{
+		Header:            ResponseMessageHeader{},
+		TLSEnabled:        1,
+		ProvisioningState: 2,
+		LinkIsUp:          1,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetSecurityParameters()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), result.TLSEnabled)
+	assert.Equal(t, uint32(2), result.ProvisioningState)
+	assert.Equal(t, uint8(1), result.LinkIsUp)
+}
+
+func TestGetProvisioningTLSMode(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetProvisioningTLSModeResponse{
+		Header:              ResponseMessageHeader{},
+		ProvisioningTLSMode: 2,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetProvisioningTLSMode()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result)
+}
+
+func TestGetZeroTouchEnabled(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetZeroTouchEnabledResponse{
+		Header:           ResponseMessageHeader{},
+		ZeroTouchEnabled: 1,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetZeroTouchEnabled()
+	assert.NoError(t, err)
+	assert.True(t, result)
+}
+
+func TestGetFQDN(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetFQDNResponse{
+		Header:            ResponseMessageHeader{},
+		SharedFQDN:        1,
+		DDNSUpdateEnabled: 1,
+		DDNSTTL:           900,
+		FQDN: AMTANSIString{
+			Length: 4,
+			Buffer: [1000]uint8{'h', 'o', 's', 't'},
+		},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetFQDN()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(1), result.SharedFQDN)
+	assert.Equal(t, uint32(900), result.DDNSTTL)
+	assert.Equal(t, "host", string(result.FQDN.Buffer[:result.FQDN.Length]))
+}
+
+func TestGetEHBCState(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetEHBCStateResponse{
+		Header:    ResponseMessageHeader{},
+		EHBCState: 1,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetEHBCState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+}
+
+func TestGetDNSSuffixList(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetDNSSuffixListResponse{
+		Header:     ResponseMessageHeader{},
+		DataLength: 12,
+		Data:       [1000]uint8{'a', '.', 'c', 'o', 'm', 0, 'b', '.', 'c', 'o', 'm', 0},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetDNSSuffixList()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.com", "b.com"}, result)
+}
+
+func TestSetDNSSuffix(t *testing.T) {
+	suffix := "vprodemo.com"
+	numBytes = GET_REQUEST_SIZE + 2 + uint32(len(suffix))
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.SetDNSSuffix(suffix)
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, result)
+}
+
+func TestSetHostFQDN(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE + 2 + uint32(len("host.vprodemo.com"))
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_NOT_PERMITTED},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.SetHostFQDN("host.vprodemo.com")
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_NOT_PERMITTED, result)
+}
+
+func TestSetDNSSuffixAndHostFQDNTooLong(t *testing.T) {
+	tooLong := string(make([]byte, HOST_FQDN_MAX_LENGTH+1))
+	_, err := pthi.SetDNSSuffix(tooLong)
+	assert.EqualError(t, err, "dns suffix longer than 256 bytes")
+	_, err = pthi.SetHostFQDN(tooLong)
+	assert.EqualError(t, err, "host fqdn longer than 256 bytes")
+}
+
+func TestGetProvisioningState(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := GetProvisioningStateResponse{
+		Header:            ResponseMessageHeader{},
+		ProvisioningState: 1,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.GetProvisioningState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+}
+
+func TestStopConfiguration(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_INVALID_AMT_MODE},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.StopConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_INVALID_AMT_MODE, result)
+}
+
+func TestStartConfiguration(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.StartConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, result)
+}
+
+func TestSetProvisioningServerOTP(t *testing.T) {
+	otp := "Otp12345"
+	numBytes = GET_REQUEST_SIZE + 2 + uint32(len(otp))
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.SetProvisioningServerOTP(otp)
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, result)
+
+	_, err = pthi.SetProvisioningServerOTP(string(make([]byte, OTP_MAX_LENGTH+1)))
+	assert.EqualError(t, err, "otp longer than 32 bytes")
+}
+
+func TestOpenUserInitiatedConnection(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.OpenUserInitiatedConnection()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, result)
+}
+
+func TestCloseUserInitiatedConnection(t *testing.T) {
+	numBytes = GET_REQUEST_SIZE
+	prepareMessage := SetResponse{
+		Header: ResponseMessageHeader{Status: AMT_STATUS_SUCCESS},
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+
+	result, err := pthi.CloseUserInitiatedConnection()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, result)
+}
+
+func TestStartWatchdogTimer(t *testing.T) {
+	prepareMessage := WatchdogStartTimerResponse{
+		Command:       WATCHDOG_MANAGEMENT_CONTROL,
+		SubCommand:    WATCHDOG_START_TIMER_RESPONSE,
+		VersionNumber: WATCHDOG_VERSION_NUMBER,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+	numBytes = 23
+
+	result, err := pthi.StartWatchdogTimer(120)
+	assert.NoError(t, err)
+	assert.True(t, result.Required())
+}
+
+func TestStartWatchdogTimerUnexpectedResponse(t *testing.T) {
+	prepareMessage := WatchdogStartTimerResponse{
+		Command:    WATCHDOG_MANAGEMENT_CONTROL,
+		SubCommand: WATCHDOG_START_TIMER_REQUEST,
+	}
+	var bin_buf bytes.Buffer
+	binary.Write(&bin_buf, binary.LittleEndian, prepareMessage)
+	message = bin_buf.Bytes()
+	numBytes = 23
+
+	_, err := pthi.StartWatchdogTimer(120)
+	assert.Error(t, err)
+}
+
+func TestStopWatchdogTimer(t *testing.T) {
+	numBytes = 4
+	err := pthi.StopWatchdogTimer()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,807 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package pthi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+)
+
+// EmulatorEnvVar names the environment variable that selects the emulated
+// HECI driver. Its value is the path of the device-state file to serve.
+const EmulatorEnvVar = "RPC_HECI_EMULATOR"
+
+const (
+	emulatorPTHIBufferSize = 5120
+	emulatorLMEBufferSize  = 8192
+)
+
+// emulatorForwardedPorts are the ports AMT asks the host to forward, in order
+var emulatorForwardedPorts = []uint32{16992, 16993}
+
+var ErrEmulatorClosed = errors.New("emulated HECI device is closed")
+
+type EmulatedVersion struct {
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type EmulatedCertHash struct {
+	Name      string `json:"name"`
+	Algorithm uint8  `json:"algorithm"`
+	Hash      string `json:"hash"`
+	IsDefault bool   `json:"isDefault"`
+	IsActive  bool   `json:"isActive"`
+}
+
+type EmulatedLANInterface struct {
+	Enabled     bool   `json:"enabled"`
+	IPAddress   string `json:"ipAddress"`
+	DHCPEnabled bool   `json:"dhcpEnabled"`
+	DHCPMode    string `json:"dhcpMode"`
+	LinkUp      bool   `json:"linkUp"`
+	MACAddress  string `json:"macAddress"`
+}
+
+type EmulatedAccount struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type EmulatedRemoteAccess struct {
+	NetworkStatus uint32 `json:"networkStatus"`
+	RemoteStatus  uint32 `json:"remoteStatus"`
+	RemoteTrigger uint32 `json:"remoteTrigger"`
+	MPSHostname   string `json:"mpsHostname"`
+}
+
+type EmulatedFeatures struct {
+	IDEROpen      bool `json:"iderOpen"`
+	SOLOpen       bool `json:"solOpen"`
+	SystemDefense bool `json:"systemDefense"`
+	WebUI         bool `json:"webUI"`
+}
+
+type EmulatedResetReason struct {
+	Reason    uint32 `json:"reason"`
+	Timestamp uint32 `json:"timestamp"`
+}
+
+type EmulatedFQDN struct {
+	FQDN                       string `json:"fqdn"`
+	SharedFQDN                 bool   `json:"sharedFqdn"`
+	DDNSUpdateEnabled          bool   `json:"ddnsUpdateEnabled"`
+	DDNSPeriodicUpdateInterval uint32 `json:"ddnsPeriodicUpdateInterval"`
+	DDNSTTL                    uint32 `json:"ddnsTTL"`
+}
+
+// EmulatorState is the scriptable device state answered by the Emulator.
+// StatusOverrides maps a request code (e.g. "0x0400005c") to the status
+// returned in the response header, to script firmware failures.
+type EmulatorState struct {
+	BiosVersion        string               `json:"biosVersion"`
+	Versions           []EmulatedVersion    `json:"versions"`
+	UUID               string               `json:"uuid"`
+	ControlMode        uint32               `json:"controlMode"`
+	DNSSuffix          string               `json:"dnsSuffix"`
+	AMTEnabled         bool                 `json:"amtEnabled"`
+	CertHashes         []EmulatedCertHash   `json:"certHashes"`
+	WiredLAN           EmulatedLANInterface `json:"wiredLan"`
+	WirelessLAN        EmulatedLANInterface `json:"wirelessLan"`
+	LocalSystemAccount EmulatedAccount      `json:"localSystemAccount"`
+	RemoteAccess       EmulatedRemoteAccess `json:"remoteAccess"`
+	Features           EmulatedFeatures     `json:"features"`
+	LastResetReason    EmulatedResetReason  `json:"lastResetReason"`
+	PowerPolicy        string               `json:"powerPolicy"`
+	DedicatedMAC       string               `json:"dedicatedMac"`
+	HostMAC            string               `json:"hostMac"`
+	ProvisioningTLS    uint32               `json:"provisioningTlsMode"`
+	ZeroTouchEnabled   bool                 `json:"zeroTouchEnabled"`
+	FQDN               EmulatedFQDN         `json:"fqdn"`
+	EHBCState          uint32               `json:"ehbcState"`
+	DNSSuffixList      []string             `json:"dnsSuffixList"`
+	ConfigPending      bool                 `json:"configurationPending"`
+	ProvisioningOTP    string               `json:"provisioningOtp"`
+	WatchdogTimeout    uint16               `json:"watchdogTimeout"`
+	WatchdogHeartbeats uint32               `json:"watchdogHeartbeats"`
+	WSMANResponse      string               `json:"wsmanResponse"`
+	StatusOverrides    map[string]Status    `json:"statusOverrides"`
+}
+
+// Emulator is a software heci.Interface that answers PTHI, watchdog and
+// LME/APF traffic from a device-state file instead of /dev/mei0.
+type Emulator struct {
+	StateFile string
+	State     EmulatorState
+
+	mu     sync.Mutex
+	useLME bool
+	useWD  bool
+	// responses queues the replies until they are read, ready wakes up
+	// readers waiting for one
+	responses    [][]byte
+	ready        *sync.Cond
+	open         bool
+	protocolSent bool
+	// announced counts the ports AMT asked the host to forward
+	announced  int
+	amtChannel uint32
+	// channels are the open APF channels by AMT's channel number
+	channels map[uint32]*emulatedChannel
+}
+
+// emulatedChannel is one APF channel the host opened
+type emulatedChannel struct {
+	hostChannel uint32
+	hostWindow  uint32
+	// request collects channel data until a whole HTTP request arrived,
+	// pending holds the response bytes the host window has no room for
+	request []byte
+	pending []byte
+}
+
+func NewEmulator(stateFile string) *Emulator {
+	return &Emulator{
+		StateFile: stateFile,
+	}
+}
+
+// EmulatorStateFile reports the state file selected through EmulatorEnvVar.
+func EmulatorStateFile() (string, bool) {
+	stateFile, ok := os.LookupEnv(EmulatorEnvVar)
+	return stateFile, ok && stateFile != ""
+}
+
+func (e *Emulator) Init(useLME bool, useWD bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.load(); err != nil {
+		return err
+	}
+	e.useLME = useLME
+	e.useWD = useWD
+	e.responses = nil
+	e.ready = sync.NewCond(&e.mu)
+	e.open = true
+	e.protocolSent = false
+	e.channels = map[uint32]*emulatedChannel{}
+	return nil
+}
+
+func (e *Emulator) GetBufferSize() uint32 {
+	if e.useLME {
+		return emulatorLMEBufferSize
+	}
+	return emulatorPTHIBufferSize
+}
+
+func (e *Emulator) SendMessage(buffer []byte, done *uint32) (bytesWritten uint32, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.open {
+		return 0, ErrEmulatorClosed
+	}
+	var replies [][]byte
+	if e.useWD {
+		replies, err = e.handleWatchdog(buffer)
+	} else if e.useLME {
+		replies, err = e.handleAPF(buffer)
+	} else {
+		replies, err = e.handlePTHI(buffer)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(replies) > 0 {
+		e.responses = append(e.responses, replies...)
+		e.ready.Broadcast()
+	}
+	return uint32(len(buffer)), nil
+}
+
+func (e *Emulator) ReceiveMessage(buffer []byte, done *uint32) (bytesRead uint32, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for e.open && len(e.responses) == 0 {
+		e.ready.Wait()
+	}
+	if !e.open {
+		return 0, ErrEmulatorClosed
+	}
+	reply := e.responses[0]
+	e.responses = e.responses[1:]
+	return uint32(copy(buffer, reply)), nil
+}
+
+func (e *Emulator) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.open = false
+	e.responses = nil
+	if e.ready != nil {
+		e.ready.Broadcast()
+	}
+}
+
+func (e *Emulator) load() error {
+	content, err := os.ReadFile(e.StateFile)
+	if err != nil {
+		return err
+	}
+	state := EmulatorState{}
+	if err = json.Unmarshal(content, &state); err != nil {
+		return fmt.Errorf("invalid emulator state file %s: %w", e.StateFile, err)
+	}
+	e.State = state
+	return nil
+}
+
+func (e *Emulator) save() error {
+	content, err := json.MarshalIndent(e.State, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.StateFile, content, 0600)
+}
+
+func (e *Emulator) handlePTHI(buffer []byte) ([][]byte, error) {
+	if len(buffer) < int(GET_REQUEST_SIZE) {
+		return nil, errors.New("invalid PTHI request header")
+	}
+	request := MessageHeader{}
+	reader := bytes.NewReader(buffer)
+	binary.Read(reader, binary.LittleEndian, &request.Version)
+	binary.Read(reader, binary.LittleEndian, &request.Reserved)
+	binary.Read(reader, binary.LittleEndian, &request.Command.val)
+	binary.Read(reader, binary.LittleEndian, &request.Length)
+	status := AMT_STATUS_SUCCESS
+	var body bytes.Buffer
+	switch request.Command.val {
+	case CODE_VERSIONS_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.codeVersions())
+	case GET_UUID_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.uuidBytes())
+	case GET_CONTROL_MODE_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.State.ControlMode)
+	case GET_PKI_FQDN_SUFFIX_REQUEST:
+		binary.Write(&body, binary.LittleEndian, toANSIString(e.State.DNSSuffix))
+	case ENUMERATE_HASH_HANDLES_REQUEST:
+		handles := AMTHashHandles{Length: uint32(len(e.State.CertHashes))}
+		for i := 0; i < len(e.State.CertHashes) && i < CERT_HASH_MAX_NUMBER; i++ {
+			handles.Handles[i] = uint32(i)
+		}
+		binary.Write(&body, binary.LittleEndian, handles)
+	case GET_CERTHASH_ENTRY_REQUEST:
+		var handle uint32
+		binary.Read(reader, binary.LittleEndian, &handle)
+		if int(handle) >= len(e.State.CertHashes) {
+			status = AMT_STATUS_INVALID_HANDLE
+			break
+		}
+		binary.Write(&body, binary.LittleEndian, e.State.CertHashes[handle].entry())
+	case GET_REMOTE_ACCESS_CONNECTION_STATUS_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.State.RemoteAccess.NetworkStatus)
+		binary.Write(&body, binary.LittleEndian, e.State.RemoteAccess.RemoteStatus)
+		binary.Write(&body, binary.LittleEndian, e.State.RemoteAccess.RemoteTrigger)
+		binary.Write(&body, binary.LittleEndian, toANSIString(e.State.RemoteAccess.MPSHostname))
+	case GET_LAN_INTERFACE_SETTINGS_REQUEST:
+		var interfaceIndex uint32
+		binary.Read(reader, binary.LittleEndian, &interfaceIndex)
+		settings := e.State.WiredLAN
+		if interfaceIndex == 1 {
+			settings = e.State.WirelessLAN
+		}
+		binary.Write(&body, binary.LittleEndian, settings.response())
+	case GET_LOCAL_SYSTEM_ACCOUNT_REQUEST:
+		account := LocalSystemAccount{}
+		copy(account.Username[:], e.State.LocalSystemAccount.Username)
+		copy(account.Password[:], e.State.LocalSystemAccount.Password)
+		binary.Write(&body, binary.LittleEndian, account)
+	case UNPROVISION_REQUEST:
+		e.State.ControlMode = 0
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+		binary.Write(&body, binary.LittleEndian, uint32(0))
+	case GET_FEATURES_STATE_REQUEST:
+		var requestID FeaturesStateRequestID
+		binary.Read(reader, binary.LittleEndian, &requestID)
+		binary.Write(&body, binary.LittleEndian, requestID)
+		binary.Write(&body, binary.LittleEndian, e.State.Features.data(requestID))
+	case GET_LAST_HOST_RESET_REASON_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.State.LastResetReason.Reason)
+		binary.Write(&body, binary.LittleEndian, e.State.LastResetReason.Timestamp)
+	case GET_CURRENT_POWER_POLICY_REQUEST:
+		binary.Write(&body, binary.LittleEndian, toANSIString(e.State.PowerPolicy))
+	case GET_MAC_ADDRESSES_REQUEST:
+		var dedicated, host [6]uint8
+		if mac, err := net.ParseMAC(e.State.DedicatedMAC); err == nil {
+			copy(dedicated[:], mac)
+		}
+		if mac, err := net.ParseMAC(e.State.HostMAC); err == nil {
+			copy(host[:], mac)
+		}
+		binary.Write(&body, binary.LittleEndian, dedicated)
+		binary.Write(&body, binary.LittleEndian, host)
+	case GET_SECURITY_PARAMETERS_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.securityParameters())
+	case GET_PROVISIONING_TLS_MODE_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.State.ProvisioningTLS)
+	case GET_ZERO_TOUCH_ENABLED_REQUEST:
+		binary.Write(&body, binary.LittleEndian, boolToUint8(e.State.ZeroTouchEnabled))
+	case GET_FQDN_REQUEST:
+		binary.Write(&body, binary.LittleEndian, boolToUint8(e.State.FQDN.SharedFQDN))
+		binary.Write(&body, binary.LittleEndian, boolToUint8(e.State.FQDN.DDNSUpdateEnabled))
+		binary.Write(&body, binary.LittleEndian, e.State.FQDN.DDNSPeriodicUpdateInterval)
+		binary.Write(&body, binary.LittleEndian, e.State.FQDN.DDNSTTL)
+		binary.Write(&body, binary.LittleEndian, toANSIString(e.State.FQDN.FQDN))
+	case GET_EHBC_STATE_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.State.EHBCState)
+	case SET_DNS_SUFFIX_REQUEST:
+		suffix, err := readEmulatedString(reader)
+		if err != nil {
+			status = AMT_STATUS_INVALID_MESSAGE_LENGTH
+			break
+		}
+		e.State.DNSSuffix = suffix
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case SET_HOST_FQDN_REQUEST:
+		fqdn, err := readEmulatedString(reader)
+		if err != nil {
+			status = AMT_STATUS_INVALID_MESSAGE_LENGTH
+			break
+		}
+		e.State.FQDN.FQDN = fqdn
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case PROVISIONING_STATE_REQUEST:
+		binary.Write(&body, binary.LittleEndian, e.provisioningState())
+	case START_CONFIGURATION_REQUEST:
+		if e.State.ControlMode != 0 {
+			status = AMT_STATUS_INVALID_AMT_MODE
+			break
+		}
+		e.State.ConfigPending = true
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case STOP_CONFIGURATION_REQUEST:
+		if !e.State.ConfigPending {
+			status = AMT_STATUS_INVALID_AMT_MODE
+			break
+		}
+		e.State.ConfigPending = false
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case SET_PROVISIONING_SERVER_OTP_REQUEST:
+		otp, err := readEmulatedString(reader)
+		if err != nil {
+			status = AMT_STATUS_INVALID_MESSAGE_LENGTH
+			break
+		}
+		e.State.ProvisioningOTP = otp
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case OPEN_USER_INITIATED_CONNECTION_REQUEST:
+		if e.State.ControlMode == 0 || e.State.RemoteAccess.MPSHostname == "" {
+			status = AMT_STATUS_NOT_PERMITTED
+			break
+		}
+		e.State.RemoteAccess.RemoteStatus = 2
+		e.State.RemoteAccess.RemoteTrigger = 0
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case CLOSE_USER_INITIATED_CONNECTION_REQUEST:
+		e.State.RemoteAccess.RemoteStatus = 0
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+	case GET_DNS_SUFFIX_LIST_REQUEST:
+		list := strings.Join(e.State.DNSSuffixList, "\x00")
+		binary.Write(&body, binary.LittleEndian, uint16(len(list)))
+		body.WriteString(list)
+	default:
+		status = AMT_STATUS_INTERNAL_ERROR
+	}
+	if override, ok := e.State.StatusOverrides[fmt.Sprintf("0x%08x", request.Command.val)]; ok {
+		status = override
+	}
+	response := ResponseMessageHeader{
+		Header: MessageHeader{
+			Version:  request.Version,
+			Command:  CommandFormat{val: request.Command.val | 0x00800000},
+			Length:   uint32(body.Len()) + 4,
+			Reserved: 0,
+		},
+		Status: status,
+	}
+	var reply bytes.Buffer
+	binary.Write(&reply, binary.LittleEndian, response)
+	reply.Write(body.Bytes())
+	return [][]byte{reply.Bytes()}, nil
+}
+
+func (e *Emulator) handleWatchdog(buffer []byte) ([][]byte, error) {
+	request := GetStateIndependenceIsChangeToAMTEnabledRequest{}
+	if err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, &request); err != nil {
+		return nil, err
+	}
+	switch request.SubCommand {
+	case 0x51:
+		// transition allowed, new interface version, plus the current state
+		enabled := uint8(0x81)
+		if e.State.AMTEnabled {
+			enabled |= 0x02
+		}
+		return [][]byte{{enabled}}, nil
+	case 0x53:
+		if len(buffer) < 5 {
+			return nil, errors.New("invalid set operational state request")
+		}
+		e.State.AMTEnabled = AMTOperationalState(buffer[4]) == AmtEnabled
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+		response := SetAmtOperationalStateResponse{
+			Command:       request.Command,
+			ByteCount:     request.ByteCount,
+			SubCommand:    request.SubCommand,
+			VersionNumber: request.VersionNumber,
+			Status:        AMT_STATUS_SUCCESS,
+		}
+		var reply bytes.Buffer
+		binary.Write(&reply, binary.LittleEndian, response)
+		return [][]byte{reply.Bytes()}, nil
+	case WATCHDOG_START_TIMER_REQUEST:
+		start := WatchdogStartTimerRequest{}
+		if err := binary.Read(bytes.NewReader(buffer), binary.LittleEndian, &start); err != nil {
+			return nil, errors.New("invalid start watchdog timer request")
+		}
+		response := WatchdogStartTimerResponse{
+			Command:       WATCHDOG_MANAGEMENT_CONTROL,
+			ByteCount:     2,
+			SubCommand:    WATCHDOG_START_TIMER_RESPONSE,
+			VersionNumber: WATCHDOG_VERSION_NUMBER,
+		}
+		if e.State.ControlMode == 0 {
+			response.WatchdogState = WATCHDOG_STATE_NOT_REQUIRED
+		} else {
+			e.State.WatchdogTimeout = start.Timeout
+			e.State.WatchdogHeartbeats++
+			if err := e.save(); err != nil {
+				return nil, err
+			}
+		}
+		var reply bytes.Buffer
+		binary.Write(&reply, binary.LittleEndian, response)
+		return [][]byte{reply.Bytes()}, nil
+	case WATCHDOG_STOP_TIMER_REQUEST:
+		e.State.WatchdogTimeout = 0
+		if err := e.save(); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported watchdog subcommand 0x%02x", request.SubCommand)
+}
+
+// handleAPF answers enough of the APF handshake (protocol version, port
+// forwarding service and a single forwarded channel) for LMEConnection.
+func (e *Emulator) handleAPF(buffer []byte) ([][]byte, error) {
+	if len(buffer) == 0 {
+		return nil, errors.New("empty APF message")
+	}
+	switch buffer[0] {
+	case apf.APF_PROTOCOLVERSION:
+		if !e.protocolSent {
+			e.protocolSent = true
+			version := apf.ProtocolVersion(1, 0, apf.APF_TRIGGER_REASON_LME_REQUEST)
+			copy(version.UUID[:], e.uuidBytes())
+			return encodeAPF(version), nil
+		}
+		return [][]byte{serviceRequest(apf.APF_SERVICE_PFWD)}, nil
+	case apf.APF_SERVICE_ACCEPT:
+		e.announced = 1
+		return [][]byte{globalRequest(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, emulatorForwardedPorts[0])}, nil
+	case apf.APF_REQUEST_SUCCESS, apf.APF_REQUEST_FAILURE:
+		if e.announced < len(emulatorForwardedPorts) {
+			e.announced++
+			return [][]byte{globalRequest(apf.APF_GLOBAL_REQUEST_STR_TCP_FORWARD_REQUEST, emulatorForwardedPorts[e.announced-1])}, nil
+		}
+		// AMT announces the udp forwarder last, which needs no reply
+		return [][]byte{globalRequest(apf.APF_GLOBAL_REQUEST_STR_UDP_SEND_TO, 0)}, nil
+	case apf.APF_CHANNEL_OPEN:
+		open := apf.APF_CHANNEL_OPEN_MESSAGE{}
+		if err := binary.Read(bytes.NewReader(buffer), binary.BigEndian, &open); err != nil {
+			return nil, err
+		}
+		e.amtChannel++
+		e.channels[e.amtChannel] = &emulatedChannel{
+			hostChannel: open.SenderChannel,
+			hostWindow:  open.InitialWindowSize,
+		}
+		return encodeAPF(apf.ChannelOpenReplySuccess(open.SenderChannel, e.amtChannel)), nil
+	case apf.APF_CHANNEL_DATA:
+		if len(buffer) < 9 {
+			return nil, errors.New("short APF channel data message")
+		}
+		channel, ok := e.channels[binary.BigEndian.Uint32(buffer[1:5])]
+		if !ok {
+			return nil, errors.New("APF channel data received before channel open")
+		}
+		data := buffer[9:]
+		if length := binary.BigEndian.Uint32(buffer[5:9]); int(length) < len(data) {
+			data = data[:length]
+		}
+		channel.request = append(channel.request, data...)
+		// hand the consumed bytes back like AMT does
+		replies := encodeAPF(apf.ChannelWindowAdjust(channel.hostChannel, uint32(len(data))))
+		if !httpRequestComplete(channel.request) {
+			return replies, nil
+		}
+		channel.request = nil
+		channel.pending = append(channel.pending, e.wsmanResponse()...)
+		return append(replies, channel.sendPending()...), nil
+	case apf.APF_CHANNEL_CLOSE:
+		if len(buffer) < 5 {
+			return nil, errors.New("short APF channel close message")
+		}
+		amtChannel := binary.BigEndian.Uint32(buffer[1:5])
+		channel, ok := e.channels[amtChannel]
+		if !ok {
+			return nil, nil
+		}
+		delete(e.channels, amtChannel)
+		return encodeAPF(apf.ChannelClose(channel.hostChannel)), nil
+	case apf.APF_CHANNEL_WINDOW_ADJUST:
+		adjust := apf.APF_CHANNEL_WINDOW_ADJUST_MESSAGE{}
+		if err := binary.Read(bytes.NewReader(buffer), binary.BigEndian, &adjust); err != nil {
+			return nil, err
+		}
+		channel, ok := e.channels[adjust.RecipientChannel]
+		if !ok {
+			return nil, nil
+		}
+		channel.hostWindow += adjust.BytesToAdd
+		return channel.sendPending(), nil
+	}
+	return nil, fmt.Errorf("unsupported APF message type %d", buffer[0])
+}
+
+// sendPending splits the pending response into channel data messages that
+// fit the host's receive window and the LME buffer
+func (c *emulatedChannel) sendPending() [][]byte {
+	var replies [][]byte
+	for len(c.pending) > 0 && c.hostWindow > 0 {
+		length := uint32(len(c.pending))
+		if length > c.hostWindow {
+			length = c.hostWindow
+		}
+		if length > emulatorLMEBufferSize-9 {
+			length = emulatorLMEBufferSize - 9
+		}
+		var reply bytes.Buffer
+		data := apf.ChannelData(c.hostChannel, c.pending[:length])
+		binary.Write(&reply, binary.BigEndian, data.MessageType)
+		binary.Write(&reply, binary.BigEndian, data.RecipientChannel)
+		binary.Write(&reply, binary.BigEndian, data.DataLength)
+		binary.Write(&reply, binary.BigEndian, data.Data)
+		replies = append(replies, reply.Bytes())
+		c.pending = c.pending[length:]
+		c.hostWindow -= length
+	}
+	return replies
+}
+
+func httpRequestComplete(request []byte) bool {
+	parsed, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(request)))
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(io.Discard, parsed.Body)
+	return err == nil
+}
+
+func (e *Emulator) wsmanResponse() string {
+	body := e.State.WSMANResponse
+	if body == "" {
+		body = `<?xml version="1.0" encoding="UTF-8"?><a:Envelope xmlns:a="http://www.w3.org/2003/05/soap-envelope"><a:Header></a:Header><a:Body></a:Body></a:Envelope>`
+	}
+	return fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: application/soap+xml; charset=UTF-8\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (e *Emulator) codeVersions() CodeVersions {
+	versions := CodeVersions{}
+	copy(versions.BiosVersion[:], e.State.BiosVersion)
+	for i, v := range e.State.Versions {
+		if i >= VERSIONS_NUMBER {
+			break
+		}
+		versions.Versions[i] = AMTVersionType{
+			Description: toUnicodeString(v.Description),
+			Version:     toUnicodeString(v.Version),
+		}
+		versions.VersionsCount++
+	}
+	return versions
+}
+
+// uuidBytes returns the UUID in the mixed-endian layout AMT reports it in.
+func (e *Emulator) uuidBytes() []byte {
+	raw := make([]byte, 16)
+	parsed, err := uuid.Parse(e.State.UUID)
+	if err != nil {
+		return raw
+	}
+	copy(raw, parsed[:])
+	raw[0], raw[1], raw[2], raw[3] = parsed[3], parsed[2], parsed[1], parsed[0]
+	raw[4], raw[5] = parsed[5], parsed[4]
+	raw[6], raw[7] = parsed[7], parsed[6]
+	return raw
+}
+
+func (c EmulatedCertHash) entry() CertHashEntry {
+	entry := CertHashEntry{
+		HashAlgorithm: c.Algorithm,
+		Name:          toANSIString(c.Name),
+	}
+	if c.IsDefault {
+		entry.IsDefault = 1
+	}
+	if c.IsActive {
+		entry.IsActive = 1
+	}
+	hash, _ := hex.DecodeString(c.Hash)
+	copy(entry.CertificateHash[:], hash)
+	return entry
+}
+
+func (l EmulatedLANInterface) response() []byte {
+	settings := GetLANInterfaceSettingsResponse{}
+	if l.Enabled {
+		settings.Enabled = 1
+	}
+	if l.DHCPEnabled {
+		settings.DhcpEnabled = 1
+	}
+	if strings.EqualFold(l.DHCPMode, "active") {
+		settings.DhcpIpMode = 1
+	}
+	if l.LinkUp {
+		settings.LinkStatus = 1
+	}
+	if ip := net.ParseIP(l.IPAddress).To4(); ip != nil {
+		settings.Ipv4Address = binary.BigEndian.Uint32(ip)
+	}
+	if mac, err := net.ParseMAC(l.MACAddress); err == nil {
+		copy(settings.MacAddress[:], mac)
+	}
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, settings.Enabled)
+	binary.Write(&body, binary.LittleEndian, settings.Ipv4Address)
+	binary.Write(&body, binary.LittleEndian, settings.DhcpEnabled)
+	binary.Write(&body, binary.LittleEndian, settings.DhcpIpMode)
+	binary.Write(&body, binary.LittleEndian, settings.LinkStatus)
+	binary.Write(&body, binary.LittleEndian, settings.MacAddress)
+	return body.Bytes()
+}
+
+func (f EmulatedFeatures) data(requestID FeaturesStateRequestID) [3]uint8 {
+	switch requestID {
+	case FeaturesStateRedirectionSession:
+		return [3]uint8{boolToUint8(f.IDEROpen), boolToUint8(f.SOLOpen), 0}
+	case FeaturesStateSystemDefense:
+		return [3]uint8{boolToUint8(f.SystemDefense), 0, 0}
+	case FeaturesStateWebUI:
+		return [3]uint8{boolToUint8(f.WebUI), 0, 0}
+	}
+	return [3]uint8{}
+}
+
+func (e *Emulator) provisioningState() uint32 {
+	if e.State.ConfigPending {
+		return 1
+	}
+	if e.State.ControlMode != 0 {
+		return 2
+	}
+	return 0
+}
+
+func (e *Emulator) securityParameters() []byte {
+	provisioningState := e.provisioningState()
+	var body bytes.Buffer
+	binary.Write(&body, binary.LittleEndian, uint8(0))
+	binary.Write(&body, binary.LittleEndian, uint8(0))
+	binary.Write(&body, binary.LittleEndian, uint8(1))
+	binary.Write(&body, binary.LittleEndian, provisioningState)
+	binary.Write(&body, binary.LittleEndian, boolToUint8(e.State.WiredLAN.Enabled))
+	binary.Write(&body, binary.LittleEndian, uint8(1))
+	binary.Write(&body, binary.LittleEndian, uint8(1))
+	binary.Write(&body, binary.LittleEndian, uint8(1))
+	binary.Write(&body, binary.LittleEndian, boolToUint8(e.State.WiredLAN.LinkUp))
+	binary.Write(&body, binary.LittleEndian, [8]uint8{})
+	return body.Bytes()
+}
+
+// readEmulatedString reads a length prefixed string from a set request
+func readEmulatedString(reader *bytes.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(reader, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+func boolToUint8(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func toANSIString(s string) AMTANSIString {
+	ansi := AMTANSIString{}
+	ansi.Length = uint16(copy(ansi.Buffer[:], s))
+	return ansi
+}
+
+func toUnicodeString(s string) AMTUnicodeString {
+	unicode := AMTUnicodeString{}
+	unicode.Length = uint16(copy(unicode.String[:], s))
+	return unicode
+}
+
+func encodeAPF(message interface{}) [][]byte {
+	var reply bytes.Buffer
+	binary.Write(&reply, binary.BigEndian, message)
+	return [][]byte{reply.Bytes()}
+}
+
+func serviceRequest(serviceName string) []byte {
+	var request bytes.Buffer
+	binary.Write(&request, binary.BigEndian, uint8(apf.APF_SERVICE_REQUEST))
+	binary.Write(&request, binary.BigEndian, uint32(len(serviceName)))
+	request.WriteString(serviceName)
+	return request.Bytes()
+}
+
+func globalRequest(requestName string, port uint32) []byte {
+	address := "::1"
+	var request bytes.Buffer
+	binary.Write(&request, binary.BigEndian, uint8(apf.APF_GLOBAL_REQUEST))
+	binary.Write(&request, binary.BigEndian, uint32(len(requestName)))
+	request.WriteString(requestName)
+	binary.Write(&request, binary.BigEndian, uint8(1))
+	binary.Write(&request, binary.BigEndian, uint32(len(address)))
+	request.WriteString(address)
+	binary.Write(&request, binary.BigEndian, port)
+	return request.Bytes()
+}
@@ -0,0 +1,492 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package pthi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-amt-cloud-toolkit/go-wsman-messages/v2/pkg/apf"
+	"github.com/stretchr/testify/assert"
+)
+
+var emulatorTestState = EmulatorState{
+	BiosVersion: "TEST.BIOS",
+	Versions: []EmulatedVersion{
+		{Description: "AMT", Version: "16.1.25"},
+		{Description: "Build Number", Version: "2049"},
+	},
+	UUID:        "12345678-9abc-def0-1234-56789abcdef0",
+	ControlMode: 2,
+	DNSSuffix:   "vprodemo.com",
+	AMTEnabled:  true,
+	CertHashes: []EmulatedCertHash{
+		{Name: "Test Root", Algorithm: 2, Hash: "0a0b0c", IsDefault: true, IsActive: true},
+	},
+	WiredLAN: EmulatedLANInterface{
+		Enabled:    true,
+		IPAddress:  "192.168.1.10",
+		LinkUp:     true,
+		MACAddress: "01:02:03:04:05:06",
+	},
+	LocalSystemAccount: EmulatedAccount{Username: "$$OsAdmin", Password: "P@ssw0rd"},
+	RemoteAccess:       EmulatedRemoteAccess{NetworkStatus: 2, MPSHostname: "mps.example.com"},
+	Features:           EmulatedFeatures{SOLOpen: true, WebUI: true},
+	LastResetReason:    EmulatedResetReason{Reason: 1},
+	PowerPolicy:        "S0-S5",
+	DedicatedMAC:       "0a:0b:0c:0d:0e:0f",
+	ZeroTouchEnabled:   true,
+	FQDN:               EmulatedFQDN{FQDN: "host.vprodemo.com", SharedFQDN: true},
+	EHBCState:          1,
+	DNSSuffixList:      []string{"vprodemo.com", "example.com"},
+}
+
+func writeEmulatorState(t *testing.T, state EmulatorState) string {
+	stateFile := filepath.Join(t.TempDir(), "device.json")
+	content, err := json.Marshal(state)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(stateFile, content, 0600))
+	return stateFile
+}
+
+func newEmulatedCommand(t *testing.T) (Command, string) {
+	stateFile := writeEmulatorState(t, emulatorTestState)
+	command := Command{Heci: NewEmulator(stateFile)}
+	assert.NoError(t, command.Open(false))
+	return command, stateFile
+}
+
+func TestNewCommandSelectsEmulator(t *testing.T) {
+	t.Setenv(EmulatorEnvVar, "device.json")
+	command := NewCommand()
+	emulator, ok := command.Heci.(*Emulator)
+	assert.True(t, ok)
+	assert.Equal(t, "device.json", emulator.StateFile)
+}
+
+func TestEmulatorInitMissingStateFile(t *testing.T) {
+	command := Command{Heci: NewEmulator(filepath.Join(t.TempDir(), "missing.json"))}
+	assert.Error(t, command.Open(false))
+}
+
+func TestEmulatorPTHI(t *testing.T) {
+	command, _ := newEmulatedCommand(t)
+	defer command.Close()
+
+	versions, err := command.GetCodeVersions()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), versions.CodeVersion.VersionsCount)
+	assert.Equal(t, "16.1.25", string(versions.CodeVersion.Versions[0].Version.String[:7]))
+
+	uuid, err := command.GetUUID()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x78, 0x56, 0x34, 0x12, 0xbc, 0x9a, 0xf0, 0xde}, []byte(uuid)[:8])
+
+	mode, err := command.GetControlMode()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mode)
+
+	suffix, err := command.GetDNSSuffix()
+	assert.NoError(t, err)
+	assert.Equal(t, "vprodemo.com", suffix)
+
+	hashes, err := command.GetCertificateHashes(AMTHashHandles{})
+	assert.NoError(t, err)
+	assert.Len(t, hashes, 1)
+	assert.Equal(t, uint8(0x0a), hashes[0].CertificateHash[0])
+	assert.Equal(t, uint32(1), hashes[0].IsDefault)
+
+	lan, err := command.GetLANInterfaceSettings(false)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0xc0a8010a), lan.Ipv4Address)
+	assert.Equal(t, [6]uint8{1, 2, 3, 4, 5, 6}, lan.MacAddress)
+
+	ras, err := command.GetRemoteAccessConnectionStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), ras.NetworkStatus)
+	assert.Equal(t, "mps.example.com", string(ras.MPSHostname.Buffer[:ras.MPSHostname.Length]))
+
+	account, err := command.GetLocalSystemAccount()
+	assert.NoError(t, err)
+	assert.Equal(t, "$$OsAdmin", string(account.Account.Username[:9]))
+
+	features, err := command.GetFeaturesState(FeaturesStateRedirectionSession)
+	assert.NoError(t, err)
+	assert.Equal(t, [3]uint8{0, 1, 0}, features.Data)
+
+	reset, err := command.GetLastHostResetReason()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(1), reset.Reason)
+
+	policy, err := command.GetCurrentPowerPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, "S0-S5", policy)
+
+	macs, err := command.GetMACAddresses()
+	assert.NoError(t, err)
+	assert.Equal(t, [6]uint8{0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f}, macs.DedicatedMac)
+
+	security, err := command.GetSecurityParameters()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), security.ProvisioningState)
+	assert.Equal(t, uint8(1), security.LinkIsUp)
+
+	zeroTouch, err := command.GetZeroTouchEnabled()
+	assert.NoError(t, err)
+	assert.True(t, zeroTouch)
+
+	fqdn, err := command.GetFQDN()
+	assert.NoError(t, err)
+	assert.Equal(t, "host.vprodemo.com", string(fqdn.FQDN.Buffer[:fqdn.FQDN.Length]))
+
+	ehbc, err := command.GetEHBCState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ehbc)
+
+	suffixes, err := command.GetDNSSuffixList()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"vprodemo.com", "example.com"}, suffixes)
+}
+
+func TestEmulatorUnprovisionPersists(t *testing.T) {
+	command, stateFile := newEmulatedCommand(t)
+	_, err := command.Unprovision()
+	assert.NoError(t, err)
+	command.Close()
+
+	reopened := Command{Heci: NewEmulator(stateFile)}
+	assert.NoError(t, reopened.Open(false))
+	defer reopened.Close()
+	mode, err := reopened.GetControlMode()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, mode)
+}
+
+func TestEmulatorStatusOverride(t *testing.T) {
+	state := emulatorTestState
+	state.StatusOverrides = map[string]Status{"0x0400006b": AMT_STATUS_NOT_READY}
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, state))}
+	assert.NoError(t, command.Open(false))
+	defer command.Close()
+
+	result, err := command.Call([]byte{1, 1, 0, 0, 0x6b, 0, 0, 4, 0, 0, 0, 0}, GET_REQUEST_SIZE)
+	assert.NoError(t, err)
+	response := readHeaderResponse(bytes.NewBuffer(result))
+	assert.Equal(t, AMT_STATUS_NOT_READY, response.Status)
+}
+
+func TestEmulatorWatchdog(t *testing.T) {
+	stateFile := writeEmulatorState(t, emulatorTestState)
+	command := Command{Heci: NewEmulator(stateFile)}
+	assert.NoError(t, command.OpenWatchdog())
+	defer command.Close()
+
+	enabled, err := command.GetIsAMTEnabled()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x83), enabled)
+
+	status, err := command.SetAmtOperationalState(AmtDisabled)
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+
+	enabled, err = command.GetIsAMTEnabled()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x81), enabled)
+}
+
+func TestEmulatorAPF(t *testing.T) {
+	stateFile := writeEmulatorState(t, emulatorTestState)
+	command := Command{Heci: NewEmulator(stateFile)}
+	assert.NoError(t, command.Open(true))
+	defer command.Close()
+
+	session := &apf.Session{Status: make(chan bool, 1)}
+	var request bytes.Buffer
+	binary.Write(&request, binary.BigEndian, apf.ProtocolVersion(1, 0, 9))
+	steps := 0
+	for request.Len() > 0 {
+		result, err := command.Call(request.Bytes(), uint32(request.Len()))
+		assert.NoError(t, err)
+		request = apf.Process(result, session)
+		steps++
+	}
+	// version, service request, one forward request per port and udp-send-to
+	assert.Equal(t, 5, steps)
+
+	open := apf.ChannelOpen(2)
+	assert.NoError(t, command.Send(open.Bytes(), uint32(open.Len())))
+	result, _, err := command.Receive()
+	assert.NoError(t, err)
+	apf.Process(result, session)
+	assert.True(t, <-session.Status)
+	assert.Equal(t, uint32(2), session.RecipientChannel)
+
+	var data bytes.Buffer
+	message := apf.ChannelData(session.SenderChannel, []byte("POST /wsman HTTP/1.1\r\n\r\n"))
+	binary.Write(&data, binary.BigEndian, message.MessageType)
+	binary.Write(&data, binary.BigEndian, message.RecipientChannel)
+	binary.Write(&data, binary.BigEndian, message.DataLength)
+	binary.Write(&data, binary.BigEndian, message.Data)
+	assert.NoError(t, command.Send(data.Bytes(), uint32(data.Len())))
+	result, _, err = command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_WINDOW_ADJUST), result[0])
+	result, n, err := command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_DATA), result[0])
+	assert.Contains(t, string(result[9:n]), "HTTP/1.1 200 OK")
+}
+
+func sendChannelData(t *testing.T, command Command, channel uint32, payload string) {
+	var data bytes.Buffer
+	message := apf.ChannelData(channel, []byte(payload))
+	binary.Write(&data, binary.BigEndian, message.MessageType)
+	binary.Write(&data, binary.BigEndian, message.RecipientChannel)
+	binary.Write(&data, binary.BigEndian, message.DataLength)
+	binary.Write(&data, binary.BigEndian, message.Data)
+	assert.NoError(t, command.Send(data.Bytes(), uint32(data.Len())))
+}
+
+func TestEmulatorAPFWindow(t *testing.T) {
+	state := emulatorTestState
+	state.WSMANResponse = strings.Repeat("x", 6000)
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, state))}
+	assert.NoError(t, command.Open(true))
+	defer command.Close()
+
+	open := apf.ChannelOpen(2)
+	assert.NoError(t, command.Send(open.Bytes(), uint32(open.Len())))
+	result, _, err := command.Receive()
+	assert.NoError(t, err)
+	session := &apf.Session{Status: make(chan bool, 1)}
+	apf.ProcessChannelOpenConfirmation(result, session)
+
+	// a request split over two messages is answered once it is complete
+	sendChannelData(t, command, session.SenderChannel, "POST /wsman HTTP/1.1\r\nContent-Length: 4\r\n\r\nab")
+	result, _, err = command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_WINDOW_ADJUST), result[0])
+	sendChannelData(t, command, session.SenderChannel, "cd")
+	result, _, err = command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_WINDOW_ADJUST), result[0])
+	assert.Equal(t, uint32(2), binary.BigEndian.Uint32(result[5:9]))
+
+	// the response stops at the window the host granted on open
+	result, n, err := command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_DATA), result[0])
+	assert.Equal(t, uint32(apf.LME_RX_WINDOW_SIZE), binary.BigEndian.Uint32(result[5:9]))
+	received := len(result[9:n])
+
+	var adjust bytes.Buffer
+	binary.Write(&adjust, binary.BigEndian, apf.ChannelWindowAdjust(session.SenderChannel, apf.LME_RX_WINDOW_SIZE))
+	assert.NoError(t, command.Send(adjust.Bytes(), uint32(adjust.Len())))
+	result, n, err = command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_DATA), result[0])
+	received += len(result[9:n])
+	assert.Equal(t, len(state.WSMANResponse)+len("HTTP/1.1 200 OK\r\nContent-Type: application/soap+xml; charset=UTF-8\r\nContent-Length: 6000\r\n\r\n"), received)
+}
+
+func TestEmulatorAPFChannels(t *testing.T) {
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, emulatorTestState))}
+	assert.NoError(t, command.Open(true))
+	defer command.Close()
+
+	sessions := map[uint32]*apf.Session{}
+	for _, channel := range []int{3, 4} {
+		open := apf.ChannelOpen(channel)
+		assert.NoError(t, command.Send(open.Bytes(), uint32(open.Len())))
+		result, _, err := command.Receive()
+		assert.NoError(t, err)
+		session := &apf.Session{Status: make(chan bool, 1)}
+		apf.ProcessChannelOpenConfirmation(result, session)
+		sessions[uint32(channel)] = session
+	}
+	assert.NotEqual(t, sessions[3].SenderChannel, sessions[4].SenderChannel)
+
+	// requests interleaved on both channels are answered on their own channel
+	sendChannelData(t, command, sessions[3].SenderChannel, "POST /wsman HTTP/1.1\r\nContent-Length: 2\r\n\r\na")
+	sendChannelData(t, command, sessions[4].SenderChannel, "POST /wsman HTTP/1.1\r\nContent-Length: 0\r\n\r\n")
+	sendChannelData(t, command, sessions[3].SenderChannel, "b")
+	answered := []uint32{}
+	for i := 0; i < 5; i++ {
+		result, _, err := command.Receive()
+		assert.NoError(t, err)
+		if result[0] == apf.APF_CHANNEL_DATA {
+			answered = append(answered, binary.BigEndian.Uint32(result[1:5]))
+		}
+	}
+	assert.Equal(t, []uint32{4, 3}, answered)
+
+	var closeMessage bytes.Buffer
+	binary.Write(&closeMessage, binary.BigEndian, apf.ChannelClose(sessions[4].SenderChannel))
+	assert.NoError(t, command.Send(closeMessage.Bytes(), uint32(closeMessage.Len())))
+	result, _, err := command.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(apf.APF_CHANNEL_CLOSE), result[0])
+	assert.Equal(t, uint32(4), binary.BigEndian.Uint32(result[1:5]))
+	// the other channel stays open
+	sendChannelData(t, command, sessions[3].SenderChannel, "")
+}
+
+func TestEmulatorSetDNSSuffixAndHostFQDN(t *testing.T) {
+	command, stateFile := newEmulatedCommand(t)
+	status, err := command.SetDNSSuffix("corp.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	status, err = command.SetHostFQDN("host.corp.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	command.Close()
+
+	reopened := Command{Heci: NewEmulator(stateFile)}
+	assert.NoError(t, reopened.Open(false))
+	defer reopened.Close()
+	suffix, err := reopened.GetDNSSuffix()
+	assert.NoError(t, err)
+	assert.Equal(t, "corp.example.com", suffix)
+	fqdn, err := reopened.GetFQDN()
+	assert.NoError(t, err)
+	assert.Equal(t, "host.corp.example.com", string(fqdn.FQDN.Buffer[:fqdn.FQDN.Length]))
+}
+
+func TestEmulatorConfigurationSession(t *testing.T) {
+	state := emulatorTestState
+	state.ControlMode = 0
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, state))}
+	assert.NoError(t, command.Open(false))
+	defer command.Close()
+
+	status, err := command.StopConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_INVALID_AMT_MODE, status)
+
+	status, err = command.SetProvisioningServerOTP("Otp12345")
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+
+	status, err = command.StartConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	provisioningState, err := command.GetProvisioningState()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, provisioningState)
+
+	status, err = command.StopConfiguration()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	provisioningState, err = command.GetProvisioningState()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, provisioningState)
+}
+
+func TestEmulatorUserInitiatedConnection(t *testing.T) {
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, emulatorTestState))}
+	assert.NoError(t, command.Open(false))
+	defer command.Close()
+
+	status, err := command.OpenUserInitiatedConnection()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	raStatus, err := command.GetRemoteAccessConnectionStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), raStatus.RemoteStatus)
+	assert.Equal(t, uint32(0), raStatus.RemoteTrigger)
+
+	status, err = command.CloseUserInitiatedConnection()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_SUCCESS, status)
+	raStatus, err = command.GetRemoteAccessConnectionStatus()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), raStatus.RemoteStatus)
+}
+
+func TestEmulatorUserInitiatedConnectionNotActivated(t *testing.T) {
+	state := emulatorTestState
+	state.ControlMode = 0
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, state))}
+	assert.NoError(t, command.Open(false))
+	defer command.Close()
+
+	status, err := command.OpenUserInitiatedConnection()
+	assert.NoError(t, err)
+	assert.Equal(t, AMT_STATUS_NOT_PERMITTED, status)
+}
+
+func TestEmulatorWatchdogTimer(t *testing.T) {
+	stateFile := writeEmulatorState(t, emulatorTestState)
+	emulator := NewEmulator(stateFile)
+	command := Command{Heci: emulator}
+	assert.NoError(t, command.OpenWatchdog())
+	defer command.Close()
+
+	response, err := command.StartWatchdogTimer(120)
+	assert.NoError(t, err)
+	assert.True(t, response.Required())
+	_, err = command.StartWatchdogTimer(120)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(120), emulator.State.WatchdogTimeout)
+	assert.Equal(t, uint32(2), emulator.State.WatchdogHeartbeats)
+
+	assert.NoError(t, command.StopWatchdogTimer())
+	assert.Equal(t, uint16(0), emulator.State.WatchdogTimeout)
+}
+
+func TestEmulatorWatchdogTimerNotActivated(t *testing.T) {
+	state := emulatorTestState
+	state.ControlMode = 0
+	command := Command{Heci: NewEmulator(writeEmulatorState(t, state))}
+	assert.NoError(t, command.OpenWatchdog())
+	defer command.Close()
+
+	response, err := command.StartWatchdogTimer(120)
+	assert.NoError(t, err)
+	assert.False(t, response.Required())
+}
+
+func TestEmulatorQueuesRepliesUntilRead(t *testing.T) {
+	emulator := NewEmulator(writeEmulatorState(t, emulatorTestState))
+	assert.NoError(t, emulator.Init(false, false))
+	defer emulator.Close()
+
+	var request bytes.Buffer
+	binary.Write(&request, binary.LittleEndian, GetRequest{Header: CreateRequestHeader(GET_CONTROL_MODE_REQUEST, 0)})
+	// more replies than any fixed queue would hold, none read yet
+	const requests = 100
+	for i := 0; i < requests; i++ {
+		_, err := emulator.SendMessage(request.Bytes(), nil)
+		assert.NoError(t, err)
+	}
+	buffer := make([]byte, emulator.GetBufferSize())
+	for i := 0; i < requests; i++ {
+		n, err := emulator.ReceiveMessage(buffer, nil)
+		assert.NoError(t, err)
+		response := GetControlModeResponse{Header: readHeaderResponse(bytes.NewBuffer(buffer[:n]))}
+		assert.Equal(t, AMT_STATUS_SUCCESS, response.Header.Status)
+	}
+}
+
+func TestEmulatorCloseWakesReader(t *testing.T) {
+	emulator := NewEmulator(writeEmulatorState(t, emulatorTestState))
+	assert.NoError(t, emulator.Init(false, false))
+
+	received := make(chan error)
+	go func() {
+		_, err := emulator.ReceiveMessage(make([]byte, emulator.GetBufferSize()), nil)
+		received <- err
+	}()
+	emulator.Close()
+	assert.Equal(t, ErrEmulatorClosed, <-received)
+	_, err := emulator.SendMessage([]byte{0}, nil)
+	assert.Equal(t, ErrEmulatorClosed, err)
+}
@@ -5,52 +5,206 @@
 
 package pthi
 
+import "rpc/pkg/utils"
+
 type Status uint32
 
 const (
-	AMT_STATUS_SUCCESS                    Status = 0
-	AMT_STATUS_INTERNAL_ERROR             Status = 1
-	AMT_STATUS_NOT_READY                  Status = 2
-	AMT_STATUS_INVALID_AMT_MODE           Status = 3
-	AMT_STATUS_INVALID_MESSAGE_LENGTH     Status = 4
-	AMT_STATUS_NOT_PERMITTED              Status = 16
-	AMT_STATUS_MAX_LIMIT_REACHED          Status = 23
-	AMT_STATUS_INVALID_PARAMETER          Status = 36
-	AMT_STATUS_RNG_GENERATION_IN_PROGRESS Status = 47
-	AMT_STATUS_RNG_NOT_READY              Status = 48
-	AMT_STATUS_CERTIFICATE_NOT_READY      Status = 49
-	AMT_STATUS_INVALID_HANDLE             Status = 2053
-	AMT_STATUS_NOT_FOUND                  Status = 2068
+	AMT_STATUS_SUCCESS                         Status = 0
+	AMT_STATUS_INTERNAL_ERROR                  Status = 1
+	AMT_STATUS_NOT_READY                       Status = 2
+	AMT_STATUS_INVALID_AMT_MODE                Status = 3
+	AMT_STATUS_INVALID_MESSAGE_LENGTH          Status = 4
+	AMT_STATUS_TABLE_FINGERPRINT_NOT_AVAILABLE Status = 5
+	AMT_STATUS_INTEGRITY_CHECK_FAILED          Status = 6
+	AMT_STATUS_UNSUPPORTED_ISVS_VERSION        Status = 7
+	AMT_STATUS_APPLICATION_NOT_REGISTERED      Status = 8
+	AMT_STATUS_INVALID_REGISTRATION_DATA       Status = 9
+	AMT_STATUS_APPLICATION_DOES_NOT_EXIST      Status = 10
+	AMT_STATUS_NOT_ENOUGH_STORAGE              Status = 11
+	AMT_STATUS_INVALID_NAME                    Status = 12
+	AMT_STATUS_BLOCK_DOES_NOT_EXIST            Status = 13
+	AMT_STATUS_INVALID_BYTE_OFFSET             Status = 14
+	AMT_STATUS_INVALID_BYTE_COUNT              Status = 15
+	AMT_STATUS_NOT_PERMITTED                   Status = 16
+	AMT_STATUS_NOT_OWNER                       Status = 17
+	AMT_STATUS_BLOCK_LOCKED_BY_OTHER           Status = 18
+	AMT_STATUS_BLOCK_NOT_LOCKED                Status = 19
+	AMT_STATUS_INVALID_GROUP_PERMISSIONS       Status = 20
+	AMT_STATUS_GROUP_DOES_NOT_EXIST            Status = 21
+	AMT_STATUS_INVALID_MEMBER_COUNT            Status = 22
+	AMT_STATUS_MAX_LIMIT_REACHED               Status = 23
+	AMT_STATUS_INVALID_AUTH_TYPE               Status = 24
+	AMT_STATUS_AUTHENTICATION_FAILED           Status = 25
+	AMT_STATUS_INVALID_DHCP_MODE               Status = 26
+	AMT_STATUS_INVALID_IP_ADDRESS              Status = 27
+	AMT_STATUS_INVALID_DOMAIN_NAME             Status = 28
+	AMT_STATUS_UNSUPPORTED_VERSION             Status = 29
+	AMT_STATUS_REQUEST_UNEXPECTED              Status = 30
+	AMT_STATUS_INVALID_TABLE_TYPE              Status = 31
+	AMT_STATUS_INVALID_PROVISIONING_STATE      Status = 32
+	AMT_STATUS_UNSUPPORTED_OBJECT              Status = 33
+	AMT_STATUS_INVALID_TIME                    Status = 34
+	AMT_STATUS_INVALID_INDEX                   Status = 35
+	AMT_STATUS_INVALID_PARAMETER               Status = 36
+	AMT_STATUS_INVALID_NETMASK                 Status = 37
+	AMT_STATUS_FLASH_WRITE_LIMIT_EXCEEDED      Status = 38
+	AMT_STATUS_INVALID_IMAGE_LENGTH            Status = 39
+	AMT_STATUS_INVALID_IMAGE_SIGNATURE         Status = 40
+	AMT_STATUS_PROPOSE_ANOTHER_VERSION         Status = 41
+	AMT_STATUS_INVALID_PID_FORMAT              Status = 42
+	AMT_STATUS_INVALID_PPS_FORMAT              Status = 43
+	AMT_STATUS_BIST_COMMAND_BLOCKED            Status = 44
+	AMT_STATUS_CONNECTION_FAILED               Status = 45
+	AMT_STATUS_CONNECTION_TOO_MANY             Status = 46
+	AMT_STATUS_RNG_GENERATION_IN_PROGRESS      Status = 47
+	AMT_STATUS_RNG_NOT_READY                   Status = 48
+	AMT_STATUS_CERTIFICATE_NOT_READY           Status = 49
+	AMT_STATUS_DISABLED_BY_POLICY              Status = 1024
+	AMT_STATUS_NETWORK_IF_ERROR_BASE           Status = 2048
+	AMT_STATUS_UNSUPPORTED_OEM_NUMBER          Status = 2049
+	AMT_STATUS_UNSUPPORTED_BOOT_OPTION         Status = 2050
+	AMT_STATUS_INVALID_COMMAND                 Status = 2051
+	AMT_STATUS_INVALID_SPECIAL_COMMAND         Status = 2052
+	AMT_STATUS_INVALID_HANDLE                  Status = 2053
+	AMT_STATUS_INVALID_PASSWORD                Status = 2054
+	AMT_STATUS_INVALID_REALM                   Status = 2055
+	AMT_STATUS_STORAGE_ACL_ENTRY_IN_USE        Status = 2056
+	AMT_STATUS_DATA_MISSING                    Status = 2057
+	AMT_STATUS_DUPLICATE                       Status = 2058
+	AMT_STATUS_EVENTLOG_FROZEN                 Status = 2059
+	AMT_STATUS_PKI_MISSING_KEYS                Status = 2060
+	AMT_STATUS_PKI_GENERATING_KEYS             Status = 2061
+	AMT_STATUS_INVALID_KEY                     Status = 2062
+	AMT_STATUS_INVALID_CERT                    Status = 2063
+	AMT_STATUS_CERT_KEY_NOT_MATCH              Status = 2064
+	AMT_STATUS_MAX_KERB_DOMAIN_REACHED         Status = 2065
+	AMT_STATUS_UNSUPPORTED                     Status = 2066
+	AMT_STATUS_INVALID_PRIORITY                Status = 2067
+	AMT_STATUS_NOT_FOUND                       Status = 2068
+	AMT_STATUS_INVALID_CREDENTIALS             Status = 2069
+	AMT_STATUS_INVALID_PASSPHRASE              Status = 2070
+	AMT_STATUS_NO_ASSOCIATION                  Status = 2072
+	AMT_STATUS_AUDIT_FAIL                      Status = 2075
+	AMT_STATUS_BLOCKING_COMPONENT              Status = 2076
+	AMT_STATUS_USER_CONSENT_REQUIRED           Status = 2081
 )
 
+type statusInfo struct {
+	name        string
+	description string
+}
+
+// statusCatalog names and describes every status the firmware documents
+var statusCatalog = map[Status]statusInfo{
+	AMT_STATUS_SUCCESS:                         {"AMT_STATUS_SUCCESS", "request completed successfully"},
+	AMT_STATUS_INTERNAL_ERROR:                  {"AMT_STATUS_INTERNAL_ERROR", "internal firmware error"},
+	AMT_STATUS_NOT_READY:                       {"AMT_STATUS_NOT_READY", "AMT is not ready, try again later"},
+	AMT_STATUS_INVALID_AMT_MODE:                {"AMT_STATUS_INVALID_AMT_MODE", "request is not allowed in the current AMT mode"},
+	AMT_STATUS_INVALID_MESSAGE_LENGTH:          {"AMT_STATUS_INVALID_MESSAGE_LENGTH", "request length is invalid"},
+	AMT_STATUS_TABLE_FINGERPRINT_NOT_AVAILABLE: {"AMT_STATUS_TABLE_FINGERPRINT_NOT_AVAILABLE", "table fingerprint is not available"},
+	AMT_STATUS_INTEGRITY_CHECK_FAILED:          {"AMT_STATUS_INTEGRITY_CHECK_FAILED", "integrity check failed"},
+	AMT_STATUS_UNSUPPORTED_ISVS_VERSION:        {"AMT_STATUS_UNSUPPORTED_ISVS_VERSION", "ISV storage version is not supported"},
+	AMT_STATUS_APPLICATION_NOT_REGISTERED:      {"AMT_STATUS_APPLICATION_NOT_REGISTERED", "application is not registered"},
+	AMT_STATUS_INVALID_REGISTRATION_DATA:       {"AMT_STATUS_INVALID_REGISTRATION_DATA", "registration data is invalid"},
+	AMT_STATUS_APPLICATION_DOES_NOT_EXIST:      {"AMT_STATUS_APPLICATION_DOES_NOT_EXIST", "application does not exist"},
+	AMT_STATUS_NOT_ENOUGH_STORAGE:              {"AMT_STATUS_NOT_ENOUGH_STORAGE", "not enough storage"},
+	AMT_STATUS_INVALID_NAME:                    {"AMT_STATUS_INVALID_NAME", "name is invalid"},
+	AMT_STATUS_BLOCK_DOES_NOT_EXIST:            {"AMT_STATUS_BLOCK_DOES_NOT_EXIST", "storage block does not exist"},
+	AMT_STATUS_INVALID_BYTE_OFFSET:             {"AMT_STATUS_INVALID_BYTE_OFFSET", "byte offset is invalid"},
+	AMT_STATUS_INVALID_BYTE_COUNT:              {"AMT_STATUS_INVALID_BYTE_COUNT", "byte count is invalid"},
+	AMT_STATUS_NOT_PERMITTED:                   {"AMT_STATUS_NOT_PERMITTED", "operation is not permitted"},
+	AMT_STATUS_NOT_OWNER:                       {"AMT_STATUS_NOT_OWNER", "caller is not the owner"},
+	AMT_STATUS_BLOCK_LOCKED_BY_OTHER:           {"AMT_STATUS_BLOCK_LOCKED_BY_OTHER", "storage block is locked by another application"},
+	AMT_STATUS_BLOCK_NOT_LOCKED:                {"AMT_STATUS_BLOCK_NOT_LOCKED", "storage block is not locked"},
+	AMT_STATUS_INVALID_GROUP_PERMISSIONS:       {"AMT_STATUS_INVALID_GROUP_PERMISSIONS", "group permissions are invalid"},
+	AMT_STATUS_GROUP_DOES_NOT_EXIST:            {"AMT_STATUS_GROUP_DOES_NOT_EXIST", "group does not exist"},
+	AMT_STATUS_INVALID_MEMBER_COUNT:            {"AMT_STATUS_INVALID_MEMBER_COUNT", "member count is invalid"},
+	AMT_STATUS_MAX_LIMIT_REACHED:               {"AMT_STATUS_MAX_LIMIT_REACHED", "maximum number of entries reached"},
+	AMT_STATUS_INVALID_AUTH_TYPE:               {"AMT_STATUS_INVALID_AUTH_TYPE", "authentication type is invalid"},
+	AMT_STATUS_AUTHENTICATION_FAILED:           {"AMT_STATUS_AUTHENTICATION_FAILED", "authentication failed"},
+	AMT_STATUS_INVALID_DHCP_MODE:               {"AMT_STATUS_INVALID_DHCP_MODE", "DHCP mode is invalid"},
+	AMT_STATUS_INVALID_IP_ADDRESS:              {"AMT_STATUS_INVALID_IP_ADDRESS", "IP address is invalid"},
+	AMT_STATUS_INVALID_DOMAIN_NAME:             {"AMT_STATUS_INVALID_DOMAIN_NAME", "domain name is invalid"},
+	AMT_STATUS_UNSUPPORTED_VERSION:             {"AMT_STATUS_UNSUPPORTED_VERSION", "version is not supported"},
+	AMT_STATUS_REQUEST_UNEXPECTED:              {"AMT_STATUS_REQUEST_UNEXPECTED", "request was not expected"},
+	AMT_STATUS_INVALID_TABLE_TYPE:              {"AMT_STATUS_INVALID_TABLE_TYPE", "table type is invalid"},
+	AMT_STATUS_INVALID_PROVISIONING_STATE:      {"AMT_STATUS_INVALID_PROVISIONING_STATE", "request is not allowed in the current provisioning state"},
+	AMT_STATUS_UNSUPPORTED_OBJECT:              {"AMT_STATUS_UNSUPPORTED_OBJECT", "object is not supported"},
+	AMT_STATUS_INVALID_TIME:                    {"AMT_STATUS_INVALID_TIME", "time is invalid"},
+	AMT_STATUS_INVALID_INDEX:                   {"AMT_STATUS_INVALID_INDEX", "index is invalid"},
+	AMT_STATUS_INVALID_PARAMETER:               {"AMT_STATUS_INVALID_PARAMETER", "parameter is invalid"},
+	AMT_STATUS_INVALID_NETMASK:                 {"AMT_STATUS_INVALID_NETMASK", "network mask is invalid"},
+	AMT_STATUS_FLASH_WRITE_LIMIT_EXCEEDED:      {"AMT_STATUS_FLASH_WRITE_LIMIT_EXCEEDED", "flash write limit exceeded"},
+	AMT_STATUS_INVALID_IMAGE_LENGTH:            {"AMT_STATUS_INVALID_IMAGE_LENGTH", "image length is invalid"},
+	AMT_STATUS_INVALID_IMAGE_SIGNATURE:         {"AMT_STATUS_INVALID_IMAGE_SIGNATURE", "image signature is invalid"},
+	AMT_STATUS_PROPOSE_ANOTHER_VERSION:         {"AMT_STATUS_PROPOSE_ANOTHER_VERSION", "propose another version"},
+	AMT_STATUS_INVALID_PID_FORMAT:              {"AMT_STATUS_INVALID_PID_FORMAT", "provisioning ID format is invalid"},
+	AMT_STATUS_INVALID_PPS_FORMAT:              {"AMT_STATUS_INVALID_PPS_FORMAT", "provisioning passphrase format is invalid"},
+	AMT_STATUS_BIST_COMMAND_BLOCKED:            {"AMT_STATUS_BIST_COMMAND_BLOCKED", "built-in self test command is blocked"},
+	AMT_STATUS_CONNECTION_FAILED:               {"AMT_STATUS_CONNECTION_FAILED", "connection failed"},
+	AMT_STATUS_CONNECTION_TOO_MANY:             {"AMT_STATUS_CONNECTION_TOO_MANY", "too many connections"},
+	AMT_STATUS_RNG_GENERATION_IN_PROGRESS:      {"AMT_STATUS_RNG_GENERATION_IN_PROGRESS", "random number generation is in progress"},
+	AMT_STATUS_RNG_NOT_READY:                   {"AMT_STATUS_RNG_NOT_READY", "random number generator is not ready"},
+	AMT_STATUS_CERTIFICATE_NOT_READY:           {"AMT_STATUS_CERTIFICATE_NOT_READY", "certificate is not ready"},
+	AMT_STATUS_DISABLED_BY_POLICY:              {"AMT_STATUS_DISABLED_BY_POLICY", "operation is disabled by policy"},
+	AMT_STATUS_NETWORK_IF_ERROR_BASE:           {"AMT_STATUS_NETWORK_IF_ERROR_BASE", "network interface error"},
+	AMT_STATUS_UNSUPPORTED_OEM_NUMBER:          {"AMT_STATUS_UNSUPPORTED_OEM_NUMBER", "OEM number is not supported"},
+	AMT_STATUS_UNSUPPORTED_BOOT_OPTION:         {"AMT_STATUS_UNSUPPORTED_BOOT_OPTION", "boot option is not supported"},
+	AMT_STATUS_INVALID_COMMAND:                 {"AMT_STATUS_INVALID_COMMAND", "command is invalid"},
+	AMT_STATUS_INVALID_SPECIAL_COMMAND:         {"AMT_STATUS_INVALID_SPECIAL_COMMAND", "special command is invalid"},
+	AMT_STATUS_INVALID_HANDLE:                  {"AMT_STATUS_INVALID_HANDLE", "handle is invalid"},
+	AMT_STATUS_INVALID_PASSWORD:                {"AMT_STATUS_INVALID_PASSWORD", "password is invalid"},
+	AMT_STATUS_INVALID_REALM:                   {"AMT_STATUS_INVALID_REALM", "realm is invalid"},
+	AMT_STATUS_STORAGE_ACL_ENTRY_IN_USE:        {"AMT_STATUS_STORAGE_ACL_ENTRY_IN_USE", "storage access entry is in use"},
+	AMT_STATUS_DATA_MISSING:                    {"AMT_STATUS_DATA_MISSING", "required data is missing"},
+	AMT_STATUS_DUPLICATE:                       {"AMT_STATUS_DUPLICATE", "entry already exists"},
+	AMT_STATUS_EVENTLOG_FROZEN:                 {"AMT_STATUS_EVENTLOG_FROZEN", "event log is frozen"},
+	AMT_STATUS_PKI_MISSING_KEYS:                {"AMT_STATUS_PKI_MISSING_KEYS", "PKI keys are missing"},
+	AMT_STATUS_PKI_GENERATING_KEYS:             {"AMT_STATUS_PKI_GENERATING_KEYS", "PKI keys are being generated"},
+	AMT_STATUS_INVALID_KEY:                     {"AMT_STATUS_INVALID_KEY", "key is invalid"},
+	AMT_STATUS_INVALID_CERT:                    {"AMT_STATUS_INVALID_CERT", "certificate is invalid"},
+	AMT_STATUS_CERT_KEY_NOT_MATCH:              {"AMT_STATUS_CERT_KEY_NOT_MATCH", "certificate does not match the key"},
+	AMT_STATUS_MAX_KERB_DOMAIN_REACHED:         {"AMT_STATUS_MAX_KERB_DOMAIN_REACHED", "maximum number of Kerberos domains reached"},
+	AMT_STATUS_UNSUPPORTED:                     {"AMT_STATUS_UNSUPPORTED", "request is not supported"},
+	AMT_STATUS_INVALID_PRIORITY:                {"AMT_STATUS_INVALID_PRIORITY", "priority is invalid"},
+	AMT_STATUS_NOT_FOUND:                       {"AMT_STATUS_NOT_FOUND", "entry was not found"},
+	AMT_STATUS_INVALID_CREDENTIALS:             {"AMT_STATUS_INVALID_CREDENTIALS", "credentials are invalid"},
+	AMT_STATUS_INVALID_PASSPHRASE:              {"AMT_STATUS_INVALID_PASSPHRASE", "passphrase is invalid"},
+	AMT_STATUS_NO_ASSOCIATION:                  {"AMT_STATUS_NO_ASSOCIATION", "no association exists"},
+	AMT_STATUS_AUDIT_FAIL:                      {"AMT_STATUS_AUDIT_FAIL", "audit record could not be written"},
+	AMT_STATUS_BLOCKING_COMPONENT:              {"AMT_STATUS_BLOCKING_COMPONENT", "blocked by another component"},
+	AMT_STATUS_USER_CONSENT_REQUIRED:           {"AMT_STATUS_USER_CONSENT_REQUIRED", "user consent is required"},
+}
+
 func (s Status) String() string {
-	switch s {
-	case AMT_STATUS_SUCCESS:
-		return "AMT_STATUS_SUCCESS"
-	case AMT_STATUS_INTERNAL_ERROR:
-		return "AMT_STATUS_INTERNAL_ERROR"
-	case AMT_STATUS_NOT_READY:
-		return "AMT_STATUS_NOT_READY"
-	case AMT_STATUS_INVALID_AMT_MODE:
-		return "AMT_STATUS_INVALID_AMT_MODE"
-	case AMT_STATUS_INVALID_MESSAGE_LENGTH:
-		return "AMT_STATUS_INVALID_MESSAGE_LENGTH"
-	case AMT_STATUS_NOT_PERMITTED:
-		return "AMT_STATUS_NOT_PERMITTED"
-	case AMT_STATUS_MAX_LIMIT_REACHED:
-		return "AMT_STATUS_MAX_LIMIT_REACHED"
-	case AMT_STATUS_INVALID_PARAMETER:
-		return "AMT_STATUS_INVALID_PARAMETER"
-	case AMT_STATUS_RNG_GENERATION_IN_PROGRESS:
-		return "AMT_STATUS_RNG_GENERATION_IN_PROGRESS"
-	case AMT_STATUS_RNG_NOT_READY:
-		return "AMT_STATUS_RNG_NOT_READY"
-	case AMT_STATUS_CERTIFICATE_NOT_READY:
-		return "AMT_STATUS_CERTIFICATE_NOT_READY"
-	case AMT_STATUS_INVALID_HANDLE:
-		return "AMT_STATUS_INVALID_HANDLE"
-	case AMT_STATUS_NOT_FOUND:
-		return "AMT_STATUS_NOT_FOUND"
+	if info, ok := statusCatalog[s]; ok {
+		return info.name
 	}
 	return "AMT_STATUS_UNKNOWN"
 }
+
+// Description explains the status in plain words
+func (s Status) Description() string {
+	if info, ok := statusCatalog[s]; ok {
+		return info.description
+	}
+	return "unknown status"
+}
+
+// CustomError maps the status to the exit code reserved for it in the AMT PT
+// status block (AmtPtStatusCodeBase + status)
+func (s Status) CustomError() utils.CustomError {
+	return utils.CustomError{
+		Code:    utils.AmtPtStatusCodeBase.Code + int(s),
+		Message: s.String() + ": " + s.Description(),
+	}
+}
+
+// Err returns nil for AMT_STATUS_SUCCESS and the status as a CustomError otherwise
+func (s Status) Err() error {
+	if s == AMT_STATUS_SUCCESS {
+		return nil
+	}
+	return s.CustomError()
+}
@@ -6,8 +6,10 @@
 package pthi
 
 import (
-	"github.com/stretchr/testify/assert"
+	"rpc/pkg/utils"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAmtStatus_String(t *testing.T) {
@@ -67,6 +69,22 @@ func TestAmtStatus_String(t *testing.T) {
 			s:    AMT_STATUS_NOT_FOUND,
 			want: "AMT_STATUS_NOT_FOUND",
 		},
+		{
+			s:    AMT_STATUS_AUTHENTICATION_FAILED,
+			want: "AMT_STATUS_AUTHENTICATION_FAILED",
+		},
+		{
+			s:    AMT_STATUS_INVALID_PASSWORD,
+			want: "AMT_STATUS_INVALID_PASSWORD",
+		},
+		{
+			s:    AMT_STATUS_DISABLED_BY_POLICY,
+			want: "AMT_STATUS_DISABLED_BY_POLICY",
+		},
+		{
+			s:    AMT_STATUS_USER_CONSENT_REQUIRED,
+			want: "AMT_STATUS_USER_CONSENT_REQUIRED",
+		},
 		{
 			s:    100,
 			want: "AMT_STATUS_UNKNOWN",
@@ -78,3 +96,36 @@ func TestAmtStatus_String(t *testing.T) {
 		})
 	}
 }
+
+func TestAmtStatus_Err(t *testing.T) {
+	tests := []struct {
+		s    Status
+		want error
+	}{
+		{
+			s:    AMT_STATUS_SUCCESS,
+			want: nil,
+		},
+		{
+			s:    AMT_STATUS_NOT_READY,
+			want: utils.CustomError{Code: 1002, Message: "AMT_STATUS_NOT_READY: AMT is not ready, try again later"},
+		},
+		{
+			s:    AMT_STATUS_NOT_PERMITTED,
+			want: utils.CustomError{Code: 1016, Message: "AMT_STATUS_NOT_PERMITTED: operation is not permitted"},
+		},
+		{
+			s:    AMT_STATUS_INVALID_PASSWORD,
+			want: utils.CustomError{Code: 3054, Message: "AMT_STATUS_INVALID_PASSWORD: password is invalid"},
+		},
+		{
+			s:    100,
+			want: utils.CustomError{Code: 1100, Message: "AMT_STATUS_UNKNOWN: unknown status"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.s.Err())
+		})
+	}
+}
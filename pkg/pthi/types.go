@@ -177,6 +177,18 @@ type AMTANSIString struct {
 // GetUUIDRequest
 // GetHashHandlesRequest
 // GetRemoteAccessConnectionStatusRequest
+// GetLastHostResetReasonRequest
+// GetCurrentPowerPolicyRequest
+// GetMACAddressesRequest
+// GetSecurityParametersRequest
+// GetProvisioningTLSModeRequest
+// GetZeroTouchEnabledRequest
+// GetFQDNRequest
+// GetEHBCStateRequest
+// GetDNSSuffixListRequest
+// GetProvisioningStateRequest
+// StopConfigurationRequest
+// StartConfigurationRequest
 type GetRequest struct {
 	Header MessageHeader
 }
@@ -305,3 +317,174 @@ type SetAmtOperationalStateResponse struct {
 	VersionNumber uint8
 	Status        Status
 }
+
+// Watchdog timer messages are management control requests on the watchdog
+// client. Re-sending the start request before the timeout passes is the
+// heartbeat that keeps the AMT agent presence watchdog running.
+const (
+	WATCHDOG_MANAGEMENT_CONTROL    = 0x02
+	WATCHDOG_VERSION_NUMBER        = 0x10
+	WATCHDOG_START_TIMER_REQUEST   = 0x13
+	WATCHDOG_START_TIMER_RESPONSE  = 0x83
+	WATCHDOG_STOP_TIMER_REQUEST    = 0x14
+	WATCHDOG_STATE_NOT_REQUIRED    = 0x01
+	WATCHDOG_START_TIMER_BYTECOUNT = 19
+)
+
+type WatchdogStartTimerRequest struct {
+	Command       uint8
+	ByteCount     uint8
+	SubCommand    uint8
+	VersionNumber uint8
+	Timeout       uint16
+	Reserved      [17]uint8
+}
+
+type WatchdogStartTimerResponse struct {
+	Command       uint8
+	ByteCount     uint8
+	SubCommand    uint8
+	VersionNumber uint8
+	Status        uint8
+	WatchdogState uint8
+}
+
+// Required reports whether AMT expects heartbeats, it does not once the
+// device is unprovisioned or no agent presence watchdog is set up
+func (r WatchdogStartTimerResponse) Required() bool {
+	return r.WatchdogState&WATCHDOG_STATE_NOT_REQUIRED == 0
+}
+
+type WatchdogStopTimerRequest struct {
+	Command       uint8
+	ByteCount     uint8
+	SubCommand    uint8
+	VersionNumber uint8
+}
+
+type FeaturesStateRequestID uint32
+
+const (
+	FeaturesStateRedirectionSession = FeaturesStateRequestID(0)
+	FeaturesStateSystemDefense      = FeaturesStateRequestID(1)
+	FeaturesStateWebUI              = FeaturesStateRequestID(2)
+)
+
+type GetFeaturesStateRequest struct {
+	Header    MessageHeader
+	RequestID FeaturesStateRequestID
+}
+
+// FeaturesStateData is interpreted by RequestID:
+// redirection session - [IderOpen, SolOpen, Reserved]
+// system defense - [SystemDefenseActivated]
+// web ui - [WebUiEnabled]
+type GetFeaturesStateResponse struct {
+	Header    ResponseMessageHeader
+	RequestID FeaturesStateRequestID
+	Data      [3]uint8
+}
+
+type GetLastHostResetReasonResponse struct {
+	Header                 ResponseMessageHeader
+	Reason                 uint32
+	RemoteControlTimeStamp uint32
+}
+
+type GetCurrentPowerPolicyResponse struct {
+	Header     ResponseMessageHeader
+	PolicyName AMTANSIString
+}
+
+type GetMACAddressesResponse struct {
+	Header       ResponseMessageHeader
+	DedicatedMac [6]uint8
+	HostMac      [6]uint8
+}
+
+type GetSecurityParametersResponse struct {
+	Header                  ResponseMessageHeader
+	EnterpriseMode          uint8
+	TLSEnabled              uint8
+	HWCryptoEnabled         uint8
+	ProvisioningState       uint32
+	NetworkInterfaceEnabled uint8
+	SOLEnabled              uint8
+	IDEREnabled             uint8
+	FWUpdateEnabled         uint8
+	LinkIsUp                uint8
+	Reserved                [8]uint8
+}
+
+type GetProvisioningTLSModeResponse struct {
+	Header              ResponseMessageHeader
+	ProvisioningTLSMode uint32
+}
+
+type GetZeroTouchEnabledResponse struct {
+	Header           ResponseMessageHeader
+	ZeroTouchEnabled uint8
+}
+
+type GetFQDNResponse struct {
+	Header                     ResponseMessageHeader
+	SharedFQDN                 uint8
+	DDNSUpdateEnabled          uint8
+	DDNSPeriodicUpdateInterval uint32
+	DDNSTTL                    uint32
+	FQDN                       AMTANSIString
+}
+
+type GetEHBCStateResponse struct {
+	Header    ResponseMessageHeader
+	EHBCState uint32
+}
+
+type GetDNSSuffixListResponse struct {
+	Header     ResponseMessageHeader
+	DataLength uint16
+	Data       [1000]uint8
+}
+
+const HOST_FQDN_MAX_LENGTH = 256
+
+// SetDNSSuffixRequest carries the suffix as a length prefixed string
+// without padding, so the request size depends on the suffix length
+type SetDNSSuffixRequest struct {
+	Header MessageHeader
+	Length uint16
+	Suffix []uint8
+}
+
+// SetHostFQDNRequest carries the FQDN as a length prefixed string
+// without padding, like SetDNSSuffixRequest
+type SetHostFQDNRequest struct {
+	Header MessageHeader
+	Length uint16
+	FQDN   []uint8
+}
+
+// SetResponse is used for the following responses:
+// SetDNSSuffixResponse
+// SetHostFQDNResponse
+// SetProvisioningServerOTPResponse
+// StopConfigurationResponse
+// StartConfigurationResponse
+type SetResponse struct {
+	Header ResponseMessageHeader
+}
+
+const OTP_MAX_LENGTH = 32
+
+type GetProvisioningStateResponse struct {
+	Header            ResponseMessageHeader
+	ProvisioningState uint32
+}
+
+// SetProvisioningServerOTPRequest carries the one-time password as a
+// length prefixed string without padding
+type SetProvisioningServerOTPRequest struct {
+	Header MessageHeader
+	Length uint16
+	OTP    []uint8
+}
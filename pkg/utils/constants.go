@@ -32,6 +32,14 @@ const (
 	CommandMaintenance = "maintenance"
 	CommandVersion     = "version"
 	CommandConfigure   = "configure"
+	CommandCIRA        = "cira"
+	CommandWatchdog    = "watchdog"
+	CommandLMS         = "lms"
+	CommandPower       = "power"
+	CommandBoot        = "boot"
+	CommandLogs        = "logs"
+	CommandInventory   = "inventory"
+	CommandConsent     = "consent"
 
 	SubCommandAddWifiSettings     = "addwifisettings"
 	SubCommandWireless            = "wireless"
@@ -40,6 +48,7 @@ const (
 	SubCommandEnableWifiPort      = "enablewifiport"
 	SubCommandSetMEBx             = "mebx"
 	SubCommandConfigureTLS        = "tls"
+	SubCommandConfigureCIRA       = "cira"
 	SubCommandChangePassword      = "changepassword"
 	SubCommandChangeAMTPassword   = "amtpassword"
 	SubCommandSyncDeviceInfo      = "syncdeviceinfo"
@@ -47,6 +56,36 @@ const (
 	SubCommandSyncHostname        = "synchostname"
 	SubCommandSyncIP              = "syncip"
 	SubCommandSetAMTFeatures      = "amtfeatures"
+	SubCommandDNSSuffix           = "dnssuffix"
+	SubCommandStopConfig          = "stopconfig"
+	SubCommandStartConfig         = "startconfig"
+	SubCommandConnect             = "connect"
+	SubCommandDisconnect          = "disconnect"
+	SubCommandStatus              = "status"
+	SubCommandPowerOn             = "on"
+	SubCommandPowerOff            = "off"
+	SubCommandReset               = "reset"
+	SubCommandPowerCycle          = "cycle"
+	SubCommandSoftOff             = "soft-off"
+	SubCommandHibernate           = "hibernate"
+	SubCommandBootCapabilities    = "capabilities"
+	SubCommandBootPXE             = "pxe"
+	SubCommandBootHDD             = "hdd"
+	SubCommandBootCD              = "cd"
+	SubCommandBootIDER            = "ider"
+	SubCommandBootHTTPS           = "https"
+	SubCommandEvents              = "events"
+	SubCommandAudit               = "audit"
+	SubCommandAlarm               = "alarm"
+	SubCommandUsers               = "users"
+	SubCommandEnvDetection        = "environmentdetection"
+	SubCommandAdd                 = "add"
+	SubCommandList                = "list"
+	SubCommandUpdate              = "update"
+	SubCommandDelete              = "delete"
+	SubCommandStart               = "start"
+	SubCommandSend                = "send"
+	SubCommandCancel              = "cancel"
 
 	// Return Codes
 	Success ReturnCode = 0
@@ -60,6 +99,7 @@ var HECIDriverNotDetected = CustomError{Code: 2, Message: "HECIDriverNotDetected
 var AmtNotDetected = CustomError{Code: 3, Message: "AmtNotDetected"}
 var AmtNotReady = CustomError{Code: 4, Message: "AmtNotReady"}
 var HelpRequested = CustomError{Code: 5, Message: "flag: help requested"}
+var MEIDeviceBusy = CustomError{Code: 6, Message: "MEIDeviceBusy"}
 var GenericFailure = CustomError{Code: 10, Message: "GenericFailure"}
 
 // (20-69) Input errors to RPC
@@ -82,6 +122,7 @@ var MissingOrInvalidConfiguration = CustomError{Code: 35, Message: "MissingOrInv
 var InvalidUserInput = CustomError{Code: 36, Message: "InvalidUserInput"}
 var InvalidUUID = CustomError{Code: 37, Message: "InvalidUUID"}
 var PasswordsDoNotMatch = CustomError{Code: 38, Message: "PasswordsDoNotMatch"}
+var NotAvailableOnRemoteTarget = CustomError{Code: 39, Message: "NotAvailableOnRemoteTarget"}
 
 // (70-99) Connection Errors
 var RPSAuthenticationFailed = CustomError{Code: 70, Message: "RPSAuthenticationFailed"}
@@ -109,6 +150,10 @@ var MissingIeee8021xConfiguration = CustomError{Code: 117, Message: "MissingIeee
 var SetMEBXPasswordFailed = CustomError{Code: 118, Message: "SetMEBXPasswordFailed"}
 var ChangeAMTPasswordFailed = CustomError{Code: 119, Message: "ChangeAMTPasswordFailed"}
 var UnableToConfigure = CustomError{Code: 120, Message: "UnableToConfigure"}
+var DNSSuffixConfigurationFailed = CustomError{Code: 121, Message: "DNSSuffixConfigurationFailed"}
+var AlarmConfigurationFailed = CustomError{Code: 122, Message: "AlarmConfigurationFailed"}
+var UserConfigurationFailed = CustomError{Code: 123, Message: "UserConfigurationFailed"}
+var EnvironmentDetectionConfigurationFailed = CustomError{Code: 124, Message: "EnvironmentDetectionConfigurationFailed"}
 
 // (150-199) Maintenance Errors
 var SyncClockFailed = CustomError{Code: 150, Message: "SyncClockFailed"}
@@ -116,10 +161,25 @@ var SyncHostnameFailed = CustomError{Code: 151, Message: "SyncHostnameFailed"}
 var SyncIpFailed = CustomError{Code: 152, Message: "SyncIpFailed"}
 var ChangePasswordFailed = CustomError{Code: 153, Message: "ChangePasswordFailed"}
 var SyncDeviceInfoFailed = CustomError{Code: 154, Message: "SyncDeviceInfoFailed"}
+var StopConfigurationFailed = CustomError{Code: 155, Message: "StopConfigurationFailed"}
+var StartConfigurationFailed = CustomError{Code: 156, Message: "StartConfigurationFailed"}
+var CIRAConnectionFailed = CustomError{Code: 157, Message: "CIRAConnectionFailed"}
+var CIRAConnectionTimeout = CustomError{Code: 158, Message: "CIRAConnectionTimeout"}
+var WatchdogRegistrationFailed = CustomError{Code: 159, Message: "WatchdogRegistrationFailed"}
+var WatchdogHeartbeatFailed = CustomError{Code: 160, Message: "WatchdogHeartbeatFailed"}
+var LMSForwardingFailed = CustomError{Code: 161, Message: "LMSForwardingFailed"}
+var PowerStateChangeFailed = CustomError{Code: 162, Message: "PowerStateChangeFailed"}
+var BootConfigurationFailed = CustomError{Code: 163, Message: "BootConfigurationFailed"}
+var BootTargetNotSupported = CustomError{Code: 164, Message: "BootTargetNotSupported"}
+var LogReadFailed = CustomError{Code: 165, Message: "LogReadFailed"}
+var AuditPolicyConfigurationFailed = CustomError{Code: 166, Message: "AuditPolicyConfigurationFailed"}
+var InventoryFailed = CustomError{Code: 167, Message: "InventoryFailed"}
+var UserConsentFailed = CustomError{Code: 168, Message: "UserConsentFailed"}
+var UserConsentCodeRejected = CustomError{Code: 169, Message: "UserConsentCodeRejected"}
 
 // (200-299) KPMU
 
 // (300-399) Redfish
 
-// (1000 - 3000) Amt PT Status Code Block
+// (1000 - 3999) Amt PT Status Code Block, 1000 + AMT status
 var AmtPtStatusCodeBase = CustomError{Code: 1000, Message: "AmtPtStatusCodeBase"}
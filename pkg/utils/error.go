@@ -5,7 +5,10 @@
 
 package utils
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 // CustomError defines a custom error type with an integer code and a message.
 type CustomError struct {
@@ -17,3 +20,12 @@ type CustomError struct {
 func (e CustomError) Error() string {
 	return fmt.Sprintf("Error %d: %s", e.Code, e.Message)
 }
+
+// AmtPtStatus returns the AMT PT status error carried by err, if there is one
+func AmtPtStatus(err error) (CustomError, bool) {
+	var customErr CustomError
+	if errors.As(err, &customErr) && customErr.Code > AmtPtStatusCodeBase.Code {
+		return customErr, true
+	}
+	return CustomError{}, false
+}
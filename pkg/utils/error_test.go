@@ -0,0 +1,38 @@
+/*********************************************************************
+ * Copyright (c) Intel Corporation 2024
+ * SPDX-License-Identifier: Apache-2.0
+ **********************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmtPtStatus(t *testing.T) {
+	notReady := CustomError{Code: 1002, Message: "AMT_STATUS_NOT_READY: AMT is not ready, try again later"}
+	tests := []struct {
+		name   string
+		err    error
+		want   CustomError
+		wantOk bool
+	}{
+		{"nil", nil, CustomError{}, false},
+		{"plain error", errors.New("amt internal error"), CustomError{}, false},
+		{"other custom error", AMTConnectionFailed, CustomError{}, false},
+		{"status code base", AmtPtStatusCodeBase, CustomError{}, false},
+		{"status", notReady, notReady, true},
+		{"wrapped status", fmt.Errorf("error getting control mode: %w", notReady), notReady, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AmtPtStatus(tt.err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,6 +4,30 @@
  **********************************************************************/
 package utils
 
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseTarget splits a remote AMT target, host[:port], into host and port.
+// The port defaults to the AMT WS-MAN port, LMSPort. IPv6 addresses with a
+// port are written in brackets.
+func ParseTarget(target string) (host string, port string, err error) {
+	host, port, err = net.SplitHostPort(target)
+	if err != nil {
+		host, port = strings.Trim(target, "[]"), LMSPort
+	}
+	if host == "" {
+		return "", "", errors.New("missing host in target " + target)
+	}
+	if number, err := strconv.ParseUint(port, 10, 16); err != nil || number == 0 {
+		return "", "", errors.New("invalid port in target " + target)
+	}
+	return host, port, nil
+}
+
 func InterpretControlMode(mode int) string {
 	switch mode {
 	case 0:
@@ -76,3 +100,37 @@ func InterpretRemoteAccessTrigger(status int) string {
 		return "unknown"
 	}
 }
+func InterpretHostResetReason(reason int) string {
+	switch reason {
+	case 0:
+		return "remote control"
+	case 1:
+		return "other"
+	default:
+		return "unknown"
+	}
+}
+func InterpretProvisioningTLSMode(mode int) string {
+	switch mode {
+	case 0:
+		return "none"
+	case 1:
+		return "psk"
+	case 2:
+		return "pki"
+	default:
+		return "unknown"
+	}
+}
+func InterpretProvisioningState(state int) string {
+	switch state {
+	case 0:
+		return "pre-provisioning"
+	case 1:
+		return "in provisioning"
+	case 2:
+		return "post-provisioning"
+	default:
+		return "unknown"
+	}
+}
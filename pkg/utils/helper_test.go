@@ -10,6 +10,29 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target string
+		host   string
+		port   string
+	}{
+		{target: "192.168.1.20", host: "192.168.1.20", port: "16992"},
+		{target: "amt.example.com:16993", host: "amt.example.com", port: "16993"},
+		{target: "fe80::1", host: "fe80::1", port: "16992"},
+		{target: "[fe80::1]:16993", host: "fe80::1", port: "16993"},
+	}
+	for _, tt := range tests {
+		host, port, err := ParseTarget(tt.target)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.host, host)
+		assert.Equal(t, tt.port, port)
+	}
+	for _, target := range []string{"", ":16993", "amt.example.com:https", "amt.example.com:0", "amt.example.com:70000"} {
+		_, _, err := ParseTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
 func TestInterpretControlMode0(t *testing.T) {
 	algorithm := InterpretControlMode(0)
 	assert.Equal(t, "pre-provisioning state", algorithm)
@@ -109,3 +132,20 @@ func TestInterpretRemoteAccessConnectionStatus3(t *testing.T) {
 	result := InterpretRemoteAccessConnectionStatus(3)
 	assert.Equal(t, "unknown", result)
 }
+func TestInterpretHostResetReason(t *testing.T) {
+	assert.Equal(t, "remote control", InterpretHostResetReason(0))
+	assert.Equal(t, "other", InterpretHostResetReason(1))
+	assert.Equal(t, "unknown", InterpretHostResetReason(2))
+}
+func TestInterpretProvisioningTLSMode(t *testing.T) {
+	assert.Equal(t, "none", InterpretProvisioningTLSMode(0))
+	assert.Equal(t, "psk", InterpretProvisioningTLSMode(1))
+	assert.Equal(t, "pki", InterpretProvisioningTLSMode(2))
+	assert.Equal(t, "unknown", InterpretProvisioningTLSMode(3))
+}
+func TestInterpretProvisioningState(t *testing.T) {
+	assert.Equal(t, "pre-provisioning", InterpretProvisioningState(0))
+	assert.Equal(t, "in provisioning", InterpretProvisioningState(1))
+	assert.Equal(t, "post-provisioning", InterpretProvisioningState(2))
+	assert.Equal(t, "unknown", InterpretProvisioningState(3))
+}